@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+// runAuditCommand verifies the hash chain of the audit log, failing if any entry was
+// modified, removed or inserted.
+func runAuditCommand(logger logger.Logger, args []string) error {
+	if len(args) != 1 || args[0] != "verify" {
+		return fmt.Errorf("usage: tribar audit verify")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	path := audit.FilePath()
+	count, err := audit.Verify(path)
+	if os.IsNotExist(err) {
+		fmt.Println("the audit log is empty")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("audit log %s is not intact: %w", path, err)
+	}
+
+	fmt.Printf("audit log %s is intact, %d entries\n", path, count)
+	return nil
+}
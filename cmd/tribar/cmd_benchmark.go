@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/pkg/audio"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// runBenchmarkCommand transcribes a clip several times with the local model and prints the
+// real-time factor, the time of every stage and the peak memory, so settings can be
+// compared on the same hardware, e.g. `tribar benchmark -threads 4 -precision fp32`. The
+// flags override the settings for the benchmark only; without an audio file the clip of
+// transcribe.BenchmarkSamples is used.
+func runBenchmarkCommand(logger logger.Logger, args []string) error {
+	flags := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+	runs := flags.Int("runs", 5, "number of measured runs")
+	threads := flags.Int("threads", -1, "intra-op threads, 0 lets ONNX Runtime decide (default from the settings)")
+	precision := flags.String("precision", "", "model precision, int8 or fp32 (default from the settings)")
+	provider := flags.String("provider", "", "execution provider, cpu or cuda (default from the settings)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tribar benchmark [flags] [audio file]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("the benchmark takes at most one audio file")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	settings := settingsManager.Get()
+	if *threads >= 0 {
+		settings.Advanced.InferenceThreads = *threads
+	}
+	if *precision != "" {
+		settings.ModelPrecision = *precision
+	}
+	if *provider != "" {
+		settings.ExecutionProvider = *provider
+	}
+
+	if err := registerExternalTranscriber(settings); err != nil {
+		return err
+	}
+
+	samples, clip := transcribe.BenchmarkSamples()
+	if flags.NArg() == 1 {
+		clip = flags.Arg(0)
+		data, err := os.ReadFile(clip)
+		if err != nil {
+			return fmt.Errorf("error reading audio: %w", err)
+		}
+		if samples, err = audio.Decode(data); err != nil {
+			return fmt.Errorf("error decoding audio: %w", err)
+		}
+	}
+
+	// Interrupting the command aborts the benchmark.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	transcriber, err := newBatchTranscriber(ctx, logger, settings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = transcriber.Shutdown() }()
+
+	report, err := transcriber.Benchmark(ctx, samples, *runs)
+	if err != nil {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	fmt.Printf("model:      %s (%s, %s)\n", report.ModelID, report.Precision, report.ExecutionProvider)
+	fmt.Printf("threads:    %d\n", settings.Advanced.InferenceThreads)
+	fmt.Printf("audio:      %s, %s\n", clip, report.AudioDuration.Round(time.Millisecond))
+	fmt.Printf("runs:       %d (after one warm-up run)\n", report.Runs)
+	fmt.Printf("time:       mean %s, min %s, max %s\n",
+		report.Mean.Round(time.Millisecond), report.Min.Round(time.Millisecond), report.Max.Round(time.Millisecond))
+	fmt.Printf("RTF:        %.3f (%.1fx real time)\n", report.RTF, 1/report.RTF)
+	for _, stage := range report.Stages {
+		fmt.Printf("  %-12s %s\n", stage.Stage, stage.Duration.Round(time.Millisecond))
+	}
+	if report.PeakMemoryBytes > 0 {
+		fmt.Printf("peak memory: %.0f MiB\n", float64(report.PeakMemoryBytes)/(1<<20))
+	}
+	if flags.NArg() == 1 {
+		fmt.Printf("text:       %s\n", report.Text)
+	}
+	return nil
+}
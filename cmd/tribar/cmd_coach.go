@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/varavelio/tribar/internal/coach"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+// runCoachCommand prints the speaking report of the last days, 7 by default, e.g.
+// `tribar coach 30`.
+func runCoachCommand(logger logger.Logger, args []string) error {
+	days := 7
+	if len(args) > 1 {
+		return fmt.Errorf("usage: tribar coach [days]")
+	}
+	if len(args) == 1 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("invalid number of days %q", args[0])
+		}
+		days = parsed
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	stats, err := coach.Load(coach.FilePath())
+	if err != nil {
+		return err
+	}
+	if len(stats.Days) == 0 {
+		fmt.Println("no speaking statistics yet, enable speech_stats_enabled in the settings")
+		return nil
+	}
+
+	fmt.Print(stats.Report(time.Now(), days))
+	return nil
+}
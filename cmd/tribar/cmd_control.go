@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/control"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/pkg/api"
+	"github.com/varavelio/tribar/pkg/record"
+)
+
+// runToggleCommand toggles the recording of the running instance. Arguments in key=value
+// form override the settings for the dictation it starts, e.g.
+// `tribar toggle language=es prompt=Formal output=copy_only`.
+func runToggleCommand(logger logger.Logger, args []string) error {
+	overrides := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		overrides[key] = value
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandToggleRecording, overrides))
+}
+
+// runPrivacyCommand turns the privacy mode of the running instance on or off, or toggles
+// it without arguments, e.g. `tribar privacy on`.
+func runPrivacyCommand(logger logger.Logger, args []string) error {
+	var cmdArgs map[string]string
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && args[0] == "on":
+		cmdArgs = map[string]string{"active": "true"}
+	case len(args) == 1 && args[0] == "off":
+		cmdArgs = map[string]string{"active": "false"}
+	default:
+		return fmt.Errorf("usage: tribar privacy [on|off]")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandSetPrivacyMode, cmdArgs))
+}
+
+// runCalibrateCommand asks the running instance to measure its latencies, the report is
+// shown as a notification and logged.
+func runCalibrateCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandCalibrate, nil))
+}
+
+// runCancelCommand aborts the transcription in progress in the running instance.
+func runCancelCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandCancelTranscription, nil))
+}
+
+// runPauseCommand asks the running instance to pause the recording in progress or to
+// resume the paused one, depending on the command.
+func runPauseCommand(logger logger.Logger, name api.CommandName) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(name, nil))
+}
+
+// runSyncCommand asks the running instance to sync its history with the other machines
+// of the user, the result is shown as a notification.
+func runSyncCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandSyncHistory, nil))
+}
+
+// runForgetCommand asks the running instance to delete a history entry, or every entry
+// with --all, also from the synced history of the other machines.
+func runForgetCommand(logger logger.Logger, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: tribar forget <history entry ID|--all>")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	if args[0] == "--all" {
+		return control.Send(api.NewCommand(api.CommandClearHistory, nil))
+	}
+	return control.Send(api.NewCommand(api.CommandDeleteHistoryEntry, map[string]string{"id": args[0]}))
+}
+
+// runRetryCommand asks the running instance to post-process again the last transcription
+// whose post-processing failed.
+func runRetryCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	return control.Send(api.NewCommand(api.CommandRetryPostProcessing, nil))
+}
+
+// runMarkCommand flags the current moment of the recording in progress of the running
+// instance, with the arguments as an optional note, e.g. `tribar mark important bit`.
+func runMarkCommand(logger logger.Logger, args []string) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	var cmdArgs map[string]string
+	if note := strings.Join(args, " "); note != "" {
+		cmdArgs = map[string]string{"note": note}
+	}
+	return control.Send(api.NewCommand(api.CommandAddMarker, cmdArgs))
+}
+
+// runQuitCommand asks the running instance to exit.
+func runQuitCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+	return control.Send(api.NewCommand(api.CommandQuit, nil))
+}
+
+// runDownloadCommand asks the running instance to download the models it deferred on a
+// metered connection, now or at the given time of day.
+func runDownloadCommand(logger logger.Logger, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: tribar download [HH:MM]")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	var cmdArgs map[string]string
+	if len(args) == 1 {
+		cmdArgs = map[string]string{"at": args[0]}
+	}
+	return control.Send(api.NewCommand(api.CommandDownloadModels, cmdArgs))
+}
+
+// runDevicesCommand lists the input devices, marking the default one and the one selected
+// in the settings, or asks the running instance to record from another one, given by ID
+// or "default" for the system's default device.
+func runDevicesCommand(logger logger.Logger, args []string) error {
+	const usage = "usage: tribar devices [use <device ID>|default]"
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "use":
+		id := args[1]
+		if id == "default" {
+			id = ""
+		}
+		return control.Send(api.NewCommand(api.CommandSetInputDevice, map[string]string{"id": id}))
+	default:
+		return errors.New(usage)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	selected := settingsManager.Get().InputDeviceID
+
+	recorder, err := record.NewRecorder()
+	if err != nil {
+		return fmt.Errorf("error creating recorder: %w", err)
+	}
+	devices, err := recorder.Devices()
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		var marks []string
+		if device.Default {
+			marks = append(marks, "default")
+		}
+		if device.ID == selected {
+			marks = append(marks, "selected")
+		}
+		line := device.ID + "\t" + device.Name
+		if len(marks) > 0 {
+			line += " (" + strings.Join(marks, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runFormCommand lists the form templates, marking the selected one, or asks the running
+// instance to fill the template with the given name or ID with the next dictations, or to
+// stop with "off".
+func runFormCommand(logger logger.Logger, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: tribar form [template name or ID|off]")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	settings := settingsManager.Get()
+
+	if len(args) == 0 {
+		for _, template := range settings.FormTemplates {
+			line := template.Name + ": " + strings.Join(template.Fields, ", ")
+			if template.ID == settings.FormTemplateID {
+				line += " (selected)"
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	id := ""
+	if args[0] != "off" {
+		index := slices.IndexFunc(settings.FormTemplates, func(t config.FormTemplate) bool {
+			return t.ID == args[0] || strings.EqualFold(t.Name, args[0])
+		})
+		if index < 0 {
+			return fmt.Errorf("unknown form template %q", args[0])
+		}
+		id = settings.FormTemplates[index].ID
+	}
+	return control.Send(api.NewCommand(api.CommandSetFormTemplate, map[string]string{"id": id}))
+}
+
+// runScratchpadCommand asks the running instance to open the scratchpad window.
+func runScratchpadCommand(logger logger.Logger) error {
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+	return control.Send(api.NewCommand(api.CommandOpenScratchpad, nil))
+}
+
+// runModelsCommand asks the running instance to check for newer revisions of the
+// installed model files, or to replace the outdated ones with them.
+func runModelsCommand(logger logger.Logger, args []string) error {
+	if len(args) != 1 || args[0] != "check" && args[0] != "update" {
+		return fmt.Errorf("usage: tribar models check|update")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	if args[0] == "update" {
+		return control.Send(api.NewCommand(api.CommandUpdateModels, nil))
+	}
+	return control.Send(api.NewCommand(api.CommandCheckModelUpdates, nil))
+}
+
+// runShareCommand asks the running instance to copy a one-time link to a history entry,
+// the latest if no ID is given, served by its local server.
+func runShareCommand(logger logger.Logger, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: tribar share [history entry ID]")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	cmdArgs := map[string]string{}
+	if len(args) == 1 {
+		cmdArgs["id"] = args[0]
+	}
+	return control.Send(api.NewCommand(api.CommandShareHistoryEntry, cmdArgs))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+// runFeaturesCommand lists the feature flags, or enables, disables or resets one to its
+// default in the settings. A running instance picks the change up from the settings file.
+func runFeaturesCommand(logger logger.Logger, args []string) error {
+	const usage = "usage: tribar features [enable|disable|reset <feature>]"
+	if len(args) != 0 && len(args) != 2 {
+		return errors.New(usage)
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	if len(args) == 0 {
+		settings := settingsManager.Get()
+		for _, feature := range config.Features() {
+			state := "off"
+			if settings.FeatureEnabled(feature.Name) {
+				state = "on"
+			}
+			fmt.Printf("%-20s %-4s %s\n", feature.Name, state, feature.Description)
+		}
+		return nil
+	}
+
+	feature, ok := config.FindFeature(args[1])
+	if !ok {
+		return fmt.Errorf("unknown feature %q, run tribar features to list them", args[1])
+	}
+
+	settings := settingsManager.Get()
+	if settings.Features == nil {
+		settings.Features = map[config.Feature]bool{}
+	}
+	switch args[0] {
+	case "enable":
+		settings.Features[feature.Name] = true
+	case "disable":
+		settings.Features[feature.Name] = false
+	case "reset":
+		delete(settings.Features, feature.Name)
+	default:
+		return errors.New(usage)
+	}
+	if err := settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("error saving settings: %w", err)
+	}
+
+	if os.Getenv(config.FeaturesEnv) != "" {
+		fmt.Printf("note: %s is set and overrides the settings for the features it names\n", config.FeaturesEnv)
+	}
+	if feature.RequiresRestart {
+		fmt.Printf("%s saved, restart the app to apply it\n", feature.Name)
+		return nil
+	}
+	fmt.Printf("%s saved\n", feature.Name)
+	return nil
+}
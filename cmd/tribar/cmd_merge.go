@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/control"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/postprocess"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/api"
+	"github.com/varavelio/tribar/pkg/audio"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// runMergeCommand merges dictations into one document in chronological order. History
+// entry IDs are merged by the running instance into the exports directory; audio files are
+// transcribed with the local model, ordered by modification time, and printed.
+func runMergeCommand(logger logger.Logger, args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	separator := flags.String("separator", "", "text between dictations, ${time} is the time of the next one (default from the settings)")
+	postProcess := flags.Bool("postprocess", false, "post-process the merged text with the selected prompt (default from the settings)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tribar merge [-separator text] [-postprocess] <history entry ID>... | <audio file>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("at least two dictations are needed to merge")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	// Flags left unset keep the values of the settings.
+	overrides := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "separator":
+			overrides["separator"] = unescapeSeparator(*separator)
+		case "postprocess":
+			overrides["post_process"] = strconv.FormatBool(*postProcess)
+		}
+	})
+
+	for _, arg := range flags.Args() {
+		if _, err := strconv.Atoi(arg); err != nil {
+			return mergeFiles(logger, flags.Args(), overrides)
+		}
+	}
+
+	overrides["ids"] = strings.Join(flags.Args(), ",")
+	return control.Send(api.NewCommand(api.CommandMergeHistory, overrides))
+}
+
+// unescapeSeparator turns the \n and \t typed in a shell argument into line breaks and
+// tabs.
+func unescapeSeparator(separator string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(separator)
+}
+
+// mergeFiles transcribes audio files with the local model and prints their texts merged in
+// the order they were recorded, with the merge overrides of runMergeCommand.
+func mergeFiles(logger logger.Logger, files []string, overrides map[string]string) error {
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	settings := settingsManager.Get()
+
+	separator, postProcess := settings.MergeSeparator, settings.MergePostProcess
+	if value, ok := overrides["separator"]; ok {
+		separator = value
+	}
+	if value, ok := overrides["post_process"]; ok {
+		postProcess = value == "true"
+	}
+
+	if err := registerExternalTranscriber(settings); err != nil {
+		return err
+	}
+	if info, ok := transcribe.ModelForLanguage(settings.ModelID, settings.Language); ok {
+		settings.ModelID = info.ID
+	}
+
+	// Interrupting the command aborts the transcription in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	transcriber, err := newBatchTranscriber(ctx, logger, settings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = transcriber.Shutdown() }()
+
+	entries := make([]state.HistoryEntry, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		samples, err := audio.Decode(data)
+		if err != nil {
+			return fmt.Errorf("%s: error decoding audio: %w", file, err)
+		}
+
+		result, err := transcriber.TranscribeSamplesWithPartials(ctx, samples, nil)
+		if err != nil {
+			return fmt.Errorf("%s: error transcribing: %w", file, err)
+		}
+		entries = append(entries, state.HistoryEntry{Text: result.Text, AudioPath: file, Timestamp: info.ModTime()})
+	}
+
+	merged := export.MergeTranscripts(entries, separator)
+	if postProcess {
+		postProcessor := postprocess.New(logger, settingsManager)
+		if !postProcessor.IsConfigured() {
+			return errors.New("post-processing the merged text requires an AI provider")
+		}
+
+		settings.PostProcessEnabled = true
+		if merged, err = postProcessor.Process(ctx, settings, merged); err != nil {
+			return fmt.Errorf("failed to post-process the merged text: %w", err)
+		}
+	}
+
+	fmt.Println(merged)
+	return nil
+}
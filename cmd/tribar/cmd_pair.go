@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/share"
+	"github.com/varavelio/tribar/internal/upload"
+)
+
+// runPairCommand pairs a phone with the app: it enables the uploads and the local server,
+// creates the pairing token on first use (or a new one with --reset, unpairing the other
+// devices) and shows the upload URL, as a QR code when qrencode is installed.
+func runPairCommand(logger logger.Logger, args []string) error {
+	reset := len(args) == 1 && args[0] == "--reset"
+	if len(args) > 1 || len(args) == 1 && !reset {
+		return fmt.Errorf("usage: tribar pair [--reset]")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	settings := settingsManager.Get()
+	serverWasEnabled := settings.LocalServerEnabled
+	if reset || settings.PhoneUploadToken == "" {
+		settings.PhoneUploadToken = upload.NewToken()
+	}
+	settings.PhoneUploadEnabled = true
+	settings.LocalServerEnabled = true
+	if err := settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("error saving settings: %w", err)
+	}
+
+	settings = settingsManager.Get()
+	if !settings.PhoneUploadEnabled || !settings.LocalServerEnabled {
+		return errors.New("phone uploads are disabled by the settings policy")
+	}
+
+	baseURL, err := share.BaseURL(settings.LocalServerAddr)
+	if err != nil {
+		return err
+	}
+	pairingURL := upload.PairingURL(baseURL, settings.PhoneUploadToken)
+
+	fmt.Println("Send audio from your phone (e.g. with a shortcut) as the body or the \"file\" form")
+	fmt.Println("field of a POST request to the following URL. The text is copied to the clipboard")
+	fmt.Println("of this computer and returned as JSON.")
+	fmt.Println()
+	fmt.Println("  " + pairingURL)
+	fmt.Println()
+
+	qr, err := exec.Command("qrencode", "-t", "ANSIUTF8", pairingURL).Output()
+	if err != nil {
+		fmt.Println("Install qrencode to show the URL as a QR code.")
+	}
+	if err == nil {
+		_, _ = os.Stdout.Write(qr)
+	}
+
+	if addr, err := net.ResolveTCPAddr("tcp", settings.LocalServerAddr); err == nil && addr.IP.IsLoopback() {
+		fmt.Printf("\nThe local server only listens on %s, set local_server_addr to \":%d\" so the phone can reach it.\n", settings.LocalServerAddr, addr.Port)
+	}
+	if !serverWasEnabled {
+		fmt.Printf("\nRestart %s to start the local server.\n", config.AppName)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/prompts"
+)
+
+// runPromptsCommand manages the post-processing prompts: it imports the prompts of a
+// community feed, lists the prior versions of a prompt and rolls a prompt back to one.
+func runPromptsCommand(logger logger.Logger, args []string) error {
+	const usage = "usage: tribar prompts import [-y] <URL or file> | versions <prompt> | rollback <prompt> <version>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	switch {
+	case args[0] == "import" && len(args) == 2:
+		return importPrompts(settingsManager, args[1], false)
+	case args[0] == "import" && len(args) == 3 && args[1] == "-y":
+		return importPrompts(settingsManager, args[2], true)
+	case args[0] == "versions" && len(args) == 2:
+		return listPromptVersions(settingsManager.Get(), args[1])
+	case args[0] == "rollback" && len(args) == 3:
+		return rollbackPrompt(settingsManager, args[1], args[2])
+	default:
+		return errors.New(usage)
+	}
+}
+
+// importPrompts imports the prompts of a community feed (a URL or a local JSON file) into
+// the settings. The prompts to add are previewed and only saved once confirmed, unless
+// confirmed is already true.
+func importPrompts(settingsManager *config.SettingsManager, source string, confirmed bool) error {
+	settings := settingsManager.Get()
+
+	imported, err := prompts.Fetch(context.Background(), source)
+	if err != nil {
+		return err
+	}
+
+	merged, added := prompts.Merge(settings.Prompts, imported)
+	if len(added) == 0 {
+		fmt.Println("every prompt of the feed is already in the settings")
+		return nil
+	}
+
+	fmt.Printf("%d of %d prompts will be added:\n", len(added), len(imported))
+	for _, prompt := range added {
+		fmt.Printf("\n  %s\n    %s\n", prompt.Name, firstLine(prompt.Body))
+	}
+
+	if !confirmed {
+		fmt.Print("\nImport them? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println("nothing was imported")
+			return nil
+		}
+	}
+
+	settings.Prompts = merged
+	if err := settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("error saving settings: %w", err)
+	}
+	fmt.Printf("imported %d prompts\n", len(added))
+	return nil
+}
+
+// listPromptVersions prints the prior versions of a prompt, numbered for rollbackPrompt.
+func listPromptVersions(settings config.Settings, idOrName string) error {
+	prompt, ok := settings.FindPrompt(idOrName)
+	if !ok {
+		return fmt.Errorf("prompt %q not found", idOrName)
+	}
+	if len(prompt.Versions) == 0 {
+		fmt.Printf("%s has not been edited\n", prompt.Name)
+		return nil
+	}
+
+	for n, version := range prompt.Versions {
+		fmt.Printf("%3d  %s  %s\n", n+1, version.SavedAt.Local().Format("2006-01-02 15:04"), firstLine(version.Body))
+	}
+	fmt.Printf("%-23s%s\n", "current", firstLine(prompt.Body))
+	return nil
+}
+
+// rollbackPrompt restores a prior version of a prompt, as numbered by listPromptVersions.
+func rollbackPrompt(settingsManager *config.SettingsManager, idOrName, version string) error {
+	prompt, ok := settingsManager.Get().FindPrompt(idOrName)
+	if !ok {
+		return fmt.Errorf("prompt %q not found", idOrName)
+	}
+
+	n, err := strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	if err := settingsManager.RollbackPrompt(prompt.ID, n); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s rolled back to version %d\n", prompt.Name, n)
+	return nil
+}
+
+// firstLine returns the first line of a text, shortened for previews.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if runes := []rune(line); len(runes) > 72 {
+		return string(runes[:72]) + "..."
+	}
+	return line
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/routing"
+	"github.com/varavelio/tribar/internal/textrules"
+)
+
+// runRulesCommand manages the text rules of the settings: it tests the routing rules
+// against a sample text, and exports the prompts, routing rules, normalization profiles
+// and history tag rules to a JSON file or imports them from one.
+func runRulesCommand(logger logger.Logger, args []string) error {
+	const usage = "usage: tribar rules test <text> | export <file> | import [-y] <file>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	switch {
+	case args[0] == "test" && len(args) == 2:
+		return testRoutingRules(settingsManager.Get(), args[1])
+	case args[0] == "export" && len(args) == 2:
+		if err := textrules.WriteFile(args[1], settingsManager.Get()); err != nil {
+			return err
+		}
+		fmt.Printf("text rules exported to %s\n", args[1])
+		return nil
+	case args[0] == "import" && len(args) == 2:
+		return importTextRules(settingsManager, args[1], false)
+	case args[0] == "import" && len(args) == 3 && args[1] == "-y":
+		return importTextRules(settingsManager, args[2], true)
+	default:
+		return errors.New(usage)
+	}
+}
+
+// importTextRules imports the text rules exported to a file into the settings. The number
+// of rules to add is previewed and they are only saved once confirmed, unless confirmed
+// is already true.
+func importTextRules(settingsManager *config.SettingsManager, path string, confirmed bool) error {
+	file, err := textrules.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	settings, added := textrules.Merge(settingsManager.Get(), file)
+	if added.Total() == 0 {
+		fmt.Println("every rule of the file is already in the settings")
+		return nil
+	}
+
+	fmt.Printf("%d prompts, %d routing rules, %d normalization profiles and %d history tag rules will be added\n",
+		added.Prompts, added.RoutingRules, added.NormalizationProfiles, added.HistoryTagRules)
+
+	if !confirmed {
+		fmt.Print("Import them? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println("nothing was imported")
+			return nil
+		}
+	}
+
+	if err := settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("error saving settings: %w", err)
+	}
+	fmt.Printf("imported %d rules\n", added.Total())
+	return nil
+}
+
+// testRoutingRules prints the routing rule that matches a sample text and the text that
+// would be delivered.
+func testRoutingRules(settings config.Settings, text string) error {
+	result, matched, err := routing.Match(settings.RoutingRules, text)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if !matched {
+		fmt.Println("no rule matches, the text is delivered normally")
+		return nil
+	}
+
+	if _, err := routing.Apply(settings, result.Rule); err != nil {
+		return err
+	}
+
+	fmt.Printf("rule:   %s\n", result.Rule.Name)
+	fmt.Printf("text:   %s\n", result.Text)
+	if result.Rule.PromptID != "" {
+		fmt.Printf("prompt: %s\n", result.Rule.PromptID)
+	}
+	if result.Rule.OutputMode != "" {
+		fmt.Printf("output: %s\n", result.Rule.OutputMode)
+	}
+	if result.Rule.SinkID != "" {
+		fmt.Printf("sink:   %s\n", result.Rule.SinkID)
+	}
+	return nil
+}
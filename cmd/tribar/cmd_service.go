@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/service"
+)
+
+// runServiceCommand installs or removes the background service definition. Uninstalling
+// with --purge also deletes the settings, models, recordings and exports.
+func runServiceCommand(logger logger.Logger, args []string) error {
+	usage := fmt.Errorf("usage: tribar service <install|uninstall [--purge]>")
+	if len(args) == 0 || len(args) > 2 {
+		return usage
+	}
+
+	purge := len(args) == 2 && args[1] == "--purge"
+	if len(args) == 2 && (!purge || args[0] != "uninstall") {
+		return usage
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	switch args[0] {
+	case "install":
+		path, err := service.Install()
+		if err != nil {
+			return fmt.Errorf("error installing service: %w", err)
+		}
+		fmt.Printf("service installed at %s\n", path)
+	case "uninstall":
+		path, err := service.Uninstall()
+		if err != nil {
+			return fmt.Errorf("error uninstalling service: %w", err)
+		}
+		fmt.Printf("service removed from %s\n", path)
+		if !purge {
+			return nil
+		}
+		if err := service.PurgeData(config.DirectoryConfig, config.DirectoryData); err != nil {
+			return fmt.Errorf("error removing app data: %w", err)
+		}
+		fmt.Printf("removed %s and %s\n", config.DirectoryConfig, config.DirectoryData)
+	default:
+		return fmt.Errorf("unknown service command %q", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/control"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/api"
+	"github.com/varavelio/tribar/pkg/audio"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// runSubtitlesCommand writes subtitles, e.g. `tribar subtitles -format vtt episode.mp3`.
+// Audio files are transcribed with the local model and their subtitles written next to
+// them (episode.vtt); without files, or with a history entry ID, the running instance
+// exports the latest or the given dictation.
+func runSubtitlesCommand(logger logger.Logger, args []string) error {
+	flags := flag.NewFlagSet("subtitles", flag.ContinueOnError)
+	formatName := flags.String("format", "srt", "subtitle format, srt or vtt")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tribar subtitles [-format srt|vtt] [history entry ID | audio file...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	format, err := export.ParseSubtitleFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	files := flags.Args()
+	if len(files) <= 1 {
+		cmdArgs := map[string]string{"format": string(format)}
+		if len(files) == 1 {
+			if _, err := strconv.Atoi(files[0]); err != nil {
+				return writeFileSubtitles(logger, files, format)
+			}
+			cmdArgs["id"] = files[0]
+		}
+		return control.Send(api.NewCommand(api.CommandExportSubtitles, cmdArgs))
+	}
+	return writeFileSubtitles(logger, files, format)
+}
+
+// writeFileSubtitles transcribes audio files with the local model and writes their
+// subtitles next to them, with the extension of the format.
+func writeFileSubtitles(logger logger.Logger, files []string, format export.SubtitleFormat) error {
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	settings := settingsManager.Get()
+
+	if err := registerExternalTranscriber(settings); err != nil {
+		return err
+	}
+	if info, ok := transcribe.ModelForLanguage(settings.ModelID, settings.Language); ok {
+		settings.ModelID = info.ID
+	}
+
+	// Interrupting the command aborts the transcription in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	transcriber, err := newBatchTranscriber(ctx, logger, settings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = transcriber.Shutdown() }()
+
+	failed := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("transcription interrupted: %w", err)
+		}
+
+		path, err := writeSubtitles(ctx, transcriber, file, format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: %s\n", file, path)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+	return nil
+}
+
+// writeSubtitles transcribes an audio file and writes its subtitles, returning their path.
+func writeSubtitles(ctx context.Context, transcriber *transcribe.Instance, file string, format export.SubtitleFormat) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	samples, err := audio.Decode(data)
+	if err != nil {
+		return "", fmt.Errorf("error decoding audio: %w", err)
+	}
+
+	result, err := transcriber.TranscribeSamplesWithPartials(ctx, samples, nil)
+	if err != nil {
+		return "", fmt.Errorf("error transcribing: %w", err)
+	}
+
+	var segments []state.Segment
+	for _, segment := range result.Segments() {
+		segments = append(segments, state.Segment{Text: segment.Text, Start: segment.Start, End: segment.End})
+	}
+	if len(segments) == 0 && result.Text != "" {
+		return "", errors.New("the model does not report timestamps")
+	}
+
+	path := strings.TrimSuffix(file, filepath.Ext(file)) + "." + string(format)
+	if err := os.WriteFile(path, []byte(export.Subtitles(format, segments)), 0644); err != nil {
+		return "", fmt.Errorf("error writing subtitles: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/varavelio/tribar/internal/cache"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/onnx"
+	"github.com/varavelio/tribar/internal/power"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// runTranscribeCommand transcribes audio files (WAV, or any format ffmpeg decodes) with
+// the local model and prints their text. Files already transcribed with the same settings
+// (see transcriptCacheKey) are served from the cache first, then the model is loaded and
+// the others are transcribed as one batch.
+func runTranscribeCommand(logger logger.Logger, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("usage: tribar transcribe <audio file>...")
+	}
+
+	if err := config.EnsureDirectories(logger); err != nil {
+		return fmt.Errorf("error ensuring app directories: %w", err)
+	}
+
+	settingsManager, err := config.NewSettingsManager()
+	if err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	settings := settingsManager.Get()
+
+	transcriptCache := cache.New(config.DirectoryCache, int64(settings.TranscriptCacheMaxMB)<<20)
+
+	if err := registerExternalTranscriber(settings); err != nil {
+		return err
+	}
+
+	if info, ok := transcribe.ModelForLanguage(settings.ModelID, settings.Language); ok {
+		settings.ModelID = info.ID
+	}
+
+	// Interrupting the command aborts the transcription in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	failed := 0
+	keys := make(map[string]string, len(files))
+	var uncached []string
+	for _, file := range files {
+		wavData, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+
+		key := transcriptCacheKey(wavData, settings)
+		if entry, ok := transcriptCache.Get(key); ok {
+			fmt.Printf("%s: %s\n", file, entry.Text)
+			continue
+		}
+		keys[file] = key
+		uncached = append(uncached, file)
+	}
+
+	if len(uncached) > 0 {
+		transcriber, err := newBatchTranscriber(ctx, logger, settings)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = transcriber.Shutdown() }()
+
+		for _, batchResult := range transcriber.TranscribeBatch(ctx, uncached) {
+			if batchResult.Err != nil {
+				fmt.Fprintln(os.Stderr, batchResult.Err)
+				failed++
+				continue
+			}
+
+			err := transcriptCache.Put(keys[batchResult.Path], cache.Entry{
+				Text:       batchResult.Result.Text,
+				Confidence: batchResult.Result.Confidence(),
+				Model:      settings.ModelID,
+				CreatedAt:  time.Now(),
+			})
+			if err != nil {
+				logger.Warn(context.Background(), "failed to cache transcription", "file", batchResult.Path, "err", err)
+			}
+
+			fmt.Printf("%s: %s\n", batchResult.Path, batchResult.Result.Text)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("transcription interrupted: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+	return nil
+}
+
+// transcriptCacheKey returns the cache key of an audio file transcribed with the settings.
+// It covers every setting that changes the text the transcribe command prints: the model,
+// the language, the precision, the TDT decoding flag and the chunk duration.
+func transcriptCacheKey(wavData []byte, settings config.Settings) string {
+	precision := transcribe.PrecisionInt8
+	if transcribe.Precision(settings.ModelPrecision) == transcribe.PrecisionFP32 {
+		precision = transcribe.PrecisionFP32
+	}
+
+	return cache.Key(wavData, settings.ModelID,
+		settings.Language,
+		string(precision),
+		strconv.FormatBool(settings.FeatureEnabled(config.FeatureTDTDecoding)),
+		settings.Advanced.TranscriptionChunkDuration().String(),
+	)
+}
+
+// newBatchTranscriber creates a transcriber with the model, precision and decoding
+// selected in the settings, downloading the model if needed. Until ctx is done, the
+// chunks transcribed at the same time follow the CPU load and thermal pressure like in
+// the app.
+func newBatchTranscriber(ctx context.Context, logger logger.Logger, settings config.Settings) (*transcribe.Instance, error) {
+	if err := onnx.EnsureSharedLibrary(logger); err != nil {
+		return nil, fmt.Errorf("error ensuring ONNX Runtime shared library: %w", err)
+	}
+
+	downloadClient, err := newDownloadClient(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedLibraryPath, executionProvider := selectRuntime(ctx, logger, settings)
+	transcriber, err := transcribe.New(transcribe.Options{
+		SharedLibraryPath:  sharedLibraryPath,
+		ModelDir:           config.DirectoryModels,
+		ModelID:            settings.ModelID,
+		ExecutionProvider:  executionProvider,
+		CUDADeviceID:       settings.CUDADeviceID,
+		MirrorURL:          settings.ModelMirror(),
+		DownloadBufferSize: settings.Advanced.DownloadBufferSize(),
+		DownloadRetries:    settings.Advanced.DownloadRetryCount(),
+		DownloadClient:     downloadClient,
+		ChunkDuration:      settings.Advanced.TranscriptionChunkDuration(),
+		ChunkWorkers:       settings.Advanced.Workers(),
+		Precision:          transcribe.Precision(settings.ModelPrecision),
+		VerifyChecksums:    settings.VerifyModelChecksums,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating transcriber: %w", err)
+	}
+	transcriber.SetIntraOpThreads(settings.Advanced.InferenceThreads)
+	transcriber.SetTDTDecoding(settings.FeatureEnabled(config.FeatureTDTDecoding))
+	go power.NewLoadMonitor(logger).Run(ctx, func(level power.ThrottleLevel) {
+		transcriber.SetChunkWorkers(level.Workers(settings.Advanced.Workers()))
+	})
+
+	if err := transcriber.DownloadModels(nil); err != nil {
+		_ = transcriber.Shutdown()
+		return nil, fmt.Errorf("error downloading models: %w", err)
+	}
+
+	if err := transcriber.LoadModels(); err != nil {
+		_ = transcriber.Shutdown()
+		return nil, fmt.Errorf("error loading models: %w", err)
+	}
+
+	return transcriber, nil
+}
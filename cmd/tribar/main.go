@@ -2,41 +2,79 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"syscall"
+	"time"
 
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/calendar"
 	"github.com/varavelio/tribar/internal/clipboard"
+	"github.com/varavelio/tribar/internal/coach"
 	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/control"
 	"github.com/varavelio/tribar/internal/engine"
+	"github.com/varavelio/tribar/internal/history"
 	"github.com/varavelio/tribar/internal/logger"
 	"github.com/varavelio/tribar/internal/notify"
+	"github.com/varavelio/tribar/internal/ocr"
 	"github.com/varavelio/tribar/internal/onnx"
 	"github.com/varavelio/tribar/internal/postprocess"
-	"github.com/varavelio/tribar/internal/record"
+	"github.com/varavelio/tribar/internal/power"
+	"github.com/varavelio/tribar/internal/remote"
+	"github.com/varavelio/tribar/internal/scratchpad"
+	"github.com/varavelio/tribar/internal/service"
+	"github.com/varavelio/tribar/internal/share"
+	"github.com/varavelio/tribar/internal/sink"
 	"github.com/varavelio/tribar/internal/sound"
 	"github.com/varavelio/tribar/internal/state"
 	"github.com/varavelio/tribar/internal/systray"
-	"github.com/varavelio/tribar/internal/transcribe"
+	"github.com/varavelio/tribar/internal/todo"
+	"github.com/varavelio/tribar/internal/upload"
+	"github.com/varavelio/tribar/pkg/api"
+	"github.com/varavelio/tribar/pkg/record"
+	"github.com/varavelio/tribar/pkg/transcribe"
 )
 
+// errRestartRequired is returned by run when the app was updated and the service manager
+// supervising it must start the new version.
+var errRestartRequired = errors.New("restart required after update")
+
 type cliFlags struct {
-	Debug bool
+	Debug    bool
+	Takeover bool
+	Args     []string
 }
 
 func main() {
 	flags := parseFlags()
 	logger := logger.NewSlogLogger(flags.Debug)
-	if err := run(logger); err != nil {
+
+	if len(flags.Args) > 0 {
+		if err := runCommand(logger, flags.Args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(logger, flags.Takeover); err != nil {
+		if errors.Is(err, errRestartRequired) {
+			logger.Info(context.Background(), "exiting so the service manager restarts the updated app")
+			os.Exit(1)
+		}
 		logger.Error(context.Background(), "error while running the app", "err", err)
 		os.Exit(1)
 	}
 }
 
-func run(logger logger.Logger) error {
+func run(logger logger.Logger, takeover bool) error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -50,6 +88,14 @@ func run(logger logger.Logger) error {
 		return fmt.Errorf("error ensuring app directories: %w", err)
 	}
 
+	if err := ensureSingleInstance(ctx, logger, takeover); err != nil {
+		return err
+	}
+
+	if err := service.SetAppUserModelID(); err != nil {
+		logger.Warn(ctx, "failed to set the app user model ID", "err", err)
+	}
+
 	if err := onnx.EnsureSharedLibrary(logger); err != nil {
 		return fmt.Errorf("error ensuring ONNX Runtime shared library: %w", err)
 	}
@@ -62,59 +108,376 @@ func run(logger logger.Logger) error {
 
 	appState := state.New(settings.HistoryLimit)
 
+	historyStore, err := history.OpenSQLite(history.FilePath())
+	if err != nil {
+		return fmt.Errorf("error opening history: %w", err)
+	}
+	defer func() { _ = historyStore.Close() }()
+	entries, err := historyStore.Load()
+	if err != nil {
+		return fmt.Errorf("error loading history: %w", err)
+	}
+	appState.LoadHistory(entries)
+
 	recorder, err := record.NewRecorder()
 	if err != nil {
 		return fmt.Errorf("error creating recorder: %w", err)
 	}
+	recorder.SetDevice(settings.InputDeviceID)
+
+	if err := registerExternalTranscriber(settings); err != nil {
+		return err
+	}
 
-	transcriber, err := transcribe.New()
+	downloadClient, err := newDownloadClient(settings)
+	if err != nil {
+		return err
+	}
+
+	sharedLibraryPath, executionProvider := selectRuntime(ctx, logger, settings)
+	transcriber, err := transcribe.New(transcribe.Options{
+		SharedLibraryPath:  sharedLibraryPath,
+		ModelDir:           config.DirectoryModels,
+		ModelID:            settings.ModelID,
+		ExecutionProvider:  executionProvider,
+		CUDADeviceID:       settings.CUDADeviceID,
+		MirrorURL:          settings.ModelMirror(),
+		DownloadBufferSize: settings.Advanced.DownloadBufferSize(),
+		DownloadRetries:    settings.Advanced.DownloadRetryCount(),
+		DownloadClient:     downloadClient,
+		ChunkDuration:      settings.Advanced.TranscriptionChunkDuration(),
+		ChunkWorkers:       settings.Advanced.Workers(),
+		Precision:          transcribe.Precision(settings.ModelPrecision),
+		VerifyChecksums:    settings.VerifyModelChecksums,
+	})
 	if err != nil {
 		return fmt.Errorf("error creating transcriber: %w", err)
 	}
 	defer func() { _ = transcriber.Shutdown() }()
 
+	vad := transcribe.NewVAD(config.DirectoryModels)
+	vad.SetMirrorURL(settings.ModelMirror())
+	vad.SetDownloadBufferSize(settings.Advanced.DownloadBufferSize())
+	vad.SetDownloadRetries(settings.Advanced.DownloadRetryCount())
+	vad.SetDownloadClient(downloadClient)
+	defer func() { _ = vad.Close() }()
+
+	punctuator := transcribe.NewPunctuator(config.DirectoryModels)
+	punctuator.SetBaseURL(settings.PunctuationModelURL)
+	punctuator.SetMirrorURL(settings.ModelMirror())
+	punctuator.SetDownloadBufferSize(settings.Advanced.DownloadBufferSize())
+	punctuator.SetDownloadRetries(settings.Advanced.DownloadRetryCount())
+	punctuator.SetDownloadClient(downloadClient)
+	defer func() { _ = punctuator.Close() }()
+
 	notifier := notify.New(logger, notify.Settings{
 		NotifyOnError:  settings.NotifyOnError,
 		NotifyOnStart:  settings.NotifyOnStart,
 		NotifyOnFinish: settings.NotifyOnFinish,
 	})
+	defer flushOnShutdown(notifier.Shutdown)
 
 	soundPlayer := sound.New(logger, sound.Settings{
 		SoundOnStart:  settings.SoundOnStart,
 		SoundOnFinish: settings.SoundOnFinish,
 	})
-	defer soundPlayer.Shutdown()
+	defer flushOnShutdown(soundPlayer.Shutdown)
 
 	cpb := clipboard.New(logger)
+	cpb.SetPasteDelay(settings.Advanced.PasteDelay())
+
+	remoteTranscriber := remote.New(logger, settingsManager)
+
+	auditLog := audit.New(logger, settingsManager)
 
 	postProcessor := postprocess.New(logger, settingsManager)
 
+	cal := calendar.New(logger, settingsManager)
+
+	sinks := sink.New(logger, settingsManager)
+
+	textRecognizer := ocr.New(logger)
+
+	shareServer := share.NewServer(logger)
+
+	scratch := scratchpad.New(logger)
+
+	actionItems := todo.New(logger, settingsManager, postProcessor, sinks)
+
+	speechStats := coach.New(logger, settingsManager)
+
+	historySync := history.NewSyncer(logger, settingsManager)
+
 	eng := engine.New(engine.Dependencies{
 		Logger:          logger,
 		SettingsManager: settingsManager,
 		State:           appState,
 		Recorder:        recorder,
 		Transcriber:     transcriber,
+		VAD:             vad,
+		Punctuator:      punctuator,
+		Remote:          remoteTranscriber,
+		Audit:           auditLog,
 		PostProcess:     postProcessor,
 		Writer:          cpb,
 		Notifier:        notifier,
 		Sound:           soundPlayer,
+		Calendar:        cal,
+		Sinks:           sinks,
+		Share:           shareServer,
+		Scratchpad:      scratch,
+		OCR:             textRecognizer,
+		Todo:            actionItems,
+		Coach:           speechStats,
+		History:         historyStore,
+		HistorySync:     historySync,
 	})
 	defer eng.Shutdown()
 
-	go loadModelsAsync(ctx, logger, eng)
+	shareServer.Handle("POST "+upload.Path, upload.NewHandler(logger, settingsManager, eng.TranscribeUpload))
+
+	go loadModelsAsync(ctx, logger, settingsManager, eng)
+	go eng.CheckOutputHelpers()
+	go eng.CheckTextRecognition()
+	go eng.RunHistorySync()
+	go cal.Run(ctx)
+	go settingsManager.Watch(ctx, logger, eng.ApplySettings)
+	go settingsManager.WatchSyncFolder(ctx, logger, eng.ApplySettings)
+	go power.NewSessionWatcher(logger).Run(ctx, eng.SetSessionLocked)
+	go power.NewPowerSourceWatcher(logger).Run(ctx, eng.SetOnBattery)
+	go power.NewLoadMonitor(logger).Run(ctx, eng.SetThrottleLevel)
+	if settings.LocalServerEnabled {
+		go func() {
+			if err := shareServer.Run(ctx, settings.LocalServerAddr); err != nil {
+				logger.Warn(ctx, "local server unavailable, share links will not work", "err", err)
+			}
+		}()
+	}
+	go func() {
+		handler := func(cmd api.Command) error {
+			if cmd.Name == api.CommandQuit {
+				logger.Info(ctx, "quit requested through the control socket")
+				stop()
+				return nil
+			}
+			return eng.Execute(cmd)
+		}
+		if err := control.NewServer(logger, handler).Run(ctx); err != nil {
+			logger.Warn(ctx, "control socket unavailable, CLI commands will not work", "err", err)
+		}
+	}()
+
+	updated := make(chan struct{})
+	go service.NewUpdateWatcher(logger).Run(ctx, func() {
+		if !settingsManager.Get().RelaunchAfterUpdate {
+			return
+		}
+		waitUntilIdle(ctx, appState)
+		if ctx.Err() == nil {
+			close(updated)
+		}
+	})
 
 	stray := systray.New(appState, eng, stop)
 	go stray.Start()
 	defer stray.Shutdown()
 
-	<-ctx.Done()
+	if err := service.NotifyReady(); err != nil {
+		logger.Warn(ctx, "failed to notify service manager readiness", "err", err)
+	}
+
+	relaunch := false
+	select {
+	case <-ctx.Done():
+	case <-updated:
+		relaunch = true
+	}
+
 	stop()
+	_ = service.NotifyStopping()
 	logger.Info(ctx, "shutting down gracefully...")
+
+	if !relaunch {
+		return nil
+	}
+
+	supervised, err := service.Relaunch()
+	if err != nil {
+		return fmt.Errorf("error relaunching after update: %w", err)
+	}
+	if supervised {
+		return errRestartRequired
+	}
+	logger.Info(ctx, "relaunching the updated app")
+	return nil
+}
+
+// waitUntilIdle blocks until no dictation is in progress or the context is canceled.
+func waitUntilIdle(ctx context.Context, appState *state.Instance) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		status, _ := appState.GetStatus()
+		if status == state.StatusLoaded || status == state.StatusUnloaded {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runCommand executes a one-shot CLI subcommand instead of starting the app.
+func runCommand(logger logger.Logger, args []string) error {
+	switch args[0] {
+	case "service":
+		return runServiceCommand(logger, args[1:])
+	case "toggle":
+		return runToggleCommand(logger, args[1:])
+	case "privacy":
+		return runPrivacyCommand(logger, args[1:])
+	case "calibrate":
+		return runCalibrateCommand(logger)
+	case "cancel":
+		return runCancelCommand(logger)
+	case "pause":
+		return runPauseCommand(logger, api.CommandPauseRecording)
+	case "resume":
+		return runPauseCommand(logger, api.CommandResumeRecording)
+	case "sync":
+		return runSyncCommand(logger)
+	case "forget":
+		return runForgetCommand(logger, args[1:])
+	case "retry":
+		return runRetryCommand(logger)
+	case "mark":
+		return runMarkCommand(logger, args[1:])
+	case "share":
+		return runShareCommand(logger, args[1:])
+	case "pair":
+		return runPairCommand(logger, args[1:])
+	case "rules":
+		return runRulesCommand(logger, args[1:])
+	case "prompts":
+		return runPromptsCommand(logger, args[1:])
+	case "features":
+		return runFeaturesCommand(logger, args[1:])
+	case "transcribe":
+		return runTranscribeCommand(logger, args[1:])
+	case "audit":
+		return runAuditCommand(logger, args[1:])
+	case "benchmark":
+		return runBenchmarkCommand(logger, args[1:])
+	case "coach":
+		return runCoachCommand(logger, args[1:])
+	case "subtitles":
+		return runSubtitlesCommand(logger, args[1:])
+	case "merge":
+		return runMergeCommand(logger, args[1:])
+	case "download":
+		return runDownloadCommand(logger, args[1:])
+	case "quit":
+		return runQuitCommand(logger)
+	case "models":
+		return runModelsCommand(logger, args[1:])
+	case "scratchpad":
+		return runScratchpadCommand(logger)
+	case "devices":
+		return runDevicesCommand(logger, args[1:])
+	case "form":
+		return runFormCommand(logger, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// newDownloadClient creates the HTTP client of the model downloads with the proxy, CA
+// file and timeout of the settings.
+func newDownloadClient(settings config.Settings) (*http.Client, error) {
+	client, err := transcribe.NewDownloadClient(transcribe.DownloadNetwork{
+		ProxyURL: settings.DownloadProxy(),
+		CAFile:   settings.DownloadCA(),
+		Timeout:  settings.Advanced.DownloadTimeout(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error configuring model downloads: %w", err)
+	}
+	return client, nil
+}
+
+// registerExternalTranscriber makes the external transcriber configured in the settings,
+// if any, available as the "external" model.
+func registerExternalTranscriber(settings config.Settings) error {
+	if len(settings.ExternalTranscriberCommand) == 0 {
+		return nil
+	}
+
+	info := transcribe.ExternalModelInfo(
+		transcribe.ExternalModelID,
+		"External ("+filepath.Base(settings.ExternalTranscriberCommand[0])+")",
+		settings.ExternalTranscriberLanguages,
+		settings.ExternalTranscriberCommand,
+	)
+	if err := transcribe.Register(info); err != nil {
+		return fmt.Errorf("error registering the external transcriber: %w", err)
+	}
 	return nil
 }
 
-func loadModelsAsync(ctx context.Context, logger logger.Logger, eng *engine.Engine) {
+// selectRuntime returns the ONNX Runtime library and execution provider to use. CUDA needs
+// the GPU build of the runtime; if it cannot be found, or the gpu_providers feature is
+// disabled, the embedded CPU runtime is used.
+func selectRuntime(ctx context.Context, logger logger.Logger, settings config.Settings) (string, transcribe.ExecutionProvider) {
+	if transcribe.ExecutionProvider(settings.ExecutionProvider) != transcribe.ExecutionProviderCUDA {
+		return onnx.SharedLibraryPath, transcribe.ExecutionProviderCPU
+	}
+	if !settings.FeatureEnabled(config.FeatureGPUProviders) {
+		logger.Info(ctx, "GPU providers feature disabled, using CPU")
+		return onnx.SharedLibraryPath, transcribe.ExecutionProviderCPU
+	}
+
+	path, err := onnx.LocateGPUSharedLibrary(settings.CUDARuntimePath)
+	if err != nil {
+		logger.Warn(ctx, "CUDA runtime unavailable, falling back to CPU", "err", err)
+		return onnx.SharedLibraryPath, transcribe.ExecutionProviderCPU
+	}
+
+	logger.Info(ctx, "using CUDA execution provider", "shared_library_path", path)
+	return path, transcribe.ExecutionProviderCUDA
+}
+
+// ensureSingleInstance keeps a second instance from starting, since both would record from
+// the microphone and answer the same hotkeys. With takeover the running instance is asked
+// to quit first; otherwise the user is told which instance is running in a notification.
+func ensureSingleInstance(ctx context.Context, logger logger.Logger, takeover bool) error {
+	running, ok := control.Discover()
+	if !ok {
+		return nil
+	}
+
+	if takeover {
+		logger.Info(ctx, "taking over from the running instance", "instance", running.String())
+		if err := control.Takeover(10 * time.Second); err != nil {
+			return fmt.Errorf("cannot take over from %s: %w", running, err)
+		}
+		return nil
+	}
+
+	notifier := notify.New(logger, notify.Settings{NotifyOnError: true})
+	notifier.Error(ctx, "Already Running", fmt.Sprintf(
+		"%s is already running. Quit it from its tray or with \"tribar quit\", or start with -takeover to replace it.", running))
+	flushOnShutdown(notifier.Shutdown)
+
+	return fmt.Errorf("%s is already running, start with -takeover to replace it", running)
+}
+
+// loadModelsAsync loads the models and, if enabled, runs the self test once they are
+// ready.
+func loadModelsAsync(ctx context.Context, logger logger.Logger, settingsManager *config.SettingsManager, eng *engine.Engine) {
 	progressCallback := func(filename string, downloaded, total int64, percent float64) {
 		logger.Info(ctx, "downloading model",
 			"file", filename,
@@ -122,16 +485,36 @@ func loadModelsAsync(ctx context.Context, logger logger.Logger, eng *engine.Engi
 		)
 	}
 
-	if err := eng.LoadModels(progressCallback); err != nil {
+	err := eng.LoadModels(progressCallback)
+	if errors.Is(err, engine.ErrDownloadDeferred) {
+		logger.Info(ctx, "models not loaded until the deferred download completes")
+		return
+	}
+	if err != nil {
 		logger.Error(ctx, "failed to load models", "err", err)
 	}
+
+	if settingsManager.Get().SelfTestOnStartup {
+		eng.SelfTest(err)
+	}
+}
+
+// flushOnShutdown gives a background dispatcher a bounded amount of time to deliver its
+// pending work before the process exits.
+func flushOnShutdown(shutdown func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	shutdown(ctx)
 }
 
 func parseFlags() cliFlags {
 	debugPtr := flag.Bool("debug", false, "enable debug mode")
+	takeoverPtr := flag.Bool("takeover", false, "quit the instance already running and replace it")
 	flag.Parse()
 
 	return cliFlags{
-		Debug: *debugPtr,
+		Debug:    *debugPtr,
+		Takeover: *takeoverPtr,
+		Args:     flag.Args(),
 	}
 }
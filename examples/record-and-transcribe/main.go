@@ -0,0 +1,59 @@
+// This example records a few seconds from the default microphone and transcribes them
+// using the public recording and transcription libraries.
+//
+//	go run ./examples/record-and-transcribe -lib /path/to/libonnxruntime.so -models ./models
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/record"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+func main() {
+	libPath := flag.String("lib", "", "path to the ONNX Runtime shared library")
+	modelDir := flag.String("models", "./models", "directory where the models are stored")
+	duration := flag.Duration("duration", 5*time.Second, "how long to record")
+	flag.Parse()
+
+	if *libPath == "" {
+		log.Fatal("usage: record-and-transcribe -lib <onnxruntime library> [-models <dir>] [-duration 5s]")
+	}
+
+	transcriber, err := transcribe.New(transcribe.Options{
+		SharedLibraryPath: *libPath,
+		ModelDir:          *modelDir,
+	})
+	if err != nil {
+		log.Fatalf("error creating transcriber: %v", err)
+	}
+	defer func() { _ = transcriber.Shutdown() }()
+
+	if err := transcriber.LoadModels(); err != nil {
+		log.Fatalf("error loading models (run the transcribe-file example to download them): %v", err)
+	}
+
+	recorder, err := record.NewRecorder()
+	if err != nil {
+		log.Fatalf("error creating recorder: %v", err)
+	}
+
+	fmt.Printf("recording for %s...\n", *duration)
+	if err := recorder.Start(); err != nil {
+		log.Fatalf("error starting recording: %v", err)
+	}
+	time.Sleep(*duration)
+	recorder.Stop()
+
+	text, err := transcriber.TranscribeSamples(context.Background(), recorder.Samples())
+	if err != nil {
+		log.Fatalf("error transcribing: %v", err)
+	}
+
+	fmt.Println(text)
+}
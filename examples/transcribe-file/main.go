@@ -0,0 +1,64 @@
+// This example transcribes audio files (WAV, or MP3/FLAC/OGG with ffmpeg installed) using
+// the public transcription library, without depending on any part of the Tribar
+// application.
+//
+//	go run ./examples/transcribe-file -lib /path/to/libonnxruntime.so -models ./models audio.wav [more.wav...]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+func main() {
+	libPath := flag.String("lib", "", "path to the ONNX Runtime shared library")
+	modelDir := flag.String("models", "./models", "directory where the models are stored")
+	flag.Parse()
+
+	if *libPath == "" || flag.NArg() == 0 {
+		log.Fatal("usage: transcribe-file -lib <onnxruntime library> [-models <dir>] <audio.wav>...")
+	}
+
+	if err := os.MkdirAll(*modelDir, 0755); err != nil {
+		log.Fatalf("error creating model directory: %v", err)
+	}
+
+	transcriber, err := transcribe.New(transcribe.Options{
+		SharedLibraryPath: *libPath,
+		ModelDir:          *modelDir,
+	})
+	if err != nil {
+		log.Fatalf("error creating transcriber: %v", err)
+	}
+	defer func() { _ = transcriber.Shutdown() }()
+
+	progress := func(filename string, downloaded, total int64, percent float64) {
+		fmt.Fprintf(os.Stderr, "\rdownloading %s: %.1f%%", filename, percent)
+	}
+	if err := transcriber.DownloadModels(progress); err != nil {
+		log.Fatalf("error downloading models: %v", err)
+	}
+
+	if err := transcriber.LoadModels(); err != nil {
+		log.Fatalf("error loading models: %v", err)
+	}
+
+	failed := false
+	for _, result := range transcriber.TranscribeBatch(context.Background(), flag.Args()) {
+		if result.Err != nil {
+			fmt.Fprintln(os.Stderr, result.Err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %s\n", result.Path, result.Result.Text)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
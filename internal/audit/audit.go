@@ -0,0 +1,228 @@
+// Package audit keeps an optional, append-only log of every text the app outputs, for
+// users in regulated environments who must account for AI-assisted text. Entries are
+// JSON lines chained by hash: each one stores the hash of the previous entry, so editing
+// or deleting an entry breaks the chain and is detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const defaultFileName = "audit.jsonl"
+
+// Event describes a text delivered by the app. Action is the output mode (e.g.
+// "copy_paste") or "sink:<id>", and App the application that had the focus, empty if it
+// could not be detected.
+type Event struct {
+	Action string
+	App    string
+	Model  string
+	Source string
+	Prompt string
+	Text   string
+}
+
+// Entry is a line of the audit log. The text itself is only stored when the settings ask
+// for it; its SHA-256 always is, so a given text can be matched against the log.
+type Entry struct {
+	Seq        int       `json:"seq"`
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	App        string    `json:"app,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	Prompt     string    `json:"prompt,omitempty"`
+	TextSHA256 string    `json:"text_sha256"`
+	TextLength int       `json:"text_length"`
+	Text       string    `json:"text,omitempty"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash,omitempty"`
+}
+
+// computeHash returns the SHA-256 of the entry serialized without its own hash.
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Instance appends events to the audit log when it is enabled in the settings.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	path            string
+
+	mu sync.Mutex
+	// last is the last entry of the log, read from the file on the first record.
+	last *Entry
+}
+
+// New creates a new audit logger writing to the data directory.
+func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		path:            FilePath(),
+	}
+}
+
+// FilePath returns the location of the audit log.
+func FilePath() string {
+	return filepath.Join(config.DirectoryData, defaultFileName)
+}
+
+// Record appends the event to the log, chained to the previous entry. It is a no-op when
+// the audit log is disabled.
+func (a *Instance) Record(event Event) error {
+	settings := a.settingsManager.Get()
+	if !settings.AuditLogEnabled {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.last == nil {
+		last, err := readLastEntry(a.path)
+		if err != nil {
+			return err
+		}
+		a.last = &last
+	}
+
+	textHash := sha256.Sum256([]byte(event.Text))
+	entry := Entry{
+		Seq:        a.last.Seq + 1,
+		Time:       time.Now().UTC(),
+		Action:     event.Action,
+		App:        event.App,
+		Model:      event.Model,
+		Source:     event.Source,
+		Prompt:     event.Prompt,
+		TextSHA256: hex.EncodeToString(textHash[:]),
+		TextLength: len([]rune(event.Text)),
+		PrevHash:   a.last.Hash,
+	}
+	if settings.AuditLogIncludeText {
+		entry.Text = event.Text
+	}
+
+	hash, err := entry.computeHash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	if err := appendEntry(a.path, entry); err != nil {
+		return err
+	}
+	a.last = &entry
+	return nil
+}
+
+// appendEntry writes the entry as a new line and flushes it to disk.
+func appendEntry(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to flush audit log: %w", err)
+	}
+	return nil
+}
+
+// readLastEntry returns the last entry of the log, or a zero entry if it does not exist.
+func readLastEntry(path string) (Entry, error) {
+	var last Entry
+	err := scanEntries(path, func(_ int, entry Entry) error {
+		last = entry
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return Entry{}, nil
+	}
+	return last, err
+}
+
+// Verify checks the hash chain of the audit log and returns the number of entries. It
+// fails on the first entry that was modified, removed or inserted.
+func Verify(path string) (int, error) {
+	var previous Entry
+	count := 0
+
+	err := scanEntries(path, func(line int, entry Entry) error {
+		hash, err := entry.computeHash()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.Hash != hash:
+			return fmt.Errorf("line %d: entry was modified, its hash does not match", line)
+		case entry.PrevHash != previous.Hash:
+			return fmt.Errorf("line %d: chain is broken, the previous entry is missing or was modified", line)
+		case entry.Seq != previous.Seq+1:
+			return fmt.Errorf("line %d: expected entry %d, found %d", line, previous.Seq+1, entry.Seq)
+		}
+
+		previous = entry
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// scanEntries calls fn with every entry of the log and its line number.
+func scanEntries(path string, fn func(line int, entry Entry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("line %d: invalid audit entry: %w", line, err)
+		}
+		if err := fn(line, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
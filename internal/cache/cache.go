@@ -0,0 +1,150 @@
+// Package cache stores transcriptions keyed by the hash of their audio, the model and the
+// settings that affect the result, so batch and watch-folder modes can skip files that
+// were already processed. Entries live as JSON files in the cache directory and the least
+// recently used ones are evicted when the cache exceeds its maximum size.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached transcription.
+type Entry struct {
+	Text       string    `json:"text"`
+	Confidence float32   `json:"confidence"`
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Instance is a size-bounded transcription cache stored in a directory.
+type Instance struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+// New creates a cache stored in dir that keeps at most maxBytes of entries, zero disables
+// the cache.
+func New(dir string, maxBytes int64) *Instance {
+	return &Instance{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+}
+
+// Key returns the cache key of an audio file transcribed with the given model and the
+// values of the settings that change the transcription (e.g. the language).
+func Key(audio []byte, modelID string, settings ...string) string {
+	audioHash := sha256.Sum256(audio)
+
+	hash := sha256.New()
+	hash.Write(audioHash[:])
+	hash.Write([]byte(modelID))
+	for _, value := range settings {
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the entry stored under the key. A hit refreshes the entry so it is evicted
+// last.
+func (c *Instance) Get(key string) (Entry, bool) {
+	if c.maxBytes <= 0 {
+		return Entry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		_ = os.Remove(path)
+		return Entry{}, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return entry, true
+}
+
+// Put stores the entry under the key and evicts the least recently used entries if the
+// cache grew over its maximum size.
+func (c *Instance) Put(key string, entry Entry) error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err := os.WriteFile(c.path(key), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits its maximum size.
+func (c *Instance) evict() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list cache directory: %w", err)
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".json") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(a, b int) bool { return files[a].ModTime().Before(files[b].ModTime()) })
+
+	for _, file := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, file.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict cache entry: %w", err)
+		}
+		total -= file.Size()
+	}
+
+	return nil
+}
+
+func (c *Instance) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
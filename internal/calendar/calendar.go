@@ -0,0 +1,181 @@
+// Package calendar reads the user's calendar (an iCalendar file or URL, as exported by most
+// calendar apps and CalDAV servers) to find the event happening right now, which is used to
+// give recordings and sessions meaningful names.
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const (
+	refreshInterval = 5 * time.Minute
+	fetchTimeout    = 10 * time.Second
+)
+
+// Instance looks up the currently active calendar event. The calendar is read in the
+// background by Run, so looking up the event never waits on the network.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	client          *http.Client
+
+	mu          sync.Mutex
+	cacheSource string
+	cacheTime   time.Time
+	cacheEvents []Event
+}
+
+// New creates a new calendar instance.
+func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		client: &http.Client{
+			Timeout: fetchTimeout,
+		},
+	}
+}
+
+// Run reads the calendar right away and every few minutes while calendar naming is
+// enabled, checking every minute for a changed source, until the context is canceled.
+func (c *Instance) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CurrentEventTitle returns the title of the event happening now, or an empty string when
+// calendar naming is disabled, no event is active or the calendar has not been read yet.
+func (c *Instance) CurrentEventTitle() string {
+	settings := c.settingsManager.Get()
+	if !settings.CalendarNamingEnabled || settings.CalendarSource == "" {
+		return ""
+	}
+
+	c.mu.Lock()
+	events := c.cacheEvents
+	if c.cacheSource != settings.CalendarSource {
+		events = nil
+	}
+	c.mu.Unlock()
+
+	now := time.Now()
+	for _, event := range events {
+		if event.Title != "" && event.ActiveAt(now) {
+			return event.Title
+		}
+	}
+
+	return ""
+}
+
+// refresh reads the calendar again when the cached events are stale or of another source.
+// Failures keep the previous events of the same source, so a flaky connection does not
+// lose the current event.
+func (c *Instance) refresh(ctx context.Context) {
+	settings := c.settingsManager.Get()
+	if !settings.CalendarNamingEnabled || settings.CalendarSource == "" {
+		return
+	}
+	source := settings.CalendarSource
+
+	c.mu.Lock()
+	fresh := c.cacheSource == source && time.Since(c.cacheTime) < refreshInterval
+	c.mu.Unlock()
+	if fresh {
+		return
+	}
+
+	events, err := c.read(ctx, source)
+	if err != nil {
+		c.logger.Warn(ctx, "failed to read calendar", "source", source, "err", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.cacheSource = source
+	c.cacheTime = time.Now()
+	c.cacheEvents = events
+	c.mu.Unlock()
+}
+
+// read returns the parsed events of the source.
+func (c *Instance) read(ctx context.Context, source string) ([]Event, error) {
+	reader, err := c.open(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	events, err := ParseICS(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse calendar: %w", err)
+	}
+
+	return events, nil
+}
+
+// open opens the calendar source, which can be a local path or an http(s) URL.
+func (c *Instance) open(ctx context.Context, source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// Slug converts an event title into a lowercase, filename-safe identifier such as
+// "weekly-standup".
+func Slug(title string) string {
+	var sb strings.Builder
+	pendingDash := false
+
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingDash = sb.Len() > 0
+			continue
+		}
+		if pendingDash {
+			sb.WriteByte('-')
+			pendingDash = false
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,238 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a timed calendar event, all-day events are ignored.
+type Event struct {
+	Title string
+	Start time.Time
+	End   time.Time
+	Rule  *RecurrenceRule
+}
+
+// RecurrenceRule is the subset of RFC 5545 recurrence rules supported: daily and weekly
+// frequencies with interval, until, count and (weekly) by-day parts.
+type RecurrenceRule struct {
+	Frequency string
+	Interval  int
+	Until     time.Time
+	Count     int
+	ByDay     []time.Weekday
+}
+
+// ParseICS extracts the timed events of an iCalendar document.
+func ParseICS(r io.Reader) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	allDay := false
+
+	for _, line := range lines {
+		name, params, value, ok := splitContentLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+			allDay = false
+		case name == "END" && value == "VEVENT":
+			if current != nil && !allDay && !current.Start.IsZero() {
+				if current.End.IsZero() {
+					current.End = current.Start
+				}
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Title = unescapeText(value)
+		case name == "DTSTART":
+			if params["VALUE"] == "DATE" {
+				allDay = true
+				continue
+			}
+			current.Start, _ = parseDateTime(value, params["TZID"])
+		case name == "DTEND":
+			current.End, _ = parseDateTime(value, params["TZID"])
+		case name == "RRULE":
+			current.Rule = parseRecurrenceRule(value)
+		}
+	}
+
+	return events, nil
+}
+
+// ActiveAt reports whether the event (or one of its recurrences) is happening at t.
+func (e Event) ActiveAt(t time.Time) bool {
+	duration := e.End.Sub(e.Start)
+	if e.Rule == nil {
+		return !t.Before(e.Start) && t.Before(e.End)
+	}
+
+	// Only occurrences starting today or yesterday (overnight events) can be active.
+	localT := t.In(e.Start.Location())
+	for daysAgo := range 2 {
+		day := localT.AddDate(0, 0, -daysAgo)
+		occurrence := time.Date(
+			day.Year(), day.Month(), day.Day(),
+			e.Start.Hour(), e.Start.Minute(), e.Start.Second(), 0,
+			e.Start.Location(),
+		)
+
+		if !e.Rule.matches(e.Start, occurrence) {
+			continue
+		}
+		if !t.Before(occurrence) && t.Before(occurrence.Add(duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches reports whether occurrence is a valid recurrence of an event starting at start.
+func (r *RecurrenceRule) matches(start, occurrence time.Time) bool {
+	if occurrence.Before(start) {
+		return false
+	}
+	if !r.Until.IsZero() && occurrence.After(r.Until) {
+		return false
+	}
+
+	days := daysBetween(start, occurrence)
+	interval := max(r.Interval, 1)
+
+	switch r.Frequency {
+	case "DAILY":
+		index := days / interval
+		return days%interval == 0 && (r.Count == 0 || index < r.Count)
+	case "WEEKLY":
+		weekStart := start.AddDate(0, 0, -int(start.Weekday()))
+		weeks := daysBetween(weekStart, occurrence) / 7
+		if weeks%interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			index := weeks / interval
+			return occurrence.Weekday() == start.Weekday() && (r.Count == 0 || index < r.Count)
+		}
+		for _, weekday := range r.ByDay {
+			if occurrence.Weekday() == weekday {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+// unfoldLines reads the content lines joining the folded continuation lines.
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitContentLine splits "NAME;PARAM=VALUE:content" into its components.
+func splitContentLine(line string) (string, map[string]string, string, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func parseDateTime(value, tzid string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+
+	location := time.Local
+	if tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+
+	return time.ParseInLocation("20060102T150405", value, location)
+}
+
+func parseRecurrenceRule(value string) *RecurrenceRule {
+	rule := &RecurrenceRule{Interval: 1}
+	weekdays := map[string]time.Weekday{
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+
+	for part := range strings.SplitSeq(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.Interval, _ = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, _ = strconv.Atoi(val)
+		case "UNTIL":
+			if until, err := parseDateTime(val, ""); err == nil {
+				rule.Until = until
+			} else if until, err := time.ParseInLocation("20060102", val, time.Local); err == nil {
+				rule.Until = until.AddDate(0, 0, 1)
+			}
+		case "BYDAY":
+			for day := range strings.SplitSeq(val, ",") {
+				// Ordinal prefixes such as "1MO" are only meaningful for monthly rules.
+				day = strings.TrimLeft(day, "+-0123456789")
+				if weekday, ok := weekdays[strings.ToUpper(day)]; ok {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		}
+	}
+
+	return rule
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
@@ -5,31 +5,144 @@ package clipboard
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
+	"unicode"
 
 	atclip "github.com/atotto/clipboard"
 	"github.com/varavelio/tribar/internal/config"
 	"github.com/varavelio/tribar/internal/logger"
 )
 
+const (
+	// writeTimeout bounds a whole Write call when the caller's context has no deadline.
+	writeTimeout = 10 * time.Second
+	// commandTimeout bounds every external command (xdotool, osascript, xclip...).
+	commandTimeout = 3 * time.Second
+	// restoreDelay is how long ghost paste waits for the OS to process the paste before
+	// restoring the original clipboard content.
+	restoreDelay = 250 * time.Millisecond
+	// chunkDelay is the pause between chunks pasted by WriteChunked, giving the target
+	// application time to process each one.
+	chunkDelay = 150 * time.Millisecond
+	// defaultPasteDelay is how long the paste workflows wait between copying the text and
+	// triggering the paste, giving the clipboard manager time to take ownership.
+	defaultPasteDelay = 50 * time.Millisecond
+	// latencyProbe is the text written to the clipboard while measuring its latency.
+	latencyProbe = "tribar clipboard latency probe"
+)
+
 // Instance handles output of transcription results.
 type Instance struct {
-	logger logger.Logger
+	logger     logger.Logger
+	pasteDelay atomic.Int64
+	// pasteUnavailable is set when the program that triggers the paste is missing, see
+	// CheckHelpers.
+	pasteUnavailable atomic.Bool
+}
+
+// MissingHelper is an external program the clipboard relies on that is not installed,
+// and the package that provides it. Paste is set for the program that triggers the
+// paste, without which only the copy only output mode works.
+type MissingHelper struct {
+	Program string
+	Package string
+	Paste   bool
 }
 
 // New creates a new clipboard instance.
 func New(logger logger.Logger) *Instance {
-	return &Instance{
+	w := &Instance{
 		logger: logger,
 	}
+	w.pasteDelay.Store(int64(defaultPasteDelay))
+	return w
+}
+
+// SetPasteDelay sets how long the paste workflows wait between copying the text and
+// triggering the paste. Slow clipboard managers may need more than the default.
+func (w *Instance) SetPasteDelay(d time.Duration) {
+	w.pasteDelay.Store(int64(max(d, 0)))
+}
+
+// CheckHelpers looks for the external programs the clipboard relies on, which only Linux
+// needs, and returns the missing ones. While the paste helper is missing, the paste
+// output modes fall back to copy only.
+func (w *Instance) CheckHelpers() []MissingHelper {
+	missing := missingHelpersPlatform()
+	w.pasteUnavailable.Store(slices.ContainsFunc(missing, func(h MissingHelper) bool { return h.Paste }))
+	return missing
+}
+
+// SupportedOutputModes returns the output modes that work with the installed helpers.
+func (w *Instance) SupportedOutputModes() []config.OutputMode {
+	if w.pasteUnavailable.Load() {
+		return []config.OutputMode{config.OutputModeCopyOnly}
+	}
+	return []config.OutputMode{config.OutputModeCopyOnly, config.OutputModeCopyPaste, config.OutputModeGhostPaste}
+}
+
+// InstallHint returns the command that installs the missing helpers with the package
+// manager of the system, e.g. "sudo apt install xdotool".
+func InstallHint(missing []MissingHelper) string {
+	packages := make([]string, 0, len(missing))
+	for _, helper := range missing {
+		packages = append(packages, helper.Package)
+	}
+	return installCommandPlatform(packages)
+}
+
+// MeasureLatency measures how long a text written to the clipboard takes to be readable
+// back, the same delay a paste has to wait for. The original content is restored.
+func (w *Instance) MeasureLatency(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	original, _ := readClipboard(ctx)
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commandTimeout)
+		defer cancel()
+		_ = runWithContext(restoreCtx, func() error { return atclip.WriteAll(original) })
+	}()
+
+	start := time.Now()
+	if err := w.copyToClipboard(ctx, latencyProbe); err != nil {
+		return 0, err
+	}
+	for {
+		content, err := readClipboard(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("clipboard error: %w", err)
+		}
+		if content == latencyProbe {
+			return time.Since(start), nil
+		}
+		if err := sleepContext(ctx, 5*time.Millisecond); err != nil {
+			return 0, fmt.Errorf("clipboard error: %w", err)
+		}
+	}
 }
 
-// Write outputs the transcription result based on the configured mode.
+// Write outputs the transcription result based on the configured mode. The operation is
+// aborted when ctx is canceled; if ctx has no deadline a default one is applied so a hung
+// external command can never block the caller forever.
 func (w *Instance) Write(ctx context.Context, mode config.OutputMode, text string) error {
 	if text == "" {
 		return nil
 	}
 
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, writeTimeout)
+		defer cancel()
+	}
+
+	if mode != config.OutputModeCopyOnly && w.pasteUnavailable.Load() {
+		w.logger.Debug(ctx, "paste helper missing, copying only", "mode", mode)
+		mode = config.OutputModeCopyOnly
+	}
+
 	switch mode {
 	case config.OutputModeCopyOnly:
 		return w.copyToClipboard(ctx, text)
@@ -42,41 +155,189 @@ func (w *Instance) Write(ctx context.Context, mode config.OutputMode, text strin
 	}
 }
 
+// WriteChunked pastes the text in consecutive chunks of at most chunkSize characters, for
+// applications that freeze when a very long text is pasted at once. Chunks are split at
+// whitespace when possible. In copy only mode the text is copied whole.
+func (w *Instance) WriteChunked(ctx context.Context, mode config.OutputMode, text string, chunkSize int) error {
+	if (mode != config.OutputModeCopyPaste && mode != config.OutputModeGhostPaste) || w.pasteUnavailable.Load() {
+		return w.Write(ctx, mode, text)
+	}
+
+	var originalContent string
+	restore := mode == config.OutputModeGhostPaste
+	if restore {
+		originalContent, _ = readClipboard(ctx)
+	}
+
+	for _, chunk := range splitChunks(text, chunkSize) {
+		chunkCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+		err := w.pasteWorkflow(chunkCtx, chunk, false)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		if err := sleepContext(ctx, chunkDelay); err != nil {
+			return fmt.Errorf("chunked paste canceled: %w", err)
+		}
+	}
+
+	if restore {
+		restoreCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		_ = runWithContext(restoreCtx, func() error { return atclip.WriteAll(originalContent) })
+	}
+
+	return nil
+}
+
+// splitChunks splits text into chunks of at most size characters, cutting after the last
+// whitespace of each chunk when there is one.
+func splitChunks(text string, size int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	for len(runes) > size {
+		cut := size
+		for i := size; i > size/2; i-- {
+			if unicode.IsSpace(runes[i-1]) {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
 // copyToClipboard copies text to the system clipboard.
 func (w *Instance) copyToClipboard(ctx context.Context, text string) error {
-	if err := atclip.WriteAll(text); err != nil {
+	err := runWithContext(ctx, func() error { return atclip.WriteAll(text) })
+	if err != nil {
 		w.logger.Error(ctx, "failed to copy to clipboard", "err", err)
 		return fmt.Errorf("clipboard error: %w", err)
 	}
 	return nil
 }
 
+// readClipboard reads the text currently stored in the system clipboard.
+func readClipboard(ctx context.Context) (string, error) {
+	var content string
+	err := runWithContext(ctx, func() error {
+		var err error
+		content, err = atclip.ReadAll()
+		return err
+	})
+	return content, err
+}
+
+// runWithContext runs fn, which cannot be canceled by itself, and returns early if ctx is
+// done first. The clipboard library shells out to external tools without a context, so
+// this prevents a stuck helper from blocking the caller.
+func runWithContext(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sleepContext waits for the given duration or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReadImage returns the image currently stored in the system clipboard encoded as PNG.
+func (w *Instance) ReadImage(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	data, err := readImagePlatform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image from clipboard: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("the clipboard does not contain an image")
+	}
+	return data, nil
+}
+
 // pasteWorkflow handles the copy-paste workflow with optional clipboard restoration.
 func (w *Instance) pasteWorkflow(ctx context.Context, text string, restore bool) error {
 	var originalContent string
 
 	if restore {
-		originalContent, _ = atclip.ReadAll()
+		// A failed read only means there is nothing to restore, but a canceled context
+		// must stop the workflow before anything is pasted.
+		originalContent, _ = readClipboard(ctx)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("clipboard error: %w", err)
+		}
 	}
 
 	if err := w.copyToClipboard(ctx, text); err != nil {
 		return err
 	}
 
-	time.Sleep(50 * time.Millisecond)
+	if err := sleepContext(ctx, time.Duration(w.pasteDelay.Load())); err != nil {
+		return fmt.Errorf("paste canceled, text remains in clipboard: %w", err)
+	}
+
+	pasteCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
 
-	if err := triggerPastePlatform(); err != nil {
+	if err := triggerPastePlatform(pasteCtx); err != nil {
 		w.logger.Warn(ctx, "paste trigger failed, text remains in clipboard", "err", err)
 		return err
 	}
 
 	if restore {
+		// The restoration outlives the caller's context, the original content must be put
+		// back even if the pipeline has already moved on.
 		go func() {
-			// Wait for the OS to process the paste before restoring
-			time.Sleep(250 * time.Millisecond)
-			_ = atclip.WriteAll(originalContent)
+			restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restoreDelay+commandTimeout)
+			defer cancel()
+
+			if err := sleepContext(restoreCtx, restoreDelay); err != nil {
+				return
+			}
+			_ = runWithContext(restoreCtx, func() error { return atclip.WriteAll(originalContent) })
 		}()
 	}
 
 	return nil
 }
+
+// ActiveApp returns the name of the application that has the keyboard focus, which is
+// the one that receives pasted text, or an empty string if it cannot be detected.
+func (w *Instance) ActiveApp(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	name, err := activeAppPlatform(ctx)
+	if err != nil {
+		w.logger.Debug(ctx, "failed to detect the active application", "err", err)
+		return ""
+	}
+	return name
+}
@@ -2,10 +2,55 @@
 
 package clipboard
 
-import "os/exec"
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
 
 // triggerPastePlatform sends Cmd+V using AppleScript.
-func triggerPastePlatform() error {
+func triggerPastePlatform(ctx context.Context) error {
 	script := `tell application "System Events" to keystroke "v" using {command down}`
-	return exec.Command("osascript", "-e", script).Run()
+	return exec.CommandContext(ctx, "osascript", "-e", script).Run()
+}
+
+// readImagePlatform reads a PNG image from the clipboard using AppleScript, which returns
+// it as a hex encoded «data PNGf...» literal.
+func readImagePlatform(ctx context.Context) ([]byte, error) {
+	output, err := exec.CommandContext(ctx, "osascript", "-e", "the clipboard as «class PNGf»").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	literal := strings.TrimSpace(string(output))
+	literal = strings.TrimPrefix(literal, "«data PNGf")
+	literal = strings.TrimSuffix(literal, "»")
+
+	data, err := hex.DecodeString(literal)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected clipboard image format: %w", err)
+	}
+	return data, nil
+}
+
+// activeAppPlatform returns the name of the frontmost application using AppleScript.
+func activeAppPlatform(ctx context.Context) (string, error) {
+	script := `tell application "System Events" to get name of first application process whose frontmost is true`
+	output, err := exec.CommandContext(ctx, "osascript", "-e", script).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// missingHelpersPlatform returns nothing, the clipboard only uses programs of the system.
+func missingHelpersPlatform() []MissingHelper {
+	return nil
+}
+
+// installCommandPlatform is never needed, no helper can be missing.
+func installCommandPlatform(packages []string) string {
+	return ""
 }
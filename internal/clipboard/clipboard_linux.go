@@ -3,10 +3,119 @@
 package clipboard
 
 import (
+	"bufio"
+	"context"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 )
 
+// packageManagers maps the IDs of the distributions in /etc/os-release to the command
+// installing packages with their package manager.
+var packageManagers = map[string]string{
+	"debian":   "sudo apt install",
+	"ubuntu":   "sudo apt install",
+	"fedora":   "sudo dnf install",
+	"rhel":     "sudo dnf install",
+	"arch":     "sudo pacman -S",
+	"opensuse": "sudo zypper install",
+	"suse":     "sudo zypper install",
+	"alpine":   "sudo apk add",
+	"void":     "sudo xbps-install",
+}
+
 // triggerPastePlatform sends Ctrl+V using xdotool (requires xwayland on wayland).
-func triggerPastePlatform() error {
-	return exec.Command("xdotool", "key", "ctrl+v").Run()
+func triggerPastePlatform(ctx context.Context) error {
+	return exec.CommandContext(ctx, "xdotool", "key", "ctrl+v").Run()
+}
+
+// readImagePlatform reads a PNG image from the clipboard using wl-paste on Wayland and
+// xclip on X11.
+func readImagePlatform(ctx context.Context) ([]byte, error) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return exec.CommandContext(ctx, "wl-paste", "--no-newline", "--type", "image/png").Output()
+	}
+	return exec.CommandContext(ctx, "xclip", "-selection", "clipboard", "-target", "image/png", "-out").Output()
+}
+
+// activeAppPlatform returns the process name of the focused window using xdotool, which
+// only sees X11 and XWayland windows.
+func activeAppPlatform(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "xdotool", "getactivewindow", "getwindowpid").Output()
+	if err != nil {
+		return "", err
+	}
+
+	comm, err := os.ReadFile(filepath.Join("/proc", strings.TrimSpace(string(output)), "comm"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(comm)), nil
+}
+
+// missingHelpersPlatform checks for the clipboard program of the display server,
+// wl-clipboard on Wayland and xclip or xsel on X11, and for xdotool, which triggers the
+// paste.
+func missingHelpersPlatform() []MissingHelper {
+	var missing []MissingHelper
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		if !installed("wl-copy") {
+			missing = append(missing, MissingHelper{Program: "wl-copy", Package: "wl-clipboard"})
+		}
+	case !installed("xclip") && !installed("xsel"):
+		missing = append(missing, MissingHelper{Program: "xclip", Package: "xclip"})
+	}
+
+	if !installed("xdotool") {
+		missing = append(missing, MissingHelper{Program: "xdotool", Package: "xdotool", Paste: true})
+	}
+	return missing
+}
+
+// installed reports whether a program is in the PATH.
+func installed(program string) bool {
+	_, err := exec.LookPath(program)
+	return err == nil
+}
+
+// installCommandPlatform returns the command installing the packages on the distribution
+// described by /etc/os-release, or a generic hint if it is unknown.
+func installCommandPlatform(packages []string) string {
+	list := strings.Join(packages, " ")
+	for _, id := range distributionIDs() {
+		if command, ok := packageManagers[id]; ok {
+			return command + " " + list
+		}
+	}
+	return "install " + list + " with the package manager of your distribution"
+}
+
+// distributionIDs returns the ID of the distribution and those it is based on (ID_LIKE),
+// e.g. "linuxmint", "ubuntu", "debian".
+func distributionIDs() []string {
+	f, err := os.Open("/etc/os-release")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || (key != "ID" && key != "ID_LIKE") {
+			continue
+		}
+		for _, id := range strings.Fields(strings.Trim(value, `"'`)) {
+			// Variants such as "opensuse-tumbleweed" share the base ID's package manager.
+			id, _, _ = strings.Cut(id, "-")
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
 }
@@ -3,6 +3,12 @@
 package clipboard
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"unsafe"
 )
@@ -33,8 +39,13 @@ type input struct {
 	padding [8]byte
 }
 
-// triggerPastePlatform sends Ctrl+V directly via Windows API.
-func triggerPastePlatform() error {
+// triggerPastePlatform sends Ctrl+V directly via Windows API. SendInput does not block,
+// so the context is only checked before injecting the keystrokes.
+func triggerPastePlatform(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	var inputs []input
 
 	// Helper to create input struct
@@ -63,3 +74,69 @@ func triggerPastePlatform() error {
 
 	return nil
 }
+
+// readImageScript saves the clipboard image as PNG and prints it base64 encoded.
+const readImageScript = `Add-Type -AssemblyName System.Windows.Forms
+$img = [System.Windows.Forms.Clipboard]::GetImage()
+if ($img -eq $null) { exit 0 }
+$ms = New-Object System.IO.MemoryStream
+$img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png)
+[Convert]::ToBase64String($ms.ToArray())`
+
+// readImagePlatform reads a PNG image from the clipboard using PowerShell.
+func readImagePlatform(ctx context.Context) ([]byte, error) {
+	output, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-STA", "-Command", readImageScript).Output()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+}
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetForegroundWindow        = user32.NewProc("GetForegroundWindow")
+	procGetWindowThreadProcessID   = user32.NewProc("GetWindowThreadProcessId")
+	procQueryFullProcessImageNameW = kernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// activeAppPlatform returns the executable name of the process owning the foreground
+// window (e.g. "WINWORD.EXE").
+func activeAppPlatform(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return "", errors.New("no foreground window")
+	}
+
+	var pid uint32
+	procGetWindowThreadProcessID.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+
+	process, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = syscall.CloseHandle(process) }()
+
+	buf := make([]uint16, syscall.MAX_PATH)
+	size := uint32(len(buf))
+	ok, _, err := procQueryFullProcessImageNameW.Call(uintptr(process), 0, uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if ok == 0 {
+		return "", err
+	}
+	return filepath.Base(syscall.UTF16ToString(buf[:size])), nil
+}
+
+// missingHelpersPlatform returns nothing, the clipboard only uses programs of the system.
+func missingHelpersPlatform() []MissingHelper {
+	return nil
+}
+
+// installCommandPlatform is never needed, no helper can be missing.
+func installCommandPlatform(packages []string) string {
+	return ""
+}
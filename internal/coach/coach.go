@@ -0,0 +1,280 @@
+// Package coach keeps speaking statistics for users practicing their delivery, e.g. for
+// presentations: how often they use filler words ("um", "like") and how fast they speak.
+// Only counts are stored, per day, never the dictated text; a report over a period of
+// days compares them day by day.
+package coach
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const (
+	defaultFileName = "speech-stats.json"
+	// dateLayout formats the day of a Day.
+	dateLayout = "2006-01-02"
+	// maxDays is the number of days of statistics kept.
+	maxDays = 366
+)
+
+// Day holds the statistics of the dictations of a day, in local time.
+type Day struct {
+	Date       string         `json:"date"`
+	Dictations int            `json:"dictations"`
+	Words      int            `json:"words"`
+	Seconds    float64        `json:"seconds"`
+	Fillers    map[string]int `json:"fillers,omitempty"`
+}
+
+// FillerCount is the number of filler words in the dictations.
+func (d Day) FillerCount() int {
+	total := 0
+	for _, count := range d.Fillers {
+		total += count
+	}
+	return total
+}
+
+// Stats is the content of the statistics file. LastReport is when the last periodic report
+// was generated.
+type Stats struct {
+	Days       []Day     `json:"days"`
+	LastReport time.Time `json:"last_report,omitzero"`
+}
+
+// Instance records the statistics of dictations when they are enabled in the settings.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	path            string
+
+	mu sync.Mutex
+}
+
+// New creates a new statistics recorder writing to the data directory.
+func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		path:            FilePath(),
+	}
+}
+
+// FilePath returns the location of the statistics file.
+func FilePath() string {
+	return filepath.Join(config.DirectoryData, defaultFileName)
+}
+
+// Record adds a dictation of the given raw text, as the recognizer heard it, and length
+// to the statistics of today. It returns the report of the last period when one is due
+// (see SpeechReportIntervalDays), empty otherwise. It is a no-op when the statistics are
+// disabled.
+func (c *Instance) Record(text string, duration time.Duration) (string, error) {
+	settings := c.settingsManager.Get()
+	if !settings.SpeechStatsEnabled {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, err := Load(c.path)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	words, fillers := Count(text, settings.FillerWords)
+	stats.add(now, words, fillers, duration)
+
+	report := ""
+	interval := settings.SpeechReportIntervalDays
+	if stats.LastReport.IsZero() {
+		stats.LastReport = now
+	}
+	if interval > 0 && now.Sub(stats.LastReport) >= time.Duration(interval)*24*time.Hour {
+		report = stats.Report(now, interval)
+		stats.LastReport = now
+	}
+
+	if err := save(c.path, stats); err != nil {
+		return "", err
+	}
+	return report, nil
+}
+
+// add records a dictation in the statistics of the day of now, dropping the days beyond
+// maxDays.
+func (s *Stats) add(now time.Time, words int, fillers map[string]int, duration time.Duration) {
+	date := now.Format(dateLayout)
+	if len(s.Days) == 0 || s.Days[len(s.Days)-1].Date != date {
+		s.Days = append(s.Days, Day{Date: date})
+	}
+
+	day := &s.Days[len(s.Days)-1]
+	day.Dictations++
+	day.Words += words
+	day.Seconds += duration.Seconds()
+	for filler, count := range fillers {
+		if day.Fillers == nil {
+			day.Fillers = make(map[string]int)
+		}
+		day.Fillers[filler] += count
+	}
+
+	if len(s.Days) > maxDays {
+		s.Days = slices.Clone(s.Days[len(s.Days)-maxDays:])
+	}
+}
+
+// Count returns the number of words of the text and how many times each filler appears in
+// it. Fillers may span several words (e.g. "you know") and are matched as whole words,
+// ignoring case and punctuation.
+func Count(text string, fillers []string) (int, map[string]int) {
+	words := splitWords(text)
+	counts := make(map[string]int)
+
+	for _, filler := range fillers {
+		fillerWords := splitWords(filler)
+		if len(fillerWords) == 0 {
+			continue
+		}
+		for i := 0; i+len(fillerWords) <= len(words); i++ {
+			if slices.Equal(words[i:i+len(fillerWords)], fillerWords) {
+				counts[strings.Join(fillerWords, " ")]++
+			}
+		}
+	}
+
+	return len(words), counts
+}
+
+// splitWords returns the lowercase words of the text, keeping apostrophes inside words.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// Report renders the statistics of the given number of days up to now as a Markdown
+// document: the totals, the speaking rate, the most used fillers and a line per day.
+func (s Stats) Report(now time.Time, days int) string {
+	from := now.AddDate(0, 0, -days+1).Format(dateLayout)
+	to := now.Format(dateLayout)
+
+	var (
+		period  []Day
+		total   Day
+		fillers = make(map[string]int)
+	)
+	for _, day := range s.Days {
+		if day.Date < from || day.Date > to {
+			continue
+		}
+		period = append(period, day)
+		total.Dictations += day.Dictations
+		total.Words += day.Words
+		total.Seconds += day.Seconds
+		for filler, count := range day.Fillers {
+			fillers[filler] += count
+		}
+	}
+	total.Fillers = fillers
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Speaking report: %s to %s\n\n", from, to)
+
+	if total.Dictations == 0 {
+		sb.WriteString("No dictations were recorded in this period.\n")
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "- Dictations: %d\n", total.Dictations)
+	fmt.Fprintf(&sb, "- Words: %d\n", total.Words)
+	fmt.Fprintf(&sb, "- Speaking time: %s\n", (time.Duration(total.Seconds) * time.Second).String())
+	fmt.Fprintf(&sb, "- Speaking rate: %s\n", rate(total))
+	fmt.Fprintf(&sb, "- Fillers: %d (%s)\n", total.FillerCount(), fillerRate(total))
+
+	if len(fillers) > 0 {
+		names := make([]string, 0, len(fillers))
+		for filler := range fillers {
+			names = append(names, filler)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if fillers[names[i]] != fillers[names[j]] {
+				return fillers[names[i]] > fillers[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		sb.WriteString("\n## Most used fillers\n\n")
+		for _, filler := range names {
+			fmt.Fprintf(&sb, "- \"%s\": %d\n", filler, fillers[filler])
+		}
+	}
+
+	sb.WriteString("\n## By day\n\n")
+	sb.WriteString("| Day | Dictations | Words | Rate | Fillers |\n")
+	sb.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+	for _, day := range period {
+		fmt.Fprintf(&sb, "| %s | %d | %d | %s | %s |\n", day.Date, day.Dictations, day.Words, rate(day), fillerRate(day))
+	}
+
+	return sb.String()
+}
+
+// rate formats the speaking rate of the day in words per minute.
+func rate(day Day) string {
+	if day.Seconds <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f wpm", float64(day.Words)/day.Seconds*60)
+}
+
+// fillerRate formats the fillers of the day per 100 words.
+func fillerRate(day Day) string {
+	if day.Words == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f per 100 words", float64(day.FillerCount())/float64(day.Words)*100)
+}
+
+// Load reads the statistics file, returning empty statistics if it does not exist.
+func Load(path string) (Stats, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Stats{}, nil
+	}
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read speech statistics: %w", err)
+	}
+
+	var stats Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return Stats{}, fmt.Errorf("failed to parse speech statistics: %w", err)
+	}
+	return stats, nil
+}
+
+// save writes the statistics file.
+func save(path string, stats Stats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal speech statistics: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write speech statistics: %w", err)
+	}
+	return nil
+}
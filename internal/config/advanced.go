@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// AdvancedSettings are performance tunables most users never need to change. Fields
+// missing from older settings files keep their defaults, and out-of-range values are
+// rejected by Validate.
+type AdvancedSettings struct {
+	// DownloadBufferKB is the size of the buffer model downloads are copied through.
+	DownloadBufferKB int `json:"download_buffer_kb"`
+	// DownloadRetries is how many times a model download failing with a transient error
+	// (a server error or a dropped connection) is retried with exponential backoff.
+	DownloadRetries int `json:"download_retries"`
+	// DownloadTimeoutSeconds bounds connecting to the model host and every wait for data,
+	// a download making progress is never cut short.
+	DownloadTimeoutSeconds int `json:"download_timeout_seconds"`
+	// TranscriptionChunkSeconds is the length of the chunks long recordings are split into
+	// and decoded one at a time.
+	TranscriptionChunkSeconds int `json:"transcription_chunk_seconds"`
+	// InferenceThreads limits the CPU threads used by a transcription when the battery
+	// saver is not active, zero lets ONNX Runtime use every core.
+	InferenceThreads int `json:"inference_threads"`
+	// TranscriptionWorkers is the number of chunks of a long recording transcribed at the
+	// same time, zero picks a number from the CPU cores.
+	TranscriptionWorkers int `json:"transcription_workers"`
+	// AnimationFrameMillis is the interval between the frames of the tray icon animation.
+	AnimationFrameMillis int `json:"animation_frame_ms"`
+	// PasteDelayMillis is how long the paste waits after copying the text, slow clipboard
+	// managers may need more. The calibration suggests a value for the machine.
+	PasteDelayMillis int `json:"paste_delay_ms"`
+}
+
+var defaultAdvancedSettings = AdvancedSettings{
+	DownloadBufferKB:          32,
+	DownloadRetries:           3,
+	DownloadTimeoutSeconds:    30,
+	TranscriptionChunkSeconds: 30,
+	InferenceThreads:          0,
+	TranscriptionWorkers:      0,
+	AnimationFrameMillis:      200,
+	PasteDelayMillis:          50,
+}
+
+// advancedLimit is the accepted range of an advanced setting.
+type advancedLimit struct {
+	name     string
+	value    int
+	min, max int
+}
+
+// Validate checks that every advanced setting is within its accepted range.
+func (a AdvancedSettings) Validate() error {
+	limits := []advancedLimit{
+		{"download_buffer_kb", a.DownloadBufferKB, 4, 4096},
+		{"download_retries", a.DownloadRetries, 0, 10},
+		{"download_timeout_seconds", a.DownloadTimeoutSeconds, 5, 600},
+		{"transcription_chunk_seconds", a.TranscriptionChunkSeconds, 10, 300},
+		{"inference_threads", a.InferenceThreads, 0, 256},
+		{"transcription_workers", a.TranscriptionWorkers, 0, 64},
+		{"animation_frame_ms", a.AnimationFrameMillis, 50, 2000},
+		{"paste_delay_ms", a.PasteDelayMillis, 0, 2000},
+	}
+
+	for _, limit := range limits {
+		if limit.value < limit.min || limit.value > limit.max {
+			return fmt.Errorf("advanced setting %s must be between %d and %d, got %d", limit.name, limit.min, limit.max, limit.value)
+		}
+	}
+	return nil
+}
+
+// DownloadBufferSize returns the download buffer size in bytes.
+func (a AdvancedSettings) DownloadBufferSize() int {
+	return a.DownloadBufferKB * 1024
+}
+
+// DownloadRetryCount returns the download retries in the form of transcribe.Options,
+// where zero means the default and a negative number none.
+func (a AdvancedSettings) DownloadRetryCount() int {
+	if a.DownloadRetries == 0 {
+		return -1
+	}
+	return a.DownloadRetries
+}
+
+// DownloadTimeout returns the connection and idle timeout of model downloads.
+func (a AdvancedSettings) DownloadTimeout() time.Duration {
+	return time.Duration(a.DownloadTimeoutSeconds) * time.Second
+}
+
+// TranscriptionChunkDuration returns the length of the transcription chunks.
+func (a AdvancedSettings) TranscriptionChunkDuration() time.Duration {
+	return time.Duration(a.TranscriptionChunkSeconds) * time.Second
+}
+
+// Workers returns the number of chunks transcribed at the same time: the configured one,
+// or a quarter of the CPU cores (between one and four) since every worker runs a
+// multi-threaded inference.
+func (a AdvancedSettings) Workers() int {
+	if a.TranscriptionWorkers > 0 {
+		return a.TranscriptionWorkers
+	}
+	return min(max(runtime.NumCPU()/4, 1), 4)
+}
+
+// AnimationFrameDuration returns the interval between the tray animation frames.
+func (a AdvancedSettings) AnimationFrameDuration() time.Duration {
+	return time.Duration(a.AnimationFrameMillis) * time.Millisecond
+}
+
+// PasteDelay returns how long the paste waits after copying the text.
+func (a AdvancedSettings) PasteDelay() time.Duration {
+	return time.Duration(a.PasteDelayMillis) * time.Millisecond
+}
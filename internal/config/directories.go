@@ -13,12 +13,13 @@ import (
 const dirAppName = "tribar"
 
 var (
-	DirectoryConfig         = ""
-	DirectoryData           = ""
-	DirectoryOnnxRuntime    = ""
-	DirectoryModels         = ""
-	DirectoryModelsParakeet = ""
-	DirectoryRecordings     = ""
+	DirectoryConfig      = ""
+	DirectoryData        = ""
+	DirectoryOnnxRuntime = ""
+	DirectoryModels      = ""
+	DirectoryRecordings  = ""
+	DirectoryExports     = ""
+	DirectoryCache       = ""
 )
 
 // EnsureDirectories creates all necessary directories if they don't exist.
@@ -37,15 +38,18 @@ func EnsureDirectories(logger logger.Logger) error {
 	DirectoryData = dataDir
 	DirectoryOnnxRuntime = filepath.Join(DirectoryData, "onnxruntime")
 	DirectoryModels = filepath.Join(DirectoryData, "models")
-	DirectoryModelsParakeet = filepath.Join(DirectoryModels, "parakeet")
 	DirectoryRecordings = filepath.Join(DirectoryData, "recordings")
+	DirectoryExports = filepath.Join(DirectoryData, "exports")
+	DirectoryCache = filepath.Join(DirectoryData, "cache")
 
 	// We only have to create the deepest directories, as os.MkdirAll will create all necessary parents.
 	ensureDirs := []string{
 		DirectoryConfig,
 		DirectoryOnnxRuntime,
-		DirectoryModelsParakeet,
+		DirectoryModels,
 		DirectoryRecordings,
+		DirectoryExports,
+		DirectoryCache,
 	}
 	for _, dir := range ensureDirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -59,8 +63,9 @@ func EnsureDirectories(logger logger.Logger) error {
 		"directory_data", DirectoryData,
 		"directory_onnx_runtime", DirectoryOnnxRuntime,
 		"directory_models", DirectoryModels,
-		"directory_parakeet_models", DirectoryModelsParakeet,
 		"directory_recordings", DirectoryRecordings,
+		"directory_exports", DirectoryExports,
+		"directory_cache", DirectoryCache,
 	)
 
 	return nil
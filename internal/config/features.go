@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// Feature names a subsystem that can be switched on or off without a separate build, so
+// risky new code can ship disabled by default and be tried by adventurous users, and code
+// that misbehaves on some machine can be turned off until it is fixed.
+type Feature string
+
+const (
+	// FeatureStreamingDecode updates the partial text of a transcription token by token
+	// while it is decoded, otherwise only as its chunks complete.
+	FeatureStreamingDecode Feature = "streaming_decode"
+	// FeatureGPUProviders lets the execution_provider setting run the models on the GPU,
+	// otherwise they always run on the CPU.
+	FeatureGPUProviders Feature = "gpu_providers"
+	// FeatureTDTDecoding decodes Parakeet following the token durations predicted by its
+	// TDT head, otherwise every encoder frame is decoded, like CTC decoding.
+	FeatureTDTDecoding Feature = "tdt_decoding"
+)
+
+// FeatureInfo describes a feature flag and its default in this release. Flags of
+// subsystems set up once on startup are only applied when the app restarts.
+type FeatureInfo struct {
+	Name            Feature
+	Description     string
+	Default         bool
+	RequiresRestart bool
+}
+
+// features lists every feature flag. A new subsystem is added here disabled by default and
+// its default flips once it is stable; the flag is removed a few releases later.
+var features = []FeatureInfo{
+	{
+		Name:        FeatureStreamingDecode,
+		Description: "update the partial text token by token while transcribing",
+		Default:     false,
+	},
+	{
+		Name:            FeatureGPUProviders,
+		Description:     "allow the CUDA execution provider",
+		Default:         false,
+		RequiresRestart: true,
+	},
+	{
+		Name:        FeatureTDTDecoding,
+		Description: "decode Parakeet with the predicted token durations",
+		Default:     false,
+	},
+}
+
+// Features returns every feature flag with its default in this release.
+func Features() []FeatureInfo {
+	return slices.Clone(features)
+}
+
+// FindFeature returns the feature flag with the given name.
+func FindFeature(name string) (FeatureInfo, bool) {
+	for _, feature := range features {
+		if string(feature.Name) == name {
+			return feature, true
+		}
+	}
+	return FeatureInfo{}, false
+}
+
+// FeaturesEnv is the environment variable that overrides the feature flags of the
+// settings, a comma-separated list of features to enable, prefixed with "-" to disable
+// them (e.g. "-streaming_decode,gpu_providers").
+const FeaturesEnv = "TRIBAR_FEATURES"
+
+// FeatureEnabled reports whether a feature is enabled: by the environment if it names the
+// feature, else by the features setting, else by its default in this release.
+func (s Settings) FeatureEnabled(feature Feature) bool {
+	for name := range strings.SplitSeq(os.Getenv(FeaturesEnv), ",") {
+		name = strings.TrimSpace(name)
+		if name == string(feature) {
+			return true
+		}
+		if name == "-"+string(feature) {
+			return false
+		}
+	}
+
+	if enabled, ok := s.Features[feature]; ok {
+		return enabled
+	}
+	info, _ := FindFeature(string(feature))
+	return info.Default
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Policy holds settings enforced by an administrator, keyed by their JSON name. Enforced
+// values override the user settings and cannot be changed from the app, which allows
+// managed deployments to e.g. force post-processing off or pin an API endpoint.
+type Policy map[string]json.RawMessage
+
+// LoadPolicy reads the system-wide policy of the platform (see loadPlatformPolicy). It
+// returns an empty policy when none is configured and fails if it sets unknown settings.
+func LoadPolicy() (Policy, error) {
+	policy, err := loadPlatformPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings policy: %w", err)
+	}
+
+	known, err := settingsFields(defaultSettings)
+	if err != nil {
+		return nil, err
+	}
+	for key := range policy {
+		if _, ok := known[key]; !ok {
+			return nil, fmt.Errorf("settings policy sets unknown setting %q", key)
+		}
+	}
+
+	if _, err := policy.Apply(defaultSettings); err != nil {
+		return nil, fmt.Errorf("invalid settings policy: %w", err)
+	}
+	return policy, nil
+}
+
+// loadPolicyFile reads a policy from a JSON file, a missing file means no policy.
+func loadPolicyFile(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// Keys returns the JSON names of the enforced settings, sorted.
+func (p Policy) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Apply returns the settings with the enforced values.
+func (p Policy) Apply(settings Settings) (Settings, error) {
+	result, err := overlaySettings(settings, p)
+	if err != nil {
+		return Settings{}, err
+	}
+	if err := result.Advanced.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("invalid policy: %w", err)
+	}
+	return result, nil
+}
+
+// settingsFields returns the settings as a map keyed by their JSON names.
+func settingsFields(settings Settings) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return fields, nil
+}
+
+// overlaySettings replaces the settings fields present in values.
+func overlaySettings(settings Settings, values map[string]json.RawMessage) (Settings, error) {
+	if len(values) == 0 {
+		return settings, nil
+	}
+
+	fields, err := settingsFields(settings)
+	if err != nil {
+		return Settings{}, err
+	}
+	for key, value := range values {
+		fields[key] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	var result Settings
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Settings{}, fmt.Errorf("failed to apply settings: %w", err)
+	}
+	return result, nil
+}
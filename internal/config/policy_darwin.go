@@ -0,0 +1,11 @@
+//go:build darwin
+
+package config
+
+// policyFilePath is the system-wide settings policy, writable only by administrators.
+const policyFilePath = "/Library/Application Support/" + dirAppName + "/settings.json"
+
+// loadPlatformPolicy reads the policy from /Library/Application Support/tribar/settings.json.
+func loadPlatformPolicy() (Policy, error) {
+	return loadPolicyFile(policyFilePath)
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !darwin
+
+package config
+
+// policyFilePath is the system-wide settings policy, writable only by administrators.
+const policyFilePath = "/etc/" + dirAppName + "/settings.json"
+
+// loadPlatformPolicy reads the policy from /etc/tribar/settings.json.
+func loadPlatformPolicy() (Policy, error) {
+	return loadPolicyFile(policyFilePath)
+}
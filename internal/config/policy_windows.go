@@ -0,0 +1,68 @@
+//go:build windows
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// policyKeyPath is the policy key under HKEY_LOCAL_MACHINE, usually deployed through Group
+// Policy.
+const policyKeyPath = `SOFTWARE\Policies\Varavelio\Tribar`
+
+// loadPlatformPolicy reads the policy from HKLM\SOFTWARE\Policies\Varavelio\Tribar. Every
+// value is named after a setting: REG_DWORD values are numbers, or for boolean settings
+// 0 for false and any other number for true as Group Policy templates write them, and
+// REG_SZ values hold a JSON literal such as false, 3 or "https://llm.example.com/v1".
+func loadPlatformPolicy() (Policy, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, policyKeyPath, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = key.Close() }()
+
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := settingsFields(defaultSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := make(Policy, len(names))
+	for _, name := range names {
+		if number, _, err := key.GetIntegerValue(name); err == nil {
+			policy[name] = json.RawMessage(strconv.FormatUint(number, 10))
+			if isBool(defaults[name]) {
+				policy[name] = json.RawMessage(strconv.FormatBool(number != 0))
+			}
+			continue
+		}
+
+		text, _, err := key.GetStringValue(name)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported type of policy value %q: %w", name, err)
+		}
+		if !json.Valid([]byte(text)) {
+			return nil, fmt.Errorf("policy value %q is not a JSON literal: %s", name, text)
+		}
+		policy[name] = json.RawMessage(text)
+	}
+	return policy, nil
+}
+
+// isBool reports whether a JSON value is a boolean.
+func isBool(value json.RawMessage) bool {
+	text := string(value)
+	return text == "true" || text == "false"
+}
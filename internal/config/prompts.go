@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxPromptVersions is the number of prior versions kept for every prompt, the oldest
+// are dropped first.
+const maxPromptVersions = 20
+
+// PromptVersion is a prior body of a prompt, kept when the prompt is edited so the change
+// can be rolled back.
+type PromptVersion struct {
+	Body    string    `json:"body"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// trackPromptVersions records the prior body of the prompts edited between previous and
+// next, matched by ID, in the versions of next. Prompts sent without versions (e.g. by a
+// client unaware of them) inherit the ones they had. It reports whether any version was
+// added.
+func trackPromptVersions(previous, next []Prompt, now time.Time) ([]Prompt, bool) {
+	prior := make(map[string]Prompt, len(previous))
+	for _, prompt := range previous {
+		prior[prompt.ID] = prompt
+	}
+
+	tracked := slices.Clone(next)
+	changed := false
+	for n, prompt := range tracked {
+		old, ok := prior[prompt.ID]
+		if !ok {
+			continue
+		}
+		if len(prompt.Versions) == 0 {
+			prompt.Versions = old.Versions
+		}
+
+		// The prior body may have been recorded already, e.g. by a rollback in another
+		// process whose settings file is being reloaded.
+		recorded := len(prompt.Versions) > 0 && prompt.Versions[len(prompt.Versions)-1].Body == old.Body
+		if old.Body != prompt.Body && !recorded {
+			prompt.Versions = appendPromptVersion(prompt.Versions, PromptVersion{Body: old.Body, SavedAt: now})
+			changed = true
+		}
+		tracked[n] = prompt
+	}
+	return tracked, changed
+}
+
+// appendPromptVersion appends a version, dropping the oldest ones beyond
+// maxPromptVersions. The slice is never modified in place since it may be shared with
+// other copies of the settings.
+func appendPromptVersion(versions []PromptVersion, version PromptVersion) []PromptVersion {
+	versions = append(slices.Clone(versions), version)
+	if len(versions) > maxPromptVersions {
+		versions = versions[len(versions)-maxPromptVersions:]
+	}
+	return versions
+}
+
+// RollbackPrompt restores the body a prompt had in one of its versions, numbered from 1
+// for the oldest. The current body is kept as the newest version, so a rollback can be
+// undone like any other edit.
+func (sm *SettingsManager) RollbackPrompt(id string, version int) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, locked := sm.policy["prompts"]; locked {
+		return errors.New("the prompts are locked by the settings policy")
+	}
+
+	prompts := slices.Clone(sm.settings.Prompts)
+	idx := slices.IndexFunc(prompts, func(prompt Prompt) bool { return prompt.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("prompt %q not found", id)
+	}
+
+	prompt := prompts[idx]
+	if version < 1 || version > len(prompt.Versions) {
+		return fmt.Errorf("prompt %q has no version %d", prompt.Name, version)
+	}
+
+	body := prompt.Versions[version-1].Body
+	prompt.Versions = appendPromptVersion(prompt.Versions, PromptVersion{Body: prompt.Body, SavedAt: time.Now()})
+	prompt.Body = body
+	prompts[idx] = prompt
+
+	sm.settings.Prompts = prompts
+	if err := sm.applyPolicyUnsafe(); err != nil {
+		return err
+	}
+	return sm.saveUnsafe()
+}
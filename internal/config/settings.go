@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 const settingsFileName = "settings.json"
@@ -17,19 +22,265 @@ const (
 	OutputModeCopyOnly   OutputMode = "copy_only"
 	OutputModeCopyPaste  OutputMode = "copy_paste"
 	OutputModeGhostPaste OutputMode = "ghost_paste"
+	// OutputModeScratchpad appends the text to the scratchpad window instead of another
+	// application.
+	OutputModeScratchpad OutputMode = "scratchpad"
 )
 
-// Prompt represents a user-configurable prompt for post-processing.
+// PasteOverflowMode defines what happens with transcriptions longer than the maximum
+// paste length.
+type PasteOverflowMode string
+
+const (
+	// PasteOverflowFile saves the text to a file and pastes a reference to it.
+	PasteOverflowFile PasteOverflowMode = "file"
+	// PasteOverflowSummary saves the text to a file and pastes an LLM summary of it.
+	PasteOverflowSummary PasteOverflowMode = "summary"
+	// PasteOverflowChunks pastes the text in consecutive chunks.
+	PasteOverflowChunks PasteOverflowMode = "chunks"
+)
+
+// Prompt represents a user-configurable prompt for post-processing. Versions holds its
+// prior bodies, oldest first, recorded by the settings manager when the body is edited.
 type Prompt struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Body string `json:"body"`
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Body     string          `json:"body"`
+	Versions []PromptVersion `json:"versions,omitempty"`
+}
+
+// SinkType defines the kind of destination a sink delivers transcriptions to.
+type SinkType string
+
+const (
+	SinkTypeEmail   SinkType = "email"
+	SinkTypeSlack   SinkType = "slack"
+	SinkTypeDiscord SinkType = "discord"
+	SinkTypeWebhook SinkType = "webhook"
+)
+
+// EmailMode defines how the e-mail sink delivers transcriptions.
+type EmailMode string
+
+const (
+	EmailModeMailto EmailMode = "mailto"
+	EmailModeSMTP   EmailMode = "smtp"
+)
+
+// SinkConfig represents an additional destination that receives every transcription.
+// Templates support the ${output}, ${date} and ${time} placeholders.
+type SinkConfig struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     SinkType `json:"type"`
+	Enabled  bool     `json:"enabled"`
+	Template string   `json:"template"`
+
+	// E-mail sink settings
+	EmailMode    EmailMode `json:"email_mode"`
+	EmailTo      string    `json:"email_to"`
+	EmailSubject string    `json:"email_subject"`
+	SMTPHost     string    `json:"smtp_host"`
+	SMTPPort     int       `json:"smtp_port"`
+	SMTPUsername string    `json:"smtp_username"`
+	SMTPPassword string    `json:"smtp_password"`
+	SMTPFrom     string    `json:"smtp_from"`
+
+	// Slack, Discord and generic webhook sink settings
+	WebhookURL string `json:"webhook_url"`
+}
+
+// HistorySyncBackend defines the kind of server the history is synced with.
+type HistorySyncBackend string
+
+const (
+	HistorySyncOff    HistorySyncBackend = ""
+	HistorySyncWebDAV HistorySyncBackend = "webdav"
+	// HistorySyncS3 is Amazon S3 or any storage compatible with its API, such as MinIO.
+	HistorySyncS3 HistorySyncBackend = "s3"
+)
+
+// CasingMode defines how the final text is capitalized.
+type CasingMode string
+
+const (
+	CasingAsIs     CasingMode = "as_is"
+	CasingLower    CasingMode = "lowercase"
+	CasingSentence CasingMode = "sentence"
+	// CasingLowerStart only lowercases the first letter, the terse start of chat messages.
+	CasingLowerStart CasingMode = "lowercase_start"
+)
+
+// PunctuationMode defines how aggressively punctuation is kept in the final text.
+type PunctuationMode string
+
+const (
+	PunctuationKeep    PunctuationMode = "keep"
+	PunctuationMinimal PunctuationMode = "minimal"
+	PunctuationNone    PunctuationMode = "none"
+)
+
+// ProfanityMode defines what happens with the profanity of the final text.
+type ProfanityMode string
+
+const (
+	ProfanityOff    ProfanityMode = "off"
+	ProfanityMask   ProfanityMode = "mask"
+	ProfanityRemove ProfanityMode = "remove"
+)
+
+// NormalizationProfile is a named set of formatting rules applied to the final text,
+// e.g. informal lowercase text for chat apps or full sentences for documents. While no
+// profile is selected, the profile is applied to the dictations pasted into the
+// applications of Apps: names of the focused application as reported by the system
+// (e.g. "Slack", "slack" or "slack.exe"), matched case-insensitively as part of it.
+type NormalizationProfile struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Casing      CasingMode      `json:"casing"`
+	Punctuation PunctuationMode `json:"punctuation"`
+	AllowEmoji  bool            `json:"allow_emoji"`
+	Apps        []string        `json:"apps"`
+}
+
+// FormTemplate defines the named fields of a form filled by voice, e.g. "To", "Subject"
+// and "Body", and how the filled form is rendered: Layout replaces ${<field name>} with
+// the text of every field and, when empty, one "<field name>: <text>" line per field is
+// output.
+type FormTemplate struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+	Layout string   `json:"layout"`
+}
+
+// TagRule automatically adds a tag to new history entries. Every non-empty condition must
+// match; a rule without conditions never matches.
+type TagRule struct {
+	Tag        string     `json:"tag"`
+	PromptID   string     `json:"prompt_id"`   // Post-processing prompt used
+	Language   string     `json:"language"`    // Language hint of the dictation
+	OutputMode OutputMode `json:"output_mode"` // Output mode of the dictation
+	Keyword    string     `json:"keyword"`     // Case-insensitive text the transcription contains
+}
+
+// RoutingRule sends transcriptions matching a regular expression to another prompt or
+// sink. Rules are evaluated in order and the first match wins.
+type RoutingRule struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Enabled    bool       `json:"enabled"`
+	Pattern    string     `json:"pattern"`     // Go regular expression, e.g. (?i)^note:
+	StripMatch bool       `json:"strip_match"` // Remove the matched trigger from the text
+	PromptID   string     `json:"prompt_id"`   // Post-processing prompt to use instead
+	OutputMode OutputMode `json:"output_mode"` // Output mode to use instead
+	// SinkID sends the text only to this sink, instead of the clipboard and the enabled
+	// sinks.
+	SinkID string `json:"sink_id"`
 }
 
 // Settings holds all user-configurable preferences.
 type Settings struct {
 	Version int `json:"version"`
 
+	// ModelID selects the speech recognition model from the transcription registry
+	ModelID string `json:"model_id"`
+
+	// ModelPrecision is the precision of the model weights: "int8" (the default, smaller
+	// and faster) or "fp32" (full precision, slightly more accurate but about four times
+	// the memory). Each precision is downloaded separately when selected, and changes
+	// apply the next time the models are loaded.
+	ModelPrecision string `json:"model_precision"`
+
+	// External transcriber settings. When a command (program and arguments) is set, it is
+	// registered as the "external" model, selectable with ModelID, and runs speech-to-text
+	// through the JSON protocol of transcribe.ExternalModel. Languages lists the ISO 639-1
+	// codes it supports, empty for any. Changes apply after a restart.
+	ExternalTranscriberCommand   []string `json:"external_transcriber_command"`
+	ExternalTranscriberLanguages []string `json:"external_transcriber_languages"`
+
+	// TrimSilenceEnabled runs a voice activity detector before local transcription to cut
+	// leading and trailing silence and shorten long pauses.
+	TrimSilenceEnabled bool `json:"trim_silence_enabled"`
+
+	// AutoStopSilenceSeconds stops a recording after that many seconds of silence
+	// following speech, for hands-free dictation without a second hotkey press; 0
+	// disables it. Speech is detected by the voice activity detector, loaded for it even
+	// without TrimSilenceEnabled, or by the input level if it cannot be loaded.
+	AutoStopSilenceSeconds int `json:"auto_stop_silence_seconds"`
+
+	// MaxRecordingMinutes stops and transcribes a recording that reaches that length, so
+	// a forgotten recording does not grow in memory without bound; 0 disables the limit.
+	MaxRecordingMinutes int `json:"max_recording_minutes"`
+
+	// Recording start cleanup, for the click of the hotkey that starts a recording, which
+	// is sometimes transcribed as a spurious word. StartTrimMs drops the first milliseconds
+	// of every recording and SuppressStartClicks mutes the short transients before the
+	// speech starts.
+	StartTrimMs         int  `json:"start_trim_ms"`
+	SuppressStartClicks bool `json:"suppress_start_clicks"`
+
+	// PreRollMs keeps the last milliseconds of audio captured while the models are loaded
+	// and adds them to the start of every recording, so the first syllable said together
+	// with the hotkey is not cut off. The input device stays open while it is set; 0
+	// disables it.
+	PreRollMs int `json:"pre_roll_ms"`
+
+	// NoiseSuppressionEnabled reduces the steady background noise of the recordings, such
+	// as fans or the murmur of an office, before they are transcribed and saved.
+	NoiseSuppressionEnabled bool `json:"noise_suppression_enabled"`
+
+	// AutoGainEnabled amplifies quiet recordings, after the noise suppression, so their
+	// speech reaches the level the models expect.
+	AutoGainEnabled bool `json:"auto_gain_enabled"`
+
+	// InputDeviceID is the ID of the input device recordings are captured from, as listed
+	// by `tribar devices`; empty uses the system's default device, which is also used
+	// while the selected one is not connected.
+	InputDeviceID string `json:"input_device_id"`
+
+	// Execution provider settings, "cpu" or "cuda". CUDA needs the GPU build of ONNX
+	// Runtime, located at CUDARuntimePath or in the default GPU runtime directory, and
+	// falls back to the CPU if unavailable. Changes apply after a restart.
+	ExecutionProvider string `json:"execution_provider"`
+	CUDADeviceID      int    `json:"cuda_device_id"`
+	CUDARuntimePath   string `json:"cuda_runtime_path"`
+
+	// ModelMirrorURL is the base URL of a mirror hosting the model files, laid out like
+	// the models directory (<mirror>/<model ID>/<file name>), for networks that cannot
+	// reach HuggingFace. The TRIBAR_MODEL_MIRROR environment variable overrides it.
+	ModelMirrorURL string `json:"model_mirror_url"`
+
+	// DownloadProxyURL is the proxy model downloads go through (http://, https:// or
+	// socks5://, with credentials as user:pass@), and DownloadCAFile a PEM file of
+	// certificate authorities trusted besides the system ones, e.g. the root of a TLS
+	// inspecting proxy. An empty proxy uses the HTTPS_PROXY and HTTP_PROXY environment
+	// variables; TRIBAR_DOWNLOAD_PROXY and TRIBAR_DOWNLOAD_CA override both settings.
+	DownloadProxyURL string `json:"download_proxy_url"`
+	DownloadCAFile   string `json:"download_ca_file"`
+
+	// DeferMeteredDownloads postpones the download of missing models on a metered
+	// connection (as flagged by NetworkManager or the Windows network settings) until it
+	// is unmetered, the user approves it (`tribar download`) or the ModelDownloadTime of
+	// day ("02:00", empty for none) comes.
+	DeferMeteredDownloads bool   `json:"defer_metered_downloads"`
+	ModelDownloadTime     string `json:"model_download_time"`
+
+	// VerifyModelChecksums checks the SHA-256 of the model files every time they are
+	// loaded, re-downloading corrupted ones. Downloads are always verified.
+	VerifyModelChecksums bool `json:"verify_model_checksums"`
+
+	// Remote transcription settings. When enabled, audio is sent to an OpenAI compatible
+	// transcription server (e.g. a self-hosted Whisper) instead of the local model; with
+	// fallback enabled the local model is still loaded and used if the server fails, is
+	// unreachable or takes longer than the max latency (zero disables the budget).
+	RemoteTranscriptionEnabled           bool   `json:"remote_transcription_enabled"`
+	RemoteTranscriptionURL               string `json:"remote_transcription_url"`
+	RemoteTranscriptionAPIKey            string `json:"remote_transcription_api_key"`
+	RemoteTranscriptionModel             string `json:"remote_transcription_model"`
+	RemoteTranscriptionFallback          bool   `json:"remote_transcription_fallback"`
+	RemoteTranscriptionMaxLatencySeconds int    `json:"remote_transcription_max_latency_seconds"`
+
 	// Notification settings
 	NotifyOnError  bool `json:"notify_on_error"`
 	NotifyOnStart  bool `json:"notify_on_start"`
@@ -39,8 +290,81 @@ type Settings struct {
 	SoundOnStart  bool `json:"sound_on_start"`
 	SoundOnFinish bool `json:"sound_on_finish"`
 
-	// Output settings
-	OutputMode OutputMode `json:"output_mode"`
+	// Desktop integration settings. ToggleHotkey is the shortcut bound to `tribar toggle`
+	// in the desktop (e.g. "cmd+shift+space"), only used to show it in the menu;
+	// PrivacyHotkey and MarkerHotkey are the ones bound to `tribar privacy` and `tribar
+	// mark`. RelaunchAfterUpdate restarts the app once idle when its executable is
+	// replaced. SelfTestOnStartup checks the models, the input device and the clipboard
+	// once the models are loaded at launch.
+	ToggleHotkey        string `json:"toggle_hotkey"`
+	PrivacyHotkey       string `json:"privacy_hotkey"`
+	MarkerHotkey        string `json:"marker_hotkey"`
+	RelaunchAfterUpdate bool   `json:"relaunch_after_update"`
+	SelfTestOnStartup   bool   `json:"self_test_on_startup"`
+
+	// Language is a hint of the spoken language (e.g. "es") available to post-processing
+	// prompts as ${language}; empty lets the model detect it. If the selected model does
+	// not support it, a model that does (e.g. the multilingual Parakeet) is loaded instead.
+	Language string `json:"language"`
+
+	// Output settings, a max paste length of zero disables the limit
+	OutputMode        OutputMode        `json:"output_mode"`
+	MaxPasteLength    int               `json:"max_paste_length"`
+	PasteOverflowMode PasteOverflowMode `json:"paste_overflow_mode"`
+	Sinks             []SinkConfig      `json:"sinks"`
+
+	// Audit log settings. When enabled, every delivered text is recorded in a hash-chained,
+	// append-only log with the target application, model and prompt; the text itself is
+	// only stored (besides its hash) when AuditLogIncludeText is set.
+	AuditLogEnabled     bool `json:"audit_log_enabled"`
+	AuditLogIncludeText bool `json:"audit_log_include_text"`
+
+	// Local server settings. When enabled, history entries can be shared through one-time
+	// links served on LocalServerAddr; listening on all interfaces (":8723") makes them
+	// reachable from the LAN. Changes apply on restart.
+	LocalServerEnabled bool   `json:"local_server_enabled"`
+	LocalServerAddr    string `json:"local_server_addr"`
+
+	// Phone upload settings. When enabled, other devices paired with `tribar pair` can
+	// upload audio to the local server to be transcribed on the desktop, authenticated
+	// with PhoneUploadToken.
+	PhoneUploadEnabled bool   `json:"phone_upload_enabled"`
+	PhoneUploadToken   string `json:"phone_upload_token"`
+
+	// Routing rules applied to transcriptions before post-processing
+	RoutingRules []RoutingRule `json:"routing_rules"`
+
+	// PunctuationEnabled restores the punctuation and capitalization of local
+	// transcriptions with a small offline model, for users without post-processing. The
+	// model files are downloaded from PunctuationModelURL, or copied by hand to the
+	// "punctuation" model directory when it is empty.
+	PunctuationEnabled  bool   `json:"punctuation_enabled"`
+	PunctuationModelURL string `json:"punctuation_model_url"`
+
+	// ITNEnabled rewrites spoken numbers, amounts and dates in written form ("twenty five
+	// dollars" becomes "$25") before post-processing, with the grammar of Language:
+	// English or Spanish, English for other languages.
+	ITNEnabled bool `json:"itn_enabled"`
+
+	// Text normalization settings, an empty profile ID disables normalization
+	NormalizationProfileID string                 `json:"normalization_profile_id"`
+	NormalizationProfiles  []NormalizationProfile `json:"normalization_profiles"`
+
+	// Form dictation settings. While FormTemplateID selects a template, dictations fill its
+	// fields in order instead of being output: saying one of FormNextFieldPhrases or
+	// FormPreviousFieldPhrases moves to another field and FormFinishPhrases, or moving
+	// past the last field, outputs the rendered form. An empty ID disables form dictation.
+	FormTemplateID           string         `json:"form_template_id"`
+	FormTemplates            []FormTemplate `json:"form_templates"`
+	FormNextFieldPhrases     []string       `json:"form_next_field_phrases"`
+	FormPreviousFieldPhrases []string       `json:"form_previous_field_phrases"`
+	FormFinishPhrases        []string       `json:"form_finish_phrases"`
+
+	// Profanity filter settings. ProfanityFilter masks ("f***") or removes the profanity of
+	// the final text before it is delivered; ProfanityWords adds words to the built-in
+	// English list.
+	ProfanityFilter ProfanityMode `json:"profanity_filter"`
+	ProfanityWords  []string      `json:"profanity_words"`
 
 	// Post-processing settings
 	PostProcessEnabled  bool   `json:"postprocess_enabled"`
@@ -52,8 +376,107 @@ type Settings struct {
 	// Prompts for post-processing
 	Prompts []Prompt `json:"prompts"`
 
-	// History settings
-	HistoryLimit int `json:"history_limit"`
+	// SummaryPrompt is used to summarize session transcripts, ${output} is replaced
+	// with the full transcript.
+	SummaryPrompt string `json:"summary_prompt"`
+
+	// History settings, the tags are the ones offered to label history entries
+	HistoryLimit    int       `json:"history_limit"`
+	HistoryTags     []string  `json:"history_tags"`
+	HistoryTagRules []TagRule `json:"history_tag_rules"`
+
+	// Settings sync settings. With a folder shared between the machines of the user (e.g.
+	// by Syncthing or Dropbox), every machine publishes its settings there and takes the
+	// changes made on the others, merged setting by setting and, for prompts, rules,
+	// profiles, templates and sinks, item by item. The hardware-specific settings, such as
+	// the input device, stay local, and so do the ones listed in SettingsSyncLocalKeys by
+	// their JSON names.
+	SettingsSyncFolder    string   `json:"settings_sync_folder"`
+	SettingsSyncLocalKeys []string `json:"settings_sync_local_keys"`
+
+	// History sync settings. With a backend, the history is merged every
+	// HistorySyncIntervalMinutes (zero only syncs on request) with a file stored at
+	// HistorySyncURL: its URL on the WebDAV server, or the path-style URL of the object on
+	// the S3-compatible storage (e.g. https://s3.eu-west-1.amazonaws.com/bucket/history).
+	// The username and password are the WebDAV credentials or the S3 access key ID and
+	// secret access key, and the region is the S3 one (us-east-1 if empty). The file is
+	// encrypted with HistorySyncPassphrase, which must be the same on every machine and is
+	// never sent; the audio of the entries stays on the machine that recorded it.
+	HistorySyncBackend         HistorySyncBackend `json:"history_sync_backend"`
+	HistorySyncURL             string             `json:"history_sync_url"`
+	HistorySyncUsername        string             `json:"history_sync_username"`
+	HistorySyncPassword        string             `json:"history_sync_password"`
+	HistorySyncRegion          string             `json:"history_sync_region"`
+	HistorySyncPassphrase      string             `json:"history_sync_passphrase"`
+	HistorySyncIntervalMinutes int                `json:"history_sync_interval_minutes"`
+
+	// LowConfidenceThreshold tags dictations whose mean token confidence is below it as
+	// "low-confidence", zero disables the tag
+	LowConfidenceThreshold float32 `json:"low_confidence_threshold"`
+
+	// PasteConfidenceThreshold only lets the paste output modes paste dictations whose
+	// mean token confidence reaches it; below it the text is only copied and a
+	// notification asks to review it before pasting. Zero always pastes.
+	PasteConfidenceThreshold float32 `json:"paste_confidence_threshold"`
+
+	// TranscriptCacheMaxMB bounds the cache of transcribed files used by batch mode (tribar
+	// transcribe), zero disables the cache
+	TranscriptCacheMaxMB int `json:"transcript_cache_max_mb"`
+
+	// Speaking statistics settings. When enabled, the words, length and FillerWords of
+	// every dictation are counted per day, and a report of the last
+	// SpeechReportIntervalDays days is exported every that many days (zero disables it);
+	// `tribar coach` prints one at any time.
+	SpeechStatsEnabled       bool     `json:"speech_stats_enabled"`
+	FillerWords              []string `json:"filler_words"`
+	SpeechReportIntervalDays int      `json:"speech_report_interval_days"`
+
+	// Session settings
+	StopOnSessionLock    bool `json:"stop_on_session_lock"`
+	SessionWindowMinutes int  `json:"session_window_minutes"`
+
+	// Chapter settings. Exported sessions are split into chapters at the pauses of at
+	// least ChapterPauseMinutes between dictations or, with ChaptersUseLLM and a configured
+	// provider, where ChapterPrompt finds topic shifts; ${output} is replaced with the
+	// numbered transcript.
+	ChaptersEnabled     bool   `json:"chapters_enabled"`
+	ChapterPauseMinutes int    `json:"chapter_pause_minutes"`
+	ChaptersUseLLM      bool   `json:"chapters_use_llm"`
+	ChapterPrompt       string `json:"chapter_prompt"`
+
+	// Merge settings. Merged dictations (`tribar merge`) are joined in chronological order
+	// with MergeSeparator, in which ${time} is replaced with the time of the dictation that
+	// follows; with MergePostProcess the combined text is post-processed again.
+	MergeSeparator   string `json:"merge_separator"`
+	MergePostProcess bool   `json:"merge_post_process"`
+
+	// Text recognition settings, the language uses Tesseract codes (e.g. "eng", "spa+eng")
+	OCREnabled  bool   `json:"ocr_enabled"`
+	OCRLanguage string `json:"ocr_language"`
+
+	// Action item extraction settings. Items are appended to the tasks file (a todo.md in
+	// the data directory if empty) and, optionally, sent to the sink with TodoSinkID, which
+	// does not need to be enabled for regular transcriptions.
+	TodoEnabled  bool   `json:"todo_enabled"`
+	TodoUseLLM   bool   `json:"todo_use_llm"`
+	TodoPrompt   string `json:"todo_prompt"`
+	TodoFilePath string `json:"todo_file_path"`
+	TodoSinkID   string `json:"todo_sink_id"`
+
+	// Calendar settings, the source is an iCalendar file path or URL
+	CalendarNamingEnabled bool   `json:"calendar_naming_enabled"`
+	CalendarSource        string `json:"calendar_source"`
+
+	// Battery saver settings
+	BatterySaverEnabled bool `json:"battery_saver_enabled"`
+	BatterySaverThreads int  `json:"battery_saver_threads"`
+
+	// Features enables or disables feature flags by name, the ones missing keep their
+	// default in this release, see FeatureEnabled
+	Features map[Feature]bool `json:"features"`
+
+	// Advanced holds the performance tunables, see AdvancedSettings
+	Advanced AdvancedSettings `json:"advanced"`
 }
 
 // defaultPrompts returns the predefined prompts for post-processing.
@@ -134,10 +557,127 @@ Raw transcription: ${output}`,
 	},
 }
 
+// defaultNormalizationProfiles returns the predefined text normalization profiles.
+var defaultNormalizationProfiles = []NormalizationProfile{
+	{
+		ID:          "9a4c1f0e-2b7d-4e55-8f3a-6c1d2e9b7a10",
+		Name:        "Chat",
+		Casing:      CasingLower,
+		Punctuation: PunctuationMinimal,
+		AllowEmoji:  true,
+		Apps:        []string{"slack", "discord", "telegram", "whatsapp", "signal", "teams", "element", "messages"},
+	},
+	{
+		ID:          "5e8b2d47-91c3-4f6a-b0d2-3a7e9c4f1b62",
+		Name:        "Document",
+		Casing:      CasingSentence,
+		Punctuation: PunctuationKeep,
+		AllowEmoji:  false,
+		Apps:        []string{"word", "pages", "soffice", "libreoffice"},
+	},
+}
+
+// defaultFormTemplates returns the predefined form templates.
+var defaultFormTemplates = []FormTemplate{
+	{
+		ID:     "3f6d8a21-7c4e-4b9a-a5d0-e2b18c7f9d34",
+		Name:   "Email",
+		Fields: []string{"To", "Subject", "Body"},
+		Layout: "To: ${To}\nSubject: ${Subject}\n\n${Body}",
+	},
+}
+
+// defaultSummaryPrompt is the predefined prompt used to summarize session transcripts.
+const defaultSummaryPrompt = `You are a meeting assistant. Your task is to summarize a timestamped speech-to-text transcript of a meeting, interview or brainstorming session.
+
+Instructions:
+- Start with a short paragraph describing the overall topic
+- List the key points discussed as bullet points
+- List decisions made, if any
+- List action items with their owners, if mentioned
+- Do not invent information that is not present in the transcript
+- Write the summary in the same language as the transcript
+- Use Markdown formatting
+
+Output the summary directly without any prefix or explanation.
+
+Transcript:
+${output}`
+
+// defaultChapterPrompt is the predefined prompt used to split session transcripts into
+// chapters.
+const defaultChapterPrompt = `You are an editor. Your task is to split a speech-to-text transcript of a long session into chapters where the topic changes, so it is easy to navigate.
+
+Rules:
+- Every line of the transcript starts with its number and timestamp
+- Output one chapter per line as "<number of its first line>: <title>"
+- The first chapter starts at line 1
+- Titles are short (at most six words) and in the language of the transcript
+- Do not create chapters shorter than a few lines
+
+Transcript:
+${output}`
+
+// defaultTodoPrompt is the predefined prompt used to extract action items from a
+// transcription.
+const defaultTodoPrompt = `You are a personal assistant. Your task is to find the action items (tasks, reminders, things to do) in a speech-to-text transcription.
+
+Rules:
+- Output one action item per line, without bullets or numbering
+- Write every item as a short imperative sentence
+- Keep the language of the transcription
+- If there are no action items, output exactly NONE
+
+Transcription:
+${output}`
+
 // defaultSettings returns the default application settings.
 var defaultSettings = Settings{
 	Version: 1,
 
+	ModelID:        "parakeet",
+	ModelPrecision: "int8",
+
+	ExternalTranscriberCommand:   []string{},
+	ExternalTranscriberLanguages: []string{},
+
+	TrimSilenceEnabled: true,
+
+	AutoStopSilenceSeconds: 0,
+	MaxRecordingMinutes:    10,
+
+	StartTrimMs:         0,
+	SuppressStartClicks: false,
+
+	PreRollMs: 0,
+
+	NoiseSuppressionEnabled: false,
+
+	AutoGainEnabled: false,
+
+	InputDeviceID: "",
+
+	ExecutionProvider: "cpu",
+	CUDADeviceID:      0,
+	CUDARuntimePath:   "",
+
+	ModelMirrorURL: "",
+
+	DownloadProxyURL: "",
+	DownloadCAFile:   "",
+
+	DeferMeteredDownloads: true,
+	ModelDownloadTime:     "",
+
+	VerifyModelChecksums: true,
+
+	RemoteTranscriptionEnabled:           false,
+	RemoteTranscriptionURL:               "",
+	RemoteTranscriptionAPIKey:            "",
+	RemoteTranscriptionModel:             "whisper-1",
+	RemoteTranscriptionFallback:          true,
+	RemoteTranscriptionMaxLatencySeconds: 10,
+
 	NotifyOnError:  true,
 	NotifyOnStart:  false,
 	NotifyOnFinish: false,
@@ -145,7 +685,46 @@ var defaultSettings = Settings{
 	SoundOnStart:  true,
 	SoundOnFinish: true,
 
-	OutputMode: OutputModeCopyPaste,
+	ToggleHotkey:        "",
+	PrivacyHotkey:       "",
+	MarkerHotkey:        "",
+	RelaunchAfterUpdate: true,
+	SelfTestOnStartup:   true,
+
+	Language: "",
+
+	OutputMode:        OutputModeCopyPaste,
+	MaxPasteLength:    10000,
+	PasteOverflowMode: PasteOverflowFile,
+	Sinks:             []SinkConfig{},
+
+	AuditLogEnabled:     false,
+	AuditLogIncludeText: false,
+
+	LocalServerEnabled: false,
+	LocalServerAddr:    "127.0.0.1:8723",
+
+	PhoneUploadEnabled: false,
+	PhoneUploadToken:   "",
+
+	RoutingRules: []RoutingRule{},
+
+	PunctuationEnabled:  false,
+	PunctuationModelURL: "",
+
+	ITNEnabled: false,
+
+	NormalizationProfileID: "",
+	NormalizationProfiles:  defaultNormalizationProfiles,
+
+	FormTemplateID:           "",
+	FormTemplates:            defaultFormTemplates,
+	FormNextFieldPhrases:     []string{"next field"},
+	FormPreviousFieldPhrases: []string{"previous field"},
+	FormFinishPhrases:        []string{"finish form"},
+
+	ProfanityFilter: ProfanityOff,
+	ProfanityWords:  []string{},
 
 	PostProcessEnabled:  false,
 	PostProcessBaseURL:  "https://api.openai.com/v1",
@@ -155,22 +734,96 @@ var defaultSettings = Settings{
 
 	Prompts: defaultPrompts,
 
-	HistoryLimit: 10,
+	SummaryPrompt: defaultSummaryPrompt,
+
+	HistoryLimit:    10,
+	HistoryTags:     []string{"work", "idea", "todo"},
+	HistoryTagRules: []TagRule{},
+
+	SettingsSyncFolder:    "",
+	SettingsSyncLocalKeys: []string{},
+
+	HistorySyncBackend:         HistorySyncOff,
+	HistorySyncIntervalMinutes: 15,
+
+	LowConfidenceThreshold: 0.6,
+
+	PasteConfidenceThreshold: 0,
+
+	TranscriptCacheMaxMB: 50,
+
+	SpeechStatsEnabled:       false,
+	FillerWords:              []string{"um", "uh", "er", "ah", "hmm", "like", "you know", "I mean", "basically", "actually", "literally"},
+	SpeechReportIntervalDays: 7,
+
+	StopOnSessionLock:    true,
+	SessionWindowMinutes: 10,
+
+	ChaptersEnabled:     true,
+	ChapterPauseMinutes: 3,
+	ChaptersUseLLM:      false,
+	ChapterPrompt:       defaultChapterPrompt,
+
+	MergeSeparator:   "\n\n",
+	MergePostProcess: false,
+
+	TodoEnabled:  false,
+	TodoUseLLM:   false,
+	TodoPrompt:   defaultTodoPrompt,
+	TodoFilePath: "",
+	TodoSinkID:   "",
+
+	OCREnabled:  false,
+	OCRLanguage: "eng",
+
+	CalendarNamingEnabled: false,
+	CalendarSource:        "",
+
+	BatterySaverEnabled: false,
+	BatterySaverThreads: 2,
+
+	Features: map[Feature]bool{},
+
+	Advanced: defaultAdvancedSettings,
 }
 
-// SettingsManager handles loading and saving of user settings.
+// SettingsManager handles loading and saving of user settings. The settings it returns
+// are the user settings with the administrator policy applied; locked settings are never
+// written to the user file.
 type SettingsManager struct {
-	mu       sync.RWMutex
-	settings Settings
-	filePath string
+	mu        sync.RWMutex
+	settings  Settings
+	effective Settings
+	policy    Policy
+	filePath  string
+
+	// diskHash is the SHA-256 of the settings file as last read or written by the manager,
+	// a different hash on disk means the file was edited externally.
+	diskHash [sha256.Size]byte
 }
 
-// NewSettingsManager creates a new settings manager and loads existing settings.
+// NewSettingsManager creates a new settings manager, loads the system-wide policy and the
+// existing settings.
 func NewSettingsManager() (*SettingsManager, error) {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	// A copy of the defaults, so editing the settings in place cannot change them.
+	defaults, err := parseSettings([]byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
 	sm := &SettingsManager{
-		settings: defaultSettings,
+		settings: defaults,
+		policy:   policy,
 		filePath: filepath.Join(DirectoryConfig, settingsFileName),
 	}
+	if err := sm.applyPolicyUnsafe(); err != nil {
+		return nil, err
+	}
 
 	if err := sm.Load(); err != nil {
 		if !os.IsNotExist(err) {
@@ -185,19 +838,161 @@ func NewSettingsManager() (*SettingsManager, error) {
 	return sm, nil
 }
 
-// Get returns a copy of the current settings.
+// Get returns a copy of the current settings, with the policy applied. The prompts are
+// copied too, so editing them in place cannot hide an edit from the version history, and
+// so are the feature flags, so setting one cannot change the current settings.
 func (sm *SettingsManager) Get() Settings {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	return sm.settings
+
+	settings := sm.effective
+	settings.Prompts = slices.Clone(settings.Prompts)
+	settings.Features = maps.Clone(settings.Features)
+	return settings
+}
+
+// LockedKeys returns the JSON names of the settings enforced by the policy, sorted.
+func (sm *SettingsManager) LockedKeys() []string {
+	return sm.policy.Keys()
+}
+
+// applyPolicyUnsafe recomputes the effective settings without acquiring the lock.
+func (sm *SettingsManager) applyPolicyUnsafe() error {
+	effective, err := sm.policy.Apply(sm.settings)
+	if err != nil {
+		return err
+	}
+	sm.effective = effective
+	return nil
 }
 
-// Update updates the settings and saves them to disk.
+// ModelMirrorEnv is the environment variable that overrides the model mirror URL.
+const ModelMirrorEnv = "TRIBAR_MODEL_MIRROR"
+
+// ModelMirror returns the model mirror URL, from the environment if set or the settings.
+func (s Settings) ModelMirror() string {
+	if mirror := os.Getenv(ModelMirrorEnv); mirror != "" {
+		return mirror
+	}
+	return s.ModelMirrorURL
+}
+
+const (
+	// DownloadProxyEnv and DownloadCAEnv are the environment variables that override the
+	// download proxy and CA file.
+	DownloadProxyEnv = "TRIBAR_DOWNLOAD_PROXY"
+	DownloadCAEnv    = "TRIBAR_DOWNLOAD_CA"
+)
+
+// DownloadProxy returns the model download proxy, from the environment if set or the
+// settings.
+func (s Settings) DownloadProxy() string {
+	if proxy := os.Getenv(DownloadProxyEnv); proxy != "" {
+		return proxy
+	}
+	return s.DownloadProxyURL
+}
+
+// DownloadCA returns the CA file trusted by model downloads, from the environment if set
+// or the settings.
+func (s Settings) DownloadCA() string {
+	if caFile := os.Getenv(DownloadCAEnv); caFile != "" {
+		return caFile
+	}
+	return s.DownloadCAFile
+}
+
+// FindNormalizationProfile returns the normalization profile with the given ID.
+func (s Settings) FindNormalizationProfile(id string) (NormalizationProfile, bool) {
+	for _, profile := range s.NormalizationProfiles {
+		if profile.ID == id {
+			return profile, true
+		}
+	}
+	return NormalizationProfile{}, false
+}
+
+// MatchNormalizationProfile returns the first normalization profile whose Apps match the
+// name of the focused application.
+func (s Settings) MatchNormalizationProfile(app string) (NormalizationProfile, bool) {
+	app = strings.ToLower(app)
+	if app == "" {
+		return NormalizationProfile{}, false
+	}
+	for _, profile := range s.NormalizationProfiles {
+		for _, name := range profile.Apps {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" && strings.Contains(app, name) {
+				return profile, true
+			}
+		}
+	}
+	return NormalizationProfile{}, false
+}
+
+// FindFormTemplate returns the form template matching the given ID.
+func (s Settings) FindFormTemplate(id string) (FormTemplate, bool) {
+	for _, template := range s.FormTemplates {
+		if template.ID == id {
+			return template, true
+		}
+	}
+	return FormTemplate{}, false
+}
+
+// FindPrompt returns the post-processing prompt matching the given ID or, case-insensitively,
+// name.
+func (s Settings) FindPrompt(idOrName string) (Prompt, bool) {
+	for _, prompt := range s.Prompts {
+		if prompt.ID == idOrName || strings.EqualFold(prompt.Name, idOrName) {
+			return prompt, true
+		}
+	}
+	return Prompt{}, false
+}
+
+// FindSink returns the sink configuration with the given ID.
+func (s Settings) FindSink(id string) (SinkConfig, bool) {
+	for _, sink := range s.Sinks {
+		if sink.ID == id {
+			return sink, true
+		}
+	}
+	return SinkConfig{}, false
+}
+
+// Update updates the settings and saves them to disk. Changes to settings locked by the
+// policy are ignored, the user keeps the values it had before the policy was deployed.
 func (sm *SettingsManager) Update(settings Settings) error {
+	if err := settings.Advanced.Validate(); err != nil {
+		return err
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	return sm.updateUnsafe(settings)
+}
 
+// updateUnsafe updates and saves the settings without acquiring the lock.
+func (sm *SettingsManager) updateUnsafe(settings Settings) error {
+	if len(sm.policy) > 0 {
+		previous, err := settingsFields(sm.settings)
+		if err != nil {
+			return err
+		}
+		locked := make(map[string]json.RawMessage, len(sm.policy))
+		for key := range sm.policy {
+			locked[key] = previous[key]
+		}
+		if settings, err = overlaySettings(settings, locked); err != nil {
+			return err
+		}
+	}
+
+	settings.Prompts, _ = trackPromptVersions(sm.settings.Prompts, settings.Prompts, time.Now())
 	sm.settings = settings
+	if err := sm.applyPolicyUnsafe(); err != nil {
+		return err
+	}
 	return sm.saveUnsafe()
 }
 
@@ -211,13 +1006,54 @@ func (sm *SettingsManager) Load() error {
 		return err
 	}
 
-	var settings Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return fmt.Errorf("failed to parse settings: %w", err)
+	settings, err := parseSettings(data)
+	if err != nil {
+		return err
 	}
 
 	sm.settings = settings
-	return nil
+	sm.diskHash = sha256.Sum256(data)
+	return sm.applyPolicyUnsafe()
+}
+
+// parseSettings decodes a settings file on top of the defaults so fields missing in older
+// settings files keep sane values. The settings present in the file replace the defaults
+// whole, except for the advanced section whose missing fields keep their defaults. The
+// result is decoded from a JSON copy of the defaults, since decoding into defaultSettings
+// itself would write into its slices and maps and merge the file into their items.
+func parseSettings(data []byte) (Settings, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return Settings{}, fmt.Errorf("failed to parse settings: %w", err)
+	}
+
+	fields, err := settingsFields(defaultSettings)
+	if err != nil {
+		return Settings{}, err
+	}
+	for key, value := range values {
+		if key != "advanced" {
+			fields[key] = value
+		}
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	var settings Settings
+	if err := json.Unmarshal(merged, &settings); err != nil {
+		return Settings{}, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if advanced, ok := values["advanced"]; ok {
+		if err := json.Unmarshal(advanced, &settings.Advanced); err != nil {
+			return Settings{}, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+	if err := settings.Advanced.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("invalid settings: %w", err)
+	}
+	return settings, nil
 }
 
 // Save writes the current settings to the config file.
@@ -227,16 +1063,38 @@ func (sm *SettingsManager) Save() error {
 	return sm.saveUnsafe()
 }
 
-// saveUnsafe writes settings to disk without acquiring the lock.
+// saveUnsafe writes settings to disk without acquiring the lock. If the file was edited
+// externally since it was last read, the in-app settings win (last writer wins) and the
+// external version is kept in a backup copy first.
 func (sm *SettingsManager) saveUnsafe() error {
 	data, err := json.MarshalIndent(sm.settings, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
+	if err := sm.backupExternalEditUnsafe(); err != nil {
+		return err
+	}
+
 	if err := os.WriteFile(sm.filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
 
+	sm.diskHash = sha256.Sum256(data)
+	return nil
+}
+
+// backupExternalEditUnsafe copies the settings file next to itself if its content is not
+// the one the manager last read or wrote.
+func (sm *SettingsManager) backupExternalEditUnsafe() error {
+	current, err := os.ReadFile(sm.filePath)
+	if err != nil || sha256.Sum256(current) == sm.diskHash {
+		return nil
+	}
+
+	backupPath := fmt.Sprintf("%s.conflict-%s.bak", sm.filePath, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupPath, current, 0644); err != nil {
+		return fmt.Errorf("failed to back up externally edited settings: %w", err)
+	}
 	return nil
 }
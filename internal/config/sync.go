@@ -0,0 +1,451 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const (
+	settingsSyncInterval = 10 * time.Second
+	// settingsSyncStateFileName keeps, in the config directory, the entries of the last
+	// sync: what this machine publishes and when every value changed.
+	settingsSyncStateFileName = "settings-sync.json"
+	// settingsSyncFilePrefix starts the names of the files of every machine in the sync
+	// folder, followed by the device ID.
+	settingsSyncFilePrefix = "tribar-settings-"
+)
+
+// syncedListKeys are the settings holding lists of items with an ID, merged item by item
+// so the prompts or rules added on different machines are all kept.
+var syncedListKeys = []string{"prompts", "routing_rules", "normalization_profiles", "form_templates", "sinks"}
+
+// secretKeys are never synced either: the sync folder is usually stored by a cloud
+// service in plain text, and the history passphrase in particular would defeat the end to
+// end encryption of the history sync. Every machine keeps its own value.
+var secretKeys = []string{
+	"remote_transcription_api_key",
+	"phone_upload_token",
+	"postprocess_api_key",
+	"history_sync_password",
+	"history_sync_passphrase",
+	// Private calendar addresses and proxy URLs embed their credentials.
+	"calendar_source",
+	"download_proxy_url",
+}
+
+// secretItemFields are the fields of the items of the list settings that hold secrets.
+// They are removed from the synced items; an item changed on another machine keeps the
+// local secret, and a new one has none until it is entered on this machine.
+var secretItemFields = map[string][]string{
+	"sinks": {"smtp_password", "webhook_url"},
+}
+
+// deviceLocalKeys are never synced: they configure the sync itself, or describe the
+// hardware or the files of a machine.
+var deviceLocalKeys = []string{
+	"version",
+	"settings_sync_folder",
+	"settings_sync_local_keys",
+	"input_device_id",
+	"execution_provider",
+	"cuda_device_id",
+	"external_transcriber_command",
+	"external_transcriber_languages",
+	"start_trim_ms",
+	"pre_roll_ms",
+	"todo_file_path",
+	"battery_saver_enabled",
+	"battery_saver_threads",
+	"features",
+	"advanced",
+}
+
+// syncEntry is the value of a setting, or of an item of a list setting named
+// "<setting>/<id>", and the device and time it was last changed at. Deleted items stay as
+// entries so the deletion wins over the older copies of other machines.
+type syncEntry struct {
+	Value   json.RawMessage `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+	Time    time.Time       `json:"time"`
+	Device  string          `json:"device"`
+}
+
+// newerThan reports whether the entry wins over other: the latest change wins, and the
+// greatest device ID breaks ties so every machine picks the same entry.
+func (e syncEntry) newerThan(other syncEntry) bool {
+	if !e.Time.Equal(other.Time) {
+		return e.Time.After(other.Time)
+	}
+	return e.Device > other.Device
+}
+
+// syncFile is the file every machine writes to the sync folder.
+type syncFile struct {
+	Device   string               `json:"device"`
+	Hostname string               `json:"hostname"`
+	Entries  map[string]syncEntry `json:"entries"`
+}
+
+// WatchSyncFolder syncs the settings with the other machines of the user through the
+// SettingsSyncFolder, e.g. a folder shared by Syncthing or Dropbox, until the context is
+// canceled, calling onChange with the new settings (policy applied) when they take
+// changes from other machines. Every machine only writes its own file in the folder, so
+// sync tools never see conflicting edits, and the values are merged setting by setting,
+// and item by item for the lists of prompts, rules, profiles, templates and sinks: the
+// last change wins. The device-local settings (see deviceLocalKeys) and the ones in
+// SettingsSyncLocalKeys or locked by the policy are kept out of the sync.
+func (sm *SettingsManager) WatchSyncFolder(ctx context.Context, logger logger.Logger, onChange func(Settings)) {
+	ticker := time.NewTicker(settingsSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if folder := sm.Get().SettingsSyncFolder; folder != "" {
+			applied, err := sm.syncFolder(folder)
+			if err != nil {
+				logger.Warn(ctx, "failed to sync settings", "folder", folder, "err", err)
+			}
+			if applied {
+				logger.Info(ctx, "settings changed on another machine, applied", "folder", folder)
+				onChange(sm.Get())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncFolder publishes the local changes to the folder and applies the newer changes of
+// the other machines, reporting whether any was applied.
+func (sm *SettingsManager) syncFolder(folder string) (bool, error) {
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return false, fmt.Errorf("failed to create sync folder: %w", err)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	user := sm.settings
+
+	excluded := slices.Concat(deviceLocalKeys, secretKeys, user.SettingsSyncLocalKeys, sm.policy.Keys())
+	isExcluded := func(name string) bool {
+		key, _, _ := strings.Cut(name, "/")
+		return slices.Contains(excluded, key)
+	}
+
+	statePath := filepath.Join(DirectoryConfig, settingsSyncStateFileName)
+	state, err := readSyncFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	// On the first sync the settings this machine left at their defaults are older than
+	// any synced change, so a new machine takes the settings of the others instead of
+	// resetting them.
+	firstSync := err != nil
+	if state.Device == "" {
+		state.Device = strings.ToLower(rand.Text())
+	}
+	state.Hostname, _ = os.Hostname()
+
+	fields, err := settingsFields(user)
+	if err != nil {
+		return false, err
+	}
+	local, err := flattenSyncFields(fields, isExcluded)
+	if err != nil {
+		return false, err
+	}
+	defaultFields, err := settingsFields(defaultSettings)
+	if err != nil {
+		return false, err
+	}
+	defaults, err := flattenSyncFields(defaultFields, isExcluded)
+	if err != nil {
+		return false, err
+	}
+
+	// Record the changes made on this machine since the last sync.
+	changed := false
+	now := time.Now().UTC()
+	for name, value := range local {
+		entry, ok := state.Entries[name]
+		if !ok || entry.Deleted || !sameJSON(entry.Value, value) {
+			state.Entries[name] = syncEntry{Value: value, Time: now, Device: state.Device}
+			if firstSync && sameJSON(value, defaults[name]) {
+				state.Entries[name] = syncEntry{Value: value, Device: state.Device}
+			}
+			changed = true
+		}
+	}
+	for name, entry := range state.Entries {
+		if _, ok := local[name]; ok || entry.Deleted {
+			continue
+		}
+		changed = true
+		// The deleted items are remembered, the settings no longer synced are forgotten.
+		if _, isItem := listKey(name); isItem && !isExcluded(name) {
+			state.Entries[name] = syncEntry{Deleted: true, Time: now, Device: state.Device}
+			continue
+		}
+		delete(state.Entries, name)
+	}
+
+	// Take the newer changes of the other machines.
+	applied := false
+	ownPath := filepath.Join(folder, settingsSyncFilePrefix+state.Device+".json")
+	paths, err := filepath.Glob(filepath.Join(folder, settingsSyncFilePrefix+"*.json"))
+	if err != nil {
+		return false, fmt.Errorf("failed to list sync folder: %w", err)
+	}
+	for _, path := range paths {
+		if path == ownPath {
+			continue
+		}
+		// A file being written by the sync tool is read again on the next sync.
+		other, err := readSyncFile(path)
+		if err != nil {
+			continue
+		}
+		for name, entry := range other.Entries {
+			if isExcluded(name) {
+				continue
+			}
+			current, ok := state.Entries[name]
+			if ok && !entry.newerThan(current) {
+				continue
+			}
+			state.Entries[name] = entry
+			changed = true
+			// A deletion of an item this machine never had changes nothing.
+			if !ok && entry.Deleted {
+				continue
+			}
+			if current.Deleted != entry.Deleted || !sameJSON(current.Value, entry.Value) {
+				applied = true
+			}
+		}
+	}
+
+	if applied {
+		merged, err := mergeSyncEntries(fields, state.Entries, isExcluded)
+		if err != nil {
+			return false, err
+		}
+		if err := sm.updateUnsafe(merged); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := os.Stat(ownPath); !changed && !applied && err == nil {
+		return false, nil
+	}
+	if err := writeSyncFile(statePath, state); err != nil {
+		return applied, err
+	}
+	return applied, writeSyncFile(ownPath, state)
+}
+
+// flattenSyncFields returns the synced settings as entry values keyed by their names: the
+// JSON name of the setting, or "<setting>/<id>" for the items of the list settings.
+func flattenSyncFields(fields map[string]json.RawMessage, isExcluded func(string) bool) (map[string]json.RawMessage, error) {
+	values := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		if isExcluded(key) {
+			continue
+		}
+		if !slices.Contains(syncedListKeys, key) {
+			values[key] = value
+			continue
+		}
+
+		items, err := listItems(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		for _, item := range items {
+			if item.id == "" {
+				continue
+			}
+			value, err := replaceItemSecrets(key, item.value, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", key, err)
+			}
+			values[key+"/"+item.id] = value
+		}
+	}
+	return values, nil
+}
+
+// replaceItemSecrets returns an item of a list setting with its secret fields (see
+// secretItemFields) taken from the local copy of the item, or removed if it is nil.
+func replaceItemSecrets(key string, value, local json.RawMessage) (json.RawMessage, error) {
+	secrets := secretItemFields[key]
+	if len(secrets) == 0 {
+		return value, nil
+	}
+
+	var fields, localFields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, err
+	}
+	if local != nil {
+		if err := json.Unmarshal(local, &localFields); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range secrets {
+		delete(fields, field)
+		if secret, ok := localFields[field]; ok {
+			fields[field] = secret
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// mergeSyncEntries returns the settings with the values of the entries. The items of the
+// lists keep their local order, and the items added on other machines follow them.
+func mergeSyncEntries(fields map[string]json.RawMessage, entries map[string]syncEntry, isExcluded func(string) bool) (Settings, error) {
+	added := make(map[string][]string)
+	for name, entry := range entries {
+		if isExcluded(name) || entry.Deleted {
+			continue
+		}
+		key, isItem := listKey(name)
+		if !isItem {
+			fields[name] = entry.Value
+			continue
+		}
+		added[key] = append(added[key], name)
+	}
+
+	for _, key := range syncedListKeys {
+		if isExcluded(key) {
+			continue
+		}
+		items, err := listItems(fields[key])
+		if err != nil {
+			return Settings{}, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+
+		merged := make([]json.RawMessage, 0, len(items))
+		for _, item := range items {
+			if item.id == "" {
+				merged = append(merged, item.value)
+				continue
+			}
+			if entry, ok := entries[key+"/"+item.id]; ok && !entry.Deleted {
+				value, err := replaceItemSecrets(key, entry.Value, item.value)
+				if err != nil {
+					return Settings{}, fmt.Errorf("failed to read %s: %w", key, err)
+				}
+				merged = append(merged, value)
+			}
+			added[key] = slices.DeleteFunc(added[key], func(name string) bool { return name == key+"/"+item.id })
+		}
+		sort.Strings(added[key])
+		for _, name := range added[key] {
+			merged = append(merged, entries[name].Value)
+		}
+
+		value, err := json.Marshal(merged)
+		if err != nil {
+			return Settings{}, fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		fields[key] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	return parseSettings(data)
+}
+
+// listKey returns the list setting of an entry name and whether it is the name of an item.
+func listKey(name string) (string, bool) {
+	key, _, isItem := strings.Cut(name, "/")
+	return key, isItem && slices.Contains(syncedListKeys, key)
+}
+
+// listItem is an item of a list setting and its ID.
+type listItem struct {
+	id    string
+	value json.RawMessage
+}
+
+// listItems splits a list setting into its items.
+func listItems(value json.RawMessage) ([]listItem, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, err
+	}
+
+	items := make([]listItem, 0, len(raw))
+	for _, value := range raw {
+		var item struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(value, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, listItem{id: item.ID, value: value})
+	}
+	return items, nil
+}
+
+// sameJSON reports whether two JSON values are equal regardless of their formatting.
+func sameJSON(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
+
+// readSyncFile reads the sync file at path.
+func readSyncFile(path string) (syncFile, error) {
+	file := syncFile{Entries: make(map[string]syncEntry)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Entries == nil {
+		file.Entries = make(map[string]syncEntry)
+	}
+	return file, nil
+}
+
+// writeSyncFile writes the sync file at path through a temporary file, so sync tools and
+// other machines never read a half-written one.
+func writeSyncFile(path string, file syncFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync file: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sync file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write sync file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const settingsWatchInterval = 2 * time.Second
+
+// Watch polls the settings file until the context is canceled and reloads it when it is
+// edited outside the app, calling onChange with the new settings (policy applied). An
+// edit that does not parse is reported once and ignored, so a half-written file never
+// resets the settings.
+func (sm *SettingsManager) Watch(ctx context.Context, logger logger.Logger, onChange func(Settings)) {
+	ticker := time.NewTicker(settingsWatchInterval)
+	defer ticker.Stop()
+
+	var invalidHash [sha256.Size]byte
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(sm.filePath)
+		if err != nil {
+			continue
+		}
+
+		hash := sha256.Sum256(data)
+		if hash == invalidHash {
+			continue
+		}
+
+		settings, changed, err := sm.reloadIfChanged(data, hash)
+		if err != nil {
+			logger.Warn(ctx, "ignoring invalid external edit of the settings file", "path", sm.filePath, "err", err)
+			invalidHash = hash
+			continue
+		}
+
+		if changed {
+			logger.Info(ctx, "settings file edited externally, reloaded", "path", sm.filePath)
+			onChange(settings)
+		}
+	}
+}
+
+// reloadIfChanged replaces the settings with the file content if it differs from the one
+// the manager last read or wrote. Prompts edited in the file get their prior body recorded
+// as a version, which is written back to the file.
+func (sm *SettingsManager) reloadIfChanged(data []byte, hash [sha256.Size]byte) (Settings, bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if hash == sm.diskHash {
+		return Settings{}, false, nil
+	}
+
+	settings, err := parseSettings(data)
+	if err != nil {
+		return Settings{}, false, err
+	}
+
+	var versioned bool
+	settings.Prompts, versioned = trackPromptVersions(sm.settings.Prompts, settings.Prompts, time.Now())
+
+	sm.settings = settings
+	sm.diskHash = hash
+	if err := sm.applyPolicyUnsafe(); err != nil {
+		return Settings{}, false, err
+	}
+	if versioned {
+		if err := sm.saveUnsafe(); err != nil {
+			return Settings{}, false, err
+		}
+	}
+	return sm.effective, true, nil
+}
@@ -0,0 +1,214 @@
+// Package control exposes the engine commands to other processes through a local socket,
+// so desktop hotkeys and scripts can drive the running instance with the tribar CLI
+// (e.g. `tribar toggle language=es prompt=Formal`). Messages are api.Command values
+// encoded as JSON, one request and one response per connection.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/pkg/api"
+)
+
+const (
+	socketFileName = "tribar.sock"
+	requestTimeout = 5 * time.Second
+)
+
+// Handler executes a command received through the socket.
+type Handler func(cmd api.Command) error
+
+// response is the reply sent back for every command, identifying the instance that
+// handled it.
+type response struct {
+	Error    string    `json:"error,omitempty"`
+	Instance *Instance `json:"instance,omitempty"`
+}
+
+// Instance identifies the process serving the control socket.
+type Instance struct {
+	PID        int    `json:"pid"`
+	Version    string `json:"version"`
+	Executable string `json:"executable"`
+}
+
+// String describes the instance for people, e.g. "Tribar v1.4.0 (PID 4242, /usr/bin/tribar)".
+// Instances of versions that do not identify themselves are "another Tribar instance".
+func (i Instance) String() string {
+	if i.PID == 0 {
+		return "another " + config.AppName + " instance"
+	}
+	return fmt.Sprintf("%s v%s (PID %d, %s)", config.AppName, i.Version, i.PID, i.Executable)
+}
+
+// currentInstance identifies this process.
+func currentInstance() Instance {
+	executable, _ := os.Executable()
+	return Instance{PID: os.Getpid(), Version: config.AppVersion, Executable: executable}
+}
+
+// SocketPath returns the path of the control socket. It requires the application
+// directories to be initialized.
+func SocketPath() string {
+	return filepath.Join(config.DirectoryData, socketFileName)
+}
+
+// Server listens for commands on the control socket.
+type Server struct {
+	logger   logger.Logger
+	handler  Handler
+	instance Instance
+}
+
+// NewServer creates a control server that dispatches commands to handler.
+func NewServer(logger logger.Logger, handler Handler) *Server {
+	return &Server{
+		logger:   logger,
+		handler:  handler,
+		instance: currentInstance(),
+	}
+}
+
+// Run serves commands until ctx is canceled.
+func (s *Server) Run(ctx context.Context) error {
+	path := SocketPath()
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	s.logger.Debug(ctx, "control socket listening", "path", path)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept control connection: %w", err)
+		}
+		go s.serve(ctx, conn)
+	}
+}
+
+// serve handles a single connection.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var cmd api.Command
+	if err := json.NewDecoder(conn).Decode(&cmd); err != nil {
+		s.logger.Warn(ctx, "invalid control command", "err", err)
+		_ = json.NewEncoder(conn).Encode(response{Error: fmt.Sprintf("invalid command: %v", err)})
+		return
+	}
+
+	s.logger.Debug(ctx, "control command received", "command", cmd.Name, "args", cmd.Args)
+
+	resp := response{Instance: &s.instance}
+	if err := s.handler(cmd); err != nil {
+		resp.Error = err.Error()
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Send delivers a command to the running instance and returns the error it reports.
+func Send(cmd api.Command) error {
+	conn, err := net.DialTimeout("unix", SocketPath(), requestTimeout)
+	if err != nil {
+		return fmt.Errorf("could not reach the running Tribar instance, is it started? %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(cmd); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Discover reports the instance serving the control socket, if any, so a second one is
+// not started: both would record from the microphone and answer the same hotkeys.
+// Instances of older versions, which do not identify themselves, are reported as a zero
+// Instance.
+func Discover() (Instance, bool) {
+	conn, err := net.DialTimeout("unix", SocketPath(), time.Second)
+	if err != nil {
+		return Instance{}, false
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var resp response
+	if err := json.NewEncoder(conn).Encode(api.NewCommand(api.CommandPing, nil)); err != nil {
+		return Instance{}, true
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil || resp.Instance == nil {
+		return Instance{}, true
+	}
+	return *resp.Instance, true
+}
+
+// Takeover asks the running instance to quit and waits until it released the control
+// socket, failing if it is still serving after timeout.
+func Takeover(timeout time.Duration) error {
+	if err := Send(api.NewCommand(api.CommandQuit, nil)); err != nil {
+		return fmt.Errorf("failed to ask the running instance to quit: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, running := Discover(); !running {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("the running instance did not quit within %s", timeout)
+}
+
+// removeStaleSocket deletes a socket file left behind by a crashed instance. It fails if
+// another instance is still listening on it.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("another instance is already listening on %s", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Package dispatch runs short fire-and-forget side effects (desktop notifications, sound
+// cues) on a background worker so callers never block on them, while still giving them a
+// well-defined lifecycle that can be flushed on shutdown.
+package dispatch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+// Task is a unit of work executed by the dispatcher. The context is canceled when the
+// per-task timeout expires.
+type Task func(ctx context.Context) error
+
+// Options configures a dispatcher.
+type Options struct {
+	// Name identifies the dispatcher in the logs.
+	Name string
+	// QueueSize is the maximum number of pending tasks; new tasks are dropped when full.
+	QueueSize int
+	// Timeout bounds the execution of every task.
+	Timeout time.Duration
+}
+
+// Instance executes queued tasks sequentially on a single worker goroutine.
+type Instance struct {
+	logger  logger.Logger
+	name    string
+	timeout time.Duration
+	queue   chan Task
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// New creates a dispatcher and starts its worker.
+func New(logger logger.Logger, opts Options) *Instance {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 16
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	d := &Instance{
+		logger:  logger,
+		name:    opts.Name,
+		timeout: opts.Timeout,
+		queue:   make(chan Task, opts.QueueSize),
+		done:    make(chan struct{}),
+	}
+
+	go d.run()
+
+	return d
+}
+
+// Submit enqueues a task without blocking. It returns false if the task was dropped
+// because the queue is full or the dispatcher has been shut down.
+func (d *Instance) Submit(task Task) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return false
+	}
+
+	select {
+	case d.queue <- task:
+		return true
+	default:
+		d.logger.Warn(context.Background(), "dispatch queue is full, dropping task", "dispatcher", d.name)
+		return false
+	}
+}
+
+// Shutdown stops accepting tasks and waits for the pending ones to finish, giving up
+// when ctx is done.
+func (d *Instance) Shutdown(ctx context.Context) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		d.logger.Warn(ctx, "dispatch flush timed out, pending tasks discarded", "dispatcher", d.name)
+	}
+}
+
+// run executes queued tasks until the queue is closed and drained.
+func (d *Instance) run() {
+	defer close(d.done)
+
+	for task := range d.queue {
+		d.execute(task)
+	}
+}
+
+// execute runs a single task with the configured timeout. Tasks that ignore their context
+// are abandoned when the timeout expires so they cannot stall the queue.
+func (d *Instance) execute(task Task) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- task(ctx) }()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			d.logger.Debug(ctx, "dispatch task failed", "dispatcher", d.name, "err", err)
+		}
+	case <-ctx.Done():
+		d.logger.Warn(ctx, "dispatch task timed out", "dispatcher", d.name)
+	}
+}
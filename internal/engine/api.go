@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/ocr"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/api"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// Snapshot returns the serializable view of the engine state for frontends.
+func (e *Engine) Snapshot() api.Snapshot {
+	status, _ := e.state.GetStatus()
+
+	history := e.state.GetHistory()
+	apiHistory := make([]api.HistoryEntry, 0, len(history))
+	for _, entry := range history {
+		tags := entry.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		apiHistory = append(apiHistory, api.HistoryEntry{
+			ID:         entry.ID,
+			Text:       entry.Text,
+			AudioPath:  entry.AudioPath,
+			SessionID:  entry.SessionID,
+			Tags:       tags,
+			Source:     string(entry.Source),
+			Confidence: entry.Confidence,
+			Timestamp:  entry.Timestamp,
+		})
+	}
+
+	sessions := e.state.GetSessions()
+	apiSessions := make([]api.Session, 0, len(sessions))
+	for _, session := range sessions {
+		apiSessions = append(apiSessions, api.Session{
+			ID:             session.ID,
+			Name:           session.Name,
+			Active:         session.Active,
+			StartedAt:      session.StartedAt,
+			LastActivity:   session.LastActivity,
+			UtteranceCount: len(session.Utterances),
+			HasSummary:     session.Summary != "",
+		})
+	}
+
+	models := transcribe.Models()
+	apiModels := make([]api.Model, 0, len(models))
+	for _, model := range models {
+		apiModels = append(apiModels, api.Model{
+			ID:        model.ID,
+			Name:      model.Name,
+			Languages: model.Languages,
+		})
+	}
+
+	supported := e.writer.SupportedOutputModes()
+	outputModes := make([]string, 0, len(supported)+1)
+	for _, mode := range supported {
+		outputModes = append(outputModes, string(mode))
+	}
+	outputModes = append(outputModes, string(config.OutputModeScratchpad))
+
+	var apiProgress *api.Progress
+	if progress, ok := e.state.GetProgress(); ok {
+		apiProgress = &api.Progress{
+			Phase:      string(progress.Phase),
+			Fraction:   progress.Fraction,
+			ETASeconds: progress.ETA.Seconds(),
+		}
+	}
+
+	var apiInputLevel *api.InputLevel
+	if level, ok := e.state.GetInputLevel(); ok {
+		apiInputLevel = &api.InputLevel{
+			PeakDBFS: max(level.Peak, api.SilenceDBFS),
+			RMSDBFS:  max(level.RMS, api.SilenceDBFS),
+		}
+	}
+
+	var textRecognitionHint string
+	if e.state.IsTextRecognitionMissing() {
+		textRecognitionHint = ocr.InstallHint()
+	}
+
+	settings := e.settingsManager.Get()
+	features := make(map[string]bool)
+	for _, feature := range config.Features() {
+		features[string(feature.Name)] = settings.FeatureEnabled(feature.Name)
+	}
+
+	return api.Snapshot{
+		Version:             api.Version,
+		Status:              apiStatus(status),
+		BatterySaver:        e.state.IsBatterySaverActive(),
+		PrivacyMode:         e.state.IsPrivacyModeActive(),
+		ThrottleLevel:       e.state.GetThrottleLevel(),
+		PartialText:         e.state.GetPartialText(),
+		Progress:            apiProgress,
+		InputLevel:          apiInputLevel,
+		Model:               e.transcriber.ModelID(),
+		Models:              apiModels,
+		ExecutionProvider:   string(e.transcriber.ExecutionProvider()),
+		History:             apiHistory,
+		Sessions:            apiSessions,
+		LockedSettings:      e.settingsManager.LockedKeys(),
+		OutputModes:         outputModes,
+		TextRecognitionHint: textRecognitionHint,
+		Features:            features,
+	}
+}
+
+// Execute performs a command received from a frontend.
+func (e *Engine) Execute(cmd api.Command) error {
+	if cmd.Version > api.Version {
+		return fmt.Errorf("unsupported API version %d, the engine supports up to %d", cmd.Version, api.Version)
+	}
+
+	switch cmd.Name {
+	case api.CommandToggleRecording:
+		overrides, err := ParseOverrides(cmd.Args)
+		if err != nil {
+			return err
+		}
+		return e.ToggleRecordingWith(overrides)
+	case api.CommandStartSession:
+		e.StartSession(cmd.Args["name"])
+	case api.CommandEndSession:
+		e.EndSession()
+	case api.CommandExportLatestSession:
+		e.ExportLatestSession()
+	case api.CommandSummarizeLatestSession:
+		go e.SummarizeLatestSession()
+	case api.CommandRecognizeClipboardImage:
+		go e.RecognizeClipboardImage()
+	case api.CommandTestMicrophone:
+		go func() {
+			if err := e.TestMicrophone(); err != nil {
+				e.logger.Warn(e.ctx, "microphone test failed", "err", err)
+			}
+		}()
+	case api.CommandCancelTranscription:
+		e.CancelTranscription()
+	case api.CommandRetryPostProcessing:
+		go func() {
+			if err := e.RetryPostProcessing(); err != nil {
+				e.logger.Warn(e.ctx, "post-processing retry failed", "err", err)
+			}
+		}()
+	case api.CommandCalibrate:
+		go func() {
+			if err := e.Calibrate(); err != nil {
+				e.logger.Warn(e.ctx, "calibration failed", "err", err)
+			}
+		}()
+	case api.CommandSyncHistory:
+		go func() {
+			merged, err := e.SyncHistory()
+			if err != nil {
+				e.handleActionError("failed to sync history", err)
+				return
+			}
+			e.notifier.Info(e.ctx, "History Synced", fmt.Sprintf("%d entries added or updated from your other machines", merged))
+		}()
+	case api.CommandUnloadModels:
+		return e.UnloadModels()
+	case api.CommandReloadModels:
+		e.ReloadModels()
+	case api.CommandSetPrivacyMode:
+		active := !e.state.IsPrivacyModeActive()
+		if value, ok := cmd.Args["active"]; ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid privacy mode %q, expected true or false", value)
+			}
+			active = parsed
+		}
+		e.SetPrivacyMode(active)
+	case api.CommandSetNormalizationProfile:
+		e.SetNormalizationProfile(cmd.Args["id"])
+	case api.CommandSetHistoryTags:
+		id, err := strconv.Atoi(cmd.Args["id"])
+		if err != nil {
+			return fmt.Errorf("invalid history entry id %q", cmd.Args["id"])
+		}
+		return e.SetHistoryTags(id, splitTags(cmd.Args["tags"]))
+	case api.CommandDeleteHistoryEntry:
+		id, err := strconv.Atoi(cmd.Args["id"])
+		if err != nil {
+			return fmt.Errorf("invalid history entry id %q", cmd.Args["id"])
+		}
+		return e.DeleteHistoryEntry(id)
+	case api.CommandClearHistory:
+		return e.ClearHistory()
+	case api.CommandSetModel:
+		return e.SetModel(cmd.Args["id"])
+	case api.CommandSetLanguage:
+		return e.SetLanguage(cmd.Args["language"])
+	case api.CommandShareHistoryEntry:
+		id := 0
+		if value := cmd.Args["id"]; value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid history entry id %q", value)
+			}
+			id = parsed
+		}
+		if _, err := e.ShareHistoryEntry(id); err != nil {
+			return err
+		}
+	case api.CommandExportSubtitles:
+		id := 0
+		if value := cmd.Args["id"]; value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid history entry id %q", value)
+			}
+			id = parsed
+		}
+		format, err := export.ParseSubtitleFormat(cmd.Args["format"])
+		if err != nil {
+			return err
+		}
+		exportPath, err := e.ExportSubtitles(id, format)
+		if err != nil {
+			return err
+		}
+		e.notifier.Info(e.ctx, "Subtitles Exported", exportPath)
+	case api.CommandAddMarker:
+		return e.AddMarker(cmd.Args["note"])
+	case api.CommandMergeHistory:
+		var ids []int
+		for _, value := range strings.Split(cmd.Args["ids"], ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("invalid history entry id %q", value)
+			}
+			ids = append(ids, id)
+		}
+
+		settings := e.settingsManager.Get()
+		separator, postProcess := settings.MergeSeparator, settings.MergePostProcess
+		if value, ok := cmd.Args["separator"]; ok {
+			separator = value
+		}
+		if value := cmd.Args["post_process"]; value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid post_process value %q, expected true or false", value)
+			}
+			postProcess = parsed
+		}
+
+		exportPath, err := e.MergeHistory(ids, separator, postProcess)
+		if err != nil {
+			return err
+		}
+		e.notifier.Info(e.ctx, "Dictations Merged", exportPath)
+	case api.CommandSetFormTemplate:
+		return e.SetFormTemplate(cmd.Args["id"])
+	case api.CommandSetInputDevice:
+		return e.SetInputDevice(cmd.Args["id"])
+	case api.CommandPauseRecording:
+		return e.PauseRecording()
+	case api.CommandResumeRecording:
+		return e.ResumeRecording()
+	case api.CommandOpenScratchpad:
+		return e.OpenScratchpad()
+	case api.CommandPing:
+		return nil
+	case api.CommandDownloadModels:
+		var at time.Time
+		if clock := cmd.Args["at"]; clock != "" {
+			parsed, err := nextClockTime(clock, time.Now())
+			if err != nil {
+				return err
+			}
+			at = parsed
+		}
+		return e.ScheduleModelDownload(at)
+	case api.CommandCheckModelUpdates:
+		go func() {
+			names, err := e.CheckModelUpdates()
+			if err != nil {
+				e.handleActionError("Model Update Check Failed", err)
+				return
+			}
+			if len(names) == 0 {
+				e.notifier.Info(e.ctx, "Models Up to Date", "The installed models are the latest revision")
+				return
+			}
+			e.notifier.Info(e.ctx, "Model Updates Available", strings.Join(names, ", ")+". Run \"tribar models update\" to install them.")
+		}()
+	case api.CommandUpdateModels:
+		go func() {
+			if err := e.UpdateModels(); err != nil {
+				e.logger.Warn(e.ctx, "model update failed", "err", err)
+			}
+		}()
+	case api.CommandExportHistory:
+		exportPath, err := e.ExportHistory(cmd.Args["tag"])
+		if err != nil {
+			return err
+		}
+		e.notifier.Info(e.ctx, "History Exported", exportPath)
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Name)
+	}
+
+	return nil
+}
+
+// splitTags parses a comma-separated list of tags.
+func splitTags(tags string) []string {
+	if strings.TrimSpace(tags) == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+// apiStatus converts the internal status into its stable API representation.
+func apiStatus(status state.Status) api.Status {
+	switch status {
+	case state.StatusUnloaded:
+		return api.StatusUnloaded
+	case state.StatusLoading:
+		return api.StatusLoading
+	case state.StatusLoaded:
+		return api.StatusLoaded
+	case state.StatusListening:
+		return api.StatusListening
+	case state.StatusPaused:
+		return api.StatusPaused
+	case state.StatusTranscribing:
+		return api.StatusTranscribing
+	case state.StatusPostProcessing:
+		return api.StatusPostProcessing
+	default:
+		return api.StatusUnknown
+	}
+}
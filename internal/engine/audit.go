@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// sourceModel returns the model that produced a text from the given source, empty for
+// sources that do not use one.
+func (e *Engine) sourceModel(settings config.Settings, source state.Source) string {
+	switch source {
+	case state.SourceLocal:
+		return e.transcriber.ModelID()
+	case state.SourceRemote:
+		return settings.RemoteTranscriptionModel
+	default:
+		return ""
+	}
+}
+
+// recordAudit appends the event to the audit log. A failure is reported to the user since
+// a missing entry defeats the purpose of the log, but the text was already delivered.
+func (e *Engine) recordAudit(event audit.Event) {
+	if err := e.audit.Record(event); err != nil {
+		e.handleActionError("failed to write the audit log", err)
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+const (
+	// autoStopInterval is how often a recording is checked for silence.
+	autoStopInterval = 250 * time.Millisecond
+	// autoStopSpeechDBFS is the RMS level of a 32ms window above which audio is
+	// considered speech while the voice activity detector is not loaded.
+	autoStopSpeechDBFS = -45
+	// autoStopWindow is the length of the windows compared with autoStopSpeechDBFS.
+	autoStopWindow = 512
+	// maxRecordingInterval is how often the length of a recording is checked.
+	maxRecordingInterval = time.Second
+)
+
+// watchSilence stops the recording seq once its last silence seconds contain no speech,
+// so hands-free dictations do not need a second toggle. The silence before the first
+// speech never stops it, users may take a moment to start talking. It returns when the
+// recording stops.
+func (e *Engine) watchSilence(seq uint64, silence time.Duration) {
+	ticker := time.NewTicker(autoStopInterval)
+	defer ticker.Stop()
+
+	full := int(silence.Seconds() * audio.SampleRate)
+	heardSpeech := false
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if e.recordingSeq.Load() != seq {
+			return
+		}
+		if status, _ := e.state.GetStatus(); status == state.StatusPaused {
+			continue
+		}
+		samples, ok := e.recorder.Tail(silence)
+		if !ok {
+			return
+		}
+
+		if e.containsSpeech(samples) {
+			heardSpeech = true
+			continue
+		}
+		if heardSpeech && len(samples) >= full {
+			e.autoStop(seq, "silence detected, stopping recording", "silence", silence)
+			return
+		}
+	}
+}
+
+// limitRecording stops the recording seq once it reaches the limit of MaxRecordingMinutes
+// and notifies it, so a forgotten recording is transcribed instead of growing in memory
+// until the machine swaps. It returns when the recording stops.
+func (e *Engine) limitRecording(seq uint64, limit time.Duration) {
+	ticker := time.NewTicker(maxRecordingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, _ := e.state.GetStatus()
+		if (status != state.StatusListening && status != state.StatusPaused) || e.recordingSeq.Load() != seq {
+			return
+		}
+		if e.recorder.Duration() < limit {
+			continue
+		}
+		if e.autoStop(seq, "maximum recording length reached, stopping recording", "limit", limit) {
+			e.notifier.Info(e.ctx, "Recording Stopped",
+				fmt.Sprintf("The recording reached the maximum length of %s and is being transcribed.", limit))
+		}
+		return
+	}
+}
+
+// containsSpeech reports whether the samples contain speech, detected by the voice
+// activity detector or, if it is not loaded, by the level of the samples.
+func (e *Engine) containsSpeech(samples []float32) bool {
+	if e.vad.Loaded() {
+		speech, err := e.vad.ContainsSpeech(samples)
+		if err == nil {
+			return speech
+		}
+		e.logger.Warn(e.ctx, "failed to detect speech, using the input level", "err", err)
+	}
+
+	for start := 0; start < len(samples); start += autoStopWindow {
+		if _, rms := audio.Levels(samples[start:min(start+autoStopWindow, len(samples))]); rms > autoStopSpeechDBFS {
+			return true
+		}
+	}
+	return false
+}
+
+// autoStop stops the recording seq like a toggle, logging the reason, unless it was
+// already stopped. It reports whether it stopped the recording.
+func (e *Engine) autoStop(seq uint64, reason string, args ...any) bool {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusListening || e.recordingSeq.Load() != seq {
+		return false
+	}
+
+	e.logger.Info(e.ctx, reason, args...)
+	e.lastToggle = time.Now()
+	e.stopRecording()
+	return true
+}
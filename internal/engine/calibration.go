@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+const (
+	// calibrationDuration is the length of the calibration recording, enough for every
+	// device to deliver its first buffers.
+	calibrationDuration = time.Second
+	// calibrationStep is the granularity of the suggested settings.
+	calibrationStep = 50 * time.Millisecond
+	// minSuggestedPasteDelay is the lowest paste delay ever suggested, clipboard managers
+	// may take ownership later than the clipboard becomes readable.
+	minSuggestedPasteDelay = 50 * time.Millisecond
+)
+
+// calibrationReport holds the latencies measured by Calibrate and the settings suggested
+// from them.
+type calibrationReport struct {
+	// startLatency is the time the input device takes to start, from the toggle to the
+	// recording being started.
+	startLatency time.Duration
+	// inputLatency is the time from the recording start to the first captured audio, the
+	// speech said before is lost.
+	inputLatency time.Duration
+	// stopLatency is the time from the toggle that stops the recording to the paste,
+	// without the transcription itself.
+	stopLatency time.Duration
+	// suggestedPreRoll is how much audio should be kept from before the recording starts
+	// so the first word is not cut off.
+	suggestedPreRoll time.Duration
+	// suggestedPasteDelay is the paste delay (advanced.paste_delay_ms) that leaves the
+	// clipboard enough time on this machine.
+	suggestedPasteDelay time.Duration
+}
+
+// Calibrate records a short sample and writes a probe to the clipboard to measure the
+// latencies around a dictation, then reports them in a notification with the suggested
+// settings. It helps debug first words being cut off on slow devices. Toggles wait until
+// it finishes; it fails while a dictation is running.
+func (e *Engine) Calibrate() error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded && status != state.StatusUnloaded {
+		return errors.New("cannot calibrate while busy")
+	}
+
+	var report calibrationReport
+	start := time.Now()
+	if err := e.recorder.Start(); err != nil {
+		e.notifier.Info(e.ctx, "Calibration Failed", err.Error())
+		return fmt.Errorf("failed to start the calibration recording: %w", err)
+	}
+	report.startLatency = time.Since(start)
+
+	select {
+	case <-time.After(calibrationDuration):
+	case <-e.ctx.Done():
+	}
+
+	stop := time.Now()
+	e.recorder.Stop()
+	report.inputLatency = e.recorder.InputLatency()
+	e.recorder.Release()
+	if report.inputLatency == 0 {
+		e.notifier.Info(e.ctx, "Calibration Failed", "No audio was captured, check the input device.")
+		return errors.New("no audio was captured during the calibration")
+	}
+
+	clipboardLatency, err := e.writer.MeasureLatency(e.ctx)
+	if err != nil {
+		e.notifier.Info(e.ctx, "Calibration Failed", err.Error())
+		return fmt.Errorf("failed to measure the clipboard latency: %w", err)
+	}
+	settings := e.settingsManager.Get()
+	report.stopLatency = time.Since(stop) + settings.Advanced.PasteDelay()
+
+	report.suggestedPreRoll = roundUp(report.startLatency+report.inputLatency, calibrationStep)
+	report.suggestedPasteDelay = max(roundUp(2*clipboardLatency, calibrationStep), minSuggestedPasteDelay)
+
+	e.logger.Info(e.ctx, "calibration finished",
+		"start_latency", report.startLatency,
+		"input_latency", report.inputLatency,
+		"clipboard_latency", clipboardLatency,
+		"stop_latency", report.stopLatency,
+		"suggested_pre_roll", report.suggestedPreRoll,
+		"suggested_paste_delay", report.suggestedPasteDelay,
+	)
+
+	message := fmt.Sprintf("Recording starts in %s, audio arrives %s later\nStop to paste takes %s\n"+
+		"Start speaking %s after the start sound or keep that much pre-roll (now %d ms); suggested paste delay %d ms (now %d ms)",
+		formatLatency(report.startLatency), formatLatency(report.inputLatency), formatLatency(report.stopLatency),
+		formatLatency(report.suggestedPreRoll), settings.PreRollMs, report.suggestedPasteDelay.Milliseconds(), settings.Advanced.PasteDelayMillis)
+	e.notifier.Info(e.ctx, "Calibration", message)
+	return nil
+}
+
+// roundUp rounds d up to a multiple of step.
+func roundUp(d, step time.Duration) time.Duration {
+	return (d + step - 1) / step * step
+}
+
+// formatLatency formats a latency in milliseconds, the precision users care about.
+func formatLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
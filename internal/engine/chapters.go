@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// minChapterUtterances is the number of utterances a session needs to be split into
+// chapters, shorter ones are easy to read through.
+const minChapterUtterances = 4
+
+// sessionChapters returns the chapters of a session for its export: the topic shifts found
+// by the LLM when enabled, or else the long pauses between dictations.
+func (e *Engine) sessionChapters(session state.Session) []export.Chapter {
+	settings := e.settingsManager.Get()
+	if !settings.ChaptersEnabled || len(session.Utterances) < minChapterUtterances {
+		return nil
+	}
+
+	if settings.ChaptersUseLLM && e.postprocess.IsConfigured() {
+		output, err := e.postprocess.SegmentChapters(e.ctx, export.NumberedTranscript(session))
+		if err != nil {
+			e.logger.Warn(e.ctx, "failed to find chapters with the LLM, using pauses", "err", err)
+		}
+		if chapters := export.ParseChapters(output, len(session.Utterances)); err == nil && chapters != nil {
+			return chapters
+		}
+	}
+
+	return export.PauseChapters(session, time.Duration(settings.ChapterPauseMinutes)*time.Minute)
+}
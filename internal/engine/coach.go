@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// recordSpeech adds a dictation to the speaking statistics, without delaying the main
+// output, and exports the periodic report when it is due. The raw transcript is counted
+// since post-processing usually removes the fillers.
+func (e *Engine) recordSpeech(result transcript) {
+	report, err := e.coach.Record(result.text, result.duration)
+	if err != nil {
+		e.logger.Warn(e.ctx, "failed to record speaking statistics", "err", err)
+		return
+	}
+	if report == "" {
+		return
+	}
+
+	filename := fmt.Sprintf("speaking-report-%s.md", time.Now().Format("20060102-150405"))
+	reportPath := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		e.handleActionError("failed to write speaking report", err)
+		return
+	}
+
+	e.logger.Info(e.ctx, "speaking report exported", "path", reportPath)
+	e.notifier.Info(e.ctx, "Speaking Report", reportPath)
+}
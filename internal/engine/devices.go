@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/varavelio/tribar/pkg/record"
+)
+
+// InputDevices lists the input devices and returns the ID of the one selected in the
+// settings, empty for the system's default device.
+func (e *Engine) InputDevices() ([]record.Device, string, error) {
+	devices, err := e.recorder.Devices()
+	if err != nil {
+		return nil, "", err
+	}
+	return devices, e.settingsManager.Get().InputDeviceID, nil
+}
+
+// SetInputDevice selects the input device of the next recordings by its ID, empty for the
+// system's default device, and saves it in the settings. A recording in progress keeps
+// its device.
+func (e *Engine) SetInputDevice(id string) error {
+	if id != "" {
+		devices, err := e.recorder.Devices()
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(devices, func(d record.Device) bool { return d.ID == id }) {
+			return fmt.Errorf("unknown input device %q", id)
+		}
+	}
+
+	settings := e.settingsManager.Get()
+	settings.InputDeviceID = id
+	if err := e.settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	e.recorder.SetDevice(id)
+	e.logger.Info(e.ctx, "input device selected", "id", id)
+	return nil
+}
+
+// SelectInputDevice selects the input device like SetInputDevice, reporting a failure in
+// a notification.
+func (e *Engine) SelectInputDevice(id string) {
+	if err := e.SetInputDevice(id); err != nil {
+		e.handleActionError("failed to select the input device", err)
+	}
+}
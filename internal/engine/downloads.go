@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/network"
+)
+
+// ErrDownloadDeferred is returned by LoadModels when the missing model files are not
+// downloaded because the connection is metered. The models are loaded in the background
+// once the download can proceed.
+var ErrDownloadDeferred = errors.New("model download deferred on a metered connection")
+
+// meteredCheckInterval is how often a deferred download checks whether the connection is
+// still metered.
+const meteredCheckInterval = time.Minute
+
+// deferDownload reports whether the download of missing model files must wait because the
+// connection is metered and the user did not approve it. The first time, it starts
+// waiting in the background for an unmetered connection, the approval or the scheduled
+// time, and then loads the models.
+func (e *Engine) deferDownload(settings config.Settings) bool {
+	e.downloadMu.Lock()
+	defer e.downloadMu.Unlock()
+
+	if e.downloadApproved {
+		e.downloadApproved = false
+		return false
+	}
+	if !settings.DeferMeteredDownloads {
+		return false
+	}
+
+	metered, err := network.Metered()
+	if err != nil {
+		e.logger.Debug(e.ctx, "cannot tell whether the connection is metered", "err", err)
+		return false
+	}
+	if !metered {
+		return false
+	}
+	if e.downloadDeferred {
+		return true
+	}
+
+	e.downloadDeferred = true
+	message := "The connection is metered, the models will be downloaded once it is not"
+	if settings.ModelDownloadTime != "" {
+		at, err := nextClockTime(settings.ModelDownloadTime, time.Now())
+		if err != nil {
+			e.logger.Warn(e.ctx, "invalid model download time", "time", settings.ModelDownloadTime, "err", err)
+		} else {
+			e.downloadAt = at
+			message += " or at " + at.Format("15:04")
+		}
+	}
+	message += ". Run \"tribar download\" to download them now."
+
+	e.logger.Info(e.ctx, "model download deferred on a metered connection", "at", e.downloadAt)
+	e.notifier.Info(e.ctx, "Model Download Deferred", message)
+
+	go e.waitForDownload()
+	return true
+}
+
+// waitForDownload waits until a deferred download can proceed and loads the models.
+func (e *Engine) waitForDownload() {
+	ticker := time.NewTicker(meteredCheckInterval)
+	defer ticker.Stop()
+
+	for !e.downloadReady() {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-e.downloadWake:
+		case <-ticker.C:
+		}
+	}
+
+	if err := e.LoadModels(e.logDownloadProgress); err != nil {
+		e.logger.Error(e.ctx, "failed to load models after the deferred download", "err", err)
+	}
+}
+
+// downloadReady ends the deferral of the download once it was approved, its scheduled
+// time came or the connection is no longer metered.
+func (e *Engine) downloadReady() bool {
+	e.downloadMu.Lock()
+	defer e.downloadMu.Unlock()
+
+	scheduled := !e.downloadAt.IsZero() && !time.Now().Before(e.downloadAt)
+	if !e.downloadApproved && !scheduled {
+		if metered, err := network.Metered(); err == nil && metered {
+			return false
+		}
+	}
+
+	e.downloadDeferred = false
+	e.downloadApproved = true
+	e.downloadAt = time.Time{}
+	return true
+}
+
+// ScheduleModelDownload lets a download deferred on a metered connection proceed: now if
+// at is zero, or at the given time otherwise, e.g. at night.
+func (e *Engine) ScheduleModelDownload(at time.Time) error {
+	e.downloadMu.Lock()
+	defer e.downloadMu.Unlock()
+
+	if !e.downloadDeferred {
+		return errors.New("no model download is waiting")
+	}
+
+	if at.IsZero() {
+		e.downloadApproved = true
+	} else {
+		e.downloadAt = at
+	}
+	e.logger.Info(e.ctx, "deferred model download scheduled", "at", at)
+
+	select {
+	case e.downloadWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DownloadDeferred reports whether a model download is waiting for an unmetered
+// connection.
+func (e *Engine) DownloadDeferred() bool {
+	e.downloadMu.Lock()
+	defer e.downloadMu.Unlock()
+	return e.downloadDeferred
+}
+
+// DownloadModelsNow approves the deferred model download, reporting a failure in a
+// notification.
+func (e *Engine) DownloadModelsNow() {
+	if err := e.ScheduleModelDownload(time.Time{}); err != nil {
+		e.handleActionError("failed to start the model download", err)
+	}
+}
+
+// nextClockTime returns the next time the clock shows the given time of day ("02:00"),
+// today or tomorrow.
+func nextClockTime(clock string, now time.Time) (time.Time, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+
+	at := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
@@ -3,23 +3,49 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/calendar"
 	"github.com/varavelio/tribar/internal/clipboard"
+	"github.com/varavelio/tribar/internal/coach"
 	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/form"
+	"github.com/varavelio/tribar/internal/history"
+	"github.com/varavelio/tribar/internal/itn"
 	"github.com/varavelio/tribar/internal/logger"
 	"github.com/varavelio/tribar/internal/notify"
+	"github.com/varavelio/tribar/internal/ocr"
 	"github.com/varavelio/tribar/internal/postprocess"
-	"github.com/varavelio/tribar/internal/record"
+	"github.com/varavelio/tribar/internal/power"
+	"github.com/varavelio/tribar/internal/profanity"
+	"github.com/varavelio/tribar/internal/remote"
+	"github.com/varavelio/tribar/internal/routing"
+	"github.com/varavelio/tribar/internal/scratchpad"
+	"github.com/varavelio/tribar/internal/share"
+	"github.com/varavelio/tribar/internal/sink"
 	"github.com/varavelio/tribar/internal/sound"
 	"github.com/varavelio/tribar/internal/state"
-	"github.com/varavelio/tribar/internal/transcribe"
+	"github.com/varavelio/tribar/internal/textnorm"
+	"github.com/varavelio/tribar/internal/todo"
+	"github.com/varavelio/tribar/pkg/record"
+	"github.com/varavelio/tribar/pkg/transcribe"
 )
 
+// toggleCooldown is the minimum time between two recording toggles, shorter intervals are
+// treated as a single press.
+const toggleCooldown = 300 * time.Millisecond
+
 // Dependencies contains all required dependencies for the engine.
 type Dependencies struct {
 	Logger          logger.Logger
@@ -27,10 +53,23 @@ type Dependencies struct {
 	State           *state.Instance
 	Recorder        *record.Recorder
 	Transcriber     *transcribe.Instance
+	VAD             *transcribe.VAD
+	Punctuator      *transcribe.Punctuator
+	Remote          *remote.Instance
+	Audit           *audit.Instance
 	PostProcess     *postprocess.Instance
 	Writer          *clipboard.Instance
 	Notifier        *notify.Instance
 	Sound           *sound.Instance
+	Calendar        *calendar.Instance
+	Sinks           *sink.Instance
+	Share           *share.Server
+	Scratchpad      *scratchpad.Instance
+	OCR             *ocr.Instance
+	Todo            *todo.Instance
+	Coach           *coach.Instance
+	History         history.Store
+	HistorySync     *history.Syncer
 }
 
 // Engine orchestrates the transcription workflow.
@@ -40,10 +79,67 @@ type Engine struct {
 	state           *state.Instance
 	recorder        *record.Recorder
 	transcriber     *transcribe.Instance
+	vad             *transcribe.VAD
+	punctuator      *transcribe.Punctuator
+	remote          *remote.Instance
+	audit           *audit.Instance
 	postprocess     *postprocess.Instance
 	writer          *clipboard.Instance
 	notifier        *notify.Instance
 	sound           *sound.Instance
+	calendar        *calendar.Instance
+	sinks           *sink.Instance
+	share           *share.Server
+	scratchpad      *scratchpad.Instance
+	ocr             *ocr.Instance
+	todo            *todo.Instance
+	coach           *coach.Instance
+	history         history.Store
+	historySync     *history.Syncer
+
+	sessionLocked       atomic.Bool
+	onBattery           atomic.Bool
+	overrides           atomic.Pointer[Overrides]
+	gpuFallbackNotified atomic.Bool
+
+	// failedPostProcess is the last text whose post-processing failed, nil if the last
+	// one succeeded.
+	failedPostProcess atomic.Pointer[failedPostProcess]
+
+	// toggleMu serializes the recording state transitions, lastToggle is the time of the
+	// last accepted toggle and markers the ones added to the recording in progress.
+	// recordingSeq numbers the recordings, so the watchers stopping a recording on their
+	// own never stop the next one.
+	toggleMu     sync.Mutex
+	lastToggle   time.Time
+	markers      []state.Marker
+	recordingSeq atomic.Uint64
+
+	// transcriptionMu guards cancelTranscription, which aborts the transcription in
+	// progress, nil if there is none.
+	transcriptionMu     sync.Mutex
+	cancelTranscription context.CancelFunc
+
+	// loadMu serializes loading, switching and unloading the models. modelMu is held for
+	// reading by the local transcriptions and for writing while the model is switched, so
+	// a switch waits for the transcriptions in progress and the ones started meanwhile
+	// wait for the new model.
+	loadMu  sync.Mutex
+	modelMu sync.RWMutex
+
+	// downloadMu guards the model download deferred on a metered connection:
+	// downloadDeferred is set while it waits, downloadApproved lets the next download
+	// proceed anyway and downloadAt is when it starts regardless, zero for never.
+	// downloadWake wakes the waiting goroutine.
+	downloadMu       sync.Mutex
+	downloadDeferred bool
+	downloadApproved bool
+	downloadAt       time.Time
+	downloadWake     chan struct{}
+
+	// formMu guards form, the form being filled by dictation, nil if none is.
+	formMu sync.Mutex
+	form   *form.Filler
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -59,21 +155,75 @@ func New(deps Dependencies) *Engine {
 		state:           deps.State,
 		recorder:        deps.Recorder,
 		transcriber:     deps.Transcriber,
+		vad:             deps.VAD,
+		punctuator:      deps.Punctuator,
+		remote:          deps.Remote,
+		audit:           deps.Audit,
 		postprocess:     deps.PostProcess,
 		writer:          deps.Writer,
 		notifier:        deps.Notifier,
 		sound:           deps.Sound,
+		calendar:        deps.Calendar,
+		sinks:           deps.Sinks,
+		share:           deps.Share,
+		scratchpad:      deps.Scratchpad,
+		ocr:             deps.OCR,
+		todo:            deps.Todo,
+		coach:           deps.Coach,
+		history:         deps.History,
+		historySync:     deps.HistorySync,
+		downloadWake:    make(chan struct{}, 1),
 		ctx:             ctx,
 		cancel:          cancel,
 	}
 }
 
-// LoadModels loads the transcription models with progress reporting.
+// LoadModels loads the transcription models with progress reporting. If the model selected
+// in the settings (or, if it cannot transcribe the configured language, one that can) is
+// not the active one, or the precision changed, the transcriber is switched to it first.
+// When the models are already loaded it only switches the model, without unloading the
+// app, see swapModelUnsafe. Nothing is loaded when only the remote server is used.
 func (e *Engine) LoadModels(progressCallback transcribe.DownloadProgressCallback) error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+
+	if status, _ := e.state.GetStatus(); status != state.StatusUnloaded && status != state.StatusLoading {
+		return e.swapModelUnsafe(progressCallback)
+	}
+
+	if remoteOnly(e.settingsManager.Get()) {
+		e.state.SetStatus(state.StatusLoaded)
+		e.applyPreRoll()
+		e.logger.Info(e.ctx, "using remote transcription, local models not loaded")
+		return nil
+	}
+
 	e.state.SetStatus(state.StatusLoading)
 
+	defer e.state.ClearProgress()
+	progressCallback = e.trackDownloadProgress(progressCallback)
+
+	settings := e.settingsManager.Get()
+	e.applyTranscriptionSettings(settings)
+	modelID := e.modelForSettings(settings)
+	precision := e.modelPrecision(settings, modelID)
+	e.transcriber.SetPrecision(precision)
+
+	if modelID != "" && (modelID != e.transcriber.ModelID() || precision != e.transcriber.Precision()) {
+		if err := e.transcriber.SwitchModel(modelID); err != nil {
+			e.state.SetStatus(state.StatusUnloaded)
+			e.notifier.Error(e.ctx, "Model Load Failed", err.Error())
+			return fmt.Errorf("failed to switch model: %w", err)
+		}
+		e.logger.Info(e.ctx, "transcription model switched", "model", modelID, "precision", precision)
+	}
+
 	allExist, _ := e.transcriber.CheckModels()
 	if !allExist {
+		if e.deferDownload(settings) {
+			e.state.SetStatus(state.StatusUnloaded)
+			return ErrDownloadDeferred
+		}
 		e.logger.Info(e.ctx, "downloading missing models...")
 		if err := e.transcriber.DownloadModels(progressCallback); err != nil {
 			e.state.SetStatus(state.StatusUnloaded)
@@ -82,38 +232,165 @@ func (e *Engine) LoadModels(progressCallback transcribe.DownloadProgressCallback
 		}
 	}
 
-	if err := e.transcriber.LoadModels(); err != nil {
+	err := e.transcriber.LoadModels()
+	if errors.Is(err, transcribe.ErrCorruptedModel) {
+		e.logger.Warn(e.ctx, "corrupted model files deleted, downloading them again", "err", err)
+		err = e.transcriber.DownloadModels(progressCallback)
+		if err == nil {
+			err = e.transcriber.LoadModels()
+		}
+	}
+	if err != nil {
 		e.state.SetStatus(state.StatusUnloaded)
 		e.notifier.Error(e.ctx, "Model Load Failed", err.Error())
 		return fmt.Errorf("failed to load models: %w", err)
 	}
 
+	if settings := e.settingsManager.Get(); settings.TrimSilenceEnabled || settings.AutoStopSilenceSeconds > 0 {
+		e.loadVAD(progressCallback)
+	}
+	if e.settingsManager.Get().PunctuationEnabled {
+		e.loadPunctuator(progressCallback)
+	}
+
 	e.state.SetStatus(state.StatusLoaded)
+	e.applyPreRoll()
 	e.logger.Info(e.ctx, "models loaded successfully")
 	return nil
 }
 
 // ToggleRecording starts or stops the recording based on current state.
 func (e *Engine) ToggleRecording() {
+	if err := e.ToggleRecordingWith(Overrides{}); err != nil {
+		e.logger.Warn(e.ctx, "failed to toggle recording", "err", err)
+	}
+}
+
+// ToggleRecordingWith starts or stops the recording like ToggleRecording. When it starts a
+// recording, the overrides replace the stored settings for that dictation only; they are
+// ignored when it stops one, since the overrides given at start are the ones applied.
+// Toggles are serialized, and those arriving within toggleCooldown of the previous one
+// (e.g. a hotkey bounce or double-press) are coalesced into it.
+func (e *Engine) ToggleRecordingWith(overrides Overrides) error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	if since := time.Since(e.lastToggle); since < toggleCooldown {
+		e.logger.Debug(e.ctx, "ignoring toggle within the cooldown", "since_last", since)
+		return nil
+	}
+	e.lastToggle = time.Now()
+
 	status, _ := e.state.GetStatus()
 
 	switch status {
-	case state.StatusListening:
+	case state.StatusListening, state.StatusPaused:
 		e.stopRecording()
 	case state.StatusLoaded:
+		if _, err := overrides.apply(e.settingsManager.Get()); err != nil {
+			return fmt.Errorf("invalid overrides: %w", err)
+		}
+		e.overrides.Store(&overrides)
 		e.startRecording()
 	case state.StatusUnloaded:
 		e.logger.Warn(e.ctx, "cannot start recording, models not loaded")
 	}
+
+	return nil
+}
+
+// SetSessionLocked informs the engine that the user session was locked (or the machine is
+// going to sleep) or unlocked. While locked, an active recording is stopped, new
+// recordings are refused and the pre-roll capture stops, unless disabled in the settings.
+func (e *Engine) SetSessionLocked(locked bool) {
+	e.sessionLocked.Store(locked)
+	defer e.applyPreRoll()
+
+	if !locked || !e.settingsManager.Get().StopOnSessionLock {
+		return
+	}
+
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status == state.StatusListening || status == state.StatusPaused {
+		e.logger.Info(e.ctx, "session locked, stopping active recording")
+		e.stopRecording()
+	}
+}
+
+// SetOnBattery informs the engine about the current power source. When the battery saver
+// is enabled and the machine runs on battery, inference is limited to fewer threads and
+// the smaller int8 weights are loaded instead of fp32 ones.
+func (e *Engine) SetOnBattery(onBattery bool) {
+	e.onBattery.Store(onBattery)
+	settings := e.settingsManager.Get()
+	active := e.applyBatterySaver(settings)
+	e.applyTranscriptionSettings(settings)
+	e.logger.Info(e.ctx, "power source updated", "on_battery", onBattery, "battery_saver", active)
+
+	go func() {
+		if err := e.swapModel(e.logDownloadProgress); err != nil && !errors.Is(err, ErrDownloadDeferred) {
+			e.logger.Error(e.ctx, "failed to switch model", "err", err)
+		}
+	}()
+}
+
+// applyBatterySaver sets the inference threads for the power source and the settings,
+// releases the sessions no setting uses while the battery saver is active and returns
+// whether it is. The callers then call swapModel, which loads the weights modelPrecision
+// selects for it.
+func (e *Engine) applyBatterySaver(settings config.Settings) bool {
+	active := e.onBattery.Load() && settings.BatterySaverEnabled
+
+	threads := settings.Advanced.InferenceThreads
+	if active {
+		threads = settings.BatterySaverThreads
+	}
+
+	e.transcriber.SetIntraOpThreads(threads)
+	e.state.SetBatterySaver(active)
+	if active {
+		e.releaseIdleSessions(settings)
+	}
+	return active
+}
+
+// SetThrottleLevel records the current CPU load and thermal pressure throttle level, used
+// to limit the concurrency of background transcription jobs.
+func (e *Engine) SetThrottleLevel(level power.ThrottleLevel) {
+	e.state.SetThrottleLevel(int(level))
+	e.applyTranscriptionSettings(e.settingsManager.Get())
+	e.logger.Debug(e.ctx, "throttle level updated", "level", level.String())
 }
 
 // StartRecording begins audio capture.
 func (e *Engine) startRecording() {
+	settings := e.settingsManager.Get()
+	if e.sessionLocked.Load() && settings.StopOnSessionLock {
+		e.logger.Warn(e.ctx, "cannot start recording, session is locked")
+		return
+	}
+
+	e.recorder.SetStartCleanup(time.Duration(settings.StartTrimMs)*time.Millisecond, settings.SuppressStartClicks)
+	e.recorder.SetNoiseSuppression(settings.NoiseSuppressionEnabled)
+	e.recorder.SetAutoGain(settings.AutoGainEnabled)
+	e.markers = nil
+
 	if err := e.recorder.Start(); err != nil {
 		e.logger.Error(e.ctx, "failed to start recording", "err", err)
 		e.notifier.Error(e.ctx, "Recording Failed", err.Error())
 		return
 	}
+	seq := e.recordingSeq.Add(1)
+	go e.trackInputLevel()
+	if settings.AutoStopSilenceSeconds > 0 {
+		go e.watchSilence(seq, time.Duration(settings.AutoStopSilenceSeconds)*time.Second)
+	}
+	if settings.MaxRecordingMinutes > 0 {
+		go e.limitRecording(seq, time.Duration(settings.MaxRecordingMinutes)*time.Minute)
+	}
 
 	e.state.SetStatus(state.StatusListening)
 	e.sound.TranscriptionStarted(e.ctx)
@@ -121,59 +398,247 @@ func (e *Engine) startRecording() {
 	e.logger.Info(e.ctx, "recording started")
 }
 
-// stopRecording stops audio capture and processes the recording.
+// stopRecording stops audio capture and processes the recording. The status leaves
+// listening before returning, so a following toggle cannot stop the same recording again.
 func (e *Engine) stopRecording() {
 	e.recorder.Stop()
+	e.state.SetStatus(state.StatusTranscribing)
 	e.logger.Info(e.ctx, "recording stopped, processing...")
 
-	go e.processRecording()
+	markers := e.markers
+	e.markers = nil
+	go e.processRecording(markers)
 }
 
-// processRecording handles the transcription pipeline in a goroutine.
-func (e *Engine) processRecording() {
-	settings := e.settingsManager.Get()
+// processRecording handles the transcription pipeline in a goroutine, markers are the ones
+// added while recording.
+func (e *Engine) processRecording(markers []state.Marker) {
+	settings := e.dictationSettings()
+	private := e.state.IsPrivacyModeActive()
 	e.state.SetStatus(state.StatusTranscribing)
 
-	audioPath := e.generateAudioPath()
-	if err := e.recorder.SaveWAV(audioPath); err != nil {
+	eventTitle := e.calendar.CurrentEventTitle()
+	audioPath, wavData, err := e.recordingAudio(eventTitle, private)
+	if err != nil {
 		e.handleError("failed to save audio", err)
 		return
 	}
 
-	wavData, err := os.ReadFile(audioPath)
-	if err != nil {
-		e.handleError("failed to read audio file", err)
+	ctx, done := e.beginTranscription()
+	result, err := e.transcribe(ctx, settings, wavData)
+	done()
+	if errors.Is(err, context.Canceled) && e.ctx.Err() == nil {
+		e.logger.Info(e.ctx, "transcription canceled", "audio_path", audioPath)
+		e.notifier.Info(e.ctx, "Transcription Canceled", "The recording was not transcribed.")
+		e.state.SetStatus(state.StatusLoaded)
 		return
 	}
-
-	text, err := e.transcriber.TranscribeWAV(wavData)
 	if err != nil {
 		e.handleError("transcription failed", err)
 		return
 	}
 
-	e.logger.Debug(e.ctx, "transcription complete", "text", text)
+	e.logger.Debug(e.ctx, "transcription complete",
+		"text", result.text,
+		"source", result.source,
+		"confidence", result.confidence,
+	)
+	result.markers = markers
+	e.deliver(settings, result, audioPath, eventTitle, private)
+}
 
-	if e.postprocess.IsEnabled() {
-		e.state.SetStatus(state.StatusPostProcessing)
-		processed, err := e.postprocess.Process(e.ctx, text)
+// recordingAudio saves the recording to the audio directory and returns its path and WAV
+// bytes. In privacy mode nothing is written to disk and the path is empty.
+func (e *Engine) recordingAudio(eventTitle string, private bool) (string, []byte, error) {
+	if private {
+		var buf bytes.Buffer
+		if err := e.recorder.WriteWAV(&buf); err != nil {
+			return "", nil, err
+		}
+		return "", buf.Bytes(), nil
+	}
+
+	audioPath := e.generateAudioPath(eventTitle)
+	if err := e.recorder.SaveWAV(audioPath); err != nil {
+		return "", nil, err
+	}
+
+	wavData, err := os.ReadFile(audioPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+	return audioPath, wavData, nil
+}
+
+// dictationSettings returns the stored settings with the overrides of the current
+// dictation applied, consuming them so they do not leak into the next one.
+func (e *Engine) dictationSettings() config.Settings {
+	settings := e.settingsManager.Get()
+
+	overrides := e.overrides.Swap(nil)
+	if overrides == nil || overrides.IsZero() {
+		return settings
+	}
+
+	overridden, err := overrides.apply(settings)
+	if err != nil {
+		e.logger.Warn(e.ctx, "ignoring dictation overrides", "err", err)
+		return settings
+	}
+
+	e.logger.Debug(e.ctx, "applying dictation overrides", "overrides", *overrides)
+	return overridden
+}
+
+// deliver runs the output pipeline shared by every text source: post-processing, output,
+// sinks, session and history bookkeeping, and user feedback. It leaves the engine loaded
+// and returns the text delivered. Private texts are only written to the output, see
+// SetPrivacyMode.
+func (e *Engine) deliver(settings config.Settings, result transcript, audioPath, eventTitle string, private bool) string {
+	text := result.text
+	routeSinkID := ""
+	route, routed, err := routing.Match(settings.RoutingRules, text)
+	if err != nil {
+		e.logger.Warn(e.ctx, "some routing rules are invalid", "err", err)
+	}
+	if routed {
+		routedSettings, err := routing.Apply(settings, route.Rule)
 		if err != nil {
-			e.logger.Warn(e.ctx, "post-processing failed, using raw transcription", "err", err)
-		} else {
+			e.logger.Warn(e.ctx, "ignoring routing rule", "err", err)
+		}
+		if err == nil {
+			e.logger.Debug(e.ctx, "transcription routed", "rule", route.Rule.Name)
+			settings = routedSettings
+			text = route.Text
+			routeSinkID = route.Rule.SinkID
+		}
+	}
+
+	event := audit.Event{
+		Model:  e.sourceModel(settings, result.source),
+		Source: string(result.source),
+	}
+
+	if settings.PunctuationEnabled && result.source == state.SourceLocal {
+		text = e.punctuate(text)
+	}
+
+	if settings.ITNEnabled {
+		text = itn.Apply(text, settings.Language)
+	}
+
+	if template, ok := settings.FindFormTemplate(settings.FormTemplateID); ok && len(template.Fields) > 0 {
+		rendered, field, finished := e.fillForm(settings, template, text)
+		if !finished {
+			e.logger.Info(e.ctx, "form dictation added", "template", template.Name, "next_field", field)
+			e.sound.TranscriptionFinished(e.ctx)
+			e.notifier.Info(e.ctx, template.Name, "Dictate the "+field+" field")
+			e.state.SetStatus(state.StatusLoaded)
+			return ""
+		}
+		text = rendered
+	}
+
+	if settings.PostProcessEnabled && e.postprocess.IsConfigured() {
+		e.state.SetStatus(state.StatusPostProcessing)
+		retry := failedPostProcess{settings: settings, text: text, sinkID: routeSinkID, event: event}
+		processed, err := e.postProcess(settings, text, private, retry)
+		if err == nil {
 			text = processed
+			event.Prompt = settings.PostProcessPromptID
 		}
 	}
 
-	if err := e.writer.Write(e.ctx, settings.OutputMode, text); err != nil {
-		e.logger.Error(e.ctx, "failed to write output", "err", err)
+	if profile, ok := e.normalizationProfile(settings); ok {
+		text = textnorm.Apply(profile, text)
+	}
+
+	text = profanity.Apply(settings.ProfanityFilter, text, settings.ProfanityWords)
+
+	outputSettings, gated := gatePaste(settings, result.confidence)
+	if private {
+		e.writePrivate(outputSettings, text, event)
 	}
+	if !private {
+		e.output(outputSettings, text, routeSinkID, event)
 
-	e.state.AddHistoryEntry(text, audioPath)
+		go e.extractActionItems(text)
+		if result.source != state.SourceOCR {
+			go e.recordSpeech(result)
+		}
+
+		sessionWindow := time.Duration(settings.SessionWindowMinutes) * time.Minute
+		sessionID := e.state.AddSessionUtterance(text, audioPath, result.markers, sessionWindow, eventTitle)
+		entry := e.state.AddHistoryEntry(state.HistoryEntry{
+			Text:       text,
+			AudioPath:  audioPath,
+			SessionID:  sessionID,
+			Tags:       autoTags(settings, text, result.confidence),
+			Source:     result.source,
+			Confidence: result.confidence,
+			Segments:   result.segments,
+			Markers:    result.markers,
+		})
+		e.saveHistoryEntry(entry)
+	}
 	e.sound.TranscriptionFinished(e.ctx)
-	e.notifier.TranscriptionFinished(e.ctx, text)
+	if gated {
+		e.notifyPasteReview(text, result.confidence)
+	}
+	if !gated {
+		e.notifier.TranscriptionFinished(e.ctx, text)
+	}
 	e.state.SetStatus(state.StatusLoaded)
 
 	e.logger.Info(e.ctx, "transcription complete", "length", len(text))
+	return text
+}
+
+// output delivers the final text to the clipboard and the enabled sinks or, when a routing
+// rule selects a sink, only to that sink. Successful deliveries are recorded in the audit
+// log along with the given event details.
+func (e *Engine) output(settings config.Settings, text, sinkID string, event audit.Event) {
+	event.Text = text
+
+	if sinkID != "" {
+		if err := e.sinks.SendTo(e.ctx, sinkID, text); err != nil {
+			e.handleActionError("failed to deliver routed transcription", err)
+			return
+		}
+		event.Action = "sink:" + sinkID
+		e.recordAudit(event)
+		return
+	}
+
+	if settings.AuditLogEnabled {
+		event.App = e.writer.ActiveApp(e.ctx)
+	}
+	err := e.writeOutput(settings, text)
+	if err != nil {
+		e.logger.Error(e.ctx, "failed to write output", "err", err)
+	}
+	if err == nil {
+		event.Action = string(settings.OutputMode)
+		e.recordAudit(event)
+	}
+
+	if err := e.sinks.Dispatch(e.ctx, text); err != nil {
+		e.handleActionError("failed to deliver transcription", err)
+	}
+}
+
+// extractActionItems delivers the action items of a transcription to the tasks file and
+// sink, without delaying the main output.
+func (e *Engine) extractActionItems(text string) {
+	count, err := e.todo.Process(e.ctx, text)
+	if err != nil {
+		e.handleActionError("failed to save action items", err)
+		return
+	}
+
+	if count > 0 {
+		e.notifier.Info(e.ctx, "Action Items Saved", fmt.Sprintf("%d action item(s) added to your tasks", count))
+	}
 }
 
 // handleError logs the error, notifies the user, and resets state.
@@ -183,13 +648,213 @@ func (e *Engine) handleError(message string, err error) {
 	e.state.SetStatus(state.StatusLoaded)
 }
 
-// generateAudioPath creates a unique path for the audio file.
-func (e *Engine) generateAudioPath() string {
+// generateAudioPath creates a unique path for the audio file, named after the current
+// calendar event when there is one (e.g. "standup-20240512-093012.wav").
+func (e *Engine) generateAudioPath(eventTitle string) string {
+	prefix := "recording"
+	if slug := calendar.Slug(eventTitle); slug != "" {
+		prefix = slug
+	}
+
 	timestamp := time.Now().Format("20060102-150405")
-	filename := fmt.Sprintf("recording-%s.wav", timestamp)
+	filename := fmt.Sprintf("%s-%s.wav", prefix, timestamp)
 	return filepath.Join(config.DirectoryRecordings, filename)
 }
 
+// StartSession starts a named session grouping all following dictations until EndSession
+// is called. An empty name generates one from the current time.
+func (e *Engine) StartSession(name string) {
+	if name == "" {
+		name = e.calendar.CurrentEventTitle()
+	}
+	if name == "" {
+		name = state.DefaultSessionName(time.Now())
+	}
+
+	session := e.state.StartSession(name)
+	e.logger.Info(e.ctx, "session started", "id", session.ID, "name", session.Name)
+}
+
+// EndSession ends the currently active session.
+func (e *Engine) EndSession() {
+	e.state.EndSession()
+	e.logger.Info(e.ctx, "session ended")
+}
+
+// ExportSession writes the session with the given ID as a Markdown document in the exports
+// directory and returns its path.
+func (e *Engine) ExportSession(id int) (string, error) {
+	session, ok := e.state.GetSession(id)
+	if !ok {
+		return "", fmt.Errorf("session %d not found", id)
+	}
+
+	prefix := "session"
+	if slug := calendar.Slug(session.Name); slug != "" && session.Name != state.DefaultSessionName(session.StartedAt) {
+		prefix = slug
+	}
+	filename := fmt.Sprintf("%s-%s.md", prefix, session.StartedAt.Format("20060102-150405"))
+	exportPath := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(exportPath, []byte(export.SessionMarkdown(session, e.sessionChapters(session))), 0644); err != nil {
+		return "", fmt.Errorf("failed to write session export: %w", err)
+	}
+
+	e.logger.Info(e.ctx, "session exported", "id", id, "path", exportPath)
+	return exportPath, nil
+}
+
+// ExportLatestSession exports the most recent session and notifies the user about the result.
+func (e *Engine) ExportLatestSession() {
+	session, ok := e.state.GetLatestSession()
+	if !ok {
+		e.notifier.Info(e.ctx, config.AppName, "There is no session to export yet")
+		return
+	}
+
+	exportPath, err := e.ExportSession(session.ID)
+	if err != nil {
+		e.handleActionError("failed to export session", err)
+		return
+	}
+
+	e.notifier.Info(e.ctx, "Session Exported", exportPath)
+}
+
+// SummarizeSession generates a summary of the session with the given ID using the
+// post-processing provider, stores it with the session and exports the resulting document.
+func (e *Engine) SummarizeSession(id int) (string, error) {
+	session, ok := e.state.GetSession(id)
+	if !ok {
+		return "", fmt.Errorf("session %d not found", id)
+	}
+
+	summary, err := e.postprocess.Summarize(e.ctx, export.SessionTranscript(session))
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize session: %w", err)
+	}
+	e.state.SetSessionSummary(id, summary)
+
+	return e.ExportSession(id)
+}
+
+// SummarizeLatestSession summarizes and exports the most recent session and notifies the
+// user about the result.
+func (e *Engine) SummarizeLatestSession() {
+	session, ok := e.state.GetLatestSession()
+	if !ok {
+		e.notifier.Info(e.ctx, config.AppName, "There is no session to summarize yet")
+		return
+	}
+
+	exportPath, err := e.SummarizeSession(session.ID)
+	if err != nil {
+		e.handleActionError("failed to summarize session", err)
+		return
+	}
+
+	e.notifier.Info(e.ctx, "Session Summary Ready", exportPath)
+}
+
+// normalizationProfile returns the normalization profile of the dictation: the selected
+// one or, when none is, the one of the focused application the text is pasted into.
+func (e *Engine) normalizationProfile(settings config.Settings) (config.NormalizationProfile, bool) {
+	if settings.NormalizationProfileID != "" {
+		return settings.FindNormalizationProfile(settings.NormalizationProfileID)
+	}
+
+	// Detecting the focused application runs a helper program on some systems, skip it
+	// when no profile has applications.
+	hasApps := slices.ContainsFunc(settings.NormalizationProfiles, func(profile config.NormalizationProfile) bool {
+		return len(profile.Apps) > 0
+	})
+	if !hasApps {
+		return config.NormalizationProfile{}, false
+	}
+
+	app := e.writer.ActiveApp(e.ctx)
+	profile, ok := settings.MatchNormalizationProfile(app)
+	if ok {
+		e.logger.Debug(e.ctx, "text style selected by application", "app", app, "profile", profile.Name)
+	}
+	return profile, ok
+}
+
+// NormalizationProfiles returns the configured normalization profiles and the ID of the
+// active one (empty when normalization is disabled).
+func (e *Engine) NormalizationProfiles() ([]config.NormalizationProfile, string) {
+	settings := e.settingsManager.Get()
+	return settings.NormalizationProfiles, settings.NormalizationProfileID
+}
+
+// ApplySettings propagates settings changed outside the engine, such as an external edit
+// of the settings file, to the components that keep their own copy of them.
+func (e *Engine) ApplySettings(settings config.Settings) {
+	e.notifier.UpdateSettings(notify.Settings{
+		NotifyOnError:  settings.NotifyOnError,
+		NotifyOnStart:  settings.NotifyOnStart,
+		NotifyOnFinish: settings.NotifyOnFinish,
+	})
+	e.sound.UpdateSettings(sound.Settings{
+		SoundOnStart:  settings.SoundOnStart,
+		SoundOnFinish: settings.SoundOnFinish,
+	})
+	e.state.SetHistoryLimit(settings.HistoryLimit)
+	e.trimHistory(settings.HistoryLimit)
+	e.transcriber.SetChunkDuration(settings.Advanced.TranscriptionChunkDuration())
+	e.writer.SetPasteDelay(settings.Advanced.PasteDelay())
+	e.recorder.SetDevice(settings.InputDeviceID)
+	e.applyPreRoll()
+	go e.CheckTextRecognition()
+	e.applyBatterySaver(settings)
+	e.applyTranscriptionSettings(settings)
+	go func() {
+		if err := e.swapModel(e.logDownloadProgress); err != nil && !errors.Is(err, ErrDownloadDeferred) {
+			e.logger.Error(e.ctx, "failed to switch model", "err", err)
+		}
+	}()
+}
+
+// AnimationFrameDuration returns the interval between the frames of the tray animation.
+func (e *Engine) AnimationFrameDuration() time.Duration {
+	return e.settingsManager.Get().Advanced.AnimationFrameDuration()
+}
+
+// ToggleHotkey returns the desktop shortcut the user bound to toggle the recording, empty
+// if none is configured.
+func (e *Engine) ToggleHotkey() string {
+	return e.settingsManager.Get().ToggleHotkey
+}
+
+// PrivacyHotkey returns the desktop shortcut the user bound to toggle the privacy mode,
+// empty if none is configured.
+func (e *Engine) PrivacyHotkey() string {
+	return e.settingsManager.Get().PrivacyHotkey
+}
+
+// SetNormalizationProfile selects the text normalization profile applied to the following
+// dictations, an empty ID disables normalization.
+func (e *Engine) SetNormalizationProfile(id string) {
+	settings := e.settingsManager.Get()
+	if _, ok := settings.FindNormalizationProfile(id); !ok && id != "" {
+		e.logger.Warn(e.ctx, "unknown normalization profile", "id", id)
+		return
+	}
+
+	settings.NormalizationProfileID = id
+	if err := e.settingsManager.Update(settings); err != nil {
+		e.handleActionError("failed to save settings", err)
+		return
+	}
+
+	e.logger.Info(e.ctx, "normalization profile selected", "id", id)
+}
+
+// handleActionError logs and notifies errors of user actions that do not affect the status.
+func (e *Engine) handleActionError(message string, err error) {
+	e.logger.Error(e.ctx, message, "err", err)
+	e.notifier.Error(e.ctx, config.AppName, fmt.Sprintf("%s: %v", message, err))
+}
+
 // GetState returns the current application state (read-only access for UI).
 func (e *Engine) GetState() *state.Instance {
 	return e.state
@@ -200,9 +865,10 @@ func (e *Engine) Shutdown() {
 	e.cancel()
 
 	status, _ := e.state.GetStatus()
-	if status == state.StatusListening {
+	if status == state.StatusListening || status == state.StatusPaused {
 		e.recorder.Stop()
 	}
+	_ = e.recorder.SetPreRoll(0)
 
 	e.logger.Info(e.ctx, "engine shutdown complete")
 }
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/form"
+)
+
+// fillForm adds a dictation to the form of the template, starting one when none is in
+// progress or another template was being filled. It returns the rendered form once the
+// dictation finishes it, otherwise the field the next dictation fills.
+func (e *Engine) fillForm(settings config.Settings, template config.FormTemplate, text string) (rendered, field string, finished bool) {
+	e.formMu.Lock()
+	defer e.formMu.Unlock()
+
+	if e.form == nil || e.form.TemplateID() != template.ID {
+		e.form = form.New(template)
+	}
+
+	commands := form.Commands{
+		Next:     settings.FormNextFieldPhrases,
+		Previous: settings.FormPreviousFieldPhrases,
+		Finish:   settings.FormFinishPhrases,
+	}
+	if !e.form.Feed(text, commands) {
+		return "", e.form.Field(), false
+	}
+
+	rendered = e.form.Render()
+	e.form = nil
+	return rendered, "", true
+}
+
+// FormTemplates returns the form templates and the ID of the selected one, empty when
+// form dictation is off.
+func (e *Engine) FormTemplates() ([]config.FormTemplate, string) {
+	settings := e.settingsManager.Get()
+	return settings.FormTemplates, settings.FormTemplateID
+}
+
+// SetFormTemplate selects the form template the next dictations fill, empty to turn form
+// dictation off, and saves it in the settings. The form in progress is discarded.
+func (e *Engine) SetFormTemplate(id string) error {
+	settings := e.settingsManager.Get()
+	if template, ok := settings.FindFormTemplate(id); id != "" && (!ok || len(template.Fields) == 0) {
+		return fmt.Errorf("unknown form template %q", id)
+	}
+
+	settings.FormTemplateID = id
+	if err := e.settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	e.formMu.Lock()
+	e.form = nil
+	e.formMu.Unlock()
+
+	e.logger.Info(e.ctx, "form template selected", "id", id)
+	return nil
+}
+
+// SelectFormTemplate selects the form template like SetFormTemplate, reporting a failure
+// in a notification.
+func (e *Engine) SelectFormTemplate(id string) {
+	if err := e.SetFormTemplate(id); err != nil {
+		e.handleActionError("failed to select the form template", err)
+	}
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/clipboard"
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// helperHintFileName records the install hint last shown, so it is shown only once.
+const helperHintFileName = "helper-hint.txt"
+
+// CheckOutputHelpers looks for the programs the clipboard relies on and, the first time
+// some are missing, tells the user how to install them. Until the paste helper is
+// installed, dictations are only copied.
+func (e *Engine) CheckOutputHelpers() {
+	missing := e.writer.CheckHelpers()
+	if len(missing) == 0 {
+		return
+	}
+
+	programs := make([]string, 0, len(missing))
+	pasteMissing := false
+	for _, helper := range missing {
+		programs = append(programs, helper.Program)
+		pasteMissing = pasteMissing || helper.Paste
+	}
+	hint := clipboard.InstallHint(missing)
+	e.logger.Warn(e.ctx, "clipboard helpers missing", "programs", programs, "install", hint)
+
+	hintPath := filepath.Join(config.DirectoryData, helperHintFileName)
+	if shown, err := os.ReadFile(hintPath); err == nil && string(shown) == hint {
+		return
+	}
+
+	message := "Missing " + strings.Join(programs, ", ") + ", install it with: " + hint
+	if pasteMissing {
+		message += "\nUntil then dictations are only copied to the clipboard."
+	}
+	e.notifier.Info(e.ctx, "Clipboard Helpers Missing", message)
+
+	if err := os.WriteFile(hintPath, []byte(hint), 0644); err != nil {
+		e.logger.Warn(e.ctx, "failed to record the helper hint", "err", err)
+	}
+}
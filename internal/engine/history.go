@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// historySyncCheckInterval is how often RunHistorySync checks whether a sync is due.
+const historySyncCheckInterval = time.Minute
+
+// saveHistoryEntry persists a history entry added to or changed in the state, and drops
+// the persisted entries beyond the history limit.
+func (e *Engine) saveHistoryEntry(entry state.HistoryEntry) {
+	if err := e.history.Save(entry); err != nil {
+		e.logger.Error(e.ctx, "failed to save history entry", "id", entry.ID, "err", err)
+		return
+	}
+	e.trimHistory(e.settingsManager.Get().HistoryLimit)
+}
+
+// trimHistory drops the persisted entries beyond the limit.
+func (e *Engine) trimHistory(limit int) {
+	if err := e.history.Trim(limit); err != nil {
+		e.logger.Error(e.ctx, "failed to trim history", "err", err)
+	}
+}
+
+// DeleteHistoryEntry removes an entry from the history. The deletion is synced, so the
+// entry is also removed on the other machines of the user.
+func (e *Engine) DeleteHistoryEntry(id int) error {
+	entry, ok := e.state.GetHistoryEntry(id)
+	if !ok {
+		return fmt.Errorf("history entry %d not found", id)
+	}
+	if err := e.deleteHistory(map[string]time.Time{entry.UID: time.Now()}); err != nil {
+		return err
+	}
+	e.logger.Info(e.ctx, "history entry deleted", "id", id)
+	return nil
+}
+
+// ClearHistory removes every entry from the history, also on the other machines of the
+// user once synced.
+func (e *Engine) ClearHistory() error {
+	now := time.Now()
+	deletions := make(map[string]time.Time)
+	for _, entry := range e.state.GetHistory() {
+		deletions[entry.UID] = now
+	}
+	if err := e.deleteHistory(deletions); err != nil {
+		return err
+	}
+	e.logger.Info(e.ctx, "history cleared", "entries", len(deletions))
+	return nil
+}
+
+// deleteHistory removes the entries with the given UIDs from the state and the store,
+// recording when they were deleted for the sync.
+func (e *Engine) deleteHistory(deletions map[string]time.Time) error {
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	uids := make([]string, 0, len(deletions))
+	for uid := range deletions {
+		uids = append(uids, uid)
+	}
+	e.state.DeleteHistory(uids)
+
+	if err := e.history.Delete(deletions); err != nil {
+		return fmt.Errorf("failed to delete history entries: %w", err)
+	}
+	return nil
+}
+
+// SyncHistory merges the history with the one synced by the other machines of the user:
+// their entries missing here are added, the ones they changed last are updated, the ones
+// deleted on any machine are removed, and the result is uploaded for them. It returns the
+// number of entries added or updated.
+func (e *Engine) SyncHistory() (int, error) {
+	if !e.historySync.IsConfigured() {
+		return 0, errors.New("history sync is not configured")
+	}
+
+	local, err := e.history.Deletions()
+	if err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	err = e.historySync.Sync(e.ctx, local, func(remote []state.HistoryEntry, deleted map[string]time.Time) []state.HistoryEntry {
+		// Only the deletions made on other machines are new here.
+		newDeletions := make(map[string]time.Time)
+		for uid, deletedAt := range deleted {
+			if _, ok := local[uid]; !ok {
+				newDeletions[uid] = deletedAt
+			}
+		}
+		if err := e.deleteHistory(newDeletions); err != nil {
+			e.logger.Error(e.ctx, "failed to delete the entries deleted on other machines", "err", err)
+		}
+		for _, entry := range e.state.MergeHistory(remote) {
+			e.saveHistoryEntry(entry)
+			merged++
+		}
+		return e.state.GetHistory()
+	})
+	if err != nil {
+		return merged, err
+	}
+
+	e.logger.Info(e.ctx, "history synced", "merged", merged)
+	return merged, nil
+}
+
+// RunHistorySync syncs the history every HistorySyncIntervalMinutes while a sync backend
+// is configured, starting right away, until the engine shuts down.
+func (e *Engine) RunHistorySync() {
+	ticker := time.NewTicker(historySyncCheckInterval)
+	defer ticker.Stop()
+
+	var lastSync time.Time
+	for {
+		interval := time.Duration(e.settingsManager.Get().HistorySyncIntervalMinutes) * time.Minute
+		if interval > 0 && e.historySync.IsConfigured() && time.Since(lastSync) >= interval {
+			lastSync = time.Now()
+			if _, err := e.SyncHistory(); err != nil {
+				e.logger.Warn(e.ctx, "failed to sync history", "err", err)
+			}
+		}
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// AddMarker flags the current moment of the recording in progress, e.g. "important bit
+// here" during a long recording, with an optional note. The markers are stored with the
+// history entry and the session utterance of the recording and listed in their exports.
+func (e *Engine) AddMarker(note string) error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusListening {
+		return errors.New("markers can only be added while recording")
+	}
+
+	marker := state.Marker{Offset: e.recorder.Duration(), Note: strings.TrimSpace(note)}
+	e.markers = append(e.markers, marker)
+
+	e.logger.Info(e.ctx, "marker added", "offset", marker.Offset, "note", marker.Note)
+	e.notifier.Info(e.ctx, "Marker Added", "At "+marker.Position())
+	return nil
+}
+
+// AddMarkerFromMenu adds a marker without a note like AddMarker, logging the failure.
+func (e *Engine) AddMarkerFromMenu() {
+	if err := e.AddMarker(""); err != nil {
+		e.logger.Warn(e.ctx, "failed to add marker", "err", err)
+	}
+}
+
+// MarkerHotkey returns the desktop shortcut the user bound to add markers, empty if none
+// is configured.
+func (e *Engine) MarkerHotkey() string {
+	return e.settingsManager.Get().MarkerHotkey
+}
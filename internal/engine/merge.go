@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// MergeHistory joins the texts of history entries into one document in chronological
+// order, writes it to the exports directory and returns its path. With postProcess the
+// combined text is post-processed again with the selected prompt, e.g. to smooth the
+// joins between dictations.
+func (e *Engine) MergeHistory(ids []int, separator string, postProcess bool) (string, error) {
+	if len(ids) < 2 {
+		return "", errors.New("at least two history entries are needed to merge")
+	}
+
+	entries := make([]state.HistoryEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, ok := e.state.GetHistoryEntry(id)
+		if !ok {
+			return "", fmt.Errorf("history entry %d not found", id)
+		}
+		entries = append(entries, entry)
+	}
+
+	merged := export.MergeTranscripts(entries, separator)
+	if postProcess {
+		if !e.postprocess.IsConfigured() {
+			return "", errors.New("post-processing the merged text requires an AI provider")
+		}
+
+		settings := e.settingsManager.Get()
+		settings.PostProcessEnabled = true
+		processed, err := e.postprocess.Process(e.ctx, settings, merged)
+		if err != nil {
+			return "", fmt.Errorf("failed to post-process the merged text: %w", err)
+		}
+		merged = processed
+	}
+
+	filename := fmt.Sprintf("merged-%s.txt", time.Now().Format("20060102-150405"))
+	exportPath := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(exportPath, []byte(merged+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write merged transcript: %w", err)
+	}
+
+	e.logger.Info(e.ctx, "history entries merged", "ids", ids, "post_processed", postProcess, "path", exportPath)
+	return exportPath, nil
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+const (
+	// microphoneTestDuration is the length of the test recording.
+	microphoneTestDuration = 2 * time.Second
+	// microphoneSilenceDBFS is the peak level below which the test recording is
+	// considered silent, usually a muted device or a denied microphone permission.
+	microphoneSilenceDBFS = -50
+	// inputLevelInterval is how often the level of a recording is reported in the state.
+	inputLevelInterval = 100 * time.Millisecond
+)
+
+// TestMicrophone records a short sample and reports the input device, the capture format
+// and the recorded level in a notification, so users can check their setup without a full
+// dictation. Toggles wait until the test finishes; it fails while a dictation is running.
+func (e *Engine) TestMicrophone() error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded && status != state.StatusUnloaded {
+		return errors.New("cannot test the microphone while busy")
+	}
+
+	device, err := e.recorder.DeviceName()
+	if err != nil {
+		e.logger.Warn(e.ctx, "failed to get the input device name", "err", err)
+		device = "Unknown input device"
+	}
+
+	if err := e.recorder.Start(); err != nil {
+		e.notifier.Info(e.ctx, "Microphone Test Failed", err.Error())
+		return fmt.Errorf("failed to start the test recording: %w", err)
+	}
+	go e.trackInputLevel()
+
+	select {
+	case <-time.After(microphoneTestDuration):
+	case <-e.ctx.Done():
+	}
+	e.recorder.Stop()
+
+	peak, rms := audio.Levels(e.recorder.Samples())
+	e.logger.Info(e.ctx, "microphone test finished", "device", device, "peak_dbfs", peak, "rms_dbfs", rms)
+
+	message := fmt.Sprintf("%s\n%d Hz mono, 16-bit\nPeak %.0f dBFS, average %.0f dBFS",
+		device, audio.SampleRate, max(peak, -99), max(rms, -99))
+	if peak < microphoneSilenceDBFS {
+		e.notifier.Info(e.ctx, "Microphone Test: No Sound",
+			message+"\nCheck that the device is not muted and that microphone access is allowed.")
+		return nil
+	}
+
+	e.notifier.Info(e.ctx, "Microphone Test", message)
+	return nil
+}
+
+// applyPreRoll keeps the pre-roll capture (PreRollMs) running while the models are loaded
+// and the session is unlocked, and releases the input device otherwise.
+func (e *Engine) applyPreRoll() {
+	settings := e.settingsManager.Get()
+	preRoll := time.Duration(settings.PreRollMs) * time.Millisecond
+
+	status, _ := e.state.GetStatus()
+	if status == state.StatusUnloaded || status == state.StatusLoading || (e.sessionLocked.Load() && settings.StopOnSessionLock) {
+		preRoll = 0
+	}
+	if err := e.recorder.SetPreRoll(preRoll); err != nil {
+		e.logger.Warn(e.ctx, "failed to start the pre-roll capture", "err", err)
+	}
+}
+
+// trackInputLevel reports the level of the recording in progress in the state every
+// inputLevelInterval, so frontends can show that the microphone picks up sound, until the
+// recording stops.
+func (e *Engine) trackInputLevel() {
+	ticker := time.NewTicker(inputLevelInterval)
+	defer ticker.Stop()
+	defer e.state.ClearInputLevel()
+
+	for {
+		peak, rms, ok := e.recorder.Level()
+		if !ok {
+			return
+		}
+		e.state.SetInputLevel(state.InputLevel{Peak: peak, RMS: rms})
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// SetModel selects the transcription model with the given registry ID, saves it in the
+// settings and switches the transcriber to it in the background, downloading the model
+// files if needed. Recordings and transcriptions in progress carry on, see
+// swapModelUnsafe. It fails while the models are being loaded.
+func (e *Engine) SetModel(id string) error {
+	if _, ok := transcribe.Get(id); !ok {
+		return fmt.Errorf("unknown model %q", id)
+	}
+
+	status, _ := e.state.GetStatus()
+	if status == state.StatusLoading {
+		return fmt.Errorf("cannot change the model while the models are loading")
+	}
+
+	settings := e.settingsManager.Get()
+	if settings.ModelID == id && status != state.StatusUnloaded {
+		return nil
+	}
+
+	settings.ModelID = id
+	if err := e.settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	go func() {
+		if err := e.LoadModels(e.logDownloadProgress); err != nil && !errors.Is(err, ErrDownloadDeferred) {
+			e.logger.Error(e.ctx, "failed to reload models", "model", id, "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// SetLanguage saves the language hint in the settings and, if the selected model cannot
+// transcribe it, switches the transcriber to one that can like SetModel. An empty
+// language lets the model detect it.
+func (e *Engine) SetLanguage(language string) error {
+	status, _ := e.state.GetStatus()
+	if status == state.StatusLoading {
+		return fmt.Errorf("cannot change the language while the models are loading")
+	}
+
+	settings := e.settingsManager.Get()
+	info, ok := transcribe.ModelForLanguage(settings.ModelID, language)
+	if !ok {
+		return fmt.Errorf("no model supports the language %q", language)
+	}
+
+	settings.Language = language
+	if err := e.settingsManager.Update(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	if info.ID == e.transcriber.ModelID() && status != state.StatusUnloaded {
+		return nil
+	}
+
+	go func() {
+		if err := e.LoadModels(e.logDownloadProgress); err != nil && !errors.Is(err, ErrDownloadDeferred) {
+			e.logger.Error(e.ctx, "failed to reload models", "model", info.ID, "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// swapModel switches the loaded transcriber to the model the settings select, if it is
+// not the active one already. It does nothing while the models are unloaded, the next
+// LoadModels picks the model up.
+func (e *Engine) swapModel(progressCallback transcribe.DownloadProgressCallback) error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+
+	if status, _ := e.state.GetStatus(); status == state.StatusUnloaded || status == state.StatusLoading {
+		return nil
+	}
+	return e.swapModelUnsafe(progressCallback)
+}
+
+// swapModelUnsafe switches the loaded transcriber to the model and precision the settings
+// select without acquiring loadMu and without leaving the loaded status, so the app stays
+// usable: the files of the new model are downloaded while the active one keeps
+// transcribing, then the switch waits for the transcriptions in progress, unloads the
+// active model and loads the new one. Recordings can start and stop meanwhile, their
+// transcriptions wait for the new model. If it fails to load, the previous model is
+// loaded again.
+func (e *Engine) swapModelUnsafe(progressCallback transcribe.DownloadProgressCallback) error {
+	settings := e.settingsManager.Get()
+	if remoteOnly(settings) {
+		return nil
+	}
+
+	previousID, previousPrecision := e.transcriber.ModelID(), e.transcriber.Precision()
+	modelID := e.modelForSettings(settings)
+	precision := e.modelPrecision(settings, modelID)
+	if modelID == "" || (modelID == previousID && precision == previousPrecision) {
+		return nil
+	}
+
+	e.transcriber.SetPrecision(precision)
+	if err := e.downloadModel(settings, modelID, progressCallback); err != nil {
+		e.transcriber.SetPrecision(previousPrecision)
+		return err
+	}
+
+	e.logger.Info(e.ctx, "switching transcription model", "model", modelID, "precision", precision)
+	e.modelMu.Lock()
+	defer e.modelMu.Unlock()
+
+	err := e.switchModel(modelID)
+	if err == nil {
+		e.logger.Info(e.ctx, "transcription model switched", "model", modelID, "precision", precision)
+		e.notifier.Info(e.ctx, "Model Switched", "Dictations are now transcribed with "+modelName(modelID))
+		return nil
+	}
+
+	e.notifier.Error(e.ctx, "Model Switch Failed", err.Error())
+	e.transcriber.SetPrecision(previousPrecision)
+	if restoreErr := e.switchModel(previousID); restoreErr != nil {
+		e.logger.Error(e.ctx, "failed to restore the previous model", "model", previousID, "err", restoreErr)
+		if status, _ := e.state.GetStatus(); status == state.StatusLoaded {
+			e.state.SetStatus(state.StatusUnloaded)
+		}
+	}
+	return fmt.Errorf("failed to switch model: %w", err)
+}
+
+// downloadModel downloads the missing files of the model with the given ID before it is
+// switched to, unless the download is deferred on a metered connection.
+func (e *Engine) downloadModel(settings config.Settings, id string, progressCallback transcribe.DownloadProgressCallback) error {
+	allExist, _, err := e.transcriber.CheckModel(id)
+	if err != nil {
+		return fmt.Errorf("failed to check model files: %w", err)
+	}
+	if allExist {
+		return nil
+	}
+	if e.deferDownload(settings) {
+		return ErrDownloadDeferred
+	}
+
+	e.logger.Info(e.ctx, "downloading the files of the new model...", "model", id)
+	defer e.state.ClearProgress()
+	if err := e.transcriber.DownloadModel(id, e.trackDownloadProgress(progressCallback)); err != nil {
+		e.notifier.Error(e.ctx, "Model Download Failed", err.Error())
+		return fmt.Errorf("failed to download models: %w", err)
+	}
+	return nil
+}
+
+// switchModel makes the model with the given ID the active one and loads it, downloading
+// its corrupted files again. modelMu must be held for writing.
+func (e *Engine) switchModel(id string) error {
+	if err := e.transcriber.SwitchModel(id); err != nil {
+		return err
+	}
+
+	err := e.transcriber.LoadModels()
+	if errors.Is(err, transcribe.ErrCorruptedModel) {
+		e.logger.Warn(e.ctx, "corrupted model files deleted, downloading them again", "err", err)
+		err = e.transcriber.DownloadModels(e.trackDownloadProgress(e.logDownloadProgress))
+		e.state.ClearProgress()
+		if err == nil {
+			err = e.transcriber.LoadModels()
+		}
+	}
+	return err
+}
+
+// modelName returns the display name of the registered model with the given ID, the ID
+// itself if it has none.
+func modelName(id string) string {
+	if info, ok := transcribe.Get(id); ok && info.Name != "" {
+		return info.Name
+	}
+	return id
+}
+
+// modelForSettings returns the ID of the model to load: the one selected in the settings,
+// or a model that supports the configured language if the selected one does not.
+func (e *Engine) modelForSettings(settings config.Settings) string {
+	info, ok := transcribe.ModelForLanguage(settings.ModelID, settings.Language)
+	if !ok {
+		e.logger.Warn(e.ctx, "no model supports the configured language", "language", settings.Language)
+		return settings.ModelID
+	}
+
+	if info.ID != settings.ModelID {
+		e.logger.Info(e.ctx, "selected model does not support the language, using another one",
+			"language", settings.Language,
+			"selected_model", settings.ModelID,
+			"model", info.ID,
+		)
+	}
+	return info.ID
+}
+
+// trackDownloadProgress wraps a download progress callback to also publish the progress
+// in the state for frontends.
+func (e *Engine) trackDownloadProgress(progressCallback transcribe.DownloadProgressCallback) transcribe.DownloadProgressCallback {
+	return func(filename string, downloaded, total int64, percent float64) {
+		e.state.SetProgress(state.ProgressDownload, percent/100)
+		if progressCallback != nil {
+			progressCallback(filename, downloaded, total, percent)
+		}
+	}
+}
+
+// modelPrecision returns the precision of the model weights selected in the settings,
+// int8 if it is empty or unknown. While the battery saver is active the int8 weights of
+// the model are used if they are already downloaded, since they take a fraction of the
+// memory and compute of fp32 ones; switching the power source never starts a download.
+func (e *Engine) modelPrecision(settings config.Settings, modelID string) transcribe.Precision {
+	switch precision := transcribe.Precision(settings.ModelPrecision); precision {
+	case transcribe.PrecisionInt8, "":
+		return transcribe.PrecisionInt8
+	case transcribe.PrecisionFP32:
+		if e.state.IsBatterySaverActive() && e.int8Downloaded(modelID) {
+			return transcribe.PrecisionInt8
+		}
+		return precision
+	default:
+		e.logger.Warn(e.ctx, "unknown model precision, using int8", "precision", precision)
+		return transcribe.PrecisionInt8
+	}
+}
+
+// int8Downloaded reports whether the int8 weights of the model with the given ID are on
+// disk.
+func (e *Engine) int8Downloaded(modelID string) bool {
+	allExist, _, err := e.transcriber.CheckModelPrecision(modelID, transcribe.PrecisionInt8)
+	return err == nil && allExist
+}
+
+// releaseIdleSessions closes the ONNX sessions of the VAD and the punctuation model when
+// no setting uses them, so they stop holding memory while the battery saver is active.
+// LoadModels loads them again once a setting needs them.
+func (e *Engine) releaseIdleSessions(settings config.Settings) {
+	if e.vad != nil && e.vad.Loaded() && !settings.TrimSilenceEnabled && settings.AutoStopSilenceSeconds <= 0 {
+		_ = e.vad.Close()
+		e.logger.Debug(e.ctx, "idle VAD session released")
+	}
+	if e.punctuator != nil && e.punctuator.Loaded() && !settings.PunctuationEnabled {
+		_ = e.punctuator.Close()
+		e.logger.Debug(e.ctx, "idle punctuation session released")
+	}
+}
+
+// logDownloadProgress reports model download progress in the logs.
+func (e *Engine) logDownloadProgress(filename string, downloaded, total int64, percent float64) {
+	e.logger.Info(e.ctx, "downloading model",
+		"file", filename,
+		"progress", fmt.Sprintf("%.1f%%", percent),
+	)
+}
+
+// UnloadModels releases the transcription model, the VAD, the punctuation model and the
+// last recording so the app uses little memory while the user is not dictating.
+// Recordings are refused until ReloadModels is called. It fails while a recording or
+// transcription is in progress, and waits for a model switch in progress.
+func (e *Engine) UnloadModels() error {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	switch status {
+	case state.StatusUnloaded:
+		return nil
+	case state.StatusLoaded:
+	default:
+		return fmt.Errorf("cannot unload the models while busy")
+	}
+
+	if err := e.transcriber.UnloadModels(); err != nil {
+		return fmt.Errorf("failed to unload models: %w", err)
+	}
+	if e.vad != nil {
+		_ = e.vad.Close()
+	}
+	if e.punctuator != nil {
+		_ = e.punctuator.Close()
+	}
+	e.recorder.Release()
+
+	e.state.SetStatus(state.StatusUnloaded)
+	e.applyPreRoll()
+	e.logger.Info(e.ctx, "models unloaded")
+	return nil
+}
+
+// ReloadModels loads the models again after UnloadModels, in the background. It does
+// nothing if they are already loaded or being loaded.
+func (e *Engine) ReloadModels() {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusUnloaded {
+		return
+	}
+	e.state.SetStatus(state.StatusLoading)
+
+	go func() {
+		if err := e.LoadModels(e.logDownloadProgress); err != nil && !errors.Is(err, ErrDownloadDeferred) {
+			e.logger.Error(e.ctx, "failed to reload models", "err", err)
+		}
+	}()
+}
+
+// CheckModelUpdates returns the names of the installed model files with a newer revision
+// available. Remote-only setups have no local model to update.
+func (e *Engine) CheckModelUpdates() ([]string, error) {
+	if remoteOnly(e.settingsManager.Get()) {
+		return nil, nil
+	}
+
+	updates, err := e.transcriber.CheckModelUpdates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for model updates: %w", err)
+	}
+
+	names := make([]string, 0, len(updates))
+	for _, update := range updates {
+		names = append(names, update.File.Name+" ("+update.Reason+")")
+	}
+	return names, nil
+}
+
+// UpdateModels replaces the outdated model files with their new revision: the models are
+// unloaded, the old files deleted, the new ones downloaded and the models loaded again.
+// It fails while a recording or transcription is in progress and does nothing if the
+// models are up to date.
+func (e *Engine) UpdateModels() error {
+	names, err := e.CheckModelUpdates()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		e.notifier.Info(e.ctx, "Models Up to Date", "The installed models are the latest revision")
+		return nil
+	}
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded && status != state.StatusUnloaded {
+		return fmt.Errorf("cannot update the models while busy")
+	}
+	if err := e.UnloadModels(); err != nil {
+		return err
+	}
+
+	e.logger.Info(e.ctx, "updating model files", "files", names)
+	e.state.SetStatus(state.StatusLoading)
+	if _, err := e.transcriber.UpdateModels(e.trackDownloadProgress(e.logDownloadProgress)); err != nil {
+		e.state.ClearProgress()
+		e.state.SetStatus(state.StatusUnloaded)
+		e.handleActionError("Model Update Failed", err)
+		return fmt.Errorf("failed to update models: %w", err)
+	}
+
+	if err := e.LoadModels(e.logDownloadProgress); err != nil {
+		return err
+	}
+	e.notifier.Info(e.ctx, "Models Updated", strings.Join(names, ", "))
+	return nil
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/ocr"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// ocrHintFileName records the Tesseract install hint last shown, so it is shown only once.
+const ocrHintFileName = "ocr-hint.txt"
+
+// CheckTextRecognition records in the state whether text recognition is enabled without
+// Tesseract, so the tray and the frontends show it as unavailable, and tells the user how
+// to install it the first time, since the app does not ship it.
+func (e *Engine) CheckTextRecognition() {
+	missing := e.settingsManager.Get().OCREnabled && !ocr.Installed()
+	e.state.SetTextRecognitionMissing(missing)
+	if !missing {
+		return
+	}
+
+	hint := ocr.InstallHint()
+	e.logger.Warn(e.ctx, "tesseract missing, text recognition will not work", "install", hint)
+
+	hintPath := filepath.Join(config.DirectoryData, ocrHintFileName)
+	if shown, err := os.ReadFile(hintPath); err == nil && string(shown) == hint {
+		return
+	}
+
+	e.notifier.Info(e.ctx, "Tesseract Missing", "Text recognition needs Tesseract, install it with: "+hint)
+
+	if err := os.WriteFile(hintPath, []byte(hint), 0644); err != nil {
+		e.logger.Warn(e.ctx, "failed to record the tesseract hint", "err", err)
+	}
+}
+
+// RecognizeClipboardImage extracts the text of the image currently in the clipboard and
+// delivers it through the same pipeline as transcriptions (post-processing, output, history).
+func (e *Engine) RecognizeClipboardImage() {
+	settings := e.settingsManager.Get()
+	if !settings.OCREnabled {
+		e.logger.Warn(e.ctx, "text recognition is disabled in the settings")
+		return
+	}
+
+	if !ocr.Installed() {
+		e.handleActionError("text recognition unavailable",
+			fmt.Errorf("%w, install it with: %s", ocr.ErrTesseractNotFound, ocr.InstallHint()))
+		return
+	}
+
+	e.toggleMu.Lock()
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded {
+		e.toggleMu.Unlock()
+		e.logger.Warn(e.ctx, "cannot recognize clipboard image while busy", "status", status)
+		return
+	}
+	e.state.SetStatus(state.StatusTranscribing)
+	e.toggleMu.Unlock()
+
+	image, err := e.writer.ReadImage(e.ctx)
+	if err != nil {
+		e.handleError("failed to read clipboard image", err)
+		return
+	}
+
+	text, err := e.ocr.Recognize(e.ctx, image, settings.OCRLanguage)
+	if err != nil {
+		e.handleError("text recognition failed", err)
+		return
+	}
+
+	if text == "" {
+		e.handleError("text recognition failed", errors.New("no text found in the image"))
+		return
+	}
+
+	e.logger.Debug(e.ctx, "text recognition complete", "text", text)
+	settings.FormTemplateID = "" // Recognized text never fills a form
+	e.deliver(settings, transcript{text: text, source: state.SourceOCR}, "", "", e.state.IsPrivacyModeActive())
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// writeOutput writes the text with the configured output mode, handling texts longer than
+// the maximum paste length.
+func (e *Engine) writeOutput(settings config.Settings, text string) error {
+	if settings.OutputMode == config.OutputModeScratchpad {
+		return e.writeScratchpad(text)
+	}
+	if exceedsPasteLength(settings, text) {
+		return e.writeOverflow(settings, text)
+	}
+	return e.writer.Write(e.ctx, settings.OutputMode, text)
+}
+
+// exceedsPasteLength reports whether the text is too long to be pasted at once with the
+// given settings. Copy only and scratchpad modes are never limited since nothing is pasted.
+func exceedsPasteLength(settings config.Settings, text string) bool {
+	if settings.MaxPasteLength <= 0 || settings.OutputMode == config.OutputModeCopyOnly || settings.OutputMode == config.OutputModeScratchpad {
+		return false
+	}
+	return utf8.RuneCountInString(text) > settings.MaxPasteLength
+}
+
+// writeOverflow outputs a text longer than the maximum paste length according to the
+// configured overflow mode.
+func (e *Engine) writeOverflow(settings config.Settings, text string) error {
+	e.logger.Info(e.ctx, "transcription exceeds the maximum paste length",
+		"length", utf8.RuneCountInString(text),
+		"max", settings.MaxPasteLength,
+		"mode", settings.PasteOverflowMode,
+	)
+
+	if settings.PasteOverflowMode == config.PasteOverflowChunks {
+		return e.writer.WriteChunked(e.ctx, settings.OutputMode, text, settings.MaxPasteLength)
+	}
+
+	path, err := saveOverflow(text)
+	if err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("[Transcript saved to %s]", path)
+	if settings.PasteOverflowMode == config.PasteOverflowSummary && e.postprocess.IsConfigured() {
+		summary, err := e.postprocess.Summarize(e.ctx, text)
+		if err != nil {
+			e.logger.Warn(e.ctx, "failed to summarize long transcription, pasting a reference", "err", err)
+		}
+		if err == nil {
+			reference = summary + "\n\n" + reference
+		}
+	}
+
+	return e.writer.Write(e.ctx, settings.OutputMode, reference)
+}
+
+// saveOverflow writes a long transcription to the exports directory and returns its path.
+func saveOverflow(text string) (string, error) {
+	filename := fmt.Sprintf("transcript-%s.txt", time.Now().Format("20060102-150405"))
+	path := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("failed to save long transcription: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// Overrides replace some settings for a single dictation, so a hotkey can trigger a
+// specialized transcription (another language, prompt or output) without switching
+// profiles. Empty fields keep the stored setting.
+type Overrides struct {
+	Language               string
+	PromptID               string
+	OutputMode             config.OutputMode
+	NormalizationProfileID string
+}
+
+// overrideKeys lists the accepted override arguments.
+var overrideKeys = map[string]struct{}{
+	"language": {},
+	"prompt":   {},
+	"output":   {},
+	"style":    {},
+}
+
+// ParseOverrides builds the overrides from command arguments such as language=es,
+// prompt=Formal, output=copy_only or style=chat. Unknown keys are rejected so typos in
+// hotkey definitions do not go unnoticed.
+func ParseOverrides(args map[string]string) (Overrides, error) {
+	var unknown []string
+	for key := range args {
+		if _, ok := overrideKeys[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return Overrides{}, fmt.Errorf("unknown override arguments: %s", strings.Join(unknown, ", "))
+	}
+
+	overrides := Overrides{
+		Language:               strings.TrimSpace(args["language"]),
+		PromptID:               strings.TrimSpace(args["prompt"]),
+		OutputMode:             config.OutputMode(strings.TrimSpace(args["output"])),
+		NormalizationProfileID: strings.TrimSpace(args["style"]),
+	}
+
+	switch overrides.OutputMode {
+	case "", config.OutputModeCopyOnly, config.OutputModeCopyPaste, config.OutputModeGhostPaste, config.OutputModeScratchpad:
+	default:
+		return Overrides{}, fmt.Errorf("unknown output mode %q", overrides.OutputMode)
+	}
+
+	return overrides, nil
+}
+
+// IsZero reports whether the overrides do not change any setting.
+func (o Overrides) IsZero() bool {
+	return o == Overrides{}
+}
+
+// apply returns a copy of the settings with the overrides applied. A prompt override
+// enables post-processing for the dictation, since asking for a prompt implies it.
+func (o Overrides) apply(settings config.Settings) (config.Settings, error) {
+	if o.Language != "" {
+		settings.Language = o.Language
+	}
+
+	if o.PromptID != "" {
+		prompt, ok := settings.FindPrompt(o.PromptID)
+		if !ok {
+			return settings, fmt.Errorf("unknown prompt %q", o.PromptID)
+		}
+		settings.PostProcessPromptID = prompt.ID
+		settings.PostProcessEnabled = true
+	}
+
+	if o.OutputMode != "" {
+		settings.OutputMode = o.OutputMode
+	}
+
+	if o.NormalizationProfileID != "" {
+		profile, ok := findNormalizationProfile(settings, o.NormalizationProfileID)
+		if !ok {
+			return settings, fmt.Errorf("unknown text style %q", o.NormalizationProfileID)
+		}
+		settings.NormalizationProfileID = profile.ID
+	}
+
+	return settings, nil
+}
+
+// findNormalizationProfile looks up a normalization profile by ID or, case-insensitively,
+// by name.
+func findNormalizationProfile(settings config.Settings, idOrName string) (config.NormalizationProfile, bool) {
+	for _, profile := range settings.NormalizationProfiles {
+		if profile.ID == idOrName || strings.EqualFold(profile.Name, idOrName) {
+			return profile, true
+		}
+	}
+	return config.NormalizationProfile{}, false
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// PauseRecording pauses the recording in progress, e.g. to take a call: nothing is
+// captured until ResumeRecording, and the parts before and after the pause are
+// transcribed as one dictation. Stopping a paused recording transcribes what was recorded
+// before the pause.
+func (e *Engine) PauseRecording() error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusListening {
+		return errors.New("no recording to pause")
+	}
+
+	if err := e.recorder.Pause(); err != nil {
+		return fmt.Errorf("failed to pause the recording: %w", err)
+	}
+	e.state.SetStatus(state.StatusPaused)
+	e.logger.Info(e.ctx, "recording paused")
+	e.notifier.Info(e.ctx, "Recording Paused", "Nothing is recorded until you resume the dictation")
+	return nil
+}
+
+// ResumeRecording continues the paused recording.
+func (e *Engine) ResumeRecording() error {
+	e.toggleMu.Lock()
+	defer e.toggleMu.Unlock()
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusPaused {
+		return errors.New("no paused recording to resume")
+	}
+
+	if err := e.recorder.Resume(); err != nil {
+		e.notifier.Error(e.ctx, "Recording Failed", err.Error())
+		return fmt.Errorf("failed to resume the recording: %w", err)
+	}
+	e.state.SetStatus(state.StatusListening)
+	e.logger.Info(e.ctx, "recording resumed")
+	e.notifier.Info(e.ctx, "Recording Resumed", "Continue the dictation")
+	return nil
+}
+
+// TogglePause pauses the recording in progress or resumes the paused one, logging the
+// failure.
+func (e *Engine) TogglePause() {
+	status, _ := e.state.GetStatus()
+
+	var err error
+	if status == state.StatusPaused {
+		err = e.ResumeRecording()
+	}
+	if status != state.StatusPaused {
+		err = e.PauseRecording()
+	}
+	if err != nil {
+		e.logger.Warn(e.ctx, "failed to pause or resume the recording", "err", err)
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/postprocess"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// maxRateLimitWait is the longest wait requested by a rate limited provider that is
+// waited out before retrying automatically. Longer waits are left to the user, who can
+// retry with RetryPostProcessing.
+const maxRateLimitWait = time.Minute
+
+// failedPostProcess is a text whose post-processing failed, kept so it can be retried.
+type failedPostProcess struct {
+	settings config.Settings
+	text     string
+	sinkID   string
+	event    audit.Event
+}
+
+// postProcess runs the LLM post-processing of a text. A rate limited request is retried
+// once after the wait the provider asks for; other failures are explained to the user in a
+// notification, and the text is kept for RetryPostProcessing unless private is set.
+func (e *Engine) postProcess(settings config.Settings, text string, private bool, retry failedPostProcess) (string, error) {
+	processed, err := e.postprocess.Process(e.ctx, settings, text)
+	if delay, ok := postprocess.RetryDelay(err); ok && delay <= maxRateLimitWait {
+		e.logger.Warn(e.ctx, "post-processing rate limited, retrying", "delay", delay)
+		e.notifier.Error(e.ctx, "Post-Processing Rate Limited", fmt.Sprintf("Rate limited by the provider, retrying in %s.", delay))
+
+		select {
+		case <-time.After(delay):
+			processed, err = e.postprocess.Process(e.ctx, settings, text)
+		case <-e.ctx.Done():
+			return text, e.ctx.Err()
+		}
+	}
+	if err == nil {
+		e.failedPostProcess.Store(nil)
+		return processed, nil
+	}
+
+	message := postprocess.Guidance(err) + "\nThe raw transcription was used."
+	if !private {
+		e.failedPostProcess.Store(&retry)
+		message += " Run \"tribar retry\" to post-process it again."
+	}
+	e.logger.Warn(e.ctx, "post-processing failed, using raw transcription", "err", err)
+	e.notifier.Error(e.ctx, "Post-Processing Failed", message)
+	return text, err
+}
+
+// RetryPostProcessing post-processes again the last text whose post-processing failed and
+// delivers the result to the output, e.g. once the API key is fixed. The history keeps the
+// raw transcription delivered at the time.
+func (e *Engine) RetryPostProcessing() error {
+	failed := e.failedPostProcess.Load()
+	if failed == nil {
+		return errors.New("no failed post-processing to retry")
+	}
+
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded {
+		return fmt.Errorf("cannot retry post-processing while busy")
+	}
+
+	e.state.SetStatus(state.StatusPostProcessing)
+	defer e.state.SetStatus(state.StatusLoaded)
+
+	processed, err := e.postProcess(failed.settings, failed.text, false, *failed)
+	if err != nil {
+		return fmt.Errorf("post-processing failed again: %w", err)
+	}
+
+	event := failed.event
+	event.Prompt = failed.settings.PostProcessPromptID
+	e.output(failed.settings, processed, failed.sinkID, event)
+	return nil
+}
@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"github.com/varavelio/tribar/internal/audit"
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// SetPrivacyMode enables or disables the privacy mode ("incognito dictation"). While it is
+// active, recordings are transcribed from memory without saving their audio, and their
+// text is only written to the output: it is kept out of the history and the sessions, and
+// not sent to the sinks or the action items extraction. The audit log, when enabled by
+// the settings, still records the deliveries.
+func (e *Engine) SetPrivacyMode(active bool) {
+	e.state.SetPrivacyMode(active)
+	e.logger.Info(e.ctx, "privacy mode changed", "active", active)
+}
+
+// TogglePrivacyMode switches the privacy mode on or off.
+func (e *Engine) TogglePrivacyMode() {
+	e.SetPrivacyMode(!e.state.IsPrivacyModeActive())
+}
+
+// writePrivate delivers a private text with the output mode only, ignoring routed and
+// enabled sinks.
+func (e *Engine) writePrivate(settings config.Settings, text string, event audit.Event) {
+	event.Text = text
+	if settings.AuditLogEnabled {
+		event.App = e.writer.ActiveApp(e.ctx)
+	}
+
+	if err := e.writeOutput(settings, text); err != nil {
+		e.logger.Error(e.ctx, "failed to write output", "err", err)
+		return
+	}
+	event.Action = string(settings.OutputMode)
+	e.recordAudit(event)
+}
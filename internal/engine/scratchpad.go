@@ -0,0 +1,34 @@
+package engine
+
+import "fmt"
+
+// writeScratchpad appends a dictation to the scratchpad, opening its window if none is
+// open so the text is not accumulated out of sight.
+func (e *Engine) writeScratchpad(text string) error {
+	if err := e.scratchpad.Append(text); err != nil {
+		return err
+	}
+	if e.scratchpad.IsOpen() {
+		return nil
+	}
+	if err := e.scratchpad.Open(e.ctx); err != nil {
+		e.logger.Warn(e.ctx, "failed to open the scratchpad window", "err", err)
+	}
+	return nil
+}
+
+// OpenScratchpad opens the scratchpad window in the default browser.
+func (e *Engine) OpenScratchpad() error {
+	if err := e.scratchpad.Open(e.ctx); err != nil {
+		return fmt.Errorf("failed to open the scratchpad: %w", err)
+	}
+	return nil
+}
+
+// ShowScratchpad opens the scratchpad window like OpenScratchpad, reporting a failure in
+// a notification.
+func (e *Engine) ShowScratchpad() {
+	if err := e.OpenScratchpad(); err != nil {
+		e.handleActionError("failed to open the scratchpad", err)
+	}
+}
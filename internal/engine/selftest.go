@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+// SelfTest checks that a dictation can go through on this machine: the models loaded
+// (loadErr is the result of LoadModels), a one-second sample transcribes, an input
+// device is available and the clipboard accepts text. It is meant to run at launch, so
+// broken setups show up immediately instead of at the first dictation; every failure is
+// reported in a single notification and the returned list.
+func (e *Engine) SelfTest(loadErr error) []string {
+	var failures []string
+	settings := e.settingsManager.Get()
+
+	switch status, _ := e.state.GetStatus(); {
+	case loadErr != nil:
+		failures = append(failures, fmt.Sprintf("Models: %v", loadErr))
+	case remoteOnly(settings):
+	case status != state.StatusLoaded:
+		failures = append(failures, "Models: not loaded")
+	default:
+		e.modelMu.RLock()
+		_, err := e.transcriber.TranscribeSamples(e.ctx, selfTestSample())
+		e.modelMu.RUnlock()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("Transcription: %v", err))
+		}
+	}
+
+	if _, err := e.recorder.DeviceName(); err != nil {
+		failures = append(failures, fmt.Sprintf("Microphone: %v", err))
+	}
+
+	if _, err := e.writer.MeasureLatency(e.ctx); err != nil {
+		failures = append(failures, fmt.Sprintf("Clipboard: %v", err))
+	}
+
+	if len(failures) == 0 {
+		e.logger.Info(e.ctx, "self test passed")
+		return nil
+	}
+
+	e.logger.Warn(e.ctx, "self test failed", "failures", failures)
+	e.notifier.Error(e.ctx, config.AppName+" Self Test Failed", strings.Join(failures, "\n"))
+	return failures
+}
+
+// selfTestSample returns one second of a quiet 440 Hz tone. It checks that the model runs
+// end to end, not what it understands, so no recorded speech needs to be shipped.
+func selfTestSample() []float32 {
+	samples := make([]float32, audio.SampleRate)
+	for n := range samples {
+		samples[n] = float32(0.1 * math.Sin(2*math.Pi*440*float64(n)/audio.SampleRate))
+	}
+	return samples
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// ShareHistoryEntry creates a one-time link serving the text of a history entry, the
+// latest if id is zero, on the local server and copies it to the clipboard so it can be
+// sent to another device.
+func (e *Engine) ShareHistoryEntry(id int) (string, error) {
+	history := e.state.GetHistory()
+	if id == 0 && len(history) > 0 {
+		id = history[0].ID
+	}
+
+	entry, ok := e.state.GetHistoryEntry(id)
+	if !ok {
+		return "", fmt.Errorf("history entry %d not found", id)
+	}
+
+	link, err := e.share.Share(entry.Text)
+	if err != nil {
+		return "", err
+	}
+
+	if err := e.writer.Write(e.ctx, config.OutputModeCopyOnly, link); err != nil {
+		return "", fmt.Errorf("failed to copy the share link: %w", err)
+	}
+
+	e.logger.Info(e.ctx, "history entry shared", "id", id)
+	return link, nil
+}
+
+// CopyShareLink shares the latest history entry like ShareHistoryEntry, reporting the
+// outcome in a notification.
+func (e *Engine) CopyShareLink() {
+	if len(e.state.GetHistory()) == 0 {
+		e.notifier.Info(e.ctx, config.AppName, "There is no dictation to share yet")
+		return
+	}
+
+	link, err := e.ShareHistoryEntry(0)
+	if err != nil {
+		e.handleActionError("failed to share the latest dictation", err)
+		return
+	}
+
+	e.notifier.Info(e.ctx, "Share Link Copied", link+"\nIt can be opened once, within 10 minutes.")
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/export"
+)
+
+// ExportSubtitles writes the segments of a history entry, the latest if id is zero, as a
+// subtitle file in the exports directory and returns its path. The cues hold the text as
+// transcribed, before post-processing.
+func (e *Engine) ExportSubtitles(id int, format export.SubtitleFormat) (string, error) {
+	history := e.state.GetHistory()
+	if id == 0 && len(history) > 0 {
+		id = history[0].ID
+	}
+
+	entry, ok := e.state.GetHistoryEntry(id)
+	if !ok {
+		return "", fmt.Errorf("history entry %d not found", id)
+	}
+	if len(entry.Segments) == 0 {
+		return "", errors.New("the dictation has no timestamps, only the local model reports them")
+	}
+
+	filename := fmt.Sprintf("subtitles-%d-%s.%s", entry.ID, time.Now().Format("20060102-150405"), format)
+	exportPath := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(exportPath, []byte(export.Subtitles(format, entry.Segments)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write subtitles: %w", err)
+	}
+
+	e.logger.Info(e.ctx, "subtitles exported", "id", entry.ID, "format", format, "path", exportPath)
+	return exportPath, nil
+}
+
+// ExportLatestSubtitles exports the latest dictation as SRT subtitles like
+// ExportSubtitles, reporting the outcome in a notification.
+func (e *Engine) ExportLatestSubtitles() {
+	if len(e.state.GetHistory()) == 0 {
+		e.notifier.Info(e.ctx, config.AppName, "There is no dictation to export yet")
+		return
+	}
+
+	exportPath, err := e.ExportSubtitles(0, export.SubtitleSRT)
+	if err != nil {
+		e.handleActionError("failed to export subtitles", err)
+		return
+	}
+
+	e.notifier.Info(e.ctx, "Subtitles Exported", exportPath)
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/calendar"
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/export"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// lowConfidenceTag labels dictations whose confidence is below the configured threshold.
+const lowConfidenceTag = "low-confidence"
+
+// autoTags returns the tags of the rules matching a dictation delivered with the given
+// settings, which include its overrides, plus the low confidence tag when it applies.
+func autoTags(settings config.Settings, text string, confidence float32) []string {
+	var tags []string
+	if confidence > 0 && confidence < settings.LowConfidenceThreshold {
+		tags = append(tags, lowConfidenceTag)
+	}
+	for _, rule := range settings.HistoryTagRules {
+		if rule.Tag != "" && tagRuleMatches(rule, settings, text) {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	return tags
+}
+
+// gatePaste returns the settings to output a dictation with the given confidence: below
+// the paste confidence threshold, the paste output modes are replaced by copy only so the
+// user reviews the text before pasting it, and gated is true. Sources that do not report
+// a confidence are never gated.
+func gatePaste(settings config.Settings, confidence float32) (gatedSettings config.Settings, gated bool) {
+	if confidence <= 0 || confidence >= settings.PasteConfidenceThreshold {
+		return settings, false
+	}
+	if settings.OutputMode != config.OutputModeCopyPaste && settings.OutputMode != config.OutputModeGhostPaste {
+		return settings, false
+	}
+
+	settings.OutputMode = config.OutputModeCopyOnly
+	return settings, true
+}
+
+// notifyPasteReview tells the user that a low confidence dictation was copied instead of
+// pasted, with the beginning of its text to review.
+func (e *Engine) notifyPasteReview(text string, confidence float32) {
+	e.logger.Info(e.ctx, "low confidence dictation copied instead of pasted", "confidence", confidence)
+
+	if runes := []rune(text); len(runes) > 100 {
+		text = string(runes[:97]) + "..."
+	}
+	e.notifier.Info(e.ctx, "Review Before Pasting", fmt.Sprintf(
+		"Recognized with %.0f%% confidence, so it was copied instead of pasted:\n%s", confidence*100, text))
+}
+
+// tagRuleMatches reports whether every condition of the rule matches the dictation.
+func tagRuleMatches(rule config.TagRule, settings config.Settings, text string) bool {
+	if rule.PromptID == "" && rule.Language == "" && rule.OutputMode == "" && rule.Keyword == "" {
+		return false
+	}
+
+	if rule.PromptID != "" && (!settings.PostProcessEnabled || rule.PromptID != settings.PostProcessPromptID) {
+		return false
+	}
+	if rule.Language != "" && !strings.EqualFold(rule.Language, settings.Language) {
+		return false
+	}
+	if rule.OutputMode != "" && rule.OutputMode != settings.OutputMode {
+		return false
+	}
+	if rule.Keyword != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(rule.Keyword)) {
+		return false
+	}
+
+	return true
+}
+
+// SetHistoryTags replaces the tags of a history entry.
+func (e *Engine) SetHistoryTags(id int, tags []string) error {
+	if !e.state.SetHistoryTags(id, tags) {
+		return fmt.Errorf("history entry %d not found", id)
+	}
+	if entry, ok := e.state.GetHistoryEntry(id); ok {
+		e.saveHistoryEntry(entry)
+	}
+	e.logger.Debug(e.ctx, "history entry tagged", "id", id, "tags", tags)
+	return nil
+}
+
+// ExportHistory writes the history entries labeled with the given tag, or all of them if
+// the tag is empty, to a Markdown document and returns its path.
+func (e *Engine) ExportHistory(tag string) (string, error) {
+	tag = state.NormalizeTag(tag)
+
+	title := "Transcription history"
+	entries := e.state.GetHistory()
+	if tag != "" {
+		title = fmt.Sprintf("Transcription history: %s", tag)
+		entries = e.state.GetHistoryByTag(tag)
+	}
+
+	if len(entries) == 0 {
+		return "", fmt.Errorf("there are no history entries to export")
+	}
+
+	prefix := "history"
+	if tag != "" {
+		prefix += "-" + calendar.Slug(tag)
+	}
+	filename := fmt.Sprintf("%s-%s.md", prefix, time.Now().Format("20060102-150405"))
+	exportPath := filepath.Join(config.DirectoryExports, filename)
+	if err := os.WriteFile(exportPath, []byte(export.HistoryMarkdown(title, entries)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write history export: %w", err)
+	}
+
+	e.logger.Info(e.ctx, "history exported", "tag", tag, "entries", len(entries), "path", exportPath)
+	return exportPath, nil
+}
@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/power"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/audio"
+	"github.com/varavelio/tribar/pkg/transcribe"
+)
+
+// transcript is a text ready to be delivered along with how it was produced. duration is
+// the length of the transcribed audio, zero for text that was not spoken, and segments
+// locate the text in it when the source reports timestamps. markers are the ones added
+// while the audio was recorded.
+type transcript struct {
+	text       string
+	source     state.Source
+	confidence float32
+	duration   time.Duration
+	segments   []state.Segment
+	markers    []state.Marker
+}
+
+// remoteOnly reports whether transcriptions only use the remote server, in which case the
+// local model is never needed.
+func remoteOnly(settings config.Settings) bool {
+	return settings.RemoteTranscriptionEnabled && !settings.RemoteTranscriptionFallback
+}
+
+// transcribe turns the recorded WAV into text and reports which backend produced it. With
+// fallback enabled the remote server is skipped while it is unhealthy, and the local model
+// is used when the server fails or exceeds the latency budget. Only the local model
+// reports a confidence. Canceling ctx aborts the transcription with the context error.
+func (e *Engine) transcribe(ctx context.Context, settings config.Settings, wavData []byte) (transcript, error) {
+	if remoteOnly(settings) {
+		text, err := e.remote.TranscribeWAV(ctx, wavData, settings.Language)
+		return transcript{text: text, source: state.SourceRemote, duration: wavDuration(wavData)}, err
+	}
+
+	if settings.RemoteTranscriptionEnabled && e.remote.Healthy() {
+		text, err := e.transcribeRemote(ctx, settings, wavData)
+		if err == nil {
+			return transcript{text: text, source: state.SourceRemote, duration: wavDuration(wavData)}, nil
+		}
+		if ctx.Err() != nil {
+			return transcript{}, ctx.Err()
+		}
+		e.logger.Warn(e.ctx, "remote transcription failed, falling back to the local model", "err", err)
+	}
+
+	samples, err := audio.DecodeWAV(wavData)
+	if err != nil {
+		return transcript{}, fmt.Errorf("failed to decode audio file: %w", err)
+	}
+
+	if settings.TrimSilenceEnabled {
+		samples = e.trimSilence(samples)
+	}
+
+	// Wait for a model switch in progress, the recording is transcribed with the new model.
+	e.modelMu.RLock()
+	defer e.modelMu.RUnlock()
+
+	provider := e.transcriber.ExecutionProvider()
+	defer e.state.SetPartialText("")
+	defer e.state.ClearProgress()
+	result, err := e.transcriber.TranscribeSamplesWithProgress(ctx, samples, e.state.SetPartialText, func(fraction float64) {
+		e.state.SetProgress(state.ProgressTranscription, fraction)
+	})
+	e.checkGPUFallback(provider)
+	if err != nil {
+		return transcript{}, err
+	}
+
+	return transcript{
+		text:       result.Text,
+		source:     state.SourceLocal,
+		confidence: result.Confidence(),
+		duration:   time.Duration(len(samples)) * time.Second / audio.SampleRate,
+		segments:   historySegments(result.Segments()),
+	}, nil
+}
+
+// historySegments converts the segments of a transcription for the history.
+func historySegments(segments []transcribe.Segment) []state.Segment {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	converted := make([]state.Segment, len(segments))
+	for n, segment := range segments {
+		converted[n] = state.Segment{Text: segment.Text, Start: segment.Start, End: segment.End}
+	}
+	return converted
+}
+
+// wavDuration returns the length of a WAV recorded by the app, 16-bit mono at
+// audio.SampleRate behind a 44 byte header.
+func wavDuration(wavData []byte) time.Duration {
+	return time.Duration(max(len(wavData)-44, 0)/2) * time.Second / audio.SampleRate
+}
+
+// beginTranscription returns the context of a new transcription, canceled by
+// CancelTranscription or when the engine shuts down. done must be called once the
+// transcription finishes.
+func (e *Engine) beginTranscription() (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(e.ctx)
+
+	e.transcriptionMu.Lock()
+	e.cancelTranscription = cancel
+	e.transcriptionMu.Unlock()
+
+	return ctx, func() {
+		e.transcriptionMu.Lock()
+		e.cancelTranscription = nil
+		e.transcriptionMu.Unlock()
+		cancel()
+	}
+}
+
+// CancelTranscription aborts the transcription in progress, nothing is delivered. It reports whether there was a transcription to cancel.
+func (e *Engine) CancelTranscription() bool {
+	e.transcriptionMu.Lock()
+	defer e.transcriptionMu.Unlock()
+
+	if e.cancelTranscription == nil {
+		return false
+	}
+	e.cancelTranscription()
+	e.cancelTranscription = nil
+	e.logger.Info(e.ctx, "canceling the transcription")
+	return true
+}
+
+// applyTranscriptionSettings configures the transcriber, shared by every transcription,
+// for the settings: the chunks transcribed at the same time and the decoding feature
+// flags. It is called again when the settings, the power source or the throttle level
+// change.
+func (e *Engine) applyTranscriptionSettings(settings config.Settings) {
+	e.transcriber.SetChunkWorkers(e.chunkWorkers(settings))
+	e.transcriber.SetTDTDecoding(settings.FeatureEnabled(config.FeatureTDTDecoding))
+	e.transcriber.SetStreamingPartials(settings.FeatureEnabled(config.FeatureStreamingDecode))
+}
+
+// chunkWorkers returns the number of chunks of a long recording transcribed at the same
+// time, reduced under CPU load or thermal pressure and to one with the battery saver.
+func (e *Engine) chunkWorkers(settings config.Settings) int {
+	if e.state.IsBatterySaverActive() {
+		return 1
+	}
+	return power.ThrottleLevel(e.state.GetThrottleLevel()).Workers(settings.Advanced.Workers())
+}
+
+// transcribeRemote sends the audio to the server within the configured latency budget.
+func (e *Engine) transcribeRemote(ctx context.Context, settings config.Settings, wavData []byte) (string, error) {
+	if budget := settings.RemoteTranscriptionMaxLatencySeconds; budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(budget)*time.Second)
+		defer cancel()
+	}
+
+	return e.remote.TranscribeWAV(ctx, wavData, settings.Language)
+}
+
+// loadVAD downloads and loads the voice activity detector if needed. Trimming silence is
+// an optimization, so failures are only logged and audio is transcribed untrimmed.
+func (e *Engine) loadVAD(progressCallback transcribe.DownloadProgressCallback) {
+	if e.vad.Loaded() {
+		return
+	}
+
+	if err := e.vad.DownloadModels(progressCallback); err != nil {
+		e.logger.Warn(e.ctx, "failed to download the VAD model, silence will not be trimmed", "err", err)
+		return
+	}
+
+	if err := e.vad.Load(); err != nil {
+		e.logger.Warn(e.ctx, "failed to load the VAD model, silence will not be trimmed", "err", err)
+	}
+}
+
+// trimSilence removes the silence of the samples before transcribing them. If no speech
+// is detected the samples are returned unchanged, so a false negative of the detector
+// never drops a dictation.
+func (e *Engine) trimSilence(samples []float32) []float32 {
+	if !e.vad.Loaded() {
+		return samples
+	}
+
+	trimmed, err := e.vad.TrimSilence(samples)
+	if err != nil {
+		e.logger.Warn(e.ctx, "failed to trim silence", "err", err)
+		return samples
+	}
+	if len(trimmed) == 0 {
+		e.logger.Debug(e.ctx, "no speech detected, transcribing the whole recording")
+		return samples
+	}
+
+	e.logger.Debug(e.ctx, "silence trimmed",
+		"original_seconds", float64(len(samples))/audio.SampleRate,
+		"trimmed_seconds", float64(len(trimmed))/audio.SampleRate,
+	)
+	return trimmed
+}
+
+// checkGPUFallback notifies the user, once, when a transcription that started on the GPU
+// switched to the CPU because the GPU ran out of memory. The transcriber keeps using the
+// CPU until the app restarts.
+func (e *Engine) checkGPUFallback(before transcribe.ExecutionProvider) {
+	if before != transcribe.ExecutionProviderCUDA || e.transcriber.ExecutionProvider() != transcribe.ExecutionProviderCPU {
+		return
+	}
+
+	e.logger.Warn(e.ctx, "GPU ran out of memory, transcribing on the CPU until restart")
+	if e.gpuFallbackNotified.CompareAndSwap(false, true) {
+		e.notifier.Info(e.ctx, "Switched to CPU",
+			"The GPU ran out of memory, transcriptions run on the CPU until the app restarts.")
+	}
+}
+
+// loadPunctuator downloads and loads the punctuation model if needed. Punctuation is
+// optional, so failures are only logged and texts are delivered as transcribed.
+func (e *Engine) loadPunctuator(progressCallback transcribe.DownloadProgressCallback) {
+	if e.punctuator.Loaded() {
+		return
+	}
+
+	if err := e.punctuator.DownloadModels(progressCallback); err != nil {
+		e.logger.Warn(e.ctx, "failed to download the punctuation model, punctuation will not be restored", "err", err)
+		return
+	}
+
+	if err := e.punctuator.Load(); err != nil {
+		e.logger.Warn(e.ctx, "failed to load the punctuation model, punctuation will not be restored", "err", err)
+	}
+}
+
+// punctuate restores the punctuation of a local transcription, returning the text
+// unchanged if the model is not loaded or fails.
+func (e *Engine) punctuate(text string) string {
+	if !e.punctuator.Loaded() {
+		return text
+	}
+
+	punctuated, err := e.punctuator.Punctuate(text)
+	if err != nil {
+		e.logger.Warn(e.ctx, "failed to restore punctuation", "err", err)
+		return text
+	}
+	return punctuated
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+// TranscribeUpload transcribes audio recorded on another device, e.g. a voice memo a phone
+// uploaded to the local server, in any format audio.Decode reads. The text goes through the
+// same pipeline as dictations, except that it is only copied to the clipboard since the
+// user is not waiting at a text field, never fills a form, and is returned. It fails while the engine is busy.
+func (e *Engine) TranscribeUpload(data []byte) (string, error) {
+	samples, err := audio.Decode(data)
+	if err != nil {
+		return "", fmt.Errorf("unsupported audio: %w", err)
+	}
+	if len(samples) == 0 {
+		return "", errors.New("the uploaded audio is empty")
+	}
+
+	e.toggleMu.Lock()
+	status, _ := e.state.GetStatus()
+	if status != state.StatusLoaded {
+		e.toggleMu.Unlock()
+		return "", errors.New("cannot transcribe while busy, try again later")
+	}
+	e.state.SetStatus(state.StatusTranscribing)
+	e.toggleMu.Unlock()
+
+	settings := e.settingsManager.Get()
+	settings.OutputMode = config.OutputModeCopyOnly
+	settings.FormTemplateID = ""
+	private := e.state.IsPrivacyModeActive()
+
+	wavData := audio.EncodeWAV(audio.Float32ToPCM16(samples), audio.SampleRate, 1)
+	audioPath := ""
+	if !private {
+		audioPath = e.generateAudioPath("upload")
+		if err := os.WriteFile(audioPath, wavData, 0644); err != nil {
+			e.handleError("failed to save uploaded audio", err)
+			return "", err
+		}
+	}
+
+	ctx, done := e.beginTranscription()
+	result, err := e.transcribe(ctx, settings, wavData)
+	done()
+	if errors.Is(err, context.Canceled) && e.ctx.Err() == nil {
+		e.logger.Info(e.ctx, "upload transcription canceled", "audio_path", audioPath)
+		e.state.SetStatus(state.StatusLoaded)
+		return "", err
+	}
+	if err != nil {
+		e.handleError("upload transcription failed", err)
+		return "", err
+	}
+
+	e.logger.Info(e.ctx, "uploaded audio transcribed", "seconds", float64(len(samples))/audio.SampleRate)
+	return e.deliver(settings, result, audioPath, "", private), nil
+}
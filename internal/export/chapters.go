@@ -0,0 +1,86 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// Chapter is a section of a session transcript.
+type Chapter struct {
+	Title string
+	// Start is the index of the first utterance of the chapter.
+	Start int
+}
+
+// PauseChapters splits a session into chapters at the pauses of at least minPause between
+// its utterances, titled "Part 1", "Part 2"... It returns nil if there is no such pause,
+// since a single chapter adds nothing to the transcript.
+func PauseChapters(session state.Session, minPause time.Duration) []Chapter {
+	if minPause <= 0 {
+		return nil
+	}
+
+	chapters := []Chapter{{Start: 0}}
+	for n := 1; n < len(session.Utterances); n++ {
+		if session.Utterances[n].Timestamp.Sub(session.Utterances[n-1].Timestamp) >= minPause {
+			chapters = append(chapters, Chapter{Start: n})
+		}
+	}
+	if len(chapters) < 2 {
+		return nil
+	}
+
+	for n := range chapters {
+		chapters[n].Title = fmt.Sprintf("Part %d", n+1)
+	}
+	return chapters
+}
+
+// NumberedTranscript renders the utterances of a session one per line, prefixed with their
+// number and timestamp ("3 [10:04:12] ..."), so an LLM can point at where chapters start.
+func NumberedTranscript(session state.Session) string {
+	var sb strings.Builder
+	for n, utterance := range session.Utterances {
+		fmt.Fprintf(&sb, "%d [%s] %s\n", n+1, utterance.Timestamp.Format(timeLayout), utterance.Text)
+	}
+	return sb.String()
+}
+
+// ParseChapters reads the chapters an LLM found in a NumberedTranscript, one per line as
+// "<number of the first utterance>: <title>". Invalid lines and numbers out of range are
+// skipped, and the first chapter always starts at the first utterance. It returns nil if
+// fewer than two chapters remain.
+func ParseChapters(output string, utterances int) []Chapter {
+	starts := make(map[int]string)
+	for _, line := range strings.Split(output, "\n") {
+		number, title, ok := strings.Cut(strings.TrimSpace(strings.TrimLeft(line, "-*• ")), ":")
+		if !ok {
+			continue
+		}
+		start, err := strconv.Atoi(strings.TrimSpace(number))
+		title = strings.TrimSpace(title)
+		if err != nil || start < 1 || start > utterances || title == "" {
+			continue
+		}
+		if _, seen := starts[start-1]; !seen {
+			starts[start-1] = title
+		}
+	}
+
+	chapters := make([]Chapter, 0, len(starts))
+	for start, title := range starts {
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	sort.Slice(chapters, func(a, b int) bool { return chapters[a].Start < chapters[b].Start })
+
+	if len(chapters) < 2 {
+		return nil
+	}
+	chapters[0].Start = 0
+	return chapters
+}
@@ -0,0 +1,99 @@
+// Package export renders transcripts into standalone documents that can be saved or shared.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+const (
+	dateTimeLayout = "2006-01-02 15:04:05"
+	timeLayout     = "15:04:05"
+)
+
+// SessionMarkdown renders all utterances of a session as a Markdown document with
+// a timestamp for every utterance, preceded by the session summary when available. The
+// chapters, if any, are listed after the summary and head their utterances, and the
+// markers added while recording follow their utterance.
+func SessionMarkdown(session state.Session, chapters []Chapter) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\n", session.Name)
+	fmt.Fprintf(&sb, "- Started: %s\n", session.StartedAt.Format(dateTimeLayout))
+	fmt.Fprintf(&sb, "- Last activity: %s\n", session.LastActivity.Format(dateTimeLayout))
+	fmt.Fprintf(&sb, "- Utterances: %d\n", len(session.Utterances))
+
+	if session.Summary != "" {
+		fmt.Fprintf(&sb, "\n## Summary\n\n%s\n", strings.TrimSpace(session.Summary))
+	}
+
+	if len(chapters) > 0 {
+		sb.WriteString("\n## Chapters\n\n")
+		for _, chapter := range chapters {
+			fmt.Fprintf(&sb, "- [%s] %s\n", session.Utterances[chapter.Start].Timestamp.Format(timeLayout), chapter.Title)
+		}
+	}
+
+	if session.Summary != "" || len(chapters) > 0 {
+		sb.WriteString("\n## Transcript\n")
+	}
+
+	next := 0
+	for n, utterance := range session.Utterances {
+		if next < len(chapters) && chapters[next].Start == n {
+			fmt.Fprintf(&sb, "\n### [%s] %s\n", utterance.Timestamp.Format(timeLayout), chapters[next].Title)
+			next++
+		}
+		fmt.Fprintf(&sb, "\n**[%s]** %s\n", utterance.Timestamp.Format(timeLayout), utterance.Text)
+		writeMarkers(&sb, utterance.Markers)
+	}
+
+	return sb.String()
+}
+
+// SessionTranscript renders the utterances of a session as plain text, one timestamped
+// line per utterance, suitable as input for an LLM.
+func SessionTranscript(session state.Session) string {
+	var sb strings.Builder
+	for _, utterance := range session.Utterances {
+		fmt.Fprintf(&sb, "[%s] %s\n", utterance.Timestamp.Format(timeLayout), utterance.Text)
+	}
+	return sb.String()
+}
+
+// HistoryMarkdown renders history entries as a Markdown document, one timestamped
+// paragraph per entry followed by its markers and tags.
+func HistoryMarkdown(title string, entries []state.HistoryEntry) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	fmt.Fprintf(&sb, "- Entries: %d\n", len(entries))
+
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "\n**[%s]** %s\n", entry.Timestamp.Format(dateTimeLayout), entry.Text)
+		writeMarkers(&sb, entry.Markers)
+		if len(entry.Tags) > 0 {
+			fmt.Fprintf(&sb, "\n_Tags: %s_\n", strings.Join(entry.Tags, ", "))
+		}
+	}
+
+	return sb.String()
+}
+
+// writeMarkers lists the markers of a dictation with their position in its recording.
+func writeMarkers(sb *strings.Builder, markers []state.Marker) {
+	if len(markers) == 0 {
+		return
+	}
+
+	sb.WriteString("\n")
+	for _, marker := range markers {
+		if marker.Note == "" {
+			fmt.Fprintf(sb, "- Marker at %s\n", marker.Position())
+			continue
+		}
+		fmt.Fprintf(sb, "- Marker at %s: %s\n", marker.Position(), marker.Note)
+	}
+}
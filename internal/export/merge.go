@@ -0,0 +1,26 @@
+package export
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// MergeTranscripts joins the texts of history entries in chronological order with the
+// separator, in which ${time} is replaced with the time of the entry that follows it.
+func MergeTranscripts(entries []state.HistoryEntry, separator string) string {
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, func(a, b state.HistoryEntry) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	var sb strings.Builder
+	for n, entry := range sorted {
+		if n > 0 {
+			sb.WriteString(strings.ReplaceAll(separator, "${time}", entry.Timestamp.Format(dateTimeLayout)))
+		}
+		sb.WriteString(strings.TrimSpace(entry.Text))
+	}
+	return sb.String()
+}
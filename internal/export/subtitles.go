@@ -0,0 +1,91 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+)
+
+// SubtitleFormat is a subtitle file format.
+type SubtitleFormat string
+
+const (
+	SubtitleSRT SubtitleFormat = "srt"
+	SubtitleVTT SubtitleFormat = "vtt"
+)
+
+// maxSubtitleLine is the length past which a cue is broken into two lines.
+const maxSubtitleLine = 42
+
+// ParseSubtitleFormat returns the format with the given name, SRT if it is empty.
+func ParseSubtitleFormat(name string) (SubtitleFormat, error) {
+	switch format := SubtitleFormat(strings.ToLower(strings.TrimPrefix(name, "."))); format {
+	case "", SubtitleSRT:
+		return SubtitleSRT, nil
+	case SubtitleVTT:
+		return SubtitleVTT, nil
+	default:
+		return "", fmt.Errorf("unknown subtitle format %q, expected srt or vtt", name)
+	}
+}
+
+// Subtitles renders segments as a SubRip (.srt) or WebVTT (.vtt) file, one cue per
+// segment.
+func Subtitles(format SubtitleFormat, segments []state.Segment) string {
+	var sb strings.Builder
+
+	separator := ","
+	if format == SubtitleVTT {
+		separator = "."
+		sb.WriteString("WEBVTT\n\n")
+	}
+
+	for n, segment := range segments {
+		if format == SubtitleSRT {
+			fmt.Fprintf(&sb, "%d\n", n+1)
+		}
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n",
+			subtitleTime(segment.Start, separator),
+			subtitleTime(segment.End, separator),
+			wrapCue(segment.Text),
+		)
+	}
+
+	return sb.String()
+}
+
+// subtitleTime formats a position in the audio as hours, minutes, seconds and
+// milliseconds, with the separator before the milliseconds of the format.
+func subtitleTime(d time.Duration, separator string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, separator, ms%1000)
+}
+
+// wrapCue breaks a long cue text into two lines at the space closest to its middle.
+func wrapCue(text string) string {
+	if len(text) <= maxSubtitleLine {
+		return text
+	}
+
+	middle := len(text) / 2
+	best := -1
+	for n, r := range text {
+		if r == ' ' && (best < 0 || abs(n-middle) < abs(best-middle)) {
+			best = n
+		}
+	}
+	if best < 0 {
+		return text
+	}
+	return text[:best] + "\n" + text[best+1:]
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
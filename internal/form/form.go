@@ -0,0 +1,149 @@
+// Package form fills the named fields of a form template by voice, for repetitive form
+// entry: dictations are added to the current field, spoken commands ("next field",
+// "previous field", "finish form") move between the fields or finish the form, and the
+// filled form is rendered with the layout of the template. Commands are matched whole,
+// ignoring case and the punctuation the transcriber adds around them.
+package form
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// Commands are the phrases of the spoken commands.
+type Commands struct {
+	Next     []string
+	Previous []string
+	Finish   []string
+}
+
+// Filler is a form being filled. It is not safe for concurrent use.
+type Filler struct {
+	template config.FormTemplate
+	values   []string
+	current  int
+}
+
+// New starts filling a form with the template, from its first field.
+func New(template config.FormTemplate) *Filler {
+	return &Filler{
+		template: template,
+		values:   make([]string, len(template.Fields)),
+	}
+}
+
+// TemplateID returns the ID of the template being filled.
+func (f *Filler) TemplateID() string {
+	return f.template.ID
+}
+
+// Field returns the name of the field the next dictation fills.
+func (f *Filler) Field() string {
+	return f.template.Fields[f.current]
+}
+
+// Feed adds a dictation to the form and runs the commands it contains, in order. It
+// returns true once the form is finished: a finish command was said or a next command
+// moved past the last field.
+func (f *Filler) Feed(text string, commands Commands) bool {
+	pattern := commandPattern(commands)
+	if pattern == nil {
+		f.add(text)
+		return false
+	}
+
+	start := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		f.add(text[start:match[0]])
+		start = match[1]
+
+		switch {
+		case match[2] >= 0:
+			if f.current == len(f.values)-1 {
+				return true
+			}
+			f.current++
+		case match[4] >= 0:
+			f.current = max(f.current-1, 0)
+		case match[6] >= 0:
+			return true
+		}
+	}
+	f.add(text[start:])
+	return false
+}
+
+// add appends a piece of dictation to the current field.
+func (f *Filler) add(text string) {
+	text = clean(text)
+	if text == "" {
+		return
+	}
+	if f.values[f.current] != "" {
+		text = f.values[f.current] + " " + text
+	}
+	f.values[f.current] = text
+}
+
+// Render returns the filled form: the layout of the template with ${<field name>}
+// replaced by the text of the fields or, without layout, a "<field name>: <text>" line
+// per field.
+func (f *Filler) Render() string {
+	if f.template.Layout == "" {
+		lines := make([]string, 0, len(f.values))
+		for i, field := range f.template.Fields {
+			lines = append(lines, field+": "+f.values[i])
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	replacements := make([]string, 0, 2*len(f.values))
+	for i, field := range f.template.Fields {
+		replacements = append(replacements, "${"+field+"}", f.values[i])
+	}
+	return strings.NewReplacer(replacements...).Replace(f.template.Layout)
+}
+
+// commandPattern matches any command phrase with the punctuation after it, capturing
+// the next, previous or finish phrases in the groups 1, 2 and 3. It is nil if there are
+// no phrases.
+func commandPattern(commands Commands) *regexp.Regexp {
+	groups := make([]string, 0, 3)
+	empty := true
+	for _, phrases := range [][]string{commands.Next, commands.Previous, commands.Finish} {
+		alternatives := make([]string, 0, len(phrases))
+		for _, phrase := range phrases {
+			words := strings.Fields(phrase)
+			for i, word := range words {
+				words[i] = regexp.QuoteMeta(word)
+			}
+			if len(words) > 0 {
+				alternatives = append(alternatives, strings.Join(words, `[\s,]+`))
+			}
+		}
+		if len(alternatives) == 0 {
+			// A group that never matches keeps the group numbers of the others.
+			groups = append(groups, `(\z.)`)
+			continue
+		}
+		empty = false
+		groups = append(groups, "("+strings.Join(alternatives, "|")+")")
+	}
+	if empty {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(groups, "|") + `)\b[\s,.;:!?]*`)
+}
+
+// clean trims a field text of the spaces and punctuation left around the commands. The
+// final period the transcriber adds to a short value, such as a name or a subject, is
+// removed, while a value of several sentences keeps it.
+func clean(text string) string {
+	text = strings.Trim(text, " \t\n,;:")
+	if trimmed, ok := strings.CutSuffix(text, "."); ok && !strings.ContainsAny(trimmed, ".!?") {
+		text = trimmed
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	// fileMagic starts the synced file, followed by the salt, the nonce and the sealed
+	// data.
+	fileMagic = "TRIBARH1"
+	saltSize  = 16
+	// keyIterations is the PBKDF2-HMAC-SHA256 work factor recommended by OWASP.
+	keyIterations = 600_000
+)
+
+// ErrWrongPassphrase is returned when the synced file cannot be decrypted, because it was
+// encrypted with another passphrase or altered.
+var ErrWrongPassphrase = errors.New("the synced history cannot be decrypted, check that the passphrase is the same on every machine")
+
+// encrypt seals the data with AES-256-GCM and a key derived from the passphrase, so the
+// server only sees random bytes.
+func encrypt(passphrase string, data []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := append(append([]byte(fileMagic), salt...), nonce...)
+	// The header is authenticated too, so it cannot be swapped.
+	return aead.Seal(header, nonce, data, header), nil
+}
+
+// decrypt opens data sealed by encrypt.
+func decrypt(passphrase string, sealed []byte) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, []byte(fileMagic)) || len(sealed) < len(fileMagic)+saltSize {
+		return nil, errors.New("the synced history is not a history file")
+	}
+	salt := sealed[len(fileMagic) : len(fileMagic)+saltSize]
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := len(fileMagic) + saltSize + aead.NonceSize()
+	if len(sealed) < headerSize {
+		return nil, errors.New("the synced history is truncated")
+	}
+	header := sealed[:headerSize]
+	data, err := aead.Open(nil, header[len(fileMagic)+saltSize:], sealed[headerSize:], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
+
+// newAEAD returns the AES-256-GCM cipher of the key derived from the passphrase and salt.
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, keyIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
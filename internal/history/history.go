@@ -0,0 +1,36 @@
+// Package history persists the transcription history of the app state, so it survives
+// restarts, and syncs it between the machines of the user through an end-to-end encrypted
+// file on a WebDAV or S3-compatible server.
+package history
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+const defaultFileName = "history.db"
+
+// Store persists the history entries. The engine saves every entry added to or changed in
+// the state, and the state is loaded from it on startup.
+type Store interface {
+	// Load returns the stored entries, newest first.
+	Load() ([]state.HistoryEntry, error)
+	// Save stores the entry, replacing the stored entry with the same ID.
+	Save(entry state.HistoryEntry) error
+	// Trim deletes all but the newest limit entries.
+	Trim(limit int) error
+	// Delete deletes the entries with the given UIDs and records when they were deleted,
+	// so the sync removes them on the other machines too.
+	Delete(deletions map[string]time.Time) error
+	// Deletions returns the recorded deletions, the time each UID was deleted.
+	Deletions() (map[string]time.Time, error)
+	Close() error
+}
+
+// FilePath returns the location of the history database.
+func FilePath() string {
+	return filepath.Join(config.DirectoryData, defaultFileName)
+}
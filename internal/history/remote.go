@@ -0,0 +1,199 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	// maxFileSize bounds the synced file read from the server.
+	maxFileSize = 64 << 20
+)
+
+var (
+	// ErrNotFound is returned by Remote.Get when the file does not exist yet.
+	ErrNotFound = errors.New("the synced history does not exist")
+	// ErrConflict is returned by Remote.Put when the file changed since it was read.
+	ErrConflict = errors.New("the synced history changed since it was read")
+)
+
+// Remote is the server the encrypted history file is synced with. Versions are opaque,
+// such as HTTP entity tags, and let Put detect the changes made by other machines.
+type Remote interface {
+	// Get returns the file and its version, or ErrNotFound.
+	Get(ctx context.Context) ([]byte, string, error)
+	// Put replaces the file if it is still at version, or creates it if version is empty
+	// and it does not exist, and returns ErrConflict otherwise.
+	Put(ctx context.Context, data []byte, version string) error
+}
+
+// httpRemote is a Remote storing the file at a URL with GET and conditional PUT
+// requests, which both WebDAV servers and S3-compatible storages support. They differ in
+// how requests are authenticated.
+type httpRemote struct {
+	client *http.Client
+	url    string
+	sign   func(req *http.Request, body []byte)
+}
+
+// newRemote returns the Remote of the sync settings.
+func newRemote(client *http.Client, settings config.Settings) (Remote, error) {
+	parsed, err := url.Parse(settings.HistorySyncURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid history sync URL %q", settings.HistorySyncURL)
+	}
+
+	remote := &httpRemote{client: client, url: settings.HistorySyncURL}
+	switch settings.HistorySyncBackend {
+	case config.HistorySyncWebDAV:
+		remote.sign = func(req *http.Request, _ []byte) {
+			if settings.HistorySyncUsername != "" || settings.HistorySyncPassword != "" {
+				req.SetBasicAuth(settings.HistorySyncUsername, settings.HistorySyncPassword)
+			}
+		}
+	case config.HistorySyncS3:
+		if parsed.RawQuery != "" {
+			return nil, fmt.Errorf("the S3 object URL %q must not have a query", settings.HistorySyncURL)
+		}
+		region := settings.HistorySyncRegion
+		if region == "" {
+			region = defaultS3Region
+		}
+		remote.sign = func(req *http.Request, body []byte) {
+			signV4(req, body, settings.HistorySyncUsername, settings.HistorySyncPassword, region, time.Now())
+		}
+	default:
+		return nil, fmt.Errorf("unknown history sync backend %q", settings.HistorySyncBackend)
+	}
+	return remote, nil
+}
+
+// Get returns the file and its entity tag.
+func (r *httpRemote) Get(ctx context.Context) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	r.sign(req, nil)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download the synced history: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download the synced history: %s", responseError(resp))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download the synced history: %w", err)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Put uploads the file with an If-Match or If-None-Match precondition.
+func (r *httpRemote) Put(ctx context.Context, data []byte, version string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, r.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if version == "" {
+		req.Header.Set("If-None-Match", "*")
+	}
+	if version != "" {
+		req.Header.Set("If-Match", version)
+	}
+	r.sign(req, data)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload the synced history: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// S3 answers 409 when a conditional write races with another one.
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to upload the synced history: %s", responseError(resp))
+	}
+	return nil
+}
+
+// responseError describes an unexpected response with the start of its body, where
+// servers explain the error.
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return fmt.Sprintf("%s: %s", resp.Status, text)
+	}
+	return resp.Status
+}
+
+// signV4 signs an S3 request with AWS Signature Version 4, the authentication of S3 and
+// the storages compatible with it.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"", // No query
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,166 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/varavelio/tribar/internal/state"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+)
+
+// schema stores every entry as JSON, so new fields of state.HistoryEntry need no
+// migration; only the columns the queries use are separate. deletions keeps the UIDs of
+// the deleted entries for the sync.
+const schema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id        INTEGER PRIMARY KEY,
+	timestamp INTEGER NOT NULL,
+	data      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_timestamp ON entries (timestamp);
+CREATE TABLE IF NOT EXISTS deletions (
+	uid        TEXT PRIMARY KEY,
+	deleted_at INTEGER NOT NULL
+);
+`
+
+// SQLiteStore is the default Store, a local SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it if it does not exist.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// SQLite allows a single writer, one connection avoids "database is locked" errors.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create history database: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Load returns the stored entries, newest first.
+func (s *SQLiteStore) Load() ([]state.HistoryEntry, error) {
+	rows, err := s.db.Query(`SELECT data FROM entries ORDER BY timestamp DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]state.HistoryEntry, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read history entry: %w", err)
+		}
+		var entry state.HistoryEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Save stores the entry, replacing the stored entry with the same ID.
+func (s *SQLiteStore) Save(entry state.HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO entries (id, timestamp, data) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET timestamp = excluded.timestamp, data = excluded.data`,
+		entry.ID, entry.Timestamp.UnixNano(), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save history entry %d: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// Trim deletes all but the newest limit entries.
+func (s *SQLiteStore) Trim(limit int) error {
+	_, err := s.db.Exec(
+		`DELETE FROM entries WHERE id NOT IN (
+			SELECT id FROM entries ORDER BY timestamp DESC, id DESC LIMIT ?
+		)`,
+		max(limit, 0),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to trim history: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the entries with the given UIDs and records when they were deleted, the
+// earliest time if a UID was already deleted.
+func (s *SQLiteStore) Delete(deletions map[string]time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete history entries: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for uid, deletedAt := range deletions {
+		if _, err := tx.Exec(`DELETE FROM entries WHERE json_extract(data, '$.uid') = ?`, uid); err != nil {
+			return fmt.Errorf("failed to delete history entry %s: %w", uid, err)
+		}
+		_, err := tx.Exec(
+			`INSERT INTO deletions (uid, deleted_at) VALUES (?, ?)
+			ON CONFLICT (uid) DO UPDATE SET deleted_at = min(deleted_at, excluded.deleted_at)`,
+			uid, deletedAt.UnixNano(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record the deletion of history entry %s: %w", uid, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete history entries: %w", err)
+	}
+	return nil
+}
+
+// Deletions returns the recorded deletions, the time each UID was deleted.
+func (s *SQLiteStore) Deletions() (map[string]time.Time, error) {
+	rows, err := s.db.Query(`SELECT uid, deleted_at FROM deletions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history deletions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	deletions := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			uid       string
+			deletedAt int64
+		)
+		if err := rows.Scan(&uid, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to read history deletion: %w", err)
+		}
+		deletions[uid] = time.Unix(0, deletedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
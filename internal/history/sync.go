@@ -0,0 +1,183 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/state"
+)
+
+const (
+	syncTimeout = 30 * time.Second
+	// syncAttempts is how many times a sync starts over when another machine uploads the
+	// file in the meantime.
+	syncAttempts = 3
+	// fileVersion is the version of the synced file contents. Version 2 added the
+	// deletions, older versions would drop them and bring deleted entries back.
+	fileVersion = 2
+)
+
+// syncedFile is the content of the synced file before encryption. Deleted holds the UIDs
+// of the entries deleted on any machine and when they were deleted.
+type syncedFile struct {
+	Version int                  `json:"version"`
+	Entries []state.HistoryEntry `json:"entries"`
+	Deleted map[string]time.Time `json:"deleted,omitempty"`
+}
+
+// Syncer syncs the history with the server of the settings.
+type Syncer struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	client          *http.Client
+
+	// mu serializes the syncs, e.g. a periodic one and one requested by the user.
+	mu sync.Mutex
+}
+
+// NewSyncer creates a new history syncer.
+func NewSyncer(logger logger.Logger, settingsManager *config.SettingsManager) *Syncer {
+	return &Syncer{
+		logger:          logger,
+		settingsManager: settingsManager,
+		client: &http.Client{
+			Timeout: syncTimeout,
+		},
+	}
+}
+
+// IsConfigured reports whether a sync backend is selected.
+func (s *Syncer) IsConfigured() bool {
+	return s.settingsManager.Get().HistorySyncBackend != config.HistorySyncOff
+}
+
+// Sync downloads the synced history, passes its entries to merge, which merges them into
+// the local history and returns it, and uploads the union of both in its place: the local
+// history limit does not apply to the synced file, so the older dictations of the other
+// machines are kept, and of the entries in both, matched by UID, the one changed last
+// wins. deleted are the local deletions; together with the synced ones they are passed to
+// merge, which deletes them from the local history, and uploaded, and the deleted entries
+// are left out of both histories, so a deletion always wins over a change. merge is called
+// again if another machine uploads the file in the meantime. The audio path and session of
+// the entries are not uploaded, they only make sense on the machine that recorded them.
+func (s *Syncer) Sync(ctx context.Context, deleted map[string]time.Time, merge func(remote []state.HistoryEntry, deleted map[string]time.Time) []state.HistoryEntry) error {
+	settings := s.settingsManager.Get()
+	if settings.HistorySyncPassphrase == "" {
+		return errors.New("a passphrase is required to encrypt the synced history")
+	}
+	remote, err := newRemote(s.client, settings)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for range syncAttempts {
+		sealed, version, err := remote.Get(ctx)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		file := syncedFile{Entries: make([]state.HistoryEntry, 0)}
+		if err == nil {
+			data, err := decrypt(settings.HistorySyncPassphrase, sealed)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, &file); err != nil {
+				return fmt.Errorf("failed to parse the synced history: %w", err)
+			}
+			if file.Version > fileVersion {
+				return fmt.Errorf("the synced history was written by a newer version of %s, update it on this machine", config.AppName)
+			}
+		}
+
+		deletions := unionDeletions(file.Deleted, deleted)
+		remoteEntries := withoutDeleted(file.Entries, deletions)
+		union := withoutDeleted(unionEntries(remoteEntries, merge(remoteEntries, deletions)), deletions)
+		file = syncedFile{Version: fileVersion, Entries: make([]state.HistoryEntry, 0, len(union)), Deleted: deletions}
+		for _, entry := range union {
+			entry.ID = 0
+			entry.AudioPath = ""
+			entry.SessionID = 0
+			file.Entries = append(file.Entries, entry)
+		}
+
+		data, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the synced history: %w", err)
+		}
+		sealed, err = encrypt(settings.HistorySyncPassphrase, data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt the synced history: %w", err)
+		}
+
+		err = remote.Put(ctx, sealed, version)
+		if errors.Is(err, ErrConflict) {
+			s.logger.Debug(ctx, "synced history changed during the sync, starting over")
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("the synced history kept changing, tried %d times", syncAttempts)
+}
+
+// unionEntries returns the remote and local entries, newest first, with a single copy of
+// the entries in both: the one changed last, the local one if they were changed at the
+// same time.
+func unionEntries(remote, local []state.HistoryEntry) []state.HistoryEntry {
+	index := make(map[string]int, len(remote)+len(local))
+	union := make([]state.HistoryEntry, 0, len(remote)+len(local))
+	for _, entries := range [][]state.HistoryEntry{remote, local} {
+		for _, entry := range entries {
+			idx, ok := index[entry.UID]
+			if !ok || entry.UID == "" {
+				index[entry.UID] = len(union)
+				union = append(union, entry)
+				continue
+			}
+			if !union[idx].Modified().After(entry.Modified()) {
+				union[idx] = entry
+			}
+		}
+	}
+
+	slices.SortStableFunc(union, func(a, b state.HistoryEntry) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	return union
+}
+
+// unionDeletions returns the remote and local deletions, with the earliest time of the
+// UIDs deleted in both.
+func unionDeletions(remote, local map[string]time.Time) map[string]time.Time {
+	union := make(map[string]time.Time, len(remote)+len(local))
+	for _, deletions := range []map[string]time.Time{remote, local} {
+		for uid, deletedAt := range deletions {
+			if earlier, ok := union[uid]; !ok || deletedAt.Before(earlier) {
+				union[uid] = deletedAt
+			}
+		}
+	}
+	return union
+}
+
+// withoutDeleted returns the entries whose UID was not deleted.
+func withoutDeleted(entries []state.HistoryEntry, deletions map[string]time.Time) []state.HistoryEntry {
+	kept := make([]state.HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := deletions[entry.UID]; !ok {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,548 @@
+// Package itn applies inverse text normalization to transcriptions, turning spoken forms
+// of numbers, amounts and dates into their written forms ("twenty five dollars" becomes
+// "$25"), so dictated numbers are usable without an LLM post-processing pass. Every
+// supported language has its own grammar, English is the fallback of the others.
+package itn
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a word of the text with the punctuation attached to it, e.g. `"twenty,`.
+type token struct {
+	lead, word, trail string
+}
+
+// lower returns the word in lower case, the form the vocabularies use.
+func (t token) lower() string {
+	return strings.ToLower(t.word)
+}
+
+// grammar reads the spoken numbers, amounts and dates of a language.
+type grammar struct {
+	// parseNumber parses the spoken number starting at tokens[i].
+	parseNumber func(tokens []token, i int) (number, bool)
+	// convertUnit converts a number followed by a currency or percent.
+	convertUnit func(tokens []token, parsed number) (string, int, bool)
+	// convertDate converts the date starting at tokens[i].
+	convertDate func(tokens []token, i int) (string, int, bool)
+	// formatNumber writes a number with its decimals.
+	formatNumber func(value int64, decimals string) string
+	// ordinalSuffix is written after ordinal numbers, nil if parseNumber never returns
+	// ordinals.
+	ordinalSuffix func(value int64) string
+	// isNumberWord reports whether a lower case word is a number word, to split
+	// hyphenated numbers.
+	isNumberWord func(word string) bool
+}
+
+var english = grammar{
+	parseNumber:   parseNumber,
+	convertUnit:   convertUnit,
+	convertDate:   convertDate,
+	formatNumber:  formatNumber,
+	ordinalSuffix: ordinalSuffix,
+	isNumberWord:  isNumberWord,
+}
+
+// grammars are the supported languages by ISO 639-1 code.
+var grammars = map[string]grammar{
+	"en": english,
+	"es": spanish,
+}
+
+// Apply rewrites the spoken numbers, currency amounts, percentages and dates of the text
+// in written form, with the grammar of the language: an ISO 639-1 code, optionally with a
+// region ("es-MX"), English for empty or unsupported languages. Single-digit numbers stay
+// spelled out ("one of them") unless they are part of an amount, and words it cannot
+// interpret unambiguously are left as is. Lines are kept, spaces inside them are
+// normalized.
+func Apply(text, language string) string {
+	g := languageGrammar(language)
+	lines := strings.Split(text, "\n")
+	for n, line := range lines {
+		lines[n] = g.applyLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// languageGrammar returns the grammar of a language, English if it has none.
+func languageGrammar(language string) grammar {
+	code, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(language)), "-")
+	code, _, _ = strings.Cut(code, "_")
+	if g, ok := grammars[code]; ok {
+		return g
+	}
+	return english
+}
+
+// applyLine normalizes a single line of text.
+func (g grammar) applyLine(line string) string {
+	tokens := g.tokenize(line)
+	out := make([]string, 0, len(tokens))
+
+	for i := 0; i < len(tokens); {
+		if written, next, ok := g.convertDate(tokens, i); ok {
+			out = append(out, written)
+			i = next
+			continue
+		}
+		if written, next, ok := g.convertNumber(tokens, i); ok {
+			out = append(out, written)
+			i = next
+			continue
+		}
+
+		out = append(out, tokens[i].lead+tokens[i].word+tokens[i].trail)
+		i++
+	}
+	return strings.Join(out, " ")
+}
+
+// tokenize splits a line into words, separating the surrounding punctuation and the parts
+// of hyphenated numbers ("twenty-five").
+func (g grammar) tokenize(line string) []token {
+	var tokens []token
+	for _, field := range strings.Fields(line) {
+		start := strings.IndexFunc(field, isWordRune)
+		if start < 0 {
+			tokens = append(tokens, token{lead: field})
+			continue
+		}
+		last := strings.LastIndexFunc(field, isWordRune)
+		_, size := utf8.DecodeRuneInString(field[last:])
+		end := last + size
+		tok := token{lead: field[:start], word: field[start:end], trail: field[end:]}
+
+		parts := strings.Split(tok.word, "-")
+		if len(parts) == 1 || !g.allNumberWords(parts) {
+			tokens = append(tokens, tok)
+			continue
+		}
+		for n, part := range parts {
+			split := token{word: part}
+			if n == 0 {
+				split.lead = tok.lead
+			}
+			if n == len(parts)-1 {
+				split.trail = tok.trail
+			}
+			tokens = append(tokens, split)
+		}
+	}
+	return tokens
+}
+
+// isWordRune reports whether r can be part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// allNumberWords reports whether every word is a number word.
+func (g grammar) allNumberWords(words []string) bool {
+	for _, word := range words {
+		if !g.isNumberWord(strings.ToLower(word)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumberWord reports whether a lower case word is an English cardinal or ordinal.
+func isNumberWord(word string) bool {
+	_, cardinal := cardinals[word]
+	_, ordinal := ordinals[word]
+	return cardinal || ordinal
+}
+
+// number is a spoken number parsed from the tokens.
+type number struct {
+	value int64
+	// decimals holds the digits said after "point", empty if none.
+	decimals string
+	// words is the number of words of the number, "twenty five" has two.
+	words   int
+	ordinal bool
+	// next is the index of the first token after the number.
+	next int
+}
+
+// parseNumber parses the English spoken number starting at tokens[i]. A number stops at the first
+// word that is not part of it, at punctuation and after an ordinal ("twenty first").
+func parseNumber(tokens []token, i int) (number, bool) {
+	var (
+		total, current int64
+		lastScale      int64 = 1 << 62
+		parsed         number
+		pendingAnd     bool
+	)
+
+	j := i
+	for ; j < len(tokens); j++ {
+		tok := tokens[j]
+		word := tok.lower()
+		if j > i && tok.lead != "" {
+			break
+		}
+
+		if word == "and" && parsed.words > 0 && current%100 == 0 && j+1 < len(tokens) && tok.trail == "" {
+			pendingAnd = true
+			continue
+		}
+
+		value, isCardinal := cardinals[word]
+		ordinalValue, isOrdinal := ordinals[word]
+		if isOrdinal {
+			value = ordinalValue
+		}
+
+		switch {
+		case (isCardinal || isOrdinal) && value < 100:
+			if !fitsBelowHundred(current, value) || (value == 0 && parsed.words > 0) {
+				return finishNumber(parsed, total+current, j, pendingAnd)
+			}
+			current += value
+		case word == "hundred" || word == "hundredth":
+			if current == 0 || current >= 100 {
+				return finishNumber(parsed, total+current, j, pendingAnd)
+			}
+			current *= 100
+			isOrdinal = word == "hundredth"
+		case scales[word] > 0 || scales[strings.TrimSuffix(word, "th")] > 0:
+			scale := scales[strings.TrimSuffix(word, "th")]
+			if current == 0 || scale >= lastScale {
+				return finishNumber(parsed, total+current, j, pendingAnd)
+			}
+			total += current * scale
+			current = 0
+			lastScale = scale
+			isOrdinal = strings.HasSuffix(word, "th")
+		case word == "point" && parsed.words > 0 && !parsed.ordinal && tok.trail == "":
+			decimals, next := parseDecimals(tokens, j+1)
+			if decimals == "" {
+				return finishNumber(parsed, total+current, j, pendingAnd)
+			}
+			parsed.decimals = decimals
+			parsed.words += next - j
+			parsed.value = total + current
+			parsed.next = next
+			return parsed, true
+		default:
+			return finishNumber(parsed, total+current, j, pendingAnd)
+		}
+
+		pendingAnd = false
+		parsed.words++
+		if isOrdinal {
+			parsed.ordinal = true
+			j++
+			break
+		}
+		if tok.trail != "" {
+			j++
+			break
+		}
+	}
+	return finishNumber(parsed, total+current, j, pendingAnd)
+}
+
+// finishNumber completes a number that ends before tokens[next]. A dangling "and" is
+// not part of it.
+func finishNumber(parsed number, value int64, next int, pendingAnd bool) (number, bool) {
+	if pendingAnd {
+		next--
+	}
+	parsed.value = value
+	parsed.next = next
+	return parsed, parsed.words > 0
+}
+
+// fitsBelowHundred reports whether a number word below a hundred can follow the current
+// value: units after tens ("twenty five"), anything after a round hundred.
+func fitsBelowHundred(current, value int64) bool {
+	rest := current % 100
+	if value >= 10 {
+		return rest == 0
+	}
+	return rest == 0 || (rest >= 20 && rest%10 == 0)
+}
+
+// parseDecimals parses the digits said after "point" ("three one four").
+func parseDecimals(tokens []token, i int) (string, int) {
+	var digits strings.Builder
+	j := i
+	for ; j < len(tokens); j++ {
+		word := tokens[j].lower()
+		value, ok := cardinals[word]
+		if word == "oh" {
+			value, ok = 0, true
+		}
+		if !ok || value > 9 || tokens[j].lead != "" {
+			break
+		}
+		digits.WriteString(strconv.FormatInt(value, 10))
+		if tokens[j].trail != "" {
+			j++
+			break
+		}
+	}
+	return digits.String(), j
+}
+
+// convertNumber converts the number starting at tokens[i], with the currency or percent
+// that follows it, and returns the written form and the index of the next token.
+func (g grammar) convertNumber(tokens []token, i int) (string, int, bool) {
+	parsed, ok := g.parseNumber(tokens, i)
+	if !ok {
+		return "", 0, false
+	}
+	lead := tokens[i].lead
+	last := tokens[parsed.next-1]
+
+	if !parsed.ordinal && last.trail == "" && parsed.next < len(tokens) {
+		if written, next, ok := g.convertUnit(tokens, parsed); ok {
+			return lead + written, next, true
+		}
+	}
+
+	// Numbers in a row ("twenty twenty", "one two three") are too ambiguous to rewrite
+	// outside of a date, the whole run is kept as said.
+	if last.trail == "" && parsed.next < len(tokens) {
+		if _, ok := g.parseNumber(tokens, parsed.next); ok {
+			return g.spokenRun(tokens, i, parsed.next), g.numberRunEnd(tokens, parsed.next), true
+		}
+	}
+
+	if parsed.value < 10 && parsed.words == 1 && parsed.decimals == "" {
+		return "", 0, false
+	}
+
+	written := g.formatNumber(parsed.value, parsed.decimals)
+	if parsed.ordinal {
+		written = strconv.FormatInt(parsed.value, 10) + g.ordinalSuffix(parsed.value)
+	}
+	return lead + written + last.trail, parsed.next, true
+}
+
+// numberRunEnd returns the index of the first token after the numbers that follow each
+// other from tokens[i].
+func (g grammar) numberRunEnd(tokens []token, i int) int {
+	for i < len(tokens) {
+		parsed, ok := g.parseNumber(tokens, i)
+		if !ok {
+			break
+		}
+		i = parsed.next
+		if tokens[i-1].trail != "" {
+			break
+		}
+	}
+	return i
+}
+
+// spokenRun returns the tokens of a run of numbers unchanged, from tokens[i] to the end of
+// the run that continues at tokens[next].
+func (g grammar) spokenRun(tokens []token, i, next int) string {
+	end := g.numberRunEnd(tokens, next)
+	words := make([]string, 0, end-i)
+	for _, tok := range tokens[i:end] {
+		words = append(words, tok.lead+tok.word+tok.trail)
+	}
+	return strings.Join(words, " ")
+}
+
+// convertUnit converts an English number followed by a currency or "percent". Cents can follow a
+// currency amount ("five dollars and twenty cents").
+func convertUnit(tokens []token, parsed number) (string, int, bool) {
+	unit := tokens[parsed.next]
+	word := unit.lower()
+	amount := formatNumber(parsed.value, parsed.decimals)
+
+	if word == "percent" {
+		return amount + "%" + unit.trail, parsed.next + 1, true
+	}
+	if word == "per" && parsed.next+1 < len(tokens) && tokens[parsed.next+1].lower() == "cent" && unit.trail == "" {
+		return amount + "%" + tokens[parsed.next+1].trail, parsed.next + 2, true
+	}
+
+	symbol, ok := currencies[word]
+	if !ok {
+		return "", 0, false
+	}
+	next := parsed.next + 1
+	trail := unit.trail
+
+	// "and twenty cents"
+	if parsed.decimals == "" && trail == "" && next < len(tokens) && tokens[next].lower() == "and" {
+		if cents, ok := parseNumber(tokens, next+1); ok && !cents.ordinal && cents.decimals == "" && cents.value < 100 &&
+			cents.next < len(tokens) && tokens[cents.next-1].trail == "" && subunits[tokens[cents.next].lower()] {
+			amount += "." + pad2(cents.value)
+			trail = tokens[cents.next].trail
+			next = cents.next + 1
+		}
+	}
+	return symbol + amount + trail, next, true
+}
+
+// convertDate converts a month followed by a day ("march third", "march the third") and
+// optionally a year ("twenty twenty four", "two thousand and five") to "March 3, 2024".
+func convertDate(tokens []token, i int) (string, int, bool) {
+	month, ok := months[tokens[i].lower()]
+	if !ok || tokens[i].trail != "" || i+1 >= len(tokens) {
+		return "", 0, false
+	}
+
+	j := i + 1
+	if tokens[j].lower() == "the" && tokens[j].trail == "" && j+1 < len(tokens) {
+		j++
+	}
+	day, ok := parseNumber(tokens, j)
+	if !ok || tokens[j].lead != "" || day.decimals != "" || day.value < 1 || day.value > 31 {
+		return "", 0, false
+	}
+	// A cardinal after "may" or "march" is usually not a date ("may two people...").
+	if !day.ordinal && j == i+1 {
+		return "", 0, false
+	}
+
+	written := month + " " + strconv.FormatInt(day.value, 10)
+	trail := tokens[day.next-1].trail
+	next := day.next
+
+	if trail == "" || trail == "," {
+		if year, yearNext, ok := parseYear(tokens, day.next); ok {
+			written += ", " + strconv.FormatInt(year, 10)
+			trail = tokens[yearNext-1].trail
+			next = yearNext
+		}
+	}
+	return tokens[i].lead + written + trail, next, true
+}
+
+// parseYear parses a spoken year, either as a whole number ("two thousand and five") or
+// as two pairs of digits ("nineteen ninety nine", "twenty oh five").
+func parseYear(tokens []token, i int) (int64, int, bool) {
+	if i >= len(tokens) || tokens[i].lead != "" {
+		return 0, 0, false
+	}
+
+	first, ok := parseNumber(tokens, i)
+	if !ok || first.ordinal || first.decimals != "" {
+		return 0, 0, false
+	}
+	if first.value >= 1000 && first.value < 3000 && first.words > 1 {
+		return first.value, first.next, true
+	}
+	if first.value < 10 || first.value > 99 || tokens[first.next-1].trail != "" || first.next >= len(tokens) {
+		return 0, 0, false
+	}
+
+	j := first.next
+	if tokens[j].lower() == "oh" && tokens[j].trail == "" && j+1 < len(tokens) {
+		unit, ok := cardinals[tokens[j+1].lower()]
+		if ok && unit > 0 && unit < 10 {
+			return first.value*100 + unit, j + 2, true
+		}
+		return 0, 0, false
+	}
+
+	second, ok := parseNumber(tokens, j)
+	if !ok || second.ordinal || second.decimals != "" || second.value < 10 || second.value > 99 {
+		return 0, 0, false
+	}
+	return first.value*100 + second.value, second.next, true
+}
+
+// formatNumber writes a number with its decimals in the English notation ("12,500.5").
+func formatNumber(value int64, decimals string) string {
+	digits := groupThousands(value, ',')
+	if decimals != "" {
+		digits += "." + decimals
+	}
+	return digits
+}
+
+// groupThousands writes a number grouping the thousands with the separator from five
+// digits ("12,500"), so years and four-digit codes stay as they are said.
+func groupThousands(value int64, separator byte) string {
+	digits := strconv.FormatInt(value, 10)
+	if len(digits) <= 4 {
+		return digits
+	}
+
+	var grouped strings.Builder
+	for n, r := range digits {
+		if n > 0 && (len(digits)-n)%3 == 0 {
+			grouped.WriteByte(separator)
+		}
+		grouped.WriteRune(r)
+	}
+	return grouped.String()
+}
+
+// ordinalSuffix returns the English suffix of an ordinal number: st, nd, rd or th.
+func ordinalSuffix(value int64) string {
+	if value%100 >= 11 && value%100 <= 13 {
+		return "th"
+	}
+	switch value % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
+
+// pad2 formats a value below a hundred with two digits.
+func pad2(value int64) string {
+	if value < 10 {
+		return "0" + strconv.FormatInt(value, 10)
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+var cardinals = map[string]int64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+	"eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13,
+	"fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18,
+	"nineteen": 19, "twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60,
+	"seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+var ordinals = map[string]int64{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6, "seventh": 7,
+	"eighth": 8, "ninth": 9, "tenth": 10, "eleventh": 11, "twelfth": 12, "thirteenth": 13,
+	"fourteenth": 14, "fifteenth": 15, "sixteenth": 16, "seventeenth": 17,
+	"eighteenth": 18, "nineteenth": 19, "twentieth": 20, "thirtieth": 30, "fortieth": 40,
+	"fiftieth": 50, "sixtieth": 60, "seventieth": 70, "eightieth": 80, "ninetieth": 90,
+}
+
+var scales = map[string]int64{
+	"thousand": 1_000, "million": 1_000_000, "billion": 1_000_000_000,
+}
+
+var currencies = map[string]string{
+	"dollar": "$", "dollars": "$", "bucks": "$",
+	"euro": "€", "euros": "€",
+	"pound": "£", "pounds": "£",
+	"yen": "¥",
+}
+
+// subunits are the words of the fractional currency unit.
+var subunits = map[string]bool{
+	"cent": true, "cents": true, "pence": true,
+}
+
+var months = map[string]string{
+	"january": "January", "february": "February", "march": "March", "april": "April",
+	"may": "May", "june": "June", "july": "July", "august": "August",
+	"september": "September", "october": "October", "november": "November",
+	"december": "December",
+}
@@ -0,0 +1,275 @@
+package itn
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Spanish numbers are written as in Spain and most of Latin America: thousands grouped
+// with periods from five digits and decimals after a comma ("12.500,5"), with the currency
+// symbol and the percent sign after the amount ("25 €", "10 %"). Ordinals are gendered
+// words usually written as said, so they are only read as the day of a date ("primero de
+// marzo"). Words are matched without accents, as transcribers do not always write them.
+var spanish = grammar{
+	parseNumber:  parseSpanishNumber,
+	convertUnit:  convertSpanishUnit,
+	convertDate:  convertSpanishDate,
+	formatNumber: formatSpanishNumber,
+	isNumberWord: isSpanishNumberWord,
+}
+
+// unaccent removes the accents of a lower case Spanish word.
+var unaccent = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u")
+
+// spanishWord returns the word of the token in lower case without accents, the form the
+// Spanish vocabularies use.
+func spanishWord(tok token) string {
+	return unaccent.Replace(tok.lower())
+}
+
+// isSpanishNumberWord reports whether a lower case word is a Spanish number word.
+func isSpanishNumberWord(word string) bool {
+	word = unaccent.Replace(word)
+	_, cardinal := spanishCardinals[word]
+	return cardinal || spanishHundreds[word] > 0 || word == "mil" || word == "millon" || word == "millones"
+}
+
+// parseSpanishNumber parses the Spanish spoken number starting at tokens[i]: tens and units
+// are joined by "y" ("treinta y uno"), hundreds are words of their own ("doscientos") and
+// "mil", "millón" and "mil millones" are scales. A number stops at the first word that is
+// not part of it and at punctuation.
+func parseSpanishNumber(tokens []token, i int) (number, bool) {
+	var (
+		total, current int64
+		lastScale      int64 = 1 << 62
+		parsed         number
+		pendingY       bool
+	)
+
+	j := i
+	for ; j < len(tokens); j++ {
+		tok := tokens[j]
+		word := spanishWord(tok)
+		if j > i && tok.lead != "" {
+			break
+		}
+
+		if word == "y" && parsed.words > 0 && current%100 >= 30 && current%10 == 0 && j+1 < len(tokens) && tok.trail == "" {
+			pendingY = true
+			continue
+		}
+
+		value, isCardinal := spanishCardinals[word]
+
+		switch {
+		case pendingY:
+			// Only a unit can follow the "y" after the tens.
+			if !isCardinal || value < 1 || value > 9 {
+				return finishNumber(parsed, total+current, j, pendingY)
+			}
+			current += value
+		case isCardinal && parsed.words == 0 && (word == "un" || word == "una") && nextIsSpanishNumber(tokens, j):
+			// The article of a number ("un veinte por ciento").
+			return number{}, false
+		case isCardinal:
+			if current%100 != 0 || (value == 0 && parsed.words > 0) {
+				return finishNumber(parsed, total+current, j, pendingY)
+			}
+			current += value
+		case spanishHundreds[word] > 0:
+			// "ciento" is always followed by the rest of the number, alone it is the one of
+			// "por ciento".
+			if current != 0 || (word == "ciento" && !nextIsSpanishNumber(tokens, j)) {
+				return finishNumber(parsed, total+current, j, pendingY)
+			}
+			current = spanishHundreds[word]
+		case word == "mil":
+			if lastScale <= 1_000 {
+				return finishNumber(parsed, total+current, j, pendingY)
+			}
+			// "mil" alone is a thousand.
+			total += max(current, 1) * 1_000
+			current = 0
+			lastScale = 1_000
+		case word == "millon" || word == "millones":
+			switch {
+			case current == 0 && lastScale == 1_000 && total < 1_000_000:
+				// "tres mil millones"
+				total *= 1_000_000
+				lastScale = 1_000_000_000
+			case current == 0 || lastScale <= 1_000_000:
+				return finishNumber(parsed, total+current, j, pendingY)
+			default:
+				total += current * 1_000_000
+				current = 0
+				lastScale = 1_000_000
+			}
+		case (word == "coma" || word == "punto") && parsed.words > 0 && tok.trail == "":
+			decimals, next := parseSpanishDecimals(tokens, j+1)
+			if decimals == "" {
+				return finishNumber(parsed, total+current, j, pendingY)
+			}
+			parsed.decimals = decimals
+			parsed.words += next - j
+			parsed.value = total + current
+			parsed.next = next
+			return parsed, true
+		default:
+			return finishNumber(parsed, total+current, j, pendingY)
+		}
+
+		pendingY = false
+		parsed.words++
+		if tok.trail != "" {
+			j++
+			break
+		}
+	}
+	return finishNumber(parsed, total+current, j, pendingY)
+}
+
+// nextIsSpanishNumber reports whether a number below a thousand continues after
+// tokens[j].
+func nextIsSpanishNumber(tokens []token, j int) bool {
+	if j+1 >= len(tokens) || tokens[j].trail != "" || tokens[j+1].lead != "" {
+		return false
+	}
+	word := spanishWord(tokens[j+1])
+	_, cardinal := spanishCardinals[word]
+	return cardinal || spanishHundreds[word] > 0
+}
+
+// parseSpanishDecimals parses the digits said after "coma" or "punto" ("cinco cero").
+func parseSpanishDecimals(tokens []token, i int) (string, int) {
+	var digits strings.Builder
+	j := i
+	for ; j < len(tokens); j++ {
+		value, ok := spanishCardinals[spanishWord(tokens[j])]
+		if !ok || value > 9 || tokens[j].lead != "" {
+			break
+		}
+		digits.WriteString(strconv.FormatInt(value, 10))
+		if tokens[j].trail != "" {
+			j++
+			break
+		}
+	}
+	return digits.String(), j
+}
+
+// convertSpanishUnit converts a Spanish number followed by a currency or "por ciento".
+// Cents can follow a currency amount ("cinco euros con veinte céntimos").
+func convertSpanishUnit(tokens []token, parsed number) (string, int, bool) {
+	unit := tokens[parsed.next]
+	word := spanishWord(unit)
+	amount := formatSpanishNumber(parsed.value, parsed.decimals)
+
+	if word == "por" && parsed.next+1 < len(tokens) && spanishWord(tokens[parsed.next+1]) == "ciento" && unit.trail == "" {
+		return amount + " %" + tokens[parsed.next+1].trail, parsed.next + 2, true
+	}
+
+	symbol, ok := spanishCurrencies[word]
+	if !ok {
+		return "", 0, false
+	}
+	next := parsed.next + 1
+	trail := unit.trail
+
+	// "con veinte céntimos", "y veinte centavos"
+	if parsed.decimals == "" && trail == "" && next < len(tokens) && tokens[next].trail == "" &&
+		(spanishWord(tokens[next]) == "con" || spanishWord(tokens[next]) == "y") {
+		if cents, ok := parseSpanishNumber(tokens, next+1); ok && cents.decimals == "" && cents.value < 100 &&
+			cents.next < len(tokens) && tokens[cents.next-1].trail == "" && spanishSubunits[spanishWord(tokens[cents.next])] {
+			amount += "," + pad2(cents.value)
+			trail = tokens[cents.next].trail
+			next = cents.next + 1
+		}
+	}
+	return amount + " " + symbol + trail, next, true
+}
+
+// convertSpanishDate converts a day followed by "de" and a month ("veintitrés de marzo",
+// "primero de mayo") and optionally "de" and a year ("de dos mil veinticuatro") to
+// "23 de marzo de 2024". The month and the "de" are kept as said.
+func convertSpanishDate(tokens []token, i int) (string, int, bool) {
+	var day int64
+	next := i + 1
+	if spanishWord(tokens[i]) == "primero" {
+		day = 1
+	} else {
+		parsed, ok := parseSpanishNumber(tokens, i)
+		if !ok || parsed.decimals != "" || parsed.value < 1 || parsed.value > 31 {
+			return "", 0, false
+		}
+		day, next = parsed.value, parsed.next
+	}
+
+	if next+1 >= len(tokens) || tokens[next-1].trail != "" || spanishWord(tokens[next]) != "de" ||
+		tokens[next].trail != "" || tokens[next+1].lead != "" || !spanishMonths[spanishWord(tokens[next+1])] {
+		return "", 0, false
+	}
+
+	written := strconv.FormatInt(day, 10) + " " + tokens[next].word + " " + tokens[next+1].word
+	trail := tokens[next+1].trail
+	next += 2
+
+	if trail == "" && next+1 < len(tokens) && tokens[next].trail == "" &&
+		(spanishWord(tokens[next]) == "de" || spanishWord(tokens[next]) == "del") && tokens[next+1].lead == "" {
+		year, ok := parseSpanishNumber(tokens, next+1)
+		if ok && year.decimals == "" && year.value >= 1000 && year.value < 3000 {
+			written += " " + tokens[next].word + " " + strconv.FormatInt(year.value, 10)
+			trail = tokens[year.next-1].trail
+			next = year.next
+		}
+	}
+	return tokens[i].lead + written + trail, next, true
+}
+
+// formatSpanishNumber writes a number with its decimals in the Spanish notation
+// ("12.500,5").
+func formatSpanishNumber(value int64, decimals string) string {
+	digits := groupThousands(value, '.')
+	if decimals != "" {
+		digits += "," + decimals
+	}
+	return digits
+}
+
+var spanishCardinals = map[string]int64{
+	"cero": 0, "un": 1, "uno": 1, "una": 1, "dos": 2, "tres": 3, "cuatro": 4, "cinco": 5,
+	"seis": 6, "siete": 7, "ocho": 8, "nueve": 9, "diez": 10, "once": 11, "doce": 12,
+	"trece": 13, "catorce": 14, "quince": 15, "dieciseis": 16, "diecisiete": 17,
+	"dieciocho": 18, "diecinueve": 19, "veinte": 20, "veintiun": 21, "veintiuno": 21,
+	"veintiuna": 21, "veintidos": 22, "veintitres": 23, "veinticuatro": 24,
+	"veinticinco": 25, "veintiseis": 26, "veintisiete": 27, "veintiocho": 28,
+	"veintinueve": 29, "treinta": 30, "cuarenta": 40, "cincuenta": 50, "sesenta": 60,
+	"setenta": 70, "ochenta": 80, "noventa": 90,
+}
+
+var spanishHundreds = map[string]int64{
+	"cien": 100, "ciento": 100, "doscientos": 200, "doscientas": 200, "trescientos": 300,
+	"trescientas": 300, "cuatrocientos": 400, "cuatrocientas": 400, "quinientos": 500,
+	"quinientas": 500, "seiscientos": 600, "seiscientas": 600, "setecientos": 700,
+	"setecientas": 700, "ochocientos": 800, "ochocientas": 800, "novecientos": 900,
+	"novecientas": 900,
+}
+
+var spanishCurrencies = map[string]string{
+	"dolar": "$", "dolares": "$",
+	"peso": "$", "pesos": "$",
+	"euro": "€", "euros": "€",
+	"libra": "£", "libras": "£",
+	"yen": "¥", "yenes": "¥",
+}
+
+// spanishSubunits are the words of the fractional currency unit.
+var spanishSubunits = map[string]bool{
+	"centimo": true, "centimos": true, "centavo": true, "centavos": true,
+	"penique": true, "peniques": true,
+}
+
+var spanishMonths = map[string]bool{
+	"enero": true, "febrero": true, "marzo": true, "abril": true, "mayo": true,
+	"junio": true, "julio": true, "agosto": true, "septiembre": true, "setiembre": true,
+	"octubre": true, "noviembre": true, "diciembre": true,
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	networkManagerDest = "org.freedesktop.NetworkManager"
+	networkManagerPath = "/org/freedesktop/NetworkManager"
+)
+
+// NetworkManager NMMetered values that mean the connection is metered, either set by the
+// user or guessed from the device (e.g. a mobile broadband modem or a phone hotspot).
+const (
+	nmMeteredYes      = 1
+	nmMeteredGuessYes = 3
+)
+
+// Metered reports whether the primary connection is metered according to NetworkManager.
+// It fails when NetworkManager is not running.
+func Metered() (bool, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return false, fmt.Errorf("cannot connect to the system bus: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	variant, err := conn.Object(networkManagerDest, networkManagerPath).GetProperty(networkManagerDest + ".Metered")
+	if err != nil {
+		return false, fmt.Errorf("cannot read the NetworkManager metered state: %w", err)
+	}
+
+	metered, _ := variant.Value().(uint32)
+	return metered == nmMeteredYes || metered == nmMeteredGuessYes, nil
+}
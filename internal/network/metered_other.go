@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package network
+
+// Metered reports whether the connection is metered. macOS does not expose Low Data Mode
+// outside the Network framework, so connections are always considered unmetered.
+func Metered() (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package network
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// costScript prints the cost type of the internet connection: Unrestricted, Fixed,
+// Variable or Unknown, nothing when offline.
+const costScript = `$profile = [Windows.Networking.Connectivity.NetworkInformation, Windows.Networking.Connectivity, ContentType = WindowsRuntime]::GetInternetConnectionProfile()
+if ($profile) { $profile.GetConnectionCost().NetworkCostType }`
+
+// Metered reports whether the internet connection is metered, i.e. it has a data limit
+// (Fixed) or is charged by the byte (Variable), as set in the Windows network settings.
+func Metered() (bool, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", costScript)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.TrimSpace(string(output)) {
+	case "Fixed", "Variable":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,3 @@
+// Package network reports the state of the network connection, so large downloads can be
+// avoided on connections the user pays for by the byte.
+package network
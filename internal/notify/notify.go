@@ -4,9 +4,12 @@ package notify
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/gen2brain/beeep"
 	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/dispatch"
 	"github.com/varavelio/tribar/internal/logger"
 )
 
@@ -28,8 +31,10 @@ func DefaultSettings() Settings {
 
 // Instance handles desktop notifications.
 type Instance struct {
-	logger   logger.Logger
-	settings Settings
+	logger     logger.Logger
+	settings   Settings
+	dispatcher *dispatch.Instance
+	mu         sync.RWMutex
 }
 
 // New creates a new notification instance.
@@ -37,31 +42,51 @@ func New(logger logger.Logger, settings Settings) *Instance {
 	return &Instance{
 		logger:   logger,
 		settings: settings,
+		dispatcher: dispatch.New(logger, dispatch.Options{
+			Name:      "notify",
+			QueueSize: 8,
+			Timeout:   5 * time.Second,
+		}),
 	}
 }
 
+// Shutdown waits for the queued notifications to be delivered, giving up when ctx is done.
+func (n *Instance) Shutdown(ctx context.Context) {
+	n.dispatcher.Shutdown(ctx)
+}
+
 // UpdateSettings updates the notification settings.
 func (n *Instance) UpdateSettings(settings Settings) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	n.settings = settings
 }
 
 // GetSettings returns the current notification settings.
 func (n *Instance) GetSettings() Settings {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.settings
 }
 
 // Error displays an error notification if error notifications are enabled.
 func (n *Instance) Error(ctx context.Context, title, message string) {
-	if !n.settings.NotifyOnError {
+	if !n.GetSettings().NotifyOnError {
 		return
 	}
 
 	n.send(ctx, title, message)
 }
 
+// Info displays an informational notification. It is always sent since it is used to
+// report the outcome of actions explicitly triggered by the user.
+func (n *Instance) Info(ctx context.Context, title, message string) {
+	n.send(ctx, title, message)
+}
+
 // TranscriptionStarted displays a notification when transcription starts.
 func (n *Instance) TranscriptionStarted(ctx context.Context) {
-	if !n.settings.NotifyOnStart {
+	if !n.GetSettings().NotifyOnStart {
 		return
 	}
 
@@ -70,7 +95,7 @@ func (n *Instance) TranscriptionStarted(ctx context.Context) {
 
 // TranscriptionFinished displays a notification when transcription completes.
 func (n *Instance) TranscriptionFinished(ctx context.Context, text string) {
-	if !n.settings.NotifyOnFinish {
+	if !n.GetSettings().NotifyOnFinish {
 		return
 	}
 
@@ -82,13 +107,16 @@ func (n *Instance) TranscriptionFinished(ctx context.Context, text string) {
 	n.send(ctx, "Transcription Complete", message)
 }
 
-// send dispatches a notification to the desktop.
+// send queues a notification to be displayed on the desktop without blocking the caller.
 func (n *Instance) send(ctx context.Context, title, message string) {
-	if err := beeep.Notify(title, message, ""); err != nil {
-		n.logger.Error(ctx, "failed to send desktop notification",
-			"title", title,
-			"message", message,
-			"err", err,
-		)
-	}
+	n.dispatcher.Submit(func(_ context.Context) error {
+		if err := beeep.Notify(title, message, ""); err != nil {
+			n.logger.Error(ctx, "failed to send desktop notification",
+				"title", title,
+				"message", message,
+				"err", err,
+			)
+		}
+		return nil
+	})
 }
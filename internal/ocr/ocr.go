@@ -0,0 +1,115 @@
+// Package ocr extracts text from images so it can be delivered through the same output
+// pipeline as transcriptions. Recognition is delegated to the Tesseract command line tool,
+// which is not shipped with the app: Installed tells whether it is available and
+// InstallHint how to install it.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const recognizeTimeout = 60 * time.Second
+
+// ErrTesseractNotFound is returned when the tesseract binary is not installed.
+var ErrTesseractNotFound = errors.New("tesseract is not installed")
+
+// Instance recognizes text in images.
+type Instance struct {
+	logger logger.Logger
+}
+
+// New creates a new OCR instance.
+func New(logger logger.Logger) *Instance {
+	return &Instance{
+		logger: logger,
+	}
+}
+
+// Recognize returns the text found in a PNG image. The language uses Tesseract codes
+// such as "eng" or "spa+eng", an empty language uses the Tesseract default.
+func (o *Instance) Recognize(ctx context.Context, image []byte, language string) (string, error) {
+	binary, ok := findTesseract()
+	if !ok {
+		return "", fmt.Errorf("%w, install it with: %s", ErrTesseractNotFound, InstallHint())
+	}
+
+	imageFile, err := os.CreateTemp("", "tribar-ocr-*.png")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary image: %w", err)
+	}
+	defer func() { _ = os.Remove(imageFile.Name()) }()
+
+	_, writeErr := imageFile.Write(image)
+	closeErr := imageFile.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		return "", fmt.Errorf("failed to write temporary image: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, recognizeTimeout)
+	defer cancel()
+
+	args := []string{imageFile.Name(), "stdout"}
+	if language != "" {
+		args = append(args, "-l", language)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	text := normalizeWhitespace(string(output))
+	o.logger.Debug(ctx, "text recognized from image", "length", len(text))
+	return text, nil
+}
+
+// Installed reports whether the tesseract binary can be found.
+func Installed() bool {
+	_, ok := findTesseract()
+	return ok
+}
+
+// InstallHint returns how to install Tesseract on this platform.
+func InstallHint() string {
+	return installHintPlatform()
+}
+
+// findTesseract returns the path of the tesseract binary, looked up in the PATH and then
+// in the places its installers use, which desktop sessions often leave out of the PATH.
+func findTesseract() (string, bool) {
+	if binary, err := exec.LookPath("tesseract"); err == nil {
+		return binary, true
+	}
+	for _, binary := range tesseractPathsPlatform() {
+		if info, err := os.Stat(binary); err == nil && !info.IsDir() {
+			return binary, true
+		}
+	}
+	return "", false
+}
+
+// normalizeWhitespace joins the lines of each paragraph, since OCR output keeps the
+// visual line breaks of the image, and separates paragraphs with a blank line.
+func normalizeWhitespace(text string) string {
+	var paragraphs []string
+	for paragraph := range strings.SplitSeq(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		joined := strings.Join(strings.Fields(paragraph), " ")
+		if joined != "" {
+			paragraphs = append(paragraphs, joined)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
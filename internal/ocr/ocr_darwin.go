@@ -0,0 +1,12 @@
+package ocr
+
+// tesseractPathsPlatform returns where Homebrew installs tesseract on Apple silicon and
+// Intel Macs, apps started from the Finder do not have it in their PATH.
+func tesseractPathsPlatform() []string {
+	return []string{"/opt/homebrew/bin/tesseract", "/usr/local/bin/tesseract"}
+}
+
+// installHintPlatform returns the Homebrew command installing tesseract.
+func installHintPlatform() string {
+	return "brew install tesseract"
+}
@@ -0,0 +1,11 @@
+package ocr
+
+// tesseractPathsPlatform returns nothing, distribution packages install to the PATH.
+func tesseractPathsPlatform() []string {
+	return nil
+}
+
+// installHintPlatform names the package in the main distribution families, which differ.
+func installHintPlatform() string {
+	return "the tesseract-ocr package (Debian, Ubuntu) or tesseract (Fedora, Arch, openSUSE) of your distribution"
+}
@@ -0,0 +1,24 @@
+package ocr
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// tesseractPathsPlatform returns where the UB Mannheim installer puts tesseract, it does
+// not add it to the PATH.
+func tesseractPathsPlatform() []string {
+	var paths []string
+	if dir := os.Getenv("ProgramFiles"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "Tesseract-OCR", "tesseract.exe"))
+	}
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "Programs", "Tesseract-OCR", "tesseract.exe"))
+	}
+	return paths
+}
+
+// installHintPlatform returns the winget command installing tesseract.
+func installHintPlatform() string {
+	return "winget install UB-Mannheim.TesseractOCR"
+}
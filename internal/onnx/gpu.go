@@ -0,0 +1,53 @@
+package onnx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// GPURuntimeDir returns the directory where the GPU build of ONNX Runtime is looked for
+// by default. The GPU build is too large to be embedded, users extract the official
+// archive (e.g. onnxruntime-linux-x64-gpu-1.23.2.tgz) there, without its top-level
+// directory, so that the library ends up in its lib subdirectory.
+func GPURuntimeDir() string {
+	return filepath.Join(config.DirectoryOnnxRuntime, runtimeVersion, runtimePlatform+"-gpu")
+}
+
+// LocateGPUSharedLibrary returns the path of the GPU build of the ONNX Runtime shared
+// library, which includes the CUDA execution provider. customPath may point to the
+// library itself or to an extracted release directory; when empty, GPURuntimeDir is used.
+// The CUDA and cuDNN libraries must be installed in the system.
+func LocateGPUSharedLibrary(customPath string) (string, error) {
+	if runtime.GOOS == "darwin" {
+		return "", fmt.Errorf("CUDA is not supported on macOS")
+	}
+
+	dir := customPath
+	if dir == "" {
+		dir = GPURuntimeDir()
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("GPU runtime not found at %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return dir, nil
+	}
+
+	candidates := []string{
+		filepath.Join(dir, "lib", sharedLibName),
+		filepath.Join(dir, sharedLibName),
+	}
+	for _, candidate := range candidates {
+		if fileExists(candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("GPU runtime library %s not found in %s", sharedLibName, dir)
+}
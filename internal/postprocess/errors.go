@@ -0,0 +1,93 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetryAfter is the wait suggested for a rate limited request when the provider
+// does not send a Retry-After header.
+const defaultRetryAfter = 20 * time.Second
+
+// APIError is an HTTP error response of the LLM provider.
+type APIError struct {
+	StatusCode int
+	// Message is the error message of the provider, empty if the body had none.
+	Message string
+	// RetryAfter is the wait requested by the provider, only set on 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API error: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError builds the error of a failed response, reading the wait of the Retry-After
+// header, in seconds or as a date, for rate limited requests.
+func newAPIError(resp *http.Response, message string) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: message}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return apiErr
+	}
+
+	apiErr.RetryAfter = defaultRetryAfter
+	header := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		apiErr.RetryAfter = max(time.Until(date).Round(time.Second), 0)
+	}
+	return apiErr
+}
+
+// RetryDelay returns how long to wait before retrying a request that was rate limited,
+// false if the error is not a rate limit.
+func RetryDelay(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	return apiErr.RetryAfter, true
+}
+
+// Guidance describes a post-processing failure in words a user can act on, e.g. "API key
+// invalid, open the settings to update it", instead of the raw error.
+func Guidance(err error) string {
+	var apiErr *APIError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		switch code := apiErr.StatusCode; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return "API key invalid, open the settings to update it."
+		case code == http.StatusPaymentRequired:
+			return "The provider account has no credits left, check your billing."
+		case code == http.StatusNotFound:
+			return "Model or endpoint not found, check the model and base URL in the settings."
+		case code == http.StatusTooManyRequests:
+			return fmt.Sprintf("Rate limited by the provider, try again in %s.", apiErr.RetryAfter)
+		case code >= 500:
+			return fmt.Sprintf("The provider is having problems (HTTP %d), try again later.", code)
+		case apiErr.Message == "":
+			return fmt.Sprintf("The provider rejected the request (HTTP %d).", code)
+		default:
+			return fmt.Sprintf("The provider rejected the request: %s", apiErr.Message)
+		}
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		return "The provider took too long to answer, try again later."
+	case errors.As(err, &netErr):
+		return "Could not reach the provider, check your connection and the base URL."
+	default:
+		return err.Error()
+	}
+}
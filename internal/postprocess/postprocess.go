@@ -38,13 +38,19 @@ func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instanc
 
 // IsEnabled returns whether post-processing is enabled.
 func (p *Instance) IsEnabled() bool {
-	settings := p.settingsManager.Get()
-	return settings.PostProcessEnabled && settings.PostProcessAPIKey != ""
+	return p.settingsManager.Get().PostProcessEnabled && p.IsConfigured()
+}
+
+// IsConfigured returns whether the LLM provider is configured, regardless of whether
+// automatic post-processing of every transcription is enabled.
+func (p *Instance) IsConfigured() bool {
+	return p.settingsManager.Get().PostProcessAPIKey != ""
 }
 
-// Process enhances the transcription using the configured LLM.
-func (p *Instance) Process(ctx context.Context, text string) (string, error) {
-	if !p.IsEnabled() {
+// Process enhances the transcription using the LLM and the prompt selected in the given
+// settings, which may carry per-dictation overrides of the stored ones.
+func (p *Instance) Process(ctx context.Context, settings config.Settings, text string) (string, error) {
+	if !settings.PostProcessEnabled || !p.IsConfigured() {
 		return text, nil
 	}
 
@@ -52,27 +58,103 @@ func (p *Instance) Process(ctx context.Context, text string) (string, error) {
 		return text, nil
 	}
 
-	prompt := p.getSystemPrompt()
-	if prompt == "" {
+	prompt, ok := settings.FindPrompt(settings.PostProcessPromptID)
+	if !ok || prompt.Body == "" {
 		return text, nil
 	}
 
-	// Replace placeholder with actual transcription text
-	input := strings.ReplaceAll(prompt, "${output}", text)
+	// Replace placeholders with the actual transcription text and language hint
+	input := strings.ReplaceAll(prompt.Body, "${output}", text)
+	input = strings.ReplaceAll(input, "${language}", languageName(settings.Language))
 	return p.callAPI(ctx, input)
 }
 
-// getSystemPrompt returns the prompt body for the configured prompt ID.
-func (p *Instance) getSystemPrompt() string {
-	settings := p.settingsManager.Get()
+// languageName returns the value of the ${language} placeholder for a language hint.
+func languageName(language string) string {
+	if language == "" {
+		return "the same language as the text"
+	}
+	return language
+}
 
-	for _, prompt := range settings.Prompts {
-		if prompt.ID == settings.PostProcessPromptID {
-			return prompt.Body
-		}
+// Summarize generates a summary of a full transcript using the configured summary prompt.
+// Unlike Process, it returns an error instead of the original text when it fails.
+func (p *Instance) Summarize(ctx context.Context, transcript string) (string, error) {
+	if !p.IsConfigured() {
+		return "", fmt.Errorf("post-processing provider is not configured")
 	}
 
-	return ""
+	if strings.TrimSpace(transcript) == "" {
+		return "", fmt.Errorf("transcript is empty")
+	}
+
+	prompt := p.settingsManager.Get().SummaryPrompt
+	if prompt == "" {
+		return "", fmt.Errorf("summary prompt is not configured")
+	}
+
+	input := strings.ReplaceAll(prompt, "${output}", transcript)
+	summary, err := p.callAPI(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return summary, nil
+}
+
+// SegmentChapters asks the LLM where the topics of a numbered session transcript (see
+// export.NumberedTranscript) change, using the configured chapter prompt, and returns its
+// answer, one "<line number>: <title>" per line.
+func (p *Instance) SegmentChapters(ctx context.Context, transcript string) (string, error) {
+	if !p.IsConfigured() {
+		return "", fmt.Errorf("post-processing provider is not configured")
+	}
+
+	prompt := p.settingsManager.Get().ChapterPrompt
+	if prompt == "" {
+		return "", fmt.Errorf("chapter prompt is not configured")
+	}
+
+	input := strings.ReplaceAll(prompt, "${output}", transcript)
+	output, err := p.callAPI(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// ExtractActionItems asks the LLM for the action items of a transcription using the
+// configured action item prompt, returning one item per element.
+func (p *Instance) ExtractActionItems(ctx context.Context, text string) ([]string, error) {
+	if !p.IsConfigured() {
+		return nil, fmt.Errorf("post-processing provider is not configured")
+	}
+
+	prompt := p.settingsManager.Get().TodoPrompt
+	if prompt == "" {
+		return nil, fmt.Errorf("action item prompt is not configured")
+	}
+
+	input := strings.ReplaceAll(prompt, "${output}", text)
+	output, err := p.callAPI(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty completion is reported by returning the input unchanged
+	if output == input {
+		return nil, nil
+	}
+
+	var items []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*• "))
+		if line == "" || strings.EqualFold(line, "NONE") {
+			continue
+		}
+		items = append(items, line)
+	}
+	return items, nil
 }
 
 // chatRequest represents the OpenAI chat completion request.
@@ -139,8 +221,18 @@ func (p *Instance) callAPI(ctx context.Context, text string) (string, error) {
 	}
 
 	var chatResp chatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return text, fmt.Errorf("failed to parse response: %w", err)
+	parseErr := json.Unmarshal(body, &chatResp)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		message := ""
+		if chatResp.Error != nil {
+			message = chatResp.Error.Message
+		}
+		return text, newAPIError(resp, message)
+	}
+
+	if parseErr != nil {
+		return text, fmt.Errorf("failed to parse response: %w", parseErr)
 	}
 
 	if chatResp.Error != nil {
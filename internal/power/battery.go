@@ -0,0 +1,55 @@
+package power
+
+import (
+	"context"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const powerSourcePollInterval = 30 * time.Second
+
+// PowerSourceWatcher periodically checks whether the machine is running on battery.
+type PowerSourceWatcher struct {
+	logger   logger.Logger
+	interval time.Duration
+}
+
+// NewPowerSourceWatcher creates a new power source watcher.
+func NewPowerSourceWatcher(logger logger.Logger) *PowerSourceWatcher {
+	return &PowerSourceWatcher{
+		logger:   logger,
+		interval: powerSourcePollInterval,
+	}
+}
+
+// Run polls the power source until the context is canceled. onChange is called once with
+// the initial power source and then every time it changes.
+func (w *PowerSourceWatcher) Run(ctx context.Context, onChange func(onBattery bool)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	known := false
+	previous := false
+
+	for {
+		onBattery, err := OnBattery()
+		if err != nil {
+			w.logger.Debug(ctx, "power source detection unavailable", "err", err)
+			return
+		}
+
+		if !known || onBattery != previous {
+			w.logger.Debug(ctx, "power source changed", "on_battery", onBattery)
+			onChange(onBattery)
+			known = true
+			previous = onBattery
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build darwin
+
+package power
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// OnBattery reports whether the machine is currently running on battery power.
+func OnBattery() (bool, error) {
+	output, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(output), "'Battery Power'"), nil
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+// OnBattery reports whether the machine is currently running on battery power.
+// Machines without any battery (desktops) are always reported as plugged in.
+func OnBattery() (bool, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		return false, err
+	}
+
+	hasBattery := false
+	for _, entry := range entries {
+		supplyDir := filepath.Join(powerSupplyDir, entry.Name())
+
+		switch readSysfsValue(filepath.Join(supplyDir, "type")) {
+		case "Mains", "USB":
+			if readSysfsValue(filepath.Join(supplyDir, "online")) == "1" {
+				return false, nil
+			}
+		case "Battery":
+			// Peripheral batteries (mice, headsets) report scope "Device".
+			if readSysfsValue(filepath.Join(supplyDir, "scope")) != "Device" {
+				hasBattery = true
+			}
+		}
+	}
+
+	return hasBattery, nil
+}
+
+func readSysfsValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package power
+
+import "errors"
+
+// OnBattery is not supported on this platform.
+func OnBattery() (bool, error) {
+	return false, errors.New("power source detection is not supported on this platform")
+}
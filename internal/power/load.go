@@ -0,0 +1,113 @@
+package power
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const loadPollInterval = 5 * time.Second
+
+// ThrottleLevel indicates how much background work should be reduced to keep the
+// machine responsive under CPU load or thermal pressure.
+type ThrottleLevel int
+
+const (
+	ThrottleNone ThrottleLevel = iota
+	ThrottleLight
+	ThrottleHeavy
+)
+
+// Thresholds used to compute the throttle level.
+const (
+	lightLoadPerCore   = 0.7
+	heavyLoadPerCore   = 1.0
+	lightTemperatureC  = 75.0
+	heavyTemperatureC  = 85.0
+	unknownTemperature = -1.0
+)
+
+// String returns a human-readable name of the throttle level.
+func (l ThrottleLevel) String() string {
+	switch l {
+	case ThrottleNone:
+		return "none"
+	case ThrottleLight:
+		return "light"
+	case ThrottleHeavy:
+		return "heavy"
+	default:
+		return "unknown"
+	}
+}
+
+// Workers scales the maximum number of concurrent workers down according to the level,
+// always allowing at least one worker.
+func (l ThrottleLevel) Workers(maxWorkers int) int {
+	switch l {
+	case ThrottleLight:
+		return max(maxWorkers/2, 1)
+	case ThrottleHeavy:
+		return 1
+	default:
+		return max(maxWorkers, 1)
+	}
+}
+
+// LoadMonitor periodically samples CPU load and temperature to compute a throttle level.
+type LoadMonitor struct {
+	logger   logger.Logger
+	interval time.Duration
+}
+
+// NewLoadMonitor creates a new load monitor.
+func NewLoadMonitor(logger logger.Logger) *LoadMonitor {
+	return &LoadMonitor{
+		logger:   logger,
+		interval: loadPollInterval,
+	}
+}
+
+// Run samples the system load until the context is canceled. onChange is called once with
+// the initial throttle level and then every time it changes.
+func (m *LoadMonitor) Run(ctx context.Context, onChange func(level ThrottleLevel)) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	previous := ThrottleLevel(-1)
+
+	for {
+		load, err := loadAverage()
+		if err != nil {
+			m.logger.Debug(ctx, "load monitoring unavailable", "err", err)
+			return
+		}
+
+		level := computeThrottleLevel(load/float64(runtime.NumCPU()), maxTemperature())
+		if level != previous {
+			m.logger.Debug(ctx, "throttle level changed", "level", level.String(), "load", load)
+			onChange(level)
+			previous = level
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// computeThrottleLevel maps the per-core load and the hottest temperature (in Celsius,
+// negative when unknown) to a throttle level.
+func computeThrottleLevel(loadPerCore, temperature float64) ThrottleLevel {
+	if loadPerCore >= heavyLoadPerCore || temperature >= heavyTemperatureC {
+		return ThrottleHeavy
+	}
+	if loadPerCore >= lightLoadPerCore || temperature >= lightTemperatureC {
+		return ThrottleLight
+	}
+	return ThrottleNone
+}
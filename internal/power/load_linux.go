@@ -0,0 +1,43 @@
+//go:build linux
+
+package power
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadAverage returns the one minute load average of the system.
+func loadAverage() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected /proc/loadavg format")
+	}
+
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// maxTemperature returns the hottest thermal zone temperature in Celsius, or a negative
+// value when no thermal zone is readable.
+func maxTemperature() float64 {
+	zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*/temp")
+
+	hottest := unknownTemperature
+	for _, zone := range zones {
+		milliCelsius, err := strconv.ParseFloat(readSysfsValue(zone), 64)
+		if err != nil {
+			continue
+		}
+		hottest = max(hottest, milliCelsius/1000)
+	}
+
+	return hottest
+}
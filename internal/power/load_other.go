@@ -0,0 +1,15 @@
+//go:build !linux
+
+package power
+
+import "errors"
+
+// loadAverage is not supported on this platform.
+func loadAverage() (float64, error) {
+	return 0, errors.New("load monitoring is not supported on this platform")
+}
+
+// maxTemperature is not supported on this platform.
+func maxTemperature() float64 {
+	return unknownTemperature
+}
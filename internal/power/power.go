@@ -0,0 +1,36 @@
+// Package power observes platform power and session events (screen lock, sleep)
+// so the engine can react to them without knowing about the underlying OS APIs.
+package power
+
+import "github.com/varavelio/tribar/internal/logger"
+
+// SessionWatcher reports when the user session gets locked or the machine goes to sleep.
+type SessionWatcher struct {
+	logger logger.Logger
+}
+
+// NewSessionWatcher creates a new session watcher.
+func NewSessionWatcher(logger logger.Logger) *SessionWatcher {
+	return &SessionWatcher{
+		logger: logger,
+	}
+}
+
+// lockState tracks what makes the user session unusable: the machine sleeping, the screen
+// locked or another session in front (fast user switching). The session is locked while
+// any of them is, so waking up does not unlock a screen that is still locked.
+type lockState struct {
+	sleeping     bool
+	screenLocked bool
+	inactive     bool
+	reported     bool
+}
+
+// report calls onLockChange when the combined state changed since the last call.
+func (s *lockState) report(onLockChange func(locked bool)) {
+	locked := s.sleeping || s.screenLocked || s.inactive
+	if locked != s.reported {
+		s.reported = locked
+		onLockChange(locked)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build darwin
+
+#include <CoreFoundation/CoreFoundation.h>
+#include <IOKit/IOMessage.h>
+#include <IOKit/pwr_mgt/IOPMLib.h>
+
+#include "session_darwin.h"
+#include "_cgo_export.h"
+
+static io_connect_t rootPort = MACH_PORT_NULL;
+static CFRunLoopRef watcherRunLoop = NULL;
+
+// powerCallback acknowledges the sleep requests of IOKit, which waits for every client
+// before sleeping, and reports sleep and wake.
+static void powerCallback(void *refcon, io_service_t service, natural_t messageType, void *messageArgument) {
+	switch (messageType) {
+	case kIOMessageCanSystemSleep:
+		IOAllowPowerChange(rootPort, (long)messageArgument);
+		break;
+	case kIOMessageSystemWillSleep:
+		goSessionEvent(SESSION_SLEEP);
+		IOAllowPowerChange(rootPort, (long)messageArgument);
+		break;
+	case kIOMessageSystemHasPoweredOn:
+		goSessionEvent(SESSION_WAKE);
+		break;
+	}
+}
+
+// screenCallback reports the screen lock notifications posted by loginwindow.
+static void screenCallback(CFNotificationCenterRef center, void *observer, CFNotificationName name,
+                           const void *object, CFDictionaryRef userInfo) {
+	if (CFStringCompare(name, CFSTR("com.apple.screenIsLocked"), 0) == kCFCompareEqualTo) {
+		goSessionEvent(SESSION_SCREEN_LOCKED);
+	} else {
+		goSessionEvent(SESSION_SCREEN_UNLOCKED);
+	}
+}
+
+// runSessionWatcher runs a run loop on the calling thread delivering the power and screen
+// lock events until stopSessionWatcher is called. It returns -1 if IOKit refuses the
+// registration.
+int runSessionWatcher(void) {
+	IONotificationPortRef notifyPort;
+	io_object_t notifier;
+	rootPort = IORegisterForSystemPower(NULL, &notifyPort, powerCallback, &notifier);
+	if (rootPort == MACH_PORT_NULL) {
+		return -1;
+	}
+
+	watcherRunLoop = CFRunLoopGetCurrent();
+	CFRunLoopAddSource(watcherRunLoop, IONotificationPortGetRunLoopSource(notifyPort), kCFRunLoopDefaultMode);
+
+	CFNotificationCenterRef center = CFNotificationCenterGetDistributedCenter();
+	CFNotificationCenterAddObserver(center, &rootPort, screenCallback, CFSTR("com.apple.screenIsLocked"), NULL,
+	                                CFNotificationSuspensionBehaviorDeliverImmediately);
+	CFNotificationCenterAddObserver(center, &rootPort, screenCallback, CFSTR("com.apple.screenIsUnlocked"), NULL,
+	                                CFNotificationSuspensionBehaviorDeliverImmediately);
+
+	CFRunLoopRun();
+
+	CFNotificationCenterRemoveEveryObserver(center, &rootPort);
+	IODeregisterForSystemPower(&notifier);
+	IOServiceClose(rootPort);
+	IONotificationPortDestroy(notifyPort);
+	rootPort = MACH_PORT_NULL;
+	return 0;
+}
+
+// stopSessionWatcher makes runSessionWatcher return.
+void stopSessionWatcher(void) {
+	if (watcherRunLoop != NULL) {
+		CFRunLoopStop(watcherRunLoop);
+	}
+}
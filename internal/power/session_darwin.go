@@ -0,0 +1,66 @@
+//go:build darwin
+
+package power
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation -framework IOKit
+
+#include "session_darwin.h"
+*/
+import "C"
+
+import (
+	"context"
+	"runtime"
+)
+
+// sessionEvents carries the events of the C callbacks, which cannot hold Go values, to
+// the running watcher.
+var sessionEvents = make(chan C.int, 8)
+
+//export goSessionEvent
+func goSessionEvent(event C.int) {
+	// IOKit waits for the sleep acknowledgment, events are dropped rather than block it.
+	select {
+	case sessionEvents <- event:
+	default:
+	}
+}
+
+// Run listens for IOKit sleep and wake notifications and the screen lock notifications of
+// loginwindow until the context is canceled, calling onLockChange with true when the
+// screen is locked or the machine is about to sleep and false when it becomes usable
+// again. Waking up does not unlock a screen locked before sleeping.
+func (w *SessionWatcher) Run(ctx context.Context, onLockChange func(locked bool)) {
+	failed := make(chan struct{})
+	go func() {
+		// The run loop belongs to the thread that created it.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if C.runSessionWatcher() != 0 {
+			close(failed)
+		}
+	}()
+	defer C.stopSessionWatcher()
+
+	var state lockState
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-failed:
+			w.logger.Warn(ctx, "session lock detection unavailable, cannot register for power notifications")
+			return
+		case event := <-sessionEvents:
+			switch event {
+			case C.SESSION_SLEEP, C.SESSION_WAKE:
+				state.sleeping = event == C.SESSION_SLEEP
+				w.logger.Debug(ctx, "system sleep state changed", "sleeping", state.sleeping)
+			case C.SESSION_SCREEN_LOCKED, C.SESSION_SCREEN_UNLOCKED:
+				state.screenLocked = event == C.SESSION_SCREEN_LOCKED
+				w.logger.Debug(ctx, "screen lock state changed", "locked", state.screenLocked)
+			}
+			state.report(onLockChange)
+		}
+	}
+}
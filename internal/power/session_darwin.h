@@ -0,0 +1,8 @@
+// Events reported by the session watcher of session_darwin.c.
+#define SESSION_SLEEP 1
+#define SESSION_WAKE 2
+#define SESSION_SCREEN_LOCKED 3
+#define SESSION_SCREEN_UNLOCKED 4
+
+int runSessionWatcher(void);
+void stopSessionWatcher(void);
@@ -0,0 +1,146 @@
+//go:build linux
+
+package power
+
+import (
+	"context"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindDest             = "org.freedesktop.login1"
+	logindPath             = "/org/freedesktop/login1"
+	logindManagerInterface = "org.freedesktop.login1.Manager"
+	logindSessionInterface = "org.freedesktop.login1.Session"
+	propertiesInterface    = "org.freedesktop.DBus.Properties"
+)
+
+// Run listens for logind lock and sleep signals until the context is canceled, calling
+// onLockChange with true when the session is locked, inactive or about to sleep and false
+// when it becomes usable again. Waking up only unlocks the session if its LockedHint, set
+// by the screen locker, is false; otherwise the Unlock signal or the hint does.
+func (w *SessionWatcher) Run(ctx context.Context, onLockChange func(locked bool)) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		w.logger.Warn(ctx, "session lock detection unavailable, cannot connect to system bus", "err", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	sessionOptions := []dbus.MatchOption{dbus.WithMatchInterface(logindSessionInterface)}
+	sessionPath, hasSession := w.currentSessionPath(conn)
+	if hasSession {
+		sessionOptions = append(sessionOptions, dbus.WithMatchObjectPath(sessionPath))
+	}
+
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchInterface(logindManagerInterface), dbus.WithMatchMember("PrepareForSleep")},
+		append([]dbus.MatchOption{dbus.WithMatchMember("Lock")}, sessionOptions...),
+		append([]dbus.MatchOption{dbus.WithMatchMember("Unlock")}, sessionOptions...),
+	}
+	if hasSession {
+		matches = append(matches, []dbus.MatchOption{
+			dbus.WithMatchInterface(propertiesInterface),
+			dbus.WithMatchMember("PropertiesChanged"),
+			dbus.WithMatchObjectPath(sessionPath),
+		})
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			w.logger.Warn(ctx, "session lock detection unavailable, cannot subscribe to logind", "err", err)
+			return
+		}
+	}
+
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	var session dbus.BusObject
+	if hasSession {
+		session = conn.Object(logindDest, sessionPath)
+	}
+	var state lockState
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+			w.handleSignal(ctx, sig, session, &state)
+			state.report(onLockChange)
+		}
+	}
+}
+
+// handleSignal updates the lock state from a logind signal. session is the logind session
+// of the app, nil when it is unknown.
+func (w *SessionWatcher) handleSignal(ctx context.Context, sig *dbus.Signal, session dbus.BusObject, state *lockState) {
+	switch sig.Name {
+	case logindManagerInterface + ".PrepareForSleep":
+		if len(sig.Body) == 0 {
+			return
+		}
+		sleeping, _ := sig.Body[0].(bool)
+		w.logger.Debug(ctx, "system sleep state changed", "sleeping", sleeping)
+		state.sleeping = sleeping
+		// The locker may have set the hint while the app was not listening, e.g. right
+		// before the machine went to sleep.
+		if !sleeping && session != nil {
+			if hint, err := session.GetProperty(logindSessionInterface + ".LockedHint"); err == nil {
+				state.screenLocked, _ = hint.Value().(bool)
+			}
+		}
+	case logindSessionInterface + ".Lock":
+		w.logger.Debug(ctx, "session locked")
+		state.screenLocked = true
+	case logindSessionInterface + ".Unlock":
+		w.logger.Debug(ctx, "session unlocked")
+		state.screenLocked = false
+	case propertiesInterface + ".PropertiesChanged":
+		if len(sig.Body) < 2 {
+			return
+		}
+		changed, _ := sig.Body[1].(map[string]dbus.Variant)
+		if hint, ok := changed["LockedHint"]; ok {
+			state.screenLocked, _ = hint.Value().(bool)
+			w.logger.Debug(ctx, "session locked hint changed", "locked", state.screenLocked)
+		}
+		if active, ok := changed["Active"]; ok {
+			isActive, _ := active.Value().(bool)
+			state.inactive = !isActive
+			w.logger.Debug(ctx, "session active state changed", "active", isActive)
+		}
+	}
+}
+
+// currentSessionPath resolves the logind object path of the session this process belongs to.
+func (w *SessionWatcher) currentSessionPath(conn *dbus.Conn) (dbus.ObjectPath, bool) {
+	manager := conn.Object(logindDest, logindPath)
+
+	var sessionPath dbus.ObjectPath
+	if sessionID := os.Getenv("XDG_SESSION_ID"); sessionID != "" {
+		if err := manager.Call(logindManagerInterface+".GetSession", 0, sessionID).Store(&sessionPath); err == nil {
+			return sessionPath, true
+		}
+	}
+
+	if err := manager.Call(logindManagerInterface+".GetSessionByPID", 0, uint32(os.Getpid())).Store(&sessionPath); err == nil {
+		return sessionPath, true
+	}
+
+	// "auto" is the display session of the user, the one on screen for processes outside
+	// any session.
+	if err := manager.Call(logindManagerInterface+".GetSession", 0, "auto").Store(&sessionPath); err == nil {
+		return sessionPath, true
+	}
+
+	// Without a display session (older logind, or no graphical login), lock signals from
+	// every session are accepted.
+	return "", false
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package power
+
+import "context"
+
+// Run is not supported on this platform yet, it only logs and returns.
+func (w *SessionWatcher) Run(ctx context.Context, onLockChange func(locked bool)) {
+	w.logger.Debug(ctx, "session lock detection is not supported on this platform")
+}
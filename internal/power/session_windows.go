@@ -0,0 +1,190 @@
+//go:build windows
+
+package power
+
+import (
+	"context"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                             = syscall.NewLazyDLL("user32.dll")
+	wtsapi32                           = syscall.NewLazyDLL("wtsapi32.dll")
+	procRegisterClassExW               = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW                = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW                 = user32.NewProc("DefWindowProcW")
+	procGetMessageW                    = user32.NewProc("GetMessageW")
+	procDispatchMessageW               = user32.NewProc("DispatchMessageW")
+	procPostMessageW                   = user32.NewProc("PostMessageW")
+	procDestroyWindow                  = user32.NewProc("DestroyWindow")
+	procPostQuitMessage                = user32.NewProc("PostQuitMessage")
+	procWTSRegisterSessionNotification = wtsapi32.NewProc("WTSRegisterSessionNotification")
+	procWTSUnRegisterSessionNotif      = wtsapi32.NewProc("WTSUnRegisterSessionNotification")
+)
+
+const (
+	wmDestroy            = 0x0002
+	wmClose              = 0x0010
+	wmPowerBroadcast     = 0x0218
+	wmWTSSessionChange   = 0x02B1
+	pbtAPMSuspend        = 0x0004
+	pbtAPMResumeSuspend  = 0x0007
+	pbtAPMResumeAuto     = 0x0012
+	wtsConsoleConnect    = 0x1
+	wtsConsoleDisconnect = 0x2
+	wtsSessionLock       = 0x7
+	wtsSessionUnlock     = 0x8
+	notifyForThisSession = 0
+)
+
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   syscall.Handle
+	icon       syscall.Handle
+	cursor     syscall.Handle
+	background syscall.Handle
+	menuName   *uint16
+	className  *uint16
+	iconSm     syscall.Handle
+}
+
+type msg struct {
+	hwnd    syscall.Handle
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+	private uint32
+}
+
+// Run listens for the session notifications of a hidden window (WTS lock, unlock and
+// console switches, and power broadcasts for sleep and resume) until the context is
+// canceled, calling onLockChange with true when the session is locked, switched away or
+// about to sleep and false when it becomes usable again. Resuming does not unlock a
+// session locked before sleeping.
+func (w *SessionWatcher) Run(ctx context.Context, onLockChange func(locked bool)) {
+	events := make(chan func(*lockState), 8)
+	windows := make(chan syscall.Handle, 1)
+	go func() {
+		// Window messages are delivered to the thread that created the window.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(events)
+		runSessionWindow(ctx.Done(), events, windows)
+	}()
+
+	var hwnd syscall.Handle
+	select {
+	case <-ctx.Done():
+		// The window may still be created, close it as soon as its handle arrives so its
+		// thread does not outlive the watcher.
+		go func() {
+			if hwnd := <-windows; hwnd != 0 {
+				_, _, _ = procPostMessageW.Call(uintptr(hwnd), wmClose, 0, 0)
+			}
+		}()
+		return
+	case hwnd = <-windows:
+	}
+	if hwnd == 0 {
+		w.logger.Warn(ctx, "session lock detection unavailable, cannot create the notification window")
+		return
+	}
+	defer func() { _, _, _ = procPostMessageW.Call(uintptr(hwnd), wmClose, 0, 0) }()
+
+	var state lockState
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-events:
+			if !ok {
+				return
+			}
+			update(&state)
+			w.logger.Debug(ctx, "session state changed", "sleeping", state.sleeping,
+				"locked", state.screenLocked, "inactive", state.inactive)
+			state.report(onLockChange)
+		}
+	}
+}
+
+// runSessionWindow creates the hidden window, sends it (zero on failure) on windows and
+// runs its message loop until it is closed, sending the state changes on events. A change
+// is never dropped, sending it waits for the watcher until done is closed.
+func runSessionWindow(done <-chan struct{}, events chan<- func(*lockState), windows chan<- syscall.Handle) {
+	send := func(update func(*lockState)) {
+		select {
+		case events <- update:
+		case <-done:
+		}
+	}
+
+	wndProc := syscall.NewCallback(func(hwnd syscall.Handle, message uint32, wParam, lParam uintptr) uintptr {
+		switch message {
+		case wmWTSSessionChange:
+			switch wParam {
+			case wtsSessionLock, wtsSessionUnlock:
+				locked := wParam == wtsSessionLock
+				send(func(s *lockState) { s.screenLocked = locked })
+			case wtsConsoleConnect, wtsConsoleDisconnect:
+				inactive := wParam == wtsConsoleDisconnect
+				send(func(s *lockState) { s.inactive = inactive })
+			}
+			return 0
+		case wmPowerBroadcast:
+			switch wParam {
+			case pbtAPMSuspend:
+				send(func(s *lockState) { s.sleeping = true })
+			case pbtAPMResumeSuspend, pbtAPMResumeAuto:
+				send(func(s *lockState) { s.sleeping = false })
+			}
+			return 1
+		case wmDestroy:
+			_, _, _ = procWTSUnRegisterSessionNotif.Call(uintptr(hwnd))
+			_, _, _ = procPostQuitMessage.Call(0)
+			return 0
+		}
+		ret, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(message), wParam, lParam)
+		return ret
+	})
+
+	className, _ := syscall.UTF16PtrFromString("TribarSessionWatcher")
+	class := wndClassEx{wndProc: wndProc, className: className}
+	class.size = uint32(unsafe.Sizeof(class))
+	if ret, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&class))); ret == 0 {
+		windows <- 0
+		return
+	}
+
+	// A top-level window that is never shown, message-only windows miss the power
+	// broadcasts.
+	ret, _, _ := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	hwnd := syscall.Handle(ret)
+	if hwnd == 0 {
+		windows <- 0
+		return
+	}
+	if ret, _, _ := procWTSRegisterSessionNotification.Call(uintptr(hwnd), notifyForThisSession); ret == 0 {
+		_, _, _ = procDestroyWindow.Call(uintptr(hwnd))
+		windows <- 0
+		return
+	}
+	windows <- hwnd
+
+	var message msg
+	for {
+		ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&message)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		_, _, _ = procDispatchMessageW.Call(uintptr(unsafe.Pointer(&message)))
+	}
+}
@@ -0,0 +1,103 @@
+// Package profanity masks or removes the profanity of the final text, for users dictating
+// into workplace chat tools. Words are matched whole and ignoring case, with their common
+// inflections ("damned", "shitty"), so words that merely contain one are left alone.
+package profanity
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// defaultWords is the built-in list of English profanity.
+var defaultWords = []string{
+	"arse", "arsehole", "ass", "asshole", "bastard", "bitch", "bollocks", "bullshit",
+	"crap", "cunt", "damn", "dick", "dickhead", "fuck", "fucker", "goddamn", "jackass",
+	"motherfucker", "piss", "prick", "shit", "slut", "twat", "wanker", "whore",
+}
+
+// suffixes are the inflections a listed word is also matched with.
+var suffixes = []string{"", "s", "es", "ed", "er", "ers", "ing", "in", "y", "ty"}
+
+var (
+	repeatedSpaces   = regexp.MustCompile(`[ \t]{2,}`)
+	spaceBeforePunct = regexp.MustCompile(`[ \t]+([,.;:!?])`)
+	danglingComma    = regexp.MustCompile(`[,;:]+([.!?])`)
+)
+
+// Apply masks or removes the profanity of the text according to the mode; extra words
+// are matched besides the built-in list. The text is returned unchanged when the filter
+// is off.
+func Apply(mode config.ProfanityMode, text string, extra []string) string {
+	if mode != config.ProfanityMask && mode != config.ProfanityRemove {
+		return text
+	}
+
+	words := make([]string, 0, len(defaultWords)+len(extra))
+	words = append(words, defaultWords...)
+	for _, word := range extra {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+
+	var sb strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		start = -1
+		if !isProfane(strings.ToLower(word), words) {
+			sb.WriteString(word)
+			return
+		}
+		if mode == config.ProfanityMask {
+			sb.WriteString(mask(word))
+		}
+	}
+
+	for idx, r := range text {
+		inWord := unicode.IsLetter(r) || (r == '\'' && start >= 0)
+		switch {
+		case inWord && start < 0:
+			start = idx
+		case !inWord && start >= 0:
+			flush(idx)
+		}
+		if !inWord {
+			sb.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+
+	if mode == config.ProfanityMask {
+		return sb.String()
+	}
+	cleaned := repeatedSpaces.ReplaceAllString(sb.String(), " ")
+	cleaned = spaceBeforePunct.ReplaceAllString(cleaned, "$1")
+	cleaned = danglingComma.ReplaceAllString(cleaned, "$1")
+	return strings.TrimSpace(cleaned)
+}
+
+// isProfane reports whether the lowercase word is one of the words or an inflection of
+// one.
+func isProfane(word string, words []string) bool {
+	word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "'")
+	for _, suffix := range suffixes {
+		if stem, ok := strings.CutSuffix(word, suffix); ok && slices.Contains(words, stem) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask keeps the first letter of the word and hides the rest, e.g. "f***".
+func mask(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+}
@@ -0,0 +1,168 @@
+// Package prompts imports post-processing prompts shared by the community, fetched from a
+// JSON feed, into the user settings.
+package prompts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+const (
+	// fetchTimeout bounds the download of a feed.
+	fetchTimeout = 30 * time.Second
+	// maxFeedSize is the largest feed accepted, prompts are short texts.
+	maxFeedSize = 1 << 20
+)
+
+// Feed is the JSON document a prompt library publishes. A bare array of prompts is
+// accepted too.
+type Feed struct {
+	Name    string          `json:"name,omitempty"`
+	Prompts []config.Prompt `json:"prompts"`
+}
+
+// Fetch reads the prompts of a feed, which can be a local path or an http(s) URL. Every
+// prompt needs a name and a body; the ID is optional, one is generated when merging.
+func Fetch(ctx context.Context, source string) ([]config.Prompt, error) {
+	data, err := read(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed Feed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		if err := json.Unmarshal(data, &feed.Prompts); err != nil {
+			return nil, fmt.Errorf("invalid prompt feed: %w", err)
+		}
+	}
+
+	if len(feed.Prompts) == 0 {
+		return nil, errors.New("the feed contains no prompts")
+	}
+	for n, prompt := range feed.Prompts {
+		if strings.TrimSpace(prompt.Name) == "" || strings.TrimSpace(prompt.Body) == "" {
+			return nil, fmt.Errorf("prompt %d of the feed has no name or body", n+1)
+		}
+	}
+	return feed.Prompts, nil
+}
+
+// read returns the content of a local file or an http(s) URL, up to maxFeedSize bytes.
+func read(ctx context.Context, source string) ([]byte, error) {
+	var body io.ReadCloser
+	switch {
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch prompts: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("bad status: %s", resp.Status)
+		}
+		body = resp.Body
+	default:
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		body = file
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts: %w", err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, fmt.Errorf("the prompt feed exceeds %d bytes", maxFeedSize)
+	}
+	return data, nil
+}
+
+// Merge appends the imported prompts to the existing ones and returns the result along
+// with the prompts actually added. Prompts with the same body as an existing one are
+// skipped, so importing a feed again adds only its new prompts. An imported prompt whose
+// ID is missing or already taken gets a new one, and a name already in use gets a numeric
+// suffix, so imports never replace or shadow the user's prompts.
+func Merge(existing, imported []config.Prompt) (merged, added []config.Prompt) {
+	merged = append([]config.Prompt(nil), existing...)
+	ids := make(map[string]bool, len(existing)+len(imported))
+	names := make(map[string]bool, len(existing)+len(imported))
+	for _, prompt := range existing {
+		ids[prompt.ID] = true
+		names[strings.ToLower(prompt.Name)] = true
+	}
+
+	for _, prompt := range imported {
+		if containsPrompt(merged, prompt) {
+			continue
+		}
+
+		prompt.Versions = nil
+		if prompt.ID == "" || ids[prompt.ID] {
+			prompt.ID = NewID()
+		}
+		prompt.Name = uniqueName(prompt.Name, names)
+
+		ids[prompt.ID] = true
+		names[strings.ToLower(prompt.Name)] = true
+		merged = append(merged, prompt)
+		added = append(added, prompt)
+	}
+	return merged, added
+}
+
+// containsPrompt reports whether a prompt with the same body is in the list, whatever its
+// name, which may have been made unique by a previous import.
+func containsPrompt(prompts []config.Prompt, prompt config.Prompt) bool {
+	for _, existing := range prompts {
+		if strings.TrimSpace(existing.Body) == strings.TrimSpace(prompt.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns the name, or the name followed by the first free number, e.g.
+// "Cleanup (2)", if it is already used. Names are compared case-insensitively like
+// config.Settings.FindPrompt does.
+func uniqueName(name string, used map[string]bool) string {
+	name = strings.TrimSpace(name)
+	if !used[strings.ToLower(name)] {
+		return name
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !used[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// NewID returns a random version 4 UUID, the format of the predefined IDs of the settings.
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,155 @@
+// Package remote sends recorded audio to a self-hosted or cloud speech-to-text server
+// instead of running the model locally. It speaks the OpenAI audio transcription API
+// (POST {base}/audio/transcriptions), which Whisper servers such as faster-whisper-server,
+// whisper.cpp's server and LocalAI implement.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const requestTimeout = 2 * time.Minute
+
+// unhealthyCooldown is how long the server is skipped after a failed request before it is
+// tried again.
+const unhealthyCooldown = time.Minute
+
+// Instance transcribes audio through the remote server configured in the settings.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	client          *http.Client
+
+	// unhealthyUntil is the Unix time in nanoseconds until which the server is considered
+	// unreachable, zero when it is healthy.
+	unhealthyUntil atomic.Int64
+}
+
+// New creates a new remote transcription client.
+func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+type transcriptionResponse struct {
+	Text  string `json:"text"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Healthy reports whether the server is expected to be reachable: it is unless a request
+// failed or exceeded its deadline during the last minute.
+func (r *Instance) Healthy() bool {
+	return time.Now().UnixNano() >= r.unhealthyUntil.Load()
+}
+
+// TranscribeWAV uploads the WAV audio and returns the transcription. The language is a
+// hint passed to the server, empty lets it detect the language. A failure, including the
+// context deadline being exceeded, marks the server as unhealthy for a minute.
+func (r *Instance) TranscribeWAV(ctx context.Context, wavData []byte, language string) (string, error) {
+	text, err := r.transcribeWAV(ctx, wavData, language)
+	if err != nil {
+		r.unhealthyUntil.Store(time.Now().Add(unhealthyCooldown).UnixNano())
+		return "", err
+	}
+
+	r.unhealthyUntil.Store(0)
+	return text, nil
+}
+
+func (r *Instance) transcribeWAV(ctx context.Context, wavData []byte, language string) (string, error) {
+	settings := r.settingsManager.Get()
+	if settings.RemoteTranscriptionURL == "" {
+		return "", fmt.Errorf("the remote transcription URL is not configured")
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := file.Write(wavData); err != nil {
+		return "", fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           settings.RemoteTranscriptionModel,
+		"language":        language,
+		"response_format": "json",
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("failed to write field %s: %w", name, err)
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to close form: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(settings.RemoteTranscriptionURL, "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("User-Agent", "Tribar/"+config.AppVersion)
+	if settings.RemoteTranscriptionAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+settings.RemoteTranscriptionAPIKey)
+	}
+
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result transcriptionResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response (status %s): %w", resp.Status, err)
+	}
+
+	if result.Error != nil {
+		return "", fmt.Errorf("server error: %s", result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	r.logger.Debug(ctx, "remote transcription complete",
+		"endpoint", endpoint,
+		"duration", time.Since(start),
+	)
+
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,77 @@
+// Package routing matches transcriptions against the user-defined routing rules, which
+// send dictations starting with a trigger (e.g. "note: ...") to another prompt or sink.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// Result is the outcome of matching a transcription against the rules.
+type Result struct {
+	Rule config.RoutingRule
+	// Text is the transcription with the matched trigger removed when the rule strips it.
+	Text string
+}
+
+// Match returns the first enabled rule, in order, whose pattern matches the text. Rules
+// with an invalid pattern are skipped and reported in the returned error, which does not
+// prevent later rules from matching.
+func Match(rules []config.RoutingRule, text string) (Result, bool, error) {
+	var errs []error
+
+	for _, rule := range rules {
+		if !rule.Enabled || rule.Pattern == "" {
+			continue
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %q has an invalid pattern: %w", rule.Name, err))
+			continue
+		}
+
+		loc := pattern.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+
+		routed := text
+		if rule.StripMatch {
+			routed = strings.TrimSpace(text[:loc[0]] + text[loc[1]:])
+		}
+
+		return Result{Rule: rule, Text: routed}, true, errors.Join(errs...)
+	}
+
+	return Result{}, false, errors.Join(errs...)
+}
+
+// Apply returns the settings to deliver a routed transcription with: the rule prompt
+// enables post-processing with it and the rule output mode replaces the configured one.
+func Apply(settings config.Settings, rule config.RoutingRule) (config.Settings, error) {
+	if rule.PromptID != "" {
+		prompt, ok := settings.FindPrompt(rule.PromptID)
+		if !ok {
+			return settings, fmt.Errorf("rule %q uses an unknown prompt %q", rule.Name, rule.PromptID)
+		}
+		settings.PostProcessEnabled = true
+		settings.PostProcessPromptID = prompt.ID
+	}
+
+	if rule.OutputMode != "" {
+		settings.OutputMode = rule.OutputMode
+	}
+
+	if rule.SinkID != "" {
+		if _, ok := settings.FindSink(rule.SinkID); !ok {
+			return settings, fmt.Errorf("rule %q uses an unknown sink %q", rule.Name, rule.SinkID)
+		}
+	}
+
+	return settings, nil
+}
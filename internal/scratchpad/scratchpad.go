@@ -0,0 +1,367 @@
+// Package scratchpad is the "dictate into scratchpad" output target: instead of pasting
+// into another application, dictations accumulate in a text that is edited in a small
+// editor window, with a word count and a button copying the whole text, to compose a long
+// text by voice. The window is a page of a server listening on the loopback interface,
+// started the first time it is opened and reachable only through a random token; the text
+// is kept in the data directory across restarts.
+package scratchpad
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/sink"
+)
+
+const (
+	defaultFileName = "scratchpad.txt"
+	// pollInterval is how often the window asks for dictations appended to the text.
+	pollInterval = time.Second
+	// openTimeout is how long a window stays open after its last poll, browsers throttle
+	// the timers of background tabs.
+	openTimeout = 90 * time.Second
+	// maxAppends is the number of appended dictations kept to merge them into the edits of
+	// a window that did not see them yet.
+	maxAppends = 50
+	// maxTextSize is the largest text a window can save.
+	maxTextSize = 10 << 20
+)
+
+// page is the editor window, html/template escapes the text and the URLs.
+var page = template.Must(template.New("scratchpad").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; flex-direction: column; height: 100vh; }
+header { display: flex; align-items: center; gap: 0.5em; padding: 0.5em 1em; border-bottom: 1px solid #ccc; }
+header span { flex: 1; color: #666; }
+textarea { flex: 1; border: none; padding: 1em; font: inherit; font-size: 1.1em; line-height: 1.5; resize: none; outline: none; }
+</style>
+</head>
+<body>
+<header>
+<span id="count"></span>
+<button id="copy">Copy All</button>
+<button id="clear">Clear</button>
+</header>
+<textarea id="text" autofocus spellcheck="true">{{.Text}}</textarea>
+<script>
+const endpoint = {{.Endpoint}};
+const text = document.getElementById("text");
+const count = document.getElementById("count");
+let version = {{.Version}};
+let dirty = false;
+let saveTimer = null;
+
+function updateCount() {
+  const words = text.value.trim() === "" ? 0 : text.value.trim().split(/\s+/).length;
+  count.textContent = words + (words === 1 ? " word, " : " words, ") + text.value.length + " characters";
+}
+
+function show(state) {
+  version = state.version;
+  if (text.value === state.text) {
+    return;
+  }
+  const atEnd = text.selectionStart === text.value.length;
+  const start = text.selectionStart, end = text.selectionEnd;
+  text.value = state.text;
+  if (atEnd) {
+    text.selectionStart = text.selectionEnd = text.value.length;
+    text.scrollTop = text.scrollHeight;
+  } else {
+    text.setSelectionRange(start, end);
+  }
+  updateCount();
+}
+
+async function save() {
+  saveTimer = null;
+  dirty = false;
+  const response = await fetch(endpoint, {
+    method: "PUT",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({text: text.value, version: version}),
+  });
+  if (response.ok && !dirty) {
+    show(await response.json());
+  }
+}
+
+async function poll() {
+  try {
+    const response = await fetch(endpoint);
+    if (response.ok && !dirty) {
+      show(await response.json());
+    }
+  } finally {
+    setTimeout(poll, {{.PollMillis}});
+  }
+}
+
+text.addEventListener("input", () => {
+  dirty = true;
+  updateCount();
+  clearTimeout(saveTimer);
+  saveTimer = setTimeout(save, 500);
+});
+
+document.getElementById("copy").addEventListener("click", async () => {
+  try {
+    await navigator.clipboard.writeText(text.value);
+  } catch {
+    text.select();
+    document.execCommand("copy");
+  }
+});
+
+document.getElementById("clear").addEventListener("click", () => {
+  if (text.value !== "" && confirm("Clear the scratchpad?")) {
+    text.value = "";
+    text.dispatchEvent(new Event("input"));
+  }
+});
+
+updateCount();
+text.selectionStart = text.selectionEnd = text.value.length;
+text.scrollTop = text.scrollHeight;
+setTimeout(poll, {{.PollMillis}});
+</script>
+</body>
+</html>
+`))
+
+// state is the text exchanged with the windows, version increases with every change.
+type state struct {
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+// appended is a dictation added to the text at a version.
+type appended struct {
+	version int
+	text    string
+}
+
+// Instance holds the scratchpad text and serves its editor window.
+type Instance struct {
+	logger logger.Logger
+	path   string
+
+	mu       sync.Mutex
+	text     string
+	version  int
+	appends  []appended
+	lastPoll time.Time
+	url      string
+}
+
+// New creates the scratchpad, loading the text kept in the data directory.
+func New(logger logger.Logger) *Instance {
+	s := &Instance{
+		logger: logger,
+		path:   FilePath(),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn(context.Background(), "failed to read the scratchpad", "err", err)
+	}
+	s.text = string(data)
+	return s
+}
+
+// FilePath returns the location of the scratchpad text.
+func FilePath() string {
+	return filepath.Join(config.DirectoryData, defaultFileName)
+}
+
+// Text returns the scratchpad text.
+func (s *Instance) Text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.text
+}
+
+// Append adds a dictation at the end of the text, separated by a space unless the text is
+// empty or ends with whitespace (e.g. a new paragraph typed in the window).
+func (s *Instance) Append(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version++
+	s.text = join(s.text, text)
+	s.appends = append(s.appends, appended{version: s.version, text: text})
+	if len(s.appends) > maxAppends {
+		s.appends = s.appends[len(s.appends)-maxAppends:]
+	}
+	return s.save()
+}
+
+// IsOpen reports whether an editor window polled the text recently.
+func (s *Instance) IsOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.lastPoll) < openTimeout
+}
+
+// Open opens the editor window in the default browser, starting its server the first
+// time. The server stops when ctx is canceled.
+func (s *Instance) Open(ctx context.Context) error {
+	url, err := s.start(ctx)
+	if err != nil {
+		return err
+	}
+	return sink.OpenURL(ctx, url)
+}
+
+// start starts the server of the editor window once and returns its URL.
+func (s *Instance) start(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.url != "" {
+		return s.url, nil
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate scratchpad token: %w", err)
+	}
+	base := "/" + hex.EncodeToString(b[:])
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start the scratchpad server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+base, s.servePage)
+	mux.HandleFunc("GET "+base+"/text", s.serveText)
+	mux.HandleFunc("PUT "+base+"/text", s.saveText)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Warn(ctx, "scratchpad server failed", "err", err)
+		}
+	}()
+
+	s.url = "http://" + listener.Addr().String() + base
+	return s.url, nil
+}
+
+// servePage serves the editor window.
+func (s *Instance) servePage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.lastPoll = time.Now()
+	data := struct {
+		Title, Text, Endpoint string
+		Version, PollMillis   int
+	}{
+		Title:      config.AppName + " Scratchpad",
+		Text:       s.text,
+		Endpoint:   r.URL.Path + "/text",
+		Version:    s.version,
+		PollMillis: int(pollInterval.Milliseconds()),
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := page.Execute(w, data); err != nil {
+		s.logger.Warn(r.Context(), "failed to render the scratchpad", "err", err)
+	}
+}
+
+// serveText returns the text and its version, polled by the windows.
+func (s *Instance) serveText(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.lastPoll = time.Now()
+	current := state{Text: s.text, Version: s.version}
+	s.mu.Unlock()
+
+	writeJSON(w, current)
+}
+
+// saveText replaces the text with the one edited in a window. Dictations appended after
+// the version the window edited are added again at the end, so they are not lost.
+func (s *Instance) saveText(w http.ResponseWriter, r *http.Request) {
+	var edited state
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTextSize)).Decode(&edited); err != nil {
+		http.Error(w, "invalid scratchpad text", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	text := edited.Text
+	for _, a := range s.appends {
+		if a.version > edited.Version {
+			text = join(text, a.text)
+		}
+	}
+	s.appends = nil
+	s.version++
+	s.text = text
+	err := s.save()
+	current := state{Text: s.text, Version: s.version}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.Warn(r.Context(), "failed to save the scratchpad", "err", err)
+	}
+	writeJSON(w, current)
+}
+
+// save writes the text to the data directory, the caller holds the lock.
+func (s *Instance) save() error {
+	if err := os.WriteFile(s.path, []byte(s.text), 0600); err != nil {
+		return fmt.Errorf("failed to save the scratchpad: %w", err)
+	}
+	return nil
+}
+
+// join appends a dictation to the text, separated by a space when needed.
+func join(text, dictation string) string {
+	if text == "" || unicode.IsSpace(rune(text[len(text)-1])) {
+		return text + dictation
+	}
+	return text + " " + dictation
+}
+
+// writeJSON sends the state as the JSON body of the reply.
+func writeJSON(w http.ResponseWriter, current state) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(current)
+}
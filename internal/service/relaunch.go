@@ -0,0 +1,28 @@
+package service
+
+import (
+	"fmt"
+	"os"
+)
+
+// Relaunch prepares a new instance of the application to start once the current process
+// exits, used after an update replaced the executable. When the process runs under the
+// service manager it returns supervised as true and does nothing else: the caller must
+// exit with a failure status so the manager restarts it.
+func Relaunch() (supervised bool, err error) {
+	if isSupervised() {
+		return true, nil
+	}
+
+	exe, err := executablePath()
+	if err != nil {
+		return false, err
+	}
+
+	cmd := relaunchCommand(os.Getpid(), exe)
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("failed to start relaunch helper: %w", err)
+	}
+	_ = cmd.Process.Release()
+	return false, nil
+}
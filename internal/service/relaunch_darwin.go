@@ -0,0 +1,23 @@
+//go:build darwin
+
+package service
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// relaunchScript waits for the process with PID $1 to exit and then starts $2, which is
+// either the app bundle or the bare executable.
+const relaunchScript = `while kill -0 "$1" 2>/dev/null; do sleep 0.2; done; if [ -d "$2" ]; then open "$2"; else exec "$2"; fi`
+
+// relaunchCommand reopens the .app bundle when the executable lives inside one so
+// LaunchServices starts it with its Info.plist (LSUIElement, icon) like Sparkle does.
+func relaunchCommand(pid int, exe string) *exec.Cmd {
+	target := exe
+	if bundle, _, found := strings.Cut(exe, ".app/Contents/MacOS/"); found {
+		target = bundle + ".app"
+	}
+	return exec.Command("/bin/sh", "-c", relaunchScript, "relaunch", strconv.Itoa(pid), target)
+}
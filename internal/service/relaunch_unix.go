@@ -0,0 +1,15 @@
+//go:build !windows && !darwin
+
+package service
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// relaunchScript waits for the process with PID $1 to exit and then starts $2.
+const relaunchScript = `while kill -0 "$1" 2>/dev/null; do sleep 0.2; done; exec "$2"`
+
+func relaunchCommand(pid int, exe string) *exec.Cmd {
+	return exec.Command("/bin/sh", "-c", relaunchScript, "relaunch", strconv.Itoa(pid), exe)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func relaunchCommand(pid int, exe string) *exec.Cmd {
+	script := fmt.Sprintf(
+		"Wait-Process -Id %d -ErrorAction SilentlyContinue; Start-Process -FilePath '%s'",
+		pid, strings.ReplaceAll(exe, "'", "''"),
+	)
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-WindowStyle", "Hidden", "-Command", script)
+}
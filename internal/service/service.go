@@ -0,0 +1,65 @@
+// Package service installs the application as a supervised background service
+// managed by the operating system (systemd user units on Linux, launchd agents on macOS,
+// Start Menu and Startup shortcuts on Windows).
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnsupported is returned when the current platform has no supported service manager.
+var ErrUnsupported = errors.New("background service is not supported on this platform")
+
+// executablePath returns the absolute, symlink-resolved path of the running binary so the
+// service definition keeps working even if it was launched through a symlink.
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine executable path: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve executable path %s: %w", exe, err)
+	}
+
+	return resolved, nil
+}
+
+// writeDefinition writes a service definition file creating its parent directory if needed.
+func writeDefinition(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write service definition %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// removeDefinition deletes a service definition file, ignoring it if it does not exist.
+func removeDefinition(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove service definition %s: %w", path, err)
+	}
+	return nil
+}
+
+// PurgeData removes the given application directories and everything inside them. It is
+// used by `tribar service uninstall --purge` to leave no user data behind.
+func PurgeData(dirs ...string) error {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+	}
+	return nil
+}
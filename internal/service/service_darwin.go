@@ -0,0 +1,114 @@
+//go:build darwin
+
+package service
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const agentLabel = "com.varavelio.tribar"
+
+const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>5</integer>
+	<key>ProcessType</key>
+	<string>Interactive</string>
+</dict>
+</plist>
+`
+
+// Install writes the launchd agent plist and loads it so it starts on login.
+// It returns the path of the written plist file.
+func Install() (string, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+
+	plistPath, err := plistFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeDefinition(plistPath, fmt.Sprintf(plistTemplate, agentLabel, escapePlistString(exe))); err != nil {
+		return "", err
+	}
+
+	// Unload first so reinstalling picks up the new definition.
+	_ = launchctl("unload", plistPath)
+	return plistPath, launchctl("load", "-w", plistPath)
+}
+
+// escapePlistString escapes a value of a plist <string> element, so paths with "&", "<"
+// or ">" still write a plist launchd can parse.
+func escapePlistString(value string) string {
+	return html.EscapeString(value)
+}
+
+// Uninstall unloads the launchd agent and removes its plist.
+// It returns the path of the removed plist file.
+func Uninstall() (string, error) {
+	plistPath, err := plistFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	_ = launchctl("unload", "-w", plistPath)
+	return plistPath, removeDefinition(plistPath)
+}
+
+// NotifyReady is a no-op on macOS since launchd has no readiness protocol.
+func NotifyReady() error {
+	return nil
+}
+
+// NotifyStopping is a no-op on macOS since launchd has no readiness protocol.
+func NotifyStopping() error {
+	return nil
+}
+
+func plistFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, "Library", "LaunchAgents", agentLabel+".plist"), nil
+}
+
+func launchctl(args ...string) error {
+	output, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %v failed: %w: %s", args, err, output)
+	}
+	return nil
+}
+
+// SetAppUserModelID is a no-op outside Windows.
+func SetAppUserModelID() error {
+	return nil
+}
+
+// isSupervised reports whether the process was started by the launchd agent.
+func isSupervised() bool {
+	return os.Getenv("XPC_SERVICE_NAME") == agentLabel
+}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const unitName = "tribar.service"
+
+const unitTemplate = `[Unit]
+Description=Tribar Voice speech to text
+PartOf=graphical-session.target
+After=graphical-session.target
+
+[Service]
+Type=notify
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+TimeoutStopSec=15
+
+[Install]
+WantedBy=graphical-session.target
+`
+
+// Install writes the systemd user unit and enables it so it starts with the graphical session.
+// It returns the path of the written unit file.
+func Install() (string, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+
+	unitPath, err := unitFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeDefinition(unitPath, fmt.Sprintf(unitTemplate, quoteExecArg(exe))); err != nil {
+		return "", err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return unitPath, err
+	}
+
+	if err := systemctl("enable", "--now", unitName); err != nil {
+		return unitPath, err
+	}
+
+	return unitPath, nil
+}
+
+// quoteExecArg quotes an argument of a unit's ExecStart= line following the systemd
+// rules, so paths with spaces, quotes, backslashes, "%" specifiers or "$" variables are
+// passed as is: it is enclosed in double quotes, backslashes and double quotes are
+// escaped with a backslash, and "%" and "$" are doubled.
+func quoteExecArg(arg string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	return `"` + replacer.Replace(arg) + `"`
+}
+
+// Uninstall disables the systemd user unit and removes its file.
+// It returns the path of the removed unit file.
+func Uninstall() (string, error) {
+	unitPath, err := unitFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	_ = systemctl("disable", "--now", unitName)
+
+	if err := removeDefinition(unitPath); err != nil {
+		return unitPath, err
+	}
+
+	return unitPath, systemctl("daemon-reload")
+}
+
+// NotifyReady tells systemd that the service finished starting up. It is a no-op when
+// the process is not supervised by systemd.
+func NotifyReady() error {
+	return sdNotify("READY=1")
+}
+
+// NotifyStopping tells systemd that the service is shutting down.
+func NotifyStopping() error {
+	return sdNotify("STOPPING=1")
+}
+
+// sdNotify implements the sd_notify protocol by sending the state to $NOTIFY_SOCKET.
+func sdNotify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+
+	// Abstract namespace sockets are announced with a leading '@'.
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd notify socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to send systemd notification: %w", err)
+	}
+
+	return nil
+}
+
+// unitFilePath returns the location of the systemd user unit following the XDG conventions.
+func unitFilePath() (string, error) {
+	baseDir := os.Getenv("XDG_CONFIG_HOME")
+	if baseDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine user home directory: %w", err)
+		}
+		baseDir = filepath.Join(homeDir, ".config")
+	}
+
+	return filepath.Join(baseDir, "systemd", "user", unitName), nil
+}
+
+func systemctl(args ...string) error {
+	args = append([]string{"--user"}, args...)
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %v failed: %w: %s", args, err, output)
+	}
+	return nil
+}
+
+// SetAppUserModelID is a no-op outside Windows.
+func SetAppUserModelID() error {
+	return nil
+}
+
+// isSupervised reports whether the process was started by the systemd unit.
+func isSupervised() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
@@ -0,0 +1,33 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+// Install is not supported on this platform.
+func Install() (string, error) {
+	return "", ErrUnsupported
+}
+
+// Uninstall is not supported on this platform.
+func Uninstall() (string, error) {
+	return "", ErrUnsupported
+}
+
+// NotifyReady is a no-op on platforms without a supported service manager.
+func NotifyReady() error {
+	return nil
+}
+
+// NotifyStopping is a no-op on platforms without a supported service manager.
+func NotifyStopping() error {
+	return nil
+}
+
+// SetAppUserModelID is a no-op outside Windows.
+func SetAppUserModelID() error {
+	return nil
+}
+
+// isSupervised is always false on platforms without a supported service manager.
+func isSupervised() bool {
+	return false
+}
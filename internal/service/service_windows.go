@@ -0,0 +1,166 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/varavelio/tribar/assets/logo"
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// appUserModelID identifies the application to the Windows shell so notifications and
+// taskbar entries are grouped under the app name and icon instead of the executable.
+const appUserModelID = "Varavelio.Tribar"
+
+const appUserModelIDKey = `HKCU\Software\Classes\AppUserModelId\` + appUserModelID
+
+const shortcutScript = `$shell = New-Object -ComObject WScript.Shell
+$shortcut = $shell.CreateShortcut($env:TRIBAR_SHORTCUT)
+$shortcut.TargetPath = $env:TRIBAR_TARGET
+$shortcut.WorkingDirectory = Split-Path -Parent $env:TRIBAR_TARGET
+$shortcut.IconLocation = $env:TRIBAR_ICON + ',0'
+$shortcut.Description = $env:TRIBAR_DESCRIPTION
+$shortcut.Save()
+`
+
+// Install writes the application icon, registers the AppUserModelID and creates the
+// Start Menu and Startup shortcuts, the latter so the app starts on login.
+// It returns the path of the Start Menu shortcut.
+func Install() (string, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+
+	iconPath := filepath.Join(config.DirectoryData, "tribar.ico")
+	if err := os.WriteFile(iconPath, logo.LogoBlackWhite.ICO.Logo, 0644); err != nil {
+		return "", fmt.Errorf("failed to write icon %s: %w", iconPath, err)
+	}
+
+	if err := registerAppUserModelID(iconPath); err != nil {
+		return "", err
+	}
+
+	startMenuPath, startupPath, err := shortcutPaths()
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range []string{startMenuPath, startupPath} {
+		if err := createShortcut(path, exe, iconPath); err != nil {
+			return "", err
+		}
+	}
+
+	return startMenuPath, nil
+}
+
+// Uninstall removes the shortcuts, the icon and the AppUserModelID registration.
+// It returns the path of the removed Start Menu shortcut.
+func Uninstall() (string, error) {
+	startMenuPath, startupPath, err := shortcutPaths()
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range []string{startupPath, startMenuPath, filepath.Join(config.DirectoryData, "tribar.ico")} {
+		if err := removeDefinition(path); err != nil {
+			return "", err
+		}
+	}
+
+	// The key is missing if the app was never installed, which is not an error.
+	_ = exec.Command("reg", "delete", appUserModelIDKey, "/f").Run()
+	return startMenuPath, nil
+}
+
+// NotifyReady is a no-op on Windows since the app runs from a Startup shortcut.
+func NotifyReady() error {
+	return nil
+}
+
+// NotifyStopping is a no-op on Windows since the app runs from a Startup shortcut.
+func NotifyStopping() error {
+	return nil
+}
+
+// SetAppUserModelID tags the running process with the AppUserModelID registered by
+// Install so its notifications and taskbar button show the app name and icon.
+func SetAppUserModelID() error {
+	id, err := syscall.UTF16PtrFromString(appUserModelID)
+	if err != nil {
+		return err
+	}
+
+	proc := syscall.NewLazyDLL("shell32.dll").NewProc("SetCurrentProcessExplicitAppUserModelID")
+	if err := proc.Find(); err != nil {
+		return fmt.Errorf("SetCurrentProcessExplicitAppUserModelID unavailable: %w", err)
+	}
+
+	if hr, _, _ := proc.Call(uintptr(unsafe.Pointer(id))); hr != 0 {
+		return fmt.Errorf("SetCurrentProcessExplicitAppUserModelID failed with HRESULT 0x%x", hr)
+	}
+	return nil
+}
+
+func shortcutPaths() (string, string, error) {
+	appData := os.Getenv("APPDATA")
+	if appData == "" {
+		return "", "", fmt.Errorf("the APPDATA environment variable is not set")
+	}
+
+	programs := filepath.Join(appData, "Microsoft", "Windows", "Start Menu", "Programs")
+	name := config.AppName + ".lnk"
+	return filepath.Join(programs, name), filepath.Join(programs, "Startup", name), nil
+}
+
+// createShortcut creates a .lnk file through the WScript.Shell COM object. Values are
+// passed through the environment so paths never need to be quoted inside the script.
+func createShortcut(path, target, iconPath string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", "-")
+	cmd.Stdin = strings.NewReader(shortcutScript)
+	cmd.Env = append(os.Environ(),
+		"TRIBAR_SHORTCUT="+path,
+		"TRIBAR_TARGET="+target,
+		"TRIBAR_ICON="+iconPath,
+		"TRIBAR_DESCRIPTION="+config.AppName,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create shortcut %s: %w: %s", path, err, output)
+	}
+	return nil
+}
+
+func registerAppUserModelID(iconPath string) error {
+	values := [][2]string{
+		{"DisplayName", config.AppName},
+		{"IconUri", iconPath},
+	}
+
+	for _, value := range values {
+		output, err := exec.Command(
+			"reg", "add", appUserModelIDKey, "/v", value[0], "/t", "REG_SZ", "/d", value[1], "/f",
+		).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to register %s: %w: %s", value[0], err, output)
+		}
+	}
+	return nil
+}
+
+// isSupervised is always false on Windows, the Startup shortcut does not restart the app.
+func isSupervised() bool {
+	return false
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const updatePollInterval = 10 * time.Second
+
+// UpdateWatcher detects when the executable of the running process is replaced, which is
+// how updaters install a new version.
+type UpdateWatcher struct {
+	logger   logger.Logger
+	interval time.Duration
+}
+
+// NewUpdateWatcher creates a new update watcher.
+func NewUpdateWatcher(logger logger.Logger) *UpdateWatcher {
+	return &UpdateWatcher{
+		logger:   logger,
+		interval: updatePollInterval,
+	}
+}
+
+// Run polls the executable until the context is canceled or an update is detected, in
+// which case onUpdate is called once. A change is only reported after it stays the same
+// for a whole interval so files still being written are not picked up.
+func (w *UpdateWatcher) Run(ctx context.Context, onUpdate func()) {
+	exe, err := executablePath()
+	if err != nil {
+		w.logger.Debug(ctx, "update detection unavailable", "err", err)
+		return
+	}
+
+	initial, err := os.Stat(exe)
+	if err != nil {
+		w.logger.Debug(ctx, "update detection unavailable", "err", err)
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var pending os.FileInfo
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// The file is briefly missing while some updaters swap it.
+		current, err := os.Stat(exe)
+		if err != nil || sameFile(current, initial) {
+			pending = nil
+			continue
+		}
+
+		if pending == nil || !sameFile(current, pending) {
+			pending = current
+			continue
+		}
+
+		w.logger.Info(ctx, "executable updated", "path", exe, "modified", current.ModTime())
+		onUpdate()
+		return
+	}
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	return a.Size() == b.Size() && a.ModTime().Equal(b.ModTime()) && os.SameFile(a, b)
+}
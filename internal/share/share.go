@@ -0,0 +1,199 @@
+// Package share runs the local HTTP server. It serves history entries through temporary
+// links, so a dictation can be opened on another device of the LAN; every link carries a
+// random token, can be opened once and expires after linkTTL. Other features mount their
+// endpoints on it with Handle.
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const (
+	// linkTTL is how long a link can be opened after it is created.
+	linkTTL = 10 * time.Minute
+	// linkPath prefixes the token in the link paths.
+	linkPath = "/share/"
+)
+
+// page renders a shared text, the text is escaped by html/template.
+var page = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto; padding: 0 1em;">
+<p style="white-space: pre-wrap;">{{.Text}}</p>
+</body>
+</html>
+`))
+
+// link is a text waiting to be opened.
+type link struct {
+	text    string
+	expires time.Time
+}
+
+// Server is the local server.
+type Server struct {
+	logger logger.Logger
+	mux    *http.ServeMux
+
+	mu    sync.Mutex
+	base  string
+	links map[string]link
+}
+
+// NewServer creates the local server, Run starts it.
+func NewServer(logger logger.Logger) *Server {
+	s := &Server{
+		logger: logger,
+		mux:    http.NewServeMux(),
+		links:  make(map[string]link),
+	}
+	s.mux.HandleFunc("GET "+linkPath+"{token}", s.serveLink)
+	return s
+}
+
+// Handle registers the handler of another endpoint, see http.ServeMux for the pattern
+// syntax. It must be called before Run.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// BaseURL returns the URL other devices reach a server listening on addr at, see Run.
+func BaseURL(addr string) (string, error) {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid local server address %q: %w", addr, err)
+	}
+	return "http://" + advertisedAddr(tcpAddr), nil
+}
+
+// Run serves the links and the other endpoints on addr until ctx is canceled. Listening
+// on all interfaces (e.g. ":8723") makes them reachable from the LAN; the links then use
+// the LAN address of the machine.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	s.base = "http://" + advertisedAddr(listener.Addr().(*net.TCPAddr))
+	s.mu.Unlock()
+
+	server := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	s.logger.Info(ctx, "local server listening", "addr", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("local server failed: %w", err)
+	}
+	return nil
+}
+
+// Share creates a one-time link serving the text and returns its URL. It fails if the
+// server is not running.
+func (s *Server) Share(text string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.base == "" {
+		return "", errors.New("the local server is not running, enable it in the settings")
+	}
+
+	now := time.Now()
+	for token, l := range s.links {
+		if now.After(l.expires) {
+			delete(s.links, token)
+		}
+	}
+	s.links[token] = link{text: text, expires: now.Add(linkTTL)}
+	return s.base + linkPath + token, nil
+}
+
+// serveLink serves the text of a link and forgets it. The text is sent as an HTML page,
+// or as plain text when the client asks for it (e.g. `curl -H "Accept: text/plain"`) or
+// adds ?format=text.
+func (s *Server) serveLink(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	s.mu.Lock()
+	l, ok := s.links[token]
+	delete(s.links, token)
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(l.expires) {
+		http.Error(w, "This link has expired or was already opened.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	if r.URL.Query().Get("format") == "text" || strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(l.text))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Title, Text string }{Title: config.AppName + " transcription", Text: l.text}
+	if err := page.Execute(w, data); err != nil {
+		s.logger.Warn(r.Context(), "failed to render shared text", "err", err)
+	}
+}
+
+// advertisedAddr returns the host and port links should use: the listening address, or
+// the LAN address of the machine when listening on all interfaces.
+func advertisedAddr(addr *net.TCPAddr) string {
+	if addr.IP != nil && !addr.IP.IsUnspecified() {
+		return addr.String()
+	}
+
+	host := "localhost"
+	if ip := lanIP(); ip != nil {
+		host = ip.String()
+	}
+	return net.JoinHostPort(host, fmt.Sprint(addr.Port))
+}
+
+// lanIP returns the address of the interface used to reach other networks, nil if there
+// is none. No packet is sent, UDP sockets only pick the route when connecting.
+func lanIP() net.IP {
+	conn, err := net.Dial("udp", "192.0.2.1:9")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP
+}
@@ -0,0 +1,201 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+const (
+	defaultSMTPPort     = 587
+	defaultEmailSubject = "Dictation ${date} ${time}"
+)
+
+// emailSink opens a prefilled compose window through a mailto: link or sends the
+// transcription through an SMTP server.
+type emailSink struct {
+	cfg config.SinkConfig
+}
+
+func (s *emailSink) Send(ctx context.Context, text string) error {
+	subjectTemplate := s.cfg.EmailSubject
+	if subjectTemplate == "" {
+		subjectTemplate = defaultEmailSubject
+	}
+
+	subject := render(subjectTemplate, text)
+	body := text
+	if s.cfg.Template != "" {
+		body = render(s.cfg.Template, text)
+	}
+
+	if s.cfg.EmailMode == config.EmailModeSMTP {
+		return s.sendSMTP(ctx, subject, body)
+	}
+	return OpenURL(ctx, s.mailtoURL(subject, body))
+}
+
+// mailtoURL builds a RFC 6068 mailto URL.
+func (s *emailSink) mailtoURL(subject, body string) string {
+	query := url.Values{}
+	query.Set("subject", subject)
+	query.Set("body", body)
+
+	// mailto expects %20 for spaces instead of the form encoding "+".
+	encoded := strings.ReplaceAll(query.Encode(), "+", "%20")
+	return "mailto:" + url.PathEscape(s.cfg.EmailTo) + "?" + encoded
+}
+
+func (s *emailSink) sendSMTP(ctx context.Context, subject, body string) error {
+	if s.cfg.SMTPHost == "" || s.cfg.EmailTo == "" {
+		return fmt.Errorf("SMTP host and recipient are required")
+	}
+
+	port := s.cfg.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	from := s.cfg.SMTPFrom
+	if from == "" {
+		from = s.cfg.SMTPUsername
+	}
+	recipients := strings.Split(s.cfg.EmailTo, ",")
+	for idx := range recipients {
+		recipients[idx] = strings.TrimSpace(recipients[idx])
+	}
+
+	client, err := s.dialSMTP(ctx, port)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if s.cfg.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("SMTP MAIL command failed: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP RCPT command failed for %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA command failed: %w", err)
+	}
+	if _, err := writer.Write(buildMessage(from, recipients, subject, body)); err != nil {
+		return fmt.Errorf("failed to write e-mail: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send e-mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dialSMTP connects to the SMTP server using implicit TLS on port 465 and STARTTLS
+// (when offered) on any other port.
+func (s *emailSink) dialSMTP(ctx context.Context, port int) (*smtp.Client, error) {
+	address := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: s.cfg.SMTPHost}
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok && port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", singleLine(from))
+	fmt.Fprintf(&sb, "To: %s\r\n", singleLine(strings.Join(to, ", ")))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mimeEncodeHeader(singleLine(subject)))
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
+
+// singleLine replaces the line breaks of a header value with spaces, so a dictated subject
+// cannot end the header and inject others.
+func singleLine(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+}
+
+// mimeEncodeHeader encodes non-ASCII header values as RFC 2047 encoded words.
+func mimeEncodeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return mime.QEncoding.Encode("UTF-8", value)
+		}
+	}
+	return value
+}
+
+// OpenURL opens a URL with the default handler of the operating system.
+func OpenURL(ctx context.Context, target string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(ctx, "xdg-open", target)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", target)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return fmt.Errorf("opening URLs is not supported on %s", runtime.GOOS)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to open %s handler: %w", strings.SplitN(target, ":", 2)[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package sink delivers transcriptions to additional destinations besides the clipboard,
+// such as e-mail drafts, Slack and Discord channels or generic webhooks. Sinks are configured in the settings and every
+// enabled sink receives each transcription.
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const sendTimeout = 15 * time.Second
+
+// Sink is a destination for transcriptions.
+type Sink interface {
+	// Send delivers the text to the destination.
+	Send(ctx context.Context, text string) error
+}
+
+// Instance dispatches transcriptions to the enabled sinks.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	client          *http.Client
+}
+
+// New creates a new sink dispatcher.
+func New(logger logger.Logger, settingsManager *config.SettingsManager) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		client: &http.Client{
+			Timeout: sendTimeout,
+		},
+	}
+}
+
+// Dispatch sends the text to every enabled sink and returns the joined errors of the
+// sinks that failed.
+func (s *Instance) Dispatch(ctx context.Context, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	var errs []error
+	for _, cfg := range s.settingsManager.Get().Sinks {
+		if !cfg.Enabled {
+			continue
+		}
+
+		if err := s.send(ctx, cfg, text); err != nil {
+			errs = append(errs, fmt.Errorf("sink %q: %w", cfg.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SendTo sends the text to the sink with the given ID, whether or not it is enabled for
+// regular transcriptions.
+func (s *Instance) SendTo(ctx context.Context, id, text string) error {
+	cfg, ok := s.settingsManager.Get().FindSink(id)
+	if !ok {
+		return fmt.Errorf("sink %q not found", id)
+	}
+	return s.send(ctx, cfg, text)
+}
+
+// send builds the sink for the configuration and delivers the text with a timeout.
+func (s *Instance) send(ctx context.Context, cfg config.SinkConfig, text string) error {
+	sink, err := s.build(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if err := sink.Send(ctx, text); err != nil {
+		return err
+	}
+
+	s.logger.Debug(ctx, "transcription sent to sink", "sink", cfg.Name, "type", cfg.Type)
+	return nil
+}
+
+// build creates the sink implementation for a configuration.
+func (s *Instance) build(cfg config.SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case config.SinkTypeEmail:
+		return &emailSink{cfg: cfg}, nil
+	case config.SinkTypeSlack, config.SinkTypeDiscord, config.SinkTypeWebhook:
+		return &webhookSink{cfg: cfg, client: s.client}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+// render replaces the template placeholders: ${output} with the transcription and ${date}
+// and ${time} with the current local date and time.
+func render(template, text string) string {
+	now := time.Now()
+	replacer := strings.NewReplacer(
+		"${output}", text,
+		"${date}", now.Format("2006-01-02"),
+		"${time}", now.Format("15:04"),
+	)
+	return replacer.Replace(template)
+}
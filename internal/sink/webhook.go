@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// discordMaxMessageLength is the maximum number of characters of a Discord message.
+const discordMaxMessageLength = 2000
+
+// webhookSink posts transcriptions to a Slack incoming webhook, a Discord webhook or a
+// generic webhook, which receives the same {"text": ...} payload as Slack.
+type webhookSink struct {
+	cfg    config.SinkConfig
+	client *http.Client
+}
+
+func (s *webhookSink) Send(ctx context.Context, text string) error {
+	if s.cfg.WebhookURL == "" {
+		return fmt.Errorf("webhook URL is required")
+	}
+
+	message := text
+	if s.cfg.Template != "" {
+		message = render(s.cfg.Template, text)
+	}
+
+	if s.cfg.Type == config.SinkTypeDiscord {
+		for _, chunk := range splitMessage(message, discordMaxMessageLength) {
+			if err := s.post(ctx, map[string]string{"content": chunk}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return s.post(ctx, map[string]string{"text": message})
+}
+
+func (s *webhookSink) post(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, respBody)
+	}
+
+	return nil
+}
+
+// splitMessage splits a message into chunks of at most limit characters, preferring to
+// break at whitespace.
+func splitMessage(message string, limit int) []string {
+	runes := []rune(message)
+	var chunks []string
+
+	for len(runes) > limit {
+		cut := limit
+		for idx := limit; idx > limit/2; idx-- {
+			if runes[idx] == ' ' || runes[idx] == '\n' {
+				cut = idx
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+		for len(runes) > 0 && (runes[0] == ' ' || runes[0] == '\n') {
+			runes = runes[1:]
+		}
+	}
+
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -7,7 +7,9 @@ import (
 	"os/exec"
 	"runtime"
 	"sync"
+	"time"
 
+	"github.com/varavelio/tribar/internal/dispatch"
 	"github.com/varavelio/tribar/internal/logger"
 )
 
@@ -27,9 +29,10 @@ func DefaultSettings() Settings {
 
 // Instance handles audio feedback.
 type Instance struct {
-	logger   logger.Logger
-	settings Settings
-	mu       sync.Mutex
+	logger     logger.Logger
+	settings   Settings
+	dispatcher *dispatch.Instance
+	mu         sync.Mutex
 }
 
 // New creates a new sound instance.
@@ -37,6 +40,11 @@ func New(logger logger.Logger, settings Settings) *Instance {
 	return &Instance{
 		logger:   logger,
 		settings: settings,
+		dispatcher: dispatch.New(logger, dispatch.Options{
+			Name:      "sound",
+			QueueSize: 4,
+			Timeout:   3 * time.Second,
+		}),
 	}
 }
 
@@ -64,7 +72,7 @@ func (s *Instance) TranscriptionStarted(ctx context.Context) {
 		return
 	}
 
-	go s.playBeep(ctx, 440, 100) // A4 note, 100ms
+	s.play(440, 100) // A4 note, 100ms
 }
 
 // TranscriptionFinished plays a sound when transcription completes.
@@ -77,7 +85,15 @@ func (s *Instance) TranscriptionFinished(ctx context.Context) {
 		return
 	}
 
-	go s.playBeep(ctx, 880, 150) // A5 note, 150ms
+	s.play(880, 150) // A5 note, 150ms
+}
+
+// play queues a beep so the caller never waits for the sound to finish.
+func (s *Instance) play(frequency, durationMs int) {
+	s.dispatcher.Submit(func(ctx context.Context) error {
+		s.playBeep(ctx, frequency, durationMs)
+		return nil
+	})
 }
 
 // playBeep plays a beep sound using system tools.
@@ -129,7 +145,7 @@ func itoa(n int) string {
 	return string(digits)
 }
 
-// Shutdown is a no-op for this implementation.
-func (s *Instance) Shutdown() {
-	// Nothing to clean up
+// Shutdown waits for the queued sounds to finish playing, giving up when ctx is done.
+func (s *Instance) Shutdown(ctx context.Context) {
+	s.dispatcher.Shutdown(ctx)
 }
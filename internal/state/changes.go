@@ -0,0 +1,28 @@
+package state
+
+// Subscribe returns a channel that receives a value after the state shown by frontends
+// changes: the status, the battery saver, the privacy mode, the partial text, the input
+// level or the progress. Changes made before the value is received are coalesced into
+// it, so subscribers read the state again instead of tracking every change, and never
+// block the engine.
+func (i *Instance) Subscribe() <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	i.subscribersMu.Lock()
+	defer i.subscribersMu.Unlock()
+	i.subscribers = append(i.subscribers, changes)
+	return changes
+}
+
+// notify signals a change to the subscribers.
+func (i *Instance) notify() {
+	i.subscribersMu.Lock()
+	defer i.subscribersMu.Unlock()
+
+	for _, changes := range i.subscribers {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
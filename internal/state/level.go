@@ -0,0 +1,30 @@
+package state
+
+// InputLevel is the level of the audio being recorded, in dBFS: 0 is full scale and
+// digital silence is negative infinity.
+type InputLevel struct {
+	Peak float64
+	RMS  float64
+}
+
+// SetInputLevel reports the level of the audio being recorded.
+func (i *Instance) SetInputLevel(level InputLevel) {
+	i.inputLevel.Store(&level)
+	i.notify()
+}
+
+// ClearInputLevel reports that nothing is being recorded.
+func (i *Instance) ClearInputLevel() {
+	if i.inputLevel.Swap(nil) != nil {
+		i.notify()
+	}
+}
+
+// GetInputLevel returns the level of the audio being recorded, false if nothing is.
+func (i *Instance) GetInputLevel() (InputLevel, bool) {
+	level := i.inputLevel.Load()
+	if level == nil {
+		return InputLevel{}, false
+	}
+	return *level, true
+}
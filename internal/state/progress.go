@@ -0,0 +1,65 @@
+package state
+
+import "time"
+
+// ProgressPhase identifies the long-running task a Progress reports.
+type ProgressPhase string
+
+const (
+	ProgressDownload      ProgressPhase = "download"
+	ProgressTranscription ProgressPhase = "transcription"
+)
+
+// Progress is the normalized progress of a long-running task, the same for every frontend.
+// Fraction goes from 0 to 1 and ETA is the estimated remaining time, zero until it can be
+// estimated.
+type Progress struct {
+	Phase    ProgressPhase
+	Fraction float64
+	ETA      time.Duration
+}
+
+// SetProgress reports the progress of the running task. The ETA is extrapolated from the
+// time elapsed since the phase started, which restarts when the phase changes or the
+// fraction goes back (e.g. the next file of a download).
+func (i *Instance) SetProgress(phase ProgressPhase, fraction float64) {
+	fraction = min(max(fraction, 0), 1)
+
+	i.progressMu.Lock()
+	defer i.progressMu.Unlock()
+
+	now := time.Now()
+	if i.progress == nil || i.progress.Phase != phase || fraction < i.progress.Fraction {
+		i.progressStart = now
+	}
+
+	var eta time.Duration
+	if fraction > 0 {
+		elapsed := now.Sub(i.progressStart)
+		eta = time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	}
+
+	i.progress = &Progress{Phase: phase, Fraction: fraction, ETA: eta}
+	i.notify()
+}
+
+// ClearProgress reports that no long-running task is in progress.
+func (i *Instance) ClearProgress() {
+	i.progressMu.Lock()
+	defer i.progressMu.Unlock()
+	if i.progress != nil {
+		i.progress = nil
+		i.notify()
+	}
+}
+
+// GetProgress returns the progress of the running task, false if there is none.
+func (i *Instance) GetProgress() (Progress, bool) {
+	i.progressMu.Lock()
+	defer i.progressMu.Unlock()
+
+	if i.progress == nil {
+		return Progress{}, false
+	}
+	return *i.progress, true
+}
@@ -0,0 +1,174 @@
+package state
+
+import "time"
+
+// maxSessions is the number of recent sessions kept in memory.
+const maxSessions = 20
+
+// SessionUtterance is a single dictation belonging to a session, with the markers added
+// while it was recorded.
+type SessionUtterance struct {
+	Text      string    `json:"text"`
+	AudioPath string    `json:"audio_path"`
+	Markers   []Marker  `json:"markers,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Session groups the utterances dictated within a time window or while a named session
+// was explicitly active.
+type Session struct {
+	ID           int                `json:"id"`
+	Name         string             `json:"name"`
+	Explicit     bool               `json:"explicit"`
+	Active       bool               `json:"active"`
+	StartedAt    time.Time          `json:"started_at"`
+	LastActivity time.Time          `json:"last_activity"`
+	Utterances   []SessionUtterance `json:"utterances"`
+	Summary      string             `json:"summary"`
+}
+
+// DefaultSessionName returns the name given to sessions started at t without an explicit name.
+func DefaultSessionName(t time.Time) string {
+	return t.Format("Session 2006-01-02 15:04")
+}
+
+// StartSession starts a new named session, ending any session that is still active.
+// All following utterances are grouped into it until EndSession is called.
+func (i *Instance) StartSession(name string) Session {
+	i.sessionsMu.Lock()
+	defer i.sessionsMu.Unlock()
+
+	i.endActiveSessionUnsafe()
+
+	now := time.Now()
+	session := Session{
+		ID:           i.nextSessionID,
+		Name:         name,
+		Explicit:     true,
+		Active:       true,
+		StartedAt:    now,
+		LastActivity: now,
+		Utterances:   make([]SessionUtterance, 0),
+	}
+	i.nextSessionID++
+	i.pushSessionUnsafe(session)
+
+	return session
+}
+
+// EndSession ends the currently active session, if any.
+func (i *Instance) EndSession() {
+	i.sessionsMu.Lock()
+	defer i.sessionsMu.Unlock()
+	i.endActiveSessionUnsafe()
+}
+
+// AddSessionUtterance appends an utterance to the active named session or, when there is
+// none, to the latest implicit session if its last activity happened within window.
+// Otherwise a new implicit session named newSessionName (or after the current time when
+// empty) is started. It returns the ID of the session used.
+func (i *Instance) AddSessionUtterance(text, audioPath string, markers []Marker, window time.Duration, newSessionName string) int {
+	i.sessionsMu.Lock()
+	defer i.sessionsMu.Unlock()
+
+	now := time.Now()
+	utterance := SessionUtterance{Text: text, AudioPath: audioPath, Markers: markers, Timestamp: now}
+
+	if len(i.sessions) > 0 {
+		latest := &i.sessions[0]
+		if latest.Active && (latest.Explicit || now.Sub(latest.LastActivity) <= window) {
+			latest.Utterances = append(latest.Utterances, utterance)
+			latest.LastActivity = now
+			return latest.ID
+		}
+	}
+
+	i.endActiveSessionUnsafe()
+
+	if newSessionName == "" {
+		newSessionName = DefaultSessionName(now)
+	}
+
+	session := Session{
+		ID:           i.nextSessionID,
+		Name:         newSessionName,
+		Active:       true,
+		StartedAt:    now,
+		LastActivity: now,
+		Utterances:   []SessionUtterance{utterance},
+	}
+	i.nextSessionID++
+	i.pushSessionUnsafe(session)
+
+	return session.ID
+}
+
+// SetSessionSummary stores the generated summary of a session.
+func (i *Instance) SetSessionSummary(id int, summary string) bool {
+	i.sessionsMu.Lock()
+	defer i.sessionsMu.Unlock()
+
+	for idx := range i.sessions {
+		if i.sessions[idx].ID == id {
+			i.sessions[idx].Summary = summary
+			return true
+		}
+	}
+	return false
+}
+
+// GetSessions returns a copy of the recent sessions, newest first.
+func (i *Instance) GetSessions() []Session {
+	i.sessionsMu.RLock()
+	defer i.sessionsMu.RUnlock()
+
+	result := make([]Session, len(i.sessions))
+	for idx, session := range i.sessions {
+		result[idx] = copySession(session)
+	}
+	return result
+}
+
+// GetSession retrieves a copy of a specific session by ID.
+func (i *Instance) GetSession(id int) (Session, bool) {
+	i.sessionsMu.RLock()
+	defer i.sessionsMu.RUnlock()
+
+	for _, session := range i.sessions {
+		if session.ID == id {
+			return copySession(session), true
+		}
+	}
+	return Session{}, false
+}
+
+// GetLatestSession returns a copy of the most recent session.
+func (i *Instance) GetLatestSession() (Session, bool) {
+	i.sessionsMu.RLock()
+	defer i.sessionsMu.RUnlock()
+
+	if len(i.sessions) == 0 {
+		return Session{}, false
+	}
+	return copySession(i.sessions[0]), true
+}
+
+// endActiveSessionUnsafe marks the latest session as ended without acquiring the lock.
+func (i *Instance) endActiveSessionUnsafe() {
+	if len(i.sessions) > 0 {
+		i.sessions[0].Active = false
+	}
+}
+
+// pushSessionUnsafe prepends a session and trims old ones without acquiring the lock.
+func (i *Instance) pushSessionUnsafe(session Session) {
+	i.sessions = append([]Session{session}, i.sessions...)
+	if len(i.sessions) > maxSessions {
+		i.sessions = i.sessions[:maxSessions]
+	}
+}
+
+func copySession(session Session) Session {
+	session.Utterances = append([]SessionUtterance(nil), session.Utterances...)
+	return session
+}
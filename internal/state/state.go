@@ -1,7 +1,12 @@
 package state
 
 import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,16 +18,97 @@ const (
 	StatusLoading
 	StatusLoaded
 	StatusListening
+	// StatusPaused is a recording in progress that captures no audio until it resumes.
+	StatusPaused
 	StatusTranscribing
 	StatusPostProcessing
 )
 
-// HistoryEntry represents a single transcription record.
+// Source identifies how the text of a history entry was produced.
+type Source string
+
+const (
+	SourceLocal  Source = "local"
+	SourceRemote Source = "remote"
+	SourceOCR    Source = "ocr"
+)
+
+// HistoryEntry represents a single transcription record. Confidence is the mean
+// probability of the tokens the model emitted, zero when the source does not report it.
+// Segments locate the transcribed text, before post-processing, in the audio; they are
+// empty when the source does not report timestamps. Markers are the moments flagged while
+// recording. UID identifies the entry across machines, for the history sync, while ID is
+// only unique on this one.
 type HistoryEntry struct {
-	ID        int       `json:"id"`
-	Text      string    `json:"text"`
-	AudioPath string    `json:"audio_path"`
-	Timestamp time.Time `json:"timestamp"`
+	ID         int       `json:"id"`
+	UID        string    `json:"uid"`
+	Text       string    `json:"text"`
+	AudioPath  string    `json:"audio_path"`
+	SessionID  int       `json:"session_id"`
+	Tags       []string  `json:"tags"`
+	Source     Source    `json:"source"`
+	Confidence float32   `json:"confidence"`
+	Segments   []Segment `json:"segments,omitempty"`
+	Markers    []Marker  `json:"markers,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	// UpdatedAt is when the entry was last changed, e.g. retagged, so the newest version
+	// of an entry wins when histories are merged. It is the timestamp until then.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Modified returns when the entry was last changed, its timestamp for the entries stored
+// before UpdatedAt existed.
+func (e HistoryEntry) Modified() time.Time {
+	if e.UpdatedAt.IsZero() {
+		return e.Timestamp
+	}
+	return e.UpdatedAt
+}
+
+// Marker flags a moment of a recording, e.g. "important bit here". Offset is its position
+// in the audio and Note is optional.
+type Marker struct {
+	Offset time.Duration `json:"offset"`
+	Note   string        `json:"note,omitempty"`
+}
+
+// Position returns the offset of the marker as minutes and seconds, e.g. "12:05", with
+// the hours first for long recordings ("1:02:05").
+func (m Marker) Position() string {
+	seconds := int(m.Offset / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Segment is a span of a transcription and where it is in the audio.
+type Segment struct {
+	Text  string        `json:"text"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// HasTag reports whether the entry is labeled with the given tag.
+func (e HistoryEntry) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, NormalizeTag(tag))
+}
+
+// NormalizeTag returns the canonical form of a tag: trimmed and lowercase.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags normalizes and deduplicates tags, dropping empty ones.
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
 }
 
 // Instance represents the application state, this state is used in all other
@@ -32,10 +118,28 @@ type Instance struct {
 	statusPrevious Status
 	statusCurrent  Status
 
+	batterySaver  atomic.Bool
+	privacyMode   atomic.Bool
+	ocrMissing    atomic.Bool
+	throttleLevel atomic.Int32
+	partialText   atomic.Pointer[string]
+	inputLevel    atomic.Pointer[InputLevel]
+
+	progressMu    sync.Mutex
+	progress      *Progress
+	progressStart time.Time
+
 	historyMu    sync.RWMutex
 	history      []HistoryEntry
 	historyLimit int
 	nextID       int
+
+	sessionsMu    sync.RWMutex
+	sessions      []Session
+	nextSessionID int
+
+	subscribersMu sync.Mutex
+	subscribers   []chan struct{}
 }
 
 // New creates a new Instance with the initial status set to StatusUnloaded.
@@ -48,6 +152,9 @@ func New(historyLimit int) *Instance {
 		history:        make([]HistoryEntry, 0),
 		historyLimit:   historyLimit,
 		nextID:         1,
+		sessionsMu:     sync.RWMutex{},
+		sessions:       make([]Session, 0),
+		nextSessionID:  1,
 	}
 }
 
@@ -55,9 +162,10 @@ func New(historyLimit int) *Instance {
 // the previous status to the current one before the change.
 func (i *Instance) SetStatus(newStatus Status) {
 	i.statusMu.Lock()
-	defer i.statusMu.Unlock()
 	i.statusPrevious = i.statusCurrent
 	i.statusCurrent = newStatus
+	i.statusMu.Unlock()
+	i.notify()
 }
 
 // GetStatus retrieves the current and previous statuses of the application instance.
@@ -67,17 +175,83 @@ func (i *Instance) GetStatus() (current Status, previous Status) {
 	return i.statusCurrent, i.statusPrevious
 }
 
-// AddHistoryEntry adds a new transcription to the history.
-func (i *Instance) AddHistoryEntry(text, audioPath string) {
+// SetBatterySaver sets whether the battery saver mode is currently active.
+func (i *Instance) SetBatterySaver(active bool) {
+	if i.batterySaver.Swap(active) != active {
+		i.notify()
+	}
+}
+
+// IsBatterySaverActive reports whether the battery saver mode is currently active.
+func (i *Instance) IsBatterySaverActive() bool {
+	return i.batterySaver.Load()
+}
+
+// SetTextRecognitionMissing sets whether text recognition is enabled in the settings but
+// cannot work because Tesseract is not installed.
+func (i *Instance) SetTextRecognitionMissing(missing bool) {
+	if i.ocrMissing.Swap(missing) != missing {
+		i.notify()
+	}
+}
+
+// IsTextRecognitionMissing reports whether text recognition is enabled in the settings
+// but cannot work because Tesseract is not installed.
+func (i *Instance) IsTextRecognitionMissing() bool {
+	return i.ocrMissing.Load()
+}
+
+// SetPrivacyMode sets whether dictations are currently kept out of the history, the saved
+// audio and the sinks.
+func (i *Instance) SetPrivacyMode(active bool) {
+	if i.privacyMode.Swap(active) != active {
+		i.notify()
+	}
+}
+
+// IsPrivacyModeActive reports whether the privacy mode is currently active.
+func (i *Instance) IsPrivacyModeActive() bool {
+	return i.privacyMode.Load()
+}
+
+// SetThrottleLevel sets the current background work throttle level, where zero means no
+// throttling and higher values mean more aggressive throttling.
+func (i *Instance) SetThrottleLevel(level int) {
+	i.throttleLevel.Store(int32(level))
+}
+
+// GetThrottleLevel returns the current background work throttle level.
+func (i *Instance) GetThrottleLevel() int {
+	return int(i.throttleLevel.Load())
+}
+
+// SetPartialText sets the text decoded so far by an ongoing transcription, an empty text
+// means there is no partial result.
+func (i *Instance) SetPartialText(text string) {
+	i.partialText.Store(&text)
+	i.notify()
+}
+
+// GetPartialText returns the text decoded so far by an ongoing transcription.
+func (i *Instance) GetPartialText() string {
+	text := i.partialText.Load()
+	if text == nil {
+		return ""
+	}
+	return *text
+}
+
+// AddHistoryEntry adds a new transcription to the history and returns it as stored. The
+// ID, UID and timestamp of the entry are assigned here and its tags are normalized.
+func (i *Instance) AddHistoryEntry(entry HistoryEntry) HistoryEntry {
 	i.historyMu.Lock()
 	defer i.historyMu.Unlock()
 
-	entry := HistoryEntry{
-		ID:        i.nextID,
-		Text:      text,
-		AudioPath: audioPath,
-		Timestamp: time.Now(),
-	}
+	entry.ID = i.nextID
+	entry.UID = rand.Text()
+	entry.Tags = normalizeTags(entry.Tags)
+	entry.Timestamp = time.Now()
+	entry.UpdatedAt = entry.Timestamp
 	i.nextID++
 
 	i.history = append([]HistoryEntry{entry}, i.history...)
@@ -85,6 +259,84 @@ func (i *Instance) AddHistoryEntry(text, audioPath string) {
 	if len(i.history) > i.historyLimit {
 		i.history = i.history[:i.historyLimit]
 	}
+	return entry
+}
+
+// LoadHistory replaces the history with the given entries, newest first, e.g. the ones
+// persisted by a previous run. The entries added after them get higher IDs.
+func (i *Instance) LoadHistory(entries []HistoryEntry) {
+	i.historyMu.Lock()
+	defer i.historyMu.Unlock()
+
+	i.history = make([]HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.UID == "" {
+			entry.UID = rand.Text()
+		}
+		i.history = append(i.history, entry)
+		i.nextID = max(i.nextID, entry.ID+1)
+	}
+
+	if len(i.history) > i.historyLimit {
+		i.history = i.history[:i.historyLimit]
+	}
+}
+
+// MergeHistory merges the entries of another history, e.g. the ones dictated on another
+// machine, matched by their UID: the unknown entries are added with new IDs, in
+// chronological order, and the known ones changed later than the local copy (see
+// Modified) replace it, keeping its ID, audio and session. Entries older than the ones the
+// history limit keeps are skipped. It returns the added and changed entries as stored.
+func (i *Instance) MergeHistory(entries []HistoryEntry) []HistoryEntry {
+	i.historyMu.Lock()
+	defer i.historyMu.Unlock()
+
+	known := make(map[string]int, len(i.history))
+	for idx, entry := range i.history {
+		known[entry.UID] = idx
+	}
+
+	merged := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.UID == "" || merged[entry.UID] {
+			continue
+		}
+		entry.Tags = normalizeTags(entry.Tags)
+
+		if idx, ok := known[entry.UID]; ok {
+			local := i.history[idx]
+			if !entry.Modified().After(local.Modified()) {
+				continue
+			}
+			entry.ID, entry.AudioPath, entry.SessionID = local.ID, local.AudioPath, local.SessionID
+			i.history[idx] = entry
+			merged[entry.UID] = true
+			continue
+		}
+
+		merged[entry.UID] = true
+		entry.ID = i.nextID
+		i.nextID++
+		i.history = append(i.history, entry)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(i.history, func(a, b HistoryEntry) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	if len(i.history) > i.historyLimit {
+		i.history = i.history[:i.historyLimit]
+	}
+
+	result := make([]HistoryEntry, 0, len(merged))
+	for _, entry := range i.history {
+		if merged[entry.UID] {
+			result = append(result, entry)
+		}
+	}
+	return result
 }
 
 // GetHistory returns a copy of the transcription history.
@@ -110,6 +362,36 @@ func (i *Instance) GetHistoryEntry(id int) (HistoryEntry, bool) {
 	return HistoryEntry{}, false
 }
 
+// GetHistoryByTag returns a copy of the history entries labeled with the given tag.
+func (i *Instance) GetHistoryByTag(tag string) []HistoryEntry {
+	i.historyMu.RLock()
+	defer i.historyMu.RUnlock()
+
+	result := make([]HistoryEntry, 0)
+	for _, entry := range i.history {
+		if entry.HasTag(tag) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// SetHistoryTags replaces the tags of a history entry. It returns false if the entry
+// does not exist.
+func (i *Instance) SetHistoryTags(id int, tags []string) bool {
+	i.historyMu.Lock()
+	defer i.historyMu.Unlock()
+
+	for idx := range i.history {
+		if i.history[idx].ID == id {
+			i.history[idx].Tags = normalizeTags(tags)
+			i.history[idx].UpdatedAt = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
 // ClearHistory removes all entries from the history.
 func (i *Instance) ClearHistory() {
 	i.historyMu.Lock()
@@ -117,6 +399,27 @@ func (i *Instance) ClearHistory() {
 	i.history = make([]HistoryEntry, 0)
 }
 
+// DeleteHistory removes the entries with the given UIDs from the history and returns them.
+func (i *Instance) DeleteHistory(uids []string) []HistoryEntry {
+	i.historyMu.Lock()
+	defer i.historyMu.Unlock()
+
+	remove := make(map[string]bool, len(uids))
+	for _, uid := range uids {
+		remove[uid] = true
+	}
+
+	var deleted []HistoryEntry
+	i.history = slices.DeleteFunc(i.history, func(entry HistoryEntry) bool {
+		if remove[entry.UID] {
+			deleted = append(deleted, entry)
+			return true
+		}
+		return false
+	})
+	return deleted
+}
+
 // SetHistoryLimit updates the maximum number of history entries.
 func (i *Instance) SetHistoryLimit(limit int) {
 	i.historyMu.Lock()
@@ -0,0 +1,90 @@
+package systray
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// deviceRefreshInterval is how often the "Input Device" submenu picks up the devices
+// connected or disconnected since it was built.
+const deviceRefreshInterval = 10 * time.Second
+
+// deviceMenu is the "Input Device" submenu, with a checkable item per input device keyed
+// by its ID, "" being the system's default device.
+type deviceMenu struct {
+	parent *systray.MenuItem
+
+	mu    sync.Mutex
+	items map[string]*systray.MenuItem
+}
+
+// addDeviceMenu adds the "Input Device" submenu listing the input devices.
+func (i *Instance) addDeviceMenu() {
+	menu := &deviceMenu{
+		parent: systray.AddMenuItem("Input Device", "Microphone the recordings are captured from"),
+		items:  make(map[string]*systray.MenuItem),
+	}
+	i.addDeviceOption(menu, "", "System Default")
+	i.refreshDevices(menu)
+
+	go func() {
+		ticker := time.NewTicker(deviceRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			i.refreshDevices(menu)
+		}
+	}()
+}
+
+// addDeviceOption adds the item selecting a device, menu.mu must be held once the menu is
+// built.
+func (i *Instance) addDeviceOption(menu *deviceMenu, id, name string) {
+	item := menu.parent.AddSubMenuItemCheckbox(name, "Record from "+name, false)
+	menu.items[id] = item
+
+	go func() {
+		for range item.ClickedCh {
+			i.engine.SelectInputDevice(id)
+			i.refreshDevices(menu)
+		}
+	}()
+}
+
+// refreshDevices adds the items of new devices, hides those of disconnected ones and
+// checks the device recordings use: the selected one, or the system's default device
+// while it is not connected.
+func (i *Instance) refreshDevices(menu *deviceMenu) {
+	devices, selected, err := i.engine.InputDevices()
+	if err != nil {
+		return
+	}
+
+	menu.mu.Lock()
+	defer menu.mu.Unlock()
+
+	connected := map[string]bool{"": true}
+	for _, device := range devices {
+		connected[device.ID] = true
+		if _, ok := menu.items[device.ID]; !ok {
+			i.addDeviceOption(menu, device.ID, device.Name)
+		}
+	}
+	if !connected[selected] {
+		selected = ""
+	}
+
+	for id, item := range menu.items {
+		if connected[id] {
+			item.Show()
+		} else {
+			item.Hide()
+		}
+		if id == selected {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+}
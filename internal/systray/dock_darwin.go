@@ -0,0 +1,23 @@
+//go:build darwin
+
+package systray
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+static void hideDockIcon(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		[NSApp setActivationPolicy:NSApplicationActivationPolicyAccessory];
+	});
+}
+*/
+import "C"
+
+// hideDockIcon makes the app a menu-bar only agent, like LSUIElement does for bundles, so
+// running the bare binary does not add a Dock icon or an application menu.
+func hideDockIcon() {
+	C.hideDockIcon()
+}
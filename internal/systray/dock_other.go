@@ -0,0 +1,6 @@
+//go:build !darwin
+
+package systray
+
+// hideDockIcon is a no-op outside macOS, where tray apps have no Dock icon.
+func hideDockIcon() {}
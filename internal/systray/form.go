@@ -0,0 +1,40 @@
+package systray
+
+import "fyne.io/systray"
+
+// formOption is a checkable submenu item selecting a form template.
+type formOption struct {
+	templateID string
+	item       *systray.MenuItem
+}
+
+// addFormMenu adds the "Form" submenu listing the form templates.
+func (i *Instance) addFormMenu() {
+	templates, activeID := i.engine.FormTemplates()
+
+	parent := systray.AddMenuItem("Form", "Fill the fields of a form with the next dictations")
+	options := []formOption{
+		{templateID: "", item: parent.AddSubMenuItemCheckbox("Off", "Output every dictation as it is", activeID == "")},
+	}
+	for _, template := range templates {
+		item := parent.AddSubMenuItemCheckbox(template.Name, "Fill the "+template.Name+" form", template.ID == activeID)
+		options = append(options, formOption{templateID: template.ID, item: item})
+	}
+
+	for _, option := range options {
+		go i.handleFormClicks(option, options)
+	}
+}
+
+func (i *Instance) handleFormClicks(option formOption, options []formOption) {
+	for range option.item.ClickedCh {
+		i.engine.SelectFormTemplate(option.templateID)
+		for _, other := range options {
+			if other.templateID == option.templateID {
+				other.item.Check()
+				continue
+			}
+			other.item.Uncheck()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package systray
+
+import (
+	"runtime"
+	"strings"
+)
+
+// macModifierSymbols maps modifier names to the symbols macOS menus use, in the order
+// macOS displays them.
+var macModifierSymbols = []struct {
+	names  []string
+	symbol string
+}{
+	{names: []string{"ctrl", "control"}, symbol: "⌃"},
+	{names: []string{"alt", "option", "opt"}, symbol: "⌥"},
+	{names: []string{"shift"}, symbol: "⇧"},
+	{names: []string{"cmd", "command", "super", "meta"}, symbol: "⌘"},
+}
+
+// formatHotkey turns a binding like "cmd+shift+space" into the label shown in menu item
+// titles: "⇧⌘Space" on macOS and "Cmd+Shift+Space" elsewhere.
+func formatHotkey(binding string) string {
+	var parts []string
+	for part := range strings.SplitSeq(binding, "+") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	if runtime.GOOS != "darwin" {
+		for n, part := range parts {
+			parts[n] = capitalize(part)
+		}
+		return strings.Join(parts, "+")
+	}
+
+	modifiers := make(map[string]bool, len(parts)-1)
+	for _, part := range parts[:len(parts)-1] {
+		modifiers[strings.ToLower(part)] = true
+	}
+
+	var label strings.Builder
+	for _, modifier := range macModifierSymbols {
+		for _, name := range modifier.names {
+			if modifiers[name] {
+				label.WriteString(modifier.symbol)
+				break
+			}
+		}
+	}
+	label.WriteString(capitalize(parts[len(parts)-1]))
+	return label.String()
+}
+
+func capitalize(word string) string {
+	if len(word) == 1 {
+		return strings.ToUpper(word)
+	}
+	return strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+}
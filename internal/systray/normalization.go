@@ -0,0 +1,40 @@
+package systray
+
+import "fyne.io/systray"
+
+// normalizationOption is a checkable submenu item selecting a normalization profile.
+type normalizationOption struct {
+	profileID string
+	item      *systray.MenuItem
+}
+
+// addNormalizationMenu adds the "Text Style" submenu listing the normalization profiles.
+func (i *Instance) addNormalizationMenu() {
+	profiles, activeID := i.engine.NormalizationProfiles()
+
+	parent := systray.AddMenuItem("Text Style", "Formatting applied to the final text")
+	options := []normalizationOption{
+		{profileID: "", item: parent.AddSubMenuItemCheckbox("Automatic", "Format the text with the style of the target application, unchanged for others", activeID == "")},
+	}
+	for _, profile := range profiles {
+		item := parent.AddSubMenuItemCheckbox(profile.Name, "Format the text with the "+profile.Name+" style", profile.ID == activeID)
+		options = append(options, normalizationOption{profileID: profile.ID, item: item})
+	}
+
+	for _, option := range options {
+		go i.handleNormalizationClicks(option, options)
+	}
+}
+
+func (i *Instance) handleNormalizationClicks(option normalizationOption, options []normalizationOption) {
+	for range option.item.ClickedCh {
+		i.engine.SetNormalizationProfile(option.profileID)
+		for _, other := range options {
+			if other.profileID == option.profileID {
+				other.item.Check()
+				continue
+			}
+			other.item.Uncheck()
+		}
+	}
+}
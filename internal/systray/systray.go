@@ -1,17 +1,19 @@
 package systray
 
 import (
+	"fmt"
+	"math"
 	"runtime"
+	"strings"
 	"time"
 
 	"fyne.io/systray"
 	"github.com/varavelio/tribar/assets/logo"
 	"github.com/varavelio/tribar/internal/config"
 	"github.com/varavelio/tribar/internal/state"
+	"github.com/varavelio/tribar/pkg/record"
 )
 
-const animationFrameDuration = time.Millisecond * 200
-
 type animationPosition int
 
 const (
@@ -24,6 +26,34 @@ const (
 // Engine defines the interface for engine actions that systray can trigger.
 type Engine interface {
 	ToggleRecording()
+	StartSession(name string)
+	EndSession()
+	ExportLatestSession()
+	SummarizeLatestSession()
+	RecognizeClipboardImage()
+	NormalizationProfiles() (profiles []config.NormalizationProfile, activeID string)
+	SetNormalizationProfile(id string)
+	ToggleHotkey() string
+	PrivacyHotkey() string
+	MarkerHotkey() string
+	TogglePrivacyMode()
+	AnimationFrameDuration() time.Duration
+	TestMicrophone() error
+	Calibrate() error
+	CopyShareLink()
+	ShowScratchpad()
+	InputDevices() ([]record.Device, string, error)
+	SelectInputDevice(id string)
+	FormTemplates() (templates []config.FormTemplate, activeID string)
+	SelectFormTemplate(id string)
+	ExportLatestSubtitles()
+	AddMarkerFromMenu()
+	TogglePause()
+	CancelTranscription() bool
+	UnloadModels() error
+	ReloadModels()
+	DownloadDeferred() bool
+	DownloadModelsNow()
 }
 
 type Instance struct {
@@ -38,11 +68,41 @@ type Instance struct {
 	animationPosCurr  animationPosition
 	animationPosPrev  animationPosition
 	animationTimer    *time.Timer
+	// animationArmed is set while animationTimer is running, it only runs while the
+	// status is animated.
+	animationArmed bool
+	// changes receives the state changes, see state.Instance.Subscribe, and done stops
+	// the animation loop.
+	changes <-chan struct{}
+	done    chan struct{}
+
+	statusPrev       state.Status
+	batterySaverPrev bool
+	privacyModePrev  bool
+	ocrMissingPrev   bool
+	partialTextPrev  string
+	progressPrev     string
+	levelPrev        string
 
 	isShuttingDown bool
 
-	menuRecord *systray.MenuItem
-	menuQuit   *systray.MenuItem
+	menuRecord         *systray.MenuItem
+	menuCancel         *systray.MenuItem
+	menuMarker         *systray.MenuItem
+	menuPause          *systray.MenuItem
+	menuOCR            *systray.MenuItem
+	menuMicTest        *systray.MenuItem
+	menuCalibrate      *systray.MenuItem
+	menuShare          *systray.MenuItem
+	menuScratchpad     *systray.MenuItem
+	menuSubtitles      *systray.MenuItem
+	menuModels         *systray.MenuItem
+	menuPrivacy        *systray.MenuItem
+	menuSessionStart   *systray.MenuItem
+	menuSessionEnd     *systray.MenuItem
+	menuSessionExport  *systray.MenuItem
+	menuSessionSummary *systray.MenuItem
+	menuQuit           *systray.MenuItem
 }
 
 func New(appState *state.Instance, engine Engine, onQuit func()) *Instance {
@@ -52,6 +112,9 @@ func New(appState *state.Instance, engine Engine, onQuit func()) *Instance {
 		onQuit:           onQuit,
 		animationPosCurr: animationPositionMiddle,
 		animationTimer:   time.NewTimer(0),
+		animationArmed:   true,
+		changes:          appState.Subscribe(),
+		done:             make(chan struct{}),
 	}
 
 	start, end := systray.RunWithExternalLoop(i.onReady, func() {})
@@ -62,17 +125,43 @@ func New(appState *state.Instance, engine Engine, onQuit func()) *Instance {
 }
 
 func (i *Instance) onReady() {
+	hideDockIcon()
 	i.setIcon()
-	i.setTitle()
 
 	versionItem := systray.AddMenuItem(config.AppName+" v"+config.AppVersion, "")
 	versionItem.Disable()
 	systray.AddSeparator()
 
 	i.menuRecord = systray.AddMenuItem("Toggle Recording", "Start or stop recording")
+	i.menuPause = systray.AddMenuItem("Pause Recording", "Stop capturing audio, e.g. to take a call, until you resume")
+	i.menuPause.Disable()
+	i.menuCancel = systray.AddMenuItem("Cancel Transcription", "Stop transcribing the last recording without delivering it")
+	i.menuCancel.Disable()
+	i.menuMarker = systray.AddMenuItem("Add Marker", "Flag this moment of the recording in the history and exports")
+	i.menuMarker.Disable()
+	i.menuOCR = systray.AddMenuItem("Text from Clipboard Image", "Recognize the text of the image in the clipboard")
+	i.menuMicTest = systray.AddMenuItem("Test Microphone", "Record two seconds and report the input device and level")
+	i.menuCalibrate = systray.AddMenuItem("Calibrate Latency", "Measure the recording and paste latencies and suggest settings")
+	i.menuShare = systray.AddMenuItem("Copy Share Link", "Copy a one-time link to the latest dictation to open it on another device")
+	i.menuScratchpad = systray.AddMenuItem("Open Scratchpad", "Open the window where the scratchpad output mode collects dictations")
+	i.menuSubtitles = systray.AddMenuItem("Export Subtitles", "Save the latest dictation as an SRT subtitle file")
+	i.menuPrivacy = systray.AddMenuItemCheckbox("Privacy Mode", "Keep dictations out of the history, saved audio and sinks", false)
+	i.menuModels = systray.AddMenuItem("Unload Models", "Free the memory used by the models until you dictate again")
+	systray.AddSeparator()
+	if i.engine != nil {
+		i.addDeviceMenu()
+		i.addNormalizationMenu()
+		i.addFormMenu()
+		systray.AddSeparator()
+	}
+	i.menuSessionStart = systray.AddMenuItem("Start New Session", "Group the following dictations into a new session")
+	i.menuSessionEnd = systray.AddMenuItem("End Session", "Stop grouping dictations into the current session")
+	i.menuSessionExport = systray.AddMenuItem("Export Latest Session", "Save the latest session as a document")
+	i.menuSessionSummary = systray.AddMenuItem("Summarize Latest Session", "Summarize the latest session with the AI provider and save it")
 	systray.AddSeparator()
 	i.menuQuit = systray.AddMenuItem("Quit", "Exit the application")
 
+	i.setTitle()
 	go i.handleMenuClicks()
 	go i.animate()
 }
@@ -84,6 +173,66 @@ func (i *Instance) handleMenuClicks() {
 			if i.engine != nil {
 				i.engine.ToggleRecording()
 			}
+		case <-i.menuPause.ClickedCh:
+			if i.engine != nil {
+				i.engine.TogglePause()
+			}
+		case <-i.menuCancel.ClickedCh:
+			if i.engine != nil {
+				i.engine.CancelTranscription()
+			}
+		case <-i.menuMarker.ClickedCh:
+			if i.engine != nil {
+				i.engine.AddMarkerFromMenu()
+			}
+		case <-i.menuOCR.ClickedCh:
+			if i.engine != nil {
+				go i.engine.RecognizeClipboardImage()
+			}
+		case <-i.menuMicTest.ClickedCh:
+			if i.engine != nil {
+				go func() { _ = i.engine.TestMicrophone() }()
+			}
+		case <-i.menuCalibrate.ClickedCh:
+			if i.engine != nil {
+				go func() { _ = i.engine.Calibrate() }()
+			}
+		case <-i.menuShare.ClickedCh:
+			if i.engine != nil {
+				go i.engine.CopyShareLink()
+			}
+		case <-i.menuScratchpad.ClickedCh:
+			if i.engine != nil {
+				go i.engine.ShowScratchpad()
+			}
+		case <-i.menuSubtitles.ClickedCh:
+			if i.engine != nil {
+				i.engine.ExportLatestSubtitles()
+			}
+		case <-i.menuPrivacy.ClickedCh:
+			if i.engine != nil {
+				i.engine.TogglePrivacyMode()
+			}
+		case <-i.menuModels.ClickedCh:
+			if i.engine != nil {
+				i.toggleModelsLoaded()
+			}
+		case <-i.menuSessionStart.ClickedCh:
+			if i.engine != nil {
+				i.engine.StartSession("")
+			}
+		case <-i.menuSessionEnd.ClickedCh:
+			if i.engine != nil {
+				i.engine.EndSession()
+			}
+		case <-i.menuSessionExport.ClickedCh:
+			if i.engine != nil {
+				i.engine.ExportLatestSession()
+			}
+		case <-i.menuSessionSummary.ClickedCh:
+			if i.engine != nil {
+				go i.engine.SummarizeLatestSession()
+			}
 		case <-i.menuQuit.ClickedCh:
 			if i.onQuit != nil {
 				i.onQuit()
@@ -100,22 +249,13 @@ func (i *Instance) Start() {
 func (i *Instance) Shutdown() {
 	i.isShuttingDown = true
 	i.animationTimer.Stop()
+	close(i.done)
 	i.systrayEnd()
 }
 
-// setNextAnimationPosition advances the animation position.
-//
-// For unloaded and loaded statuses, the animation position is always set to middle, for
-// other statuses, it cycles through middle, right, and left positions.
+// setNextAnimationPosition advances the animation position, cycling through middle,
+// right, and left positions.
 func (i *Instance) setNextAnimationPosition() {
-	statusCurrent, _ := i.appState.GetStatus()
-	i.animationPosPrev = i.animationPosCurr
-
-	if statusCurrent == state.StatusUnloaded || statusCurrent == state.StatusLoaded {
-		i.animationPosCurr = animationPositionMiddle
-		return
-	}
-
 	// Ping-Pong animation between left, middle, and right positions
 	switch i.animationPosCurr {
 	case animationPositionMiddle:
@@ -147,14 +287,233 @@ func (i *Instance) setTitle() {
 		title += " - Model loaded"
 	case state.StatusListening:
 		title += " - Listening..."
+	case state.StatusPaused:
+		title += " - Paused"
 	case state.StatusTranscribing:
 		title += " - Transcribing..."
 	case state.StatusPostProcessing:
 		title += " - Post-processing..."
 	}
 
+	if i.appState.IsBatterySaverActive() {
+		title += " (battery saver)"
+	}
+	if i.appState.IsPrivacyModeActive() {
+		title += " (privacy mode)"
+	}
+
 	systray.SetTitle(title)
-	systray.SetTooltip(title)
+
+	tooltip := title
+	if level := i.levelLine(); level != "" {
+		tooltip += "\n" + level
+	}
+	if progress := i.progressLine(); progress != "" {
+		tooltip += "\n" + progress
+	}
+	if partial := latestSentence(i.appState.GetPartialText()); partial != "" {
+		tooltip += "\n" + partial
+	}
+	systray.SetTooltip(tooltip)
+
+	i.setRecordTitle()
+	i.setPrivacyItem()
+	i.setModelsTitle(statusCurrent)
+	i.setPauseItem(statusCurrent)
+	i.setCancelItem(statusCurrent)
+	i.setMarkerItem(statusCurrent)
+	i.setOCRItem()
+}
+
+// setPauseItem enables the pause menu item only while recording, offering to resume a
+// paused recording.
+func (i *Instance) setPauseItem(status state.Status) {
+	if i.menuPause == nil {
+		return
+	}
+
+	if status == state.StatusPaused {
+		i.menuPause.SetTitle("Resume Recording")
+		i.menuPause.Enable()
+		return
+	}
+	i.menuPause.SetTitle("Pause Recording")
+	if status == state.StatusListening {
+		i.menuPause.Enable()
+		return
+	}
+	i.menuPause.Disable()
+}
+
+// setCancelItem enables the cancel menu item only while a recording is transcribed.
+func (i *Instance) setCancelItem(status state.Status) {
+	if i.menuCancel == nil {
+		return
+	}
+
+	if status == state.StatusTranscribing {
+		i.menuCancel.Enable()
+		return
+	}
+	i.menuCancel.Disable()
+}
+
+// setRecordTitle shows the hotkey bound to toggle the recording in the menu item title so
+// users learn the shortcut from the menu.
+func (i *Instance) setRecordTitle() {
+	if i.menuRecord == nil || i.engine == nil {
+		return
+	}
+
+	title := "Toggle Recording"
+	if hotkey := formatHotkey(i.engine.ToggleHotkey()); hotkey != "" {
+		title += " (" + hotkey + ")"
+	}
+	i.menuRecord.SetTitle(title)
+}
+
+// setPrivacyItem checks the privacy mode menu item while the mode is active and shows the
+// hotkey bound to it.
+func (i *Instance) setPrivacyItem() {
+	if i.menuPrivacy == nil || i.engine == nil {
+		return
+	}
+
+	title := "Privacy Mode"
+	if hotkey := formatHotkey(i.engine.PrivacyHotkey()); hotkey != "" {
+		title += " (" + hotkey + ")"
+	}
+	i.menuPrivacy.SetTitle(title)
+
+	if i.appState.IsPrivacyModeActive() {
+		i.menuPrivacy.Check()
+		return
+	}
+	i.menuPrivacy.Uncheck()
+}
+
+// setMarkerItem enables the marker menu item only while recording and shows the hotkey
+// bound to it.
+func (i *Instance) setMarkerItem(status state.Status) {
+	if i.menuMarker == nil || i.engine == nil {
+		return
+	}
+
+	title := "Add Marker"
+	if hotkey := formatHotkey(i.engine.MarkerHotkey()); hotkey != "" {
+		title += " (" + hotkey + ")"
+	}
+	i.menuMarker.SetTitle(title)
+
+	if status == state.StatusListening {
+		i.menuMarker.Enable()
+		return
+	}
+	i.menuMarker.Disable()
+}
+
+// setOCRItem disables the text recognition menu item while Tesseract is missing, telling
+// the user why in its title.
+func (i *Instance) setOCRItem() {
+	if i.menuOCR == nil {
+		return
+	}
+
+	if i.appState.IsTextRecognitionMissing() {
+		i.menuOCR.SetTitle("Text from Clipboard Image (Tesseract missing)")
+		i.menuOCR.Disable()
+		return
+	}
+	i.menuOCR.SetTitle("Text from Clipboard Image")
+	i.menuOCR.Enable()
+}
+
+// setModelsTitle offers to unload the models while they are loaded and to load them again
+// once unloaded.
+func (i *Instance) setModelsTitle(status state.Status) {
+	if i.menuModels == nil {
+		return
+	}
+
+	if status == state.StatusUnloaded && i.engine != nil && i.engine.DownloadDeferred() {
+		i.menuModels.SetTitle("Download Models Now")
+		return
+	}
+	if status == state.StatusUnloaded {
+		i.menuModels.SetTitle("Reload Models")
+		return
+	}
+	i.menuModels.SetTitle("Unload Models")
+}
+
+// toggleModelsLoaded unloads the models, or loads them again if they are unloaded,
+// downloading them at once if their download was deferred on a metered connection.
+func (i *Instance) toggleModelsLoaded() {
+	status, _ := i.appState.GetStatus()
+	if status == state.StatusUnloaded && i.engine.DownloadDeferred() {
+		i.engine.DownloadModelsNow()
+		return
+	}
+	if status == state.StatusUnloaded {
+		i.engine.ReloadModels()
+		return
+	}
+	_ = i.engine.UnloadModels()
+}
+
+// progressLine describes the progress of the running task for the tooltip, e.g.
+// "Downloading 42% (about 1m left)", or returns an empty string if there is none.
+func (i *Instance) progressLine() string {
+	progress, ok := i.appState.GetProgress()
+	if !ok {
+		return ""
+	}
+
+	line := "Transcribing"
+	if progress.Phase == state.ProgressDownload {
+		line = "Downloading"
+	}
+	line += fmt.Sprintf(" %d%%", int(progress.Fraction*100))
+
+	if progress.ETA > 0 {
+		line += " (about " + progress.ETA.Round(time.Second).String() + " left)"
+	}
+	return line
+}
+
+// levelLine draws the level of the audio being recorded for the tooltip, e.g.
+// "Input ■■■■□□□□□□", or returns an empty string if nothing is being recorded. The RMS
+// level is shown from -60 dBFS, the floor of a quiet room, to full scale.
+func (i *Instance) levelLine() string {
+	const (
+		steps     = 10
+		floorDBFS = -60
+	)
+
+	level, ok := i.appState.GetInputLevel()
+	if !ok {
+		return ""
+	}
+
+	filled := int(math.Round((max(level.RMS, floorDBFS) - floorDBFS) / -floorDBFS * steps))
+	return "Input " + strings.Repeat("■", filled) + strings.Repeat("□", steps-filled)
+}
+
+// latestSentence returns the last sentence of a partial transcription, shortened to fit
+// in a tooltip.
+func latestSentence(text string) string {
+	const maxLength = 100
+
+	text = strings.TrimSpace(text)
+	if cut := strings.LastIndexAny(strings.TrimRight(text, ".?!"), ".?!"); cut >= 0 {
+		text = strings.TrimSpace(text[cut+1:])
+	}
+
+	runes := []rune(text)
+	if len(runes) > maxLength {
+		text = "..." + string(runes[len(runes)-maxLength+3:])
+	}
+	return text
 }
 
 // setIcon updates the systray icon based on the current status and animation position.
@@ -176,7 +535,7 @@ func (i *Instance) setIcon() {
 		res = pngOrIco(logo.LogoBlackAmber)
 	case state.StatusLoaded:
 		res = pngOrIco(logo.LogoBlackWhite)
-	case state.StatusListening:
+	case state.StatusListening, state.StatusPaused:
 		res = pngOrIco(logo.LogoBlackPink)
 	case state.StatusTranscribing:
 		res = pngOrIco(logo.LogoBlackBlue)
@@ -194,25 +553,81 @@ func (i *Instance) setIcon() {
 	}
 }
 
-// animate runs the animation loop, updating the systray icon and title based on the current status
-// and animation position at regular intervals defined by animationFrameDuration.
+// frameDuration returns the interval between animation frames configured in the advanced
+// settings.
+func (i *Instance) frameDuration() time.Duration {
+	const defaultFrameDuration = 200 * time.Millisecond
+
+	if i.engine == nil {
+		return defaultFrameDuration
+	}
+	return i.engine.AnimationFrameDuration()
+}
+
+// isAnimated reports whether the icon is animated in a status, the ones where the engine
+// is busy. The icon of the others stays in the middle position.
+func isAnimated(status state.Status) bool {
+	switch status {
+	case state.StatusLoading, state.StatusListening, state.StatusTranscribing, state.StatusPostProcessing:
+		return true
+	default:
+		return false
+	}
+}
+
+// animate updates the systray icon and title when the state changes, and advances the
+// icon animation at the interval returned by frameDuration while the status is animated.
+// While idle it only wakes up on state changes.
 func (i *Instance) animate() {
-	for range i.animationTimer.C {
+	for {
+		select {
+		case <-i.done:
+			return
+		case <-i.changes:
+		case <-i.animationTimer.C:
+			i.animationArmed = false
+			i.setNextAnimationPosition()
+		}
 		if i.isShuttingDown {
 			return
 		}
 
-		statusCurrent, statusPrevious := i.appState.GetStatus()
-
-		if statusPrevious != statusCurrent {
-			i.setTitle()
+		statusCurrent := i.refresh()
+		if isAnimated(statusCurrent) && !i.animationArmed {
+			i.animationTimer.Reset(i.frameDuration())
+			i.animationArmed = true
 		}
+	}
+}
 
-		if statusPrevious != statusCurrent || i.animationPosPrev != i.animationPosCurr {
-			i.setIcon()
-		}
+// refresh updates the title and the icon if the state they show changed since the last
+// refresh, and returns the current status.
+func (i *Instance) refresh() state.Status {
+	statusCurrent, _ := i.appState.GetStatus()
+	if !isAnimated(statusCurrent) {
+		i.animationPosCurr = animationPositionMiddle
+	}
+
+	batterySaver := i.appState.IsBatterySaverActive()
+	privacyMode := i.appState.IsPrivacyModeActive()
+	ocrMissing := i.appState.IsTextRecognitionMissing()
+	partialText := i.appState.GetPartialText()
+	progress := i.progressLine()
+	level := i.levelLine()
+	if statusCurrent != i.statusPrev || batterySaver != i.batterySaverPrev || privacyMode != i.privacyModePrev || ocrMissing != i.ocrMissingPrev || partialText != i.partialTextPrev || progress != i.progressPrev || level != i.levelPrev {
+		i.setTitle()
+		i.batterySaverPrev = batterySaver
+		i.privacyModePrev = privacyMode
+		i.ocrMissingPrev = ocrMissing
+		i.partialTextPrev = partialText
+		i.progressPrev = progress
+		i.levelPrev = level
+	}
 
-		i.setNextAnimationPosition()
-		i.animationTimer.Reset(animationFrameDuration)
+	if statusCurrent != i.statusPrev || i.animationPosPrev != i.animationPosCurr {
+		i.setIcon()
 	}
+	i.statusPrev = statusCurrent
+	i.animationPosPrev = i.animationPosCurr
+	return statusCurrent
 }
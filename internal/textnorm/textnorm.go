@@ -0,0 +1,124 @@
+// Package textnorm applies deterministic formatting profiles to the final text, so the
+// same dictation can read like a chat message or like a document depending on the target.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/varavelio/tribar/internal/config"
+)
+
+// Apply formats the text according to the profile: punctuation first, then casing and
+// finally emoji removal when they are not allowed.
+func Apply(profile config.NormalizationProfile, text string) string {
+	text = applyPunctuation(profile.Punctuation, text)
+	text = applyCasing(profile.Casing, text)
+	if !profile.AllowEmoji {
+		text = removeEmoji(text)
+	}
+	return strings.TrimSpace(text)
+}
+
+func applyPunctuation(mode config.PunctuationMode, text string) string {
+	switch mode {
+	case config.PunctuationMinimal:
+		return strings.TrimRight(strings.TrimSpace(text), ".…")
+	case config.PunctuationNone:
+		var sb strings.Builder
+		runes := []rune(text)
+		for idx, r := range runes {
+			// Apostrophes inside words ("don't") are part of the word, not punctuation.
+			isInnerApostrophe := (r == '\'' || r == '’') && idx > 0 && idx < len(runes)-1 &&
+				unicode.IsLetter(runes[idx-1]) && unicode.IsLetter(runes[idx+1])
+			if unicode.IsPunct(r) && !isInnerApostrophe {
+				continue
+			}
+			sb.WriteRune(r)
+		}
+		return collapseSpaces(sb.String())
+	default:
+		return text
+	}
+}
+
+func applyCasing(mode config.CasingMode, text string) string {
+	switch mode {
+	case config.CasingLower:
+		return strings.ToLower(text)
+	case config.CasingLowerStart:
+		for idx, r := range text {
+			if unicode.IsLetter(r) {
+				// Acronyms and the pronoun "I" keep their case ("API is down").
+				next, _ := utf8.DecodeRuneInString(text[idx+utf8.RuneLen(r):])
+				if unicode.IsUpper(next) || (r == 'I' && !unicode.IsLetter(next)) {
+					return text
+				}
+				return text[:idx] + string(unicode.ToLower(r)) + text[idx+utf8.RuneLen(r):]
+			}
+		}
+		return text
+	case config.CasingSentence:
+		var sb strings.Builder
+		capitalizeNext := true
+		for _, r := range text {
+			if capitalizeNext && unicode.IsLetter(r) {
+				r = unicode.ToUpper(r)
+				capitalizeNext = false
+			}
+			if r == '.' || r == '!' || r == '?' || r == '\n' {
+				capitalizeNext = true
+			}
+			sb.WriteRune(r)
+		}
+		return sb.String()
+	default:
+		return text
+	}
+}
+
+func removeEmoji(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return collapseSpaces(cleaned)
+}
+
+// collapseSpaces replaces the runs of horizontal whitespace left by removed runes with a
+// single space and drops the trailing ones, keeping the line breaks of paragraphs and
+// lists and the indentation of their lines.
+func collapseSpaces(text string) string {
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		body := strings.TrimLeftFunc(line, isHorizontalSpace)
+		if body == "" {
+			lines[idx] = ""
+			continue
+		}
+		indent := line[:len(line)-len(body)]
+		lines[idx] = indent + strings.Join(strings.FieldsFunc(body, isHorizontalSpace), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isHorizontalSpace reports whether r is whitespace other than a line break.
+func isHorizontalSpace(r rune) bool {
+	return r != '\n' && r != '\r' && unicode.IsSpace(r)
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r == 0x200D || r == 0xFE0F: // Zero width joiner and emoji presentation selector
+		return true
+	default:
+		return false
+	}
+}
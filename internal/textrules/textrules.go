@@ -0,0 +1,189 @@
+// Package textrules exports the text rules of the settings (post-processing prompts,
+// routing rules, normalization profiles and history tag rules) to a JSON file and imports
+// them, so a team can share its domain rules between installations without the rest of
+// the settings.
+package textrules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/prompts"
+)
+
+// fileVersion is the version of the exported file contents.
+const fileVersion = 1
+
+// File is the JSON document the text rules are exported to.
+type File struct {
+	Version               int                           `json:"version"`
+	Prompts               []config.Prompt               `json:"prompts"`
+	RoutingRules          []config.RoutingRule          `json:"routing_rules"`
+	NormalizationProfiles []config.NormalizationProfile `json:"normalization_profiles"`
+	HistoryTagRules       []config.TagRule              `json:"history_tag_rules"`
+}
+
+// Added counts the rules an import added to the settings.
+type Added struct {
+	Prompts               int
+	RoutingRules          int
+	NormalizationProfiles int
+	HistoryTagRules       int
+}
+
+// Total returns the number of rules added.
+func (a Added) Total() int {
+	return a.Prompts + a.RoutingRules + a.NormalizationProfiles + a.HistoryTagRules
+}
+
+// Export returns the text rules of the settings. The prior versions of the prompts are
+// left out, and so are the sinks of the routing rules, which are configured per machine
+// and may hold secrets.
+func Export(settings config.Settings) File {
+	file := File{
+		Version:               fileVersion,
+		Prompts:               make([]config.Prompt, 0, len(settings.Prompts)),
+		RoutingRules:          make([]config.RoutingRule, 0, len(settings.RoutingRules)),
+		NormalizationProfiles: slices.Clone(settings.NormalizationProfiles),
+		HistoryTagRules:       slices.Clone(settings.HistoryTagRules),
+	}
+	for _, prompt := range settings.Prompts {
+		prompt.Versions = nil
+		file.Prompts = append(file.Prompts, prompt)
+	}
+	for _, rule := range settings.RoutingRules {
+		rule.SinkID = ""
+		file.RoutingRules = append(file.RoutingRules, rule)
+	}
+	return file
+}
+
+// WriteFile exports the text rules of the settings to path.
+func WriteFile(path string, settings config.Settings) error {
+	data, err := json.MarshalIndent(Export(settings), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal text rules: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write text rules: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads the text rules exported to path. The patterns of the routing rules must
+// be valid regular expressions.
+func ReadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("invalid text rules file: %w", err)
+	}
+	if file.Version > fileVersion {
+		return File{}, fmt.Errorf("the text rules were exported by a newer version of %s", config.AppName)
+	}
+	for _, rule := range file.RoutingRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return File{}, fmt.Errorf("routing rule %q has an invalid pattern: %w", rule.Name, err)
+		}
+	}
+	if len(file.Prompts)+len(file.RoutingRules)+len(file.NormalizationProfiles)+len(file.HistoryTagRules) == 0 {
+		return File{}, errors.New("the file contains no text rules")
+	}
+	return file, nil
+}
+
+// Merge adds the imported rules to the settings and returns them with the count of rules
+// added. Rules already in the settings (prompts with the same body, routing rules with
+// the same pattern, profiles with the same formatting and identical tag rules) are
+// skipped, so importing a file again adds only its new rules, and imported rules never
+// replace the user's: taken IDs are replaced by new ones (see prompts.Merge). The rules
+// referring to an imported prompt are updated to its ID in the settings.
+func Merge(settings config.Settings, file File) (config.Settings, Added) {
+	var added Added
+
+	promptIDs := make(map[string]string, len(file.Prompts))
+	for _, prompt := range file.Prompts {
+		merged, imported := prompts.Merge(settings.Prompts, []config.Prompt{prompt})
+		settings.Prompts = merged
+		if len(imported) == 1 {
+			promptIDs[prompt.ID] = imported[0].ID
+			added.Prompts++
+			continue
+		}
+		for _, existing := range settings.Prompts {
+			if strings.TrimSpace(existing.Body) == strings.TrimSpace(prompt.Body) {
+				promptIDs[prompt.ID] = existing.ID
+				break
+			}
+		}
+	}
+	promptID := func(id string) string {
+		if mapped, ok := promptIDs[id]; ok && id != "" {
+			return mapped
+		}
+		return id
+	}
+
+	settings.RoutingRules = slices.Clone(settings.RoutingRules)
+	for _, rule := range file.RoutingRules {
+		if slices.ContainsFunc(settings.RoutingRules, func(existing config.RoutingRule) bool {
+			return existing.Pattern == rule.Pattern
+		}) {
+			continue
+		}
+		if rule.ID == "" || slices.ContainsFunc(settings.RoutingRules, func(existing config.RoutingRule) bool {
+			return existing.ID == rule.ID
+		}) {
+			rule.ID = prompts.NewID()
+		}
+		rule.PromptID = promptID(rule.PromptID)
+		rule.SinkID = ""
+		settings.RoutingRules = append(settings.RoutingRules, rule)
+		added.RoutingRules++
+	}
+
+	settings.NormalizationProfiles = slices.Clone(settings.NormalizationProfiles)
+	for _, profile := range file.NormalizationProfiles {
+		if slices.ContainsFunc(settings.NormalizationProfiles, func(existing config.NormalizationProfile) bool {
+			return sameFormatting(existing, profile)
+		}) {
+			continue
+		}
+		if profile.ID == "" || slices.ContainsFunc(settings.NormalizationProfiles, func(existing config.NormalizationProfile) bool {
+			return existing.ID == profile.ID
+		}) {
+			profile.ID = prompts.NewID()
+		}
+		settings.NormalizationProfiles = append(settings.NormalizationProfiles, profile)
+		added.NormalizationProfiles++
+	}
+
+	settings.HistoryTagRules = slices.Clone(settings.HistoryTagRules)
+	for _, rule := range file.HistoryTagRules {
+		rule.PromptID = promptID(rule.PromptID)
+		if rule.Tag == "" || slices.Contains(settings.HistoryTagRules, rule) {
+			continue
+		}
+		settings.HistoryTagRules = append(settings.HistoryTagRules, rule)
+		added.HistoryTagRules++
+	}
+
+	return settings, added
+}
+
+// sameFormatting reports whether two normalization profiles format the text the same way
+// for the same applications, whatever their names.
+func sameFormatting(a, b config.NormalizationProfile) bool {
+	return a.Casing == b.Casing && a.Punctuation == b.Punctuation && a.AllowEmoji == b.AllowEmoji &&
+		slices.Equal(a.Apps, b.Apps)
+}
@@ -0,0 +1,141 @@
+// Package todo detects action items in transcriptions ("remind me to...", "TODO...") and
+// delivers them to a tasks file and, optionally, a sink, separately from the main text.
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+	"github.com/varavelio/tribar/internal/postprocess"
+	"github.com/varavelio/tribar/internal/sink"
+)
+
+const defaultFileName = "todo.md"
+
+// actionItemPattern matches the phrases that introduce an action item and captures the
+// item up to the end of the sentence.
+var actionItemPattern = regexp.MustCompile(`(?i)\b(?:remind me to|don't forget to|do not forget to|to-?do|action item)\s*[:,]?\s+([^.!?\n]+)`)
+
+// Instance extracts and delivers action items.
+type Instance struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	postprocess     *postprocess.Instance
+	sinks           *sink.Instance
+}
+
+// New creates a new action item extractor.
+func New(
+	logger logger.Logger,
+	settingsManager *config.SettingsManager,
+	postprocess *postprocess.Instance,
+	sinks *sink.Instance,
+) *Instance {
+	return &Instance{
+		logger:          logger,
+		settingsManager: settingsManager,
+		postprocess:     postprocess,
+		sinks:           sinks,
+	}
+}
+
+// Extract returns the action items found in the text by the built-in phrase rules.
+func Extract(text string) []string {
+	var items []string
+	for _, match := range actionItemPattern.FindAllStringSubmatch(text, -1) {
+		item := strings.TrimSpace(match[1])
+		if item == "" {
+			continue
+		}
+		runes := []rune(item)
+		items = append(items, strings.ToUpper(string(runes[:1]))+string(runes[1:]))
+	}
+	return items
+}
+
+// Process extracts the action items of a transcription, when enabled, and delivers them.
+// It returns the number of items found.
+func (t *Instance) Process(ctx context.Context, text string) (int, error) {
+	settings := t.settingsManager.Get()
+	if !settings.TodoEnabled || strings.TrimSpace(text) == "" {
+		return 0, nil
+	}
+
+	items, err := t.extract(ctx, settings, text)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	var errs []error
+	if err := appendToFile(filePath(settings), items); err != nil {
+		errs = append(errs, err)
+	}
+
+	if settings.TodoSinkID != "" {
+		if err := t.sinks.SendTo(ctx, settings.TodoSinkID, formatList(items)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send action items: %w", err))
+		}
+	}
+
+	t.logger.Debug(ctx, "action items extracted", "count", len(items))
+	return len(items), errors.Join(errs...)
+}
+
+// extract finds the action items with the LLM when configured, falling back to the
+// phrase rules if the LLM is unavailable or fails.
+func (t *Instance) extract(ctx context.Context, settings config.Settings, text string) ([]string, error) {
+	if !settings.TodoUseLLM || !t.postprocess.IsConfigured() {
+		return Extract(text), nil
+	}
+
+	items, err := t.postprocess.ExtractActionItems(ctx, text)
+	if err != nil {
+		t.logger.Warn(ctx, "action item extraction with the LLM failed, using phrase rules", "err", err)
+		return Extract(text), nil
+	}
+	return items, nil
+}
+
+// filePath returns the configured tasks file or the default one in the data directory.
+func filePath(settings config.Settings) string {
+	if settings.TodoFilePath != "" {
+		return settings.TodoFilePath
+	}
+	return filepath.Join(config.DirectoryData, defaultFileName)
+}
+
+// appendToFile appends the items to a Markdown task list.
+func appendToFile(path string, items []string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open tasks file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.WriteString(formatList(items)); err != nil {
+		return fmt.Errorf("failed to write tasks file: %w", err)
+	}
+	return nil
+}
+
+// formatList renders the items as a Markdown task list stamped with the current date.
+func formatList(items []string) string {
+	date := time.Now().Format("2006-01-02 15:04")
+
+	var sb strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&sb, "- [ ] %s (%s)\n", item, date)
+	}
+	return sb.String()
+}
@@ -0,0 +1,137 @@
+// Package upload receives audio recorded on other devices through the local server, e.g. a
+// phone shortcut uploading a voice memo, so it is transcribed on the desktop. Requests are
+// authenticated with the pairing token of the settings, which `tribar pair` creates and
+// shows as a QR code.
+package upload
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/varavelio/tribar/internal/config"
+	"github.com/varavelio/tribar/internal/logger"
+)
+
+const (
+	// Path is the endpoint audio is uploaded to.
+	Path = "/upload"
+	// maxUploadSize is the largest upload accepted, an hour of compressed voice memo.
+	maxUploadSize = 100 << 20
+)
+
+// TranscribeFunc transcribes and delivers the uploaded audio, returning the text.
+type TranscribeFunc func(data []byte) (string, error)
+
+// response is the JSON body of every reply.
+type response struct {
+	Text  string `json:"text,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Handler serves the upload endpoint. The audio is sent as the request body or as the
+// "file" field of a multipart form, with the token in an "Authorization: Bearer" header or
+// the "token" query parameter.
+type Handler struct {
+	logger          logger.Logger
+	settingsManager *config.SettingsManager
+	transcribe      TranscribeFunc
+}
+
+// NewHandler creates the upload endpoint handler.
+func NewHandler(logger logger.Logger, settingsManager *config.SettingsManager, transcribe TranscribeFunc) *Handler {
+	return &Handler{
+		logger:          logger,
+		settingsManager: settingsManager,
+		transcribe:      transcribe,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	settings := h.settingsManager.Get()
+	if !settings.PhoneUploadEnabled || settings.PhoneUploadToken == "" {
+		writeJSON(w, http.StatusNotFound, response{Error: "uploads are disabled, run \"tribar pair\" on the desktop"})
+		return
+	}
+	if !validToken(r, settings.PhoneUploadToken) {
+		h.logger.Warn(r.Context(), "rejected upload with an invalid token", "remote_addr", r.RemoteAddr)
+		writeJSON(w, http.StatusUnauthorized, response{Error: "invalid pairing token, pair the device again"})
+		return
+	}
+
+	data, err := readAudio(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+
+	h.logger.Info(r.Context(), "audio uploaded", "remote_addr", r.RemoteAddr, "bytes", len(data))
+	text, err := h.transcribe(data)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, response{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Text: text})
+}
+
+// validToken reports whether the request carries the pairing token.
+func validToken(r *http.Request, token string) bool {
+	given := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = strings.TrimSpace(bearer)
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// readAudio returns the uploaded audio, up to maxUploadSize bytes.
+func readAudio(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	body := io.Reader(r.Body)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("the form has no \"file\" field: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		body = file
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the upload: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("the upload is empty")
+	}
+	return data, nil
+}
+
+// writeJSON sends a JSON reply.
+func writeJSON(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// NewToken returns a random pairing token.
+func NewToken() string {
+	var b [24]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// PairingURL returns the URL a device uploads to, with the token, given the base URL of
+// the local server. It is what the pairing QR code encodes.
+func PairingURL(baseURL, token string) string {
+	return baseURL + Path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,223 @@
+// Package api defines the stable, serializable contract between the engine and its
+// frontends (tray, CLI, HTTP, D-Bus...). External user interfaces can depend on this
+// package, or on the JSON Schema it embeds, without importing any internal package.
+//
+// Changes to these types must be backwards compatible; breaking changes require bumping
+// Version so frontends can detect them.
+package api
+
+import (
+	_ "embed"
+	"time"
+)
+
+// Version is the current version of the API contract.
+const Version = 1
+
+// Schema is the JSON Schema (draft 2020-12) describing Snapshot and Command, for frontends
+// that are not written in Go.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Status is the current activity of the engine.
+type Status string
+
+const (
+	StatusUnknown        Status = "unknown"
+	StatusUnloaded       Status = "unloaded"
+	StatusLoading        Status = "loading"
+	StatusLoaded         Status = "loaded"
+	StatusListening      Status = "listening"
+	StatusPaused         Status = "paused"
+	StatusTranscribing   Status = "transcribing"
+	StatusPostProcessing Status = "post_processing"
+)
+
+// Snapshot is a point-in-time, read-only view of the engine state. LockedSettings are the
+// JSON names of the settings enforced by an administrator policy, which frontends should
+// show as read-only. OutputModes are the output modes that work on this system, frontends
+// should disable the others (pasting needs xdotool on Linux). TextRecognitionHint is set
+// when text recognition is enabled but Tesseract is not installed, and tells how to
+// install it. Features maps every feature flag to whether it is enabled, so frontends can
+// show the experimental subsystems.
+type Snapshot struct {
+	Version             int             `json:"version"`
+	Status              Status          `json:"status"`
+	BatterySaver        bool            `json:"battery_saver"`
+	PrivacyMode         bool            `json:"privacy_mode"`
+	ThrottleLevel       int             `json:"throttle_level"`
+	PartialText         string          `json:"partial_text,omitempty"`
+	Progress            *Progress       `json:"progress,omitempty"`
+	InputLevel          *InputLevel     `json:"input_level,omitempty"`
+	Model               string          `json:"model"`
+	Models              []Model         `json:"models"`
+	ExecutionProvider   string          `json:"execution_provider"` // "cpu" or "cuda"
+	History             []HistoryEntry  `json:"history"`
+	Sessions            []Session       `json:"sessions"`
+	LockedSettings      []string        `json:"locked_settings"`
+	OutputModes         []string        `json:"output_modes"`
+	TextRecognitionHint string          `json:"text_recognition_hint,omitempty"`
+	Features            map[string]bool `json:"features"`
+}
+
+// Progress is the progress of a long-running task, absent from snapshots when none is
+// running. Phase is "download" or "transcription", Fraction goes from 0 to 1 and
+// ETASeconds is the estimated remaining time, zero until it can be estimated.
+type Progress struct {
+	Phase      string  `json:"phase"`
+	Fraction   float64 `json:"fraction"`
+	ETASeconds float64 `json:"eta_seconds"`
+}
+
+// InputLevel is the level of the audio being recorded, absent from snapshots when nothing
+// is. PeakDBFS and RMSDBFS are in dBFS, from 0 (full scale) down to SilenceDBFS.
+type InputLevel struct {
+	PeakDBFS float64 `json:"peak_dbfs"`
+	RMSDBFS  float64 `json:"rms_dbfs"`
+}
+
+// SilenceDBFS is the lowest level reported, digital silence.
+const SilenceDBFS = -99
+
+// Model is a speech recognition model that can be selected with set_model. Languages are
+// the ISO 639-1 codes it supports, empty if it is not restricted.
+type Model struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Languages []string `json:"languages"`
+}
+
+// HistoryEntry is a single transcription of the history. Tags is empty, never null, when
+// the entry has none. Source is how its text was produced: "local" or "remote"
+// transcription, or "ocr". Confidence is the mean token probability between 0 and 1, zero
+// when the source does not report it.
+type HistoryEntry struct {
+	ID         int       `json:"id"`
+	Text       string    `json:"text"`
+	AudioPath  string    `json:"audio_path"`
+	SessionID  int       `json:"session_id"`
+	Tags       []string  `json:"tags"`
+	Source     string    `json:"source"`
+	Confidence float32   `json:"confidence"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Session is a group of dictations, without its utterances to keep snapshots small.
+type Session struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Active         bool      `json:"active"`
+	StartedAt      time.Time `json:"started_at"`
+	LastActivity   time.Time `json:"last_activity"`
+	UtteranceCount int       `json:"utterance_count"`
+	HasSummary     bool      `json:"has_summary"`
+}
+
+// CommandName identifies an action the engine can perform.
+type CommandName string
+
+const (
+	// CommandToggleRecording starts or stops a dictation. When starting, the optional
+	// "language", "prompt", "output" and "style" args override the settings for it.
+	CommandToggleRecording CommandName = "toggle_recording"
+	// CommandStartSession starts a session named "name", from the calendar or the time
+	// when it is empty.
+	CommandStartSession CommandName = "start_session"
+	// CommandEndSession ends the active session.
+	CommandEndSession CommandName = "end_session"
+	// CommandExportLatestSession exports the most recent session to Markdown.
+	CommandExportLatestSession CommandName = "export_latest_session"
+	// CommandSummarizeLatestSession summarizes and exports the most recent session.
+	CommandSummarizeLatestSession CommandName = "summarize_latest_session"
+	// CommandRecognizeClipboardImage delivers the text of the image in the clipboard.
+	CommandRecognizeClipboardImage CommandName = "recognize_clipboard_image"
+	// CommandSetNormalizationProfile selects the normalization profile "id", empty to
+	// disable normalization.
+	CommandSetNormalizationProfile CommandName = "set_normalization_profile"
+	// CommandSetHistoryTags replaces the tags of the history entry "id" with the
+	// comma-separated "tags".
+	CommandSetHistoryTags CommandName = "set_history_tags"
+	// CommandExportHistory exports the history entries tagged "tag", all of them if it is
+	// empty.
+	CommandExportHistory CommandName = "export_history"
+	// CommandSetModel selects the model "id".
+	CommandSetModel CommandName = "set_model"
+	// CommandSetLanguage selects the "language" code, empty for automatic detection.
+	CommandSetLanguage CommandName = "set_language"
+	// CommandTestMicrophone records a short sample and notifies its level.
+	CommandTestMicrophone CommandName = "test_microphone"
+	// CommandUnloadModels releases the models to free memory.
+	CommandUnloadModels CommandName = "unload_models"
+	// CommandReloadModels loads the models again.
+	CommandReloadModels CommandName = "reload_models"
+	// CommandSetPrivacyMode sets the privacy mode to "active" ("true" or "false"),
+	// toggling it when omitted.
+	CommandSetPrivacyMode CommandName = "set_privacy_mode"
+	// CommandCalibrate measures the latencies around a dictation and suggests settings.
+	CommandCalibrate CommandName = "calibrate"
+	// CommandCancelTranscription cancels the transcription in progress.
+	CommandCancelTranscription CommandName = "cancel_transcription"
+	// CommandRetryPostProcessing post-processes again the last text that failed to.
+	CommandRetryPostProcessing CommandName = "retry_post_processing"
+	// CommandShareHistoryEntry copies a one-time link to the history entry "id", the
+	// latest when omitted.
+	CommandShareHistoryEntry CommandName = "share_history_entry"
+	// CommandExportSubtitles exports the history entry "id", the latest when omitted, as
+	// subtitles in "format", "srt" (default) or "vtt".
+	CommandExportSubtitles CommandName = "export_subtitles"
+	// CommandAddMarker marks the current moment of the recording with an optional "note".
+	CommandAddMarker CommandName = "add_marker"
+	// CommandMergeHistory merges the comma-separated history entry "ids"; "separator" and
+	// "post_process" ("true" or "false") override the settings.
+	CommandMergeHistory CommandName = "merge_history"
+	// CommandDownloadModels starts a model download deferred on a metered connection, or
+	// schedules it at the time of day "at" (e.g. "02:00").
+	CommandDownloadModels CommandName = "download_models"
+	// CommandCheckModelUpdates notifies the model files with a newer revision.
+	CommandCheckModelUpdates CommandName = "check_model_updates"
+	// CommandUpdateModels replaces the model files with their newer revision.
+	CommandUpdateModels CommandName = "update_models"
+	// CommandOpenScratchpad opens the scratchpad window, where the "scratchpad" output
+	// mode appends dictations.
+	CommandOpenScratchpad CommandName = "open_scratchpad"
+	// CommandSetInputDevice selects the input device "id", empty for the system default.
+	CommandSetInputDevice CommandName = "set_input_device"
+	// CommandSetFormTemplate selects the form template "id" dictations fill, empty to
+	// turn form dictation off.
+	CommandSetFormTemplate CommandName = "set_form_template"
+	// CommandPauseRecording pauses the recording in progress.
+	CommandPauseRecording CommandName = "pause_recording"
+	// CommandResumeRecording continues the paused recording.
+	CommandResumeRecording CommandName = "resume_recording"
+	// CommandDeleteHistoryEntry deletes the history entry "id", also on the other machines
+	// of the user once synced.
+	CommandDeleteHistoryEntry CommandName = "delete_history_entry"
+	// CommandClearHistory deletes every history entry, also on the other machines of the
+	// user once synced.
+	CommandClearHistory CommandName = "clear_history"
+	// CommandSyncHistory merges the history with the one synced by the other machines of
+	// the user.
+	CommandSyncHistory CommandName = "sync_history"
+	// CommandPing does nothing, it checks that an instance is running.
+	CommandPing CommandName = "ping"
+	// CommandQuit exits the running instance.
+	CommandQuit CommandName = "quit"
+)
+
+// Command is a request for the engine to perform an action. Args holds the optional,
+// command specific string arguments documented with each command name.
+type Command struct {
+	Version int               `json:"version"`
+	Name    CommandName       `json:"name"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// NewCommand creates a command for the current API version.
+func NewCommand(name CommandName, args map[string]string) Command {
+	return Command{
+		Version: Version,
+		Name:    name,
+		Args:    args,
+	}
+}
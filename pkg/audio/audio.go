@@ -0,0 +1,121 @@
+// Package audio provides the audio utilities needed to feed speech recognition models:
+// decoding (WAV natively, compressed formats through ffmpeg), WAV encoding, down-mixing
+// to mono and resampling.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-audio/wav"
+)
+
+// SampleRate is the sample rate expected by the speech recognition models.
+const SampleRate = 16000
+
+// DecodeWAV reads WAV bytes and converts them to 16kHz mono float32 samples normalized
+// to [-1, 1]. The WAV can be in any format (sample rate, channels, bit depth).
+func DecodeWAV(wavData []byte) ([]float32, error) {
+	reader := bytes.NewReader(wavData)
+	decoder := wav.NewDecoder(reader)
+
+	if !decoder.IsValidFile() {
+		return nil, errors.New("invalid WAV file")
+	}
+
+	buf, err := decoder.FullPCMBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding WAV: %w", err)
+	}
+
+	// Convert to float32 normalized
+	rawSamples := make([]float32, len(buf.Data))
+	for j, val := range buf.Data {
+		rawSamples[j] = float32(val) / 32768.0
+	}
+
+	return Normalize(rawSamples, buf.Format.NumChannels, buf.Format.SampleRate), nil
+}
+
+// Normalize converts interleaved samples with any number of channels and sample rate into
+// 16kHz mono samples.
+func Normalize(samples []float32, numChannels, sampleRate int) []float32 {
+	if numChannels > 1 {
+		samples = ConvertToMono(samples, numChannels)
+	}
+	return Resample(samples, sampleRate, SampleRate)
+}
+
+// ConvertToMono converts multi-channel audio to mono by averaging channels.
+func ConvertToMono(samples []float32, numChannels int) []float32 {
+	numSamples := len(samples) / numChannels
+	mono := make([]float32, numSamples)
+
+	for i := range numSamples {
+		var sum float32
+		for ch := range numChannels {
+			sum += samples[i*numChannels+ch]
+		}
+		mono[i] = sum / float32(numChannels)
+	}
+
+	return mono
+}
+
+// PCM16ToFloat32 converts little-endian signed 16-bit PCM bytes to float32 samples
+// normalized to [-1, 1].
+func PCM16ToFloat32(pcm []byte) []float32 {
+	samples := make([]float32, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float32(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / 32768.0
+	}
+	return samples
+}
+
+// Float32ToPCM16 converts float32 samples in [-1, 1] to little-endian signed 16-bit PCM
+// bytes, clipping samples out of range.
+func Float32ToPCM16(samples []float32) []byte {
+	pcm := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		value := max(-1, min(1, sample)) * 32767
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(value)))
+	}
+	return pcm
+}
+
+// WriteWAVHeader writes the standard 44 bytes header of a 16-bit PCM WAV file.
+func WriteWAVHeader(w io.Writer, dataSize, sampleRate, channels int) error {
+	fields := []any{
+		[]byte("RIFF"),
+		int32(36 + dataSize),
+		[]byte("WAVE"),
+		[]byte("fmt "),
+		int32(16),
+		int16(1), // Audio format (PCM)
+		int16(channels),
+		int32(sampleRate),
+		int32(sampleRate * channels * 2),
+		int16(channels * 2),
+		int16(16), // Bits per sample
+		[]byte("data"),
+		int32(dataSize),
+	}
+
+	for _, field := range fields {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("error writing WAV header: %w", err)
+		}
+	}
+	return nil
+}
+
+// EncodeWAV wraps 16-bit PCM bytes into a complete WAV file.
+func EncodeWAV(pcm []byte, sampleRate, channels int) []byte {
+	var buf bytes.Buffer
+	_ = WriteWAVHeader(&buf, len(pcm), sampleRate, channels)
+	buf.Write(pcm)
+	return buf.Bytes()
+}
@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// clickFrameDuration is the size of the frames whose energy is compared when looking
+	// for clicks.
+	clickFrameDuration = 5 * time.Millisecond
+	// maxClickDuration is the longest burst treated as a click, longer ones are speech.
+	maxClickDuration = 40 * time.Millisecond
+	// clickThreshold is how much louder than the background a frame must be to belong to
+	// a burst, about 18 dB.
+	clickThreshold = 8
+	// minClickLevel is the lowest RMS a burst must reach, so the noise of a silent room is
+	// never mistaken for clicks.
+	minClickLevel = 0.01
+)
+
+// SuppressClicks mutes the short transients, such as the click of the key that started a
+// recording, found in the first window of 16kHz mono samples before the speech starts. A
+// burst counts as a click when it is at most 40ms long and followed by quieter audio; the
+// first longer burst is taken as the start of the speech and nothing after it is touched.
+// The samples are modified in place, the number of muted clicks is returned.
+func SuppressClicks(samples []float32, window time.Duration) int {
+	frameSize := int(clickFrameDuration.Seconds() * SampleRate)
+	frames := min(int(window.Seconds()*SampleRate), len(samples)) / frameSize
+	if frames < 2 {
+		return 0
+	}
+
+	levels := make([]float64, frames)
+	for f := range levels {
+		var sumSquares float64
+		for _, sample := range samples[f*frameSize : (f+1)*frameSize] {
+			sumSquares += float64(sample) * float64(sample)
+		}
+		levels[f] = math.Sqrt(sumSquares / float64(frameSize))
+	}
+
+	// The background is the level of the quietest fifth of the frames.
+	sorted := slices.Clone(levels)
+	slices.Sort(sorted)
+	threshold := max(sorted[len(sorted)/5]*clickThreshold, minClickLevel)
+
+	maxFrames := int(maxClickDuration / clickFrameDuration)
+	clicks := 0
+	for f := 0; f < frames; f++ {
+		if levels[f] < threshold {
+			continue
+		}
+
+		end := f
+		for end < frames && levels[end] >= threshold {
+			end++
+		}
+		// A burst reaching the end of the window may go on, as speech does.
+		if end-f > maxFrames || end == frames {
+			break
+		}
+
+		// The frames around the burst hold its onset and decay.
+		clear(samples[max(f-1, 0)*frameSize : min(end+1, frames)*frameSize])
+		clicks++
+		f = end
+	}
+	return clicks
+}
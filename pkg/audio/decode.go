@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// ErrFFmpegNotFound is returned when decoding a format other than WAV without ffmpeg
+// installed.
+var ErrFFmpegNotFound = errors.New("ffmpeg is required to decode audio formats other than WAV, install it and make sure it is in the PATH")
+
+// Decode converts audio bytes to 16kHz mono float32 samples normalized to [-1, 1]. WAV is
+// decoded natively; any other format ffmpeg understands (MP3, FLAC, OGG, M4A...) is
+// decoded with the ffmpeg command.
+func Decode(data []byte) ([]float32, error) {
+	if IsWAV(data) {
+		return DecodeWAV(data)
+	}
+	return decodeFFmpeg(data)
+}
+
+// IsWAV reports whether the bytes start with a RIFF WAVE header.
+func IsWAV(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE"
+}
+
+// decodeFFmpeg pipes the audio through ffmpeg, which detects the format from its content
+// and converts it to 16kHz mono 16-bit PCM.
+func decodeFFmpeg(data []byte) ([]float32, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrFFmpegNotFound
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path,
+		"-nostdin", "-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-f", "s16le", "-acodec", "pcm_s16le",
+		"-ac", "1", "-ar", strconv.Itoa(SampleRate),
+		"pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return nil, fmt.Errorf("error decoding audio with ffmpeg: %s", msg)
+		}
+		return nil, fmt.Errorf("error decoding audio with ffmpeg: %w", err)
+	}
+
+	return PCM16ToFloat32(stdout.Bytes()), nil
+}
@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// gainFrameSize is the length of the frames whose level is measured, 20ms at 16kHz.
+	gainFrameSize = 320
+	// gainTargetRMS is the level the speech is brought to, -20 dBFS, the level of speech
+	// recorded at a good distance from a well adjusted microphone.
+	gainTargetRMS = 0.1
+	// maxGain is the strongest boost, 24 dB, so a recording that is mostly silence is not
+	// turned into loud noise.
+	maxGain = 16
+	// gainSpeechPercentile is the percentile of the frame levels taken as the level of the
+	// speech; the quieter frames are pauses and the louder ones plosives.
+	gainSpeechPercentile = 0.9
+	// gainPeakPercentile is the percentile of the sample magnitudes kept below full scale;
+	// the rarer peaks above it, such as clicks, are clipped.
+	gainPeakPercentile = 0.999
+	// maxPeak is the magnitude the gain may bring gainPeakPercentile to, -0.1 dBFS.
+	maxPeak = 0.99
+)
+
+// AutoGain amplifies quiet 16kHz mono samples, in place, so their speech reaches about
+// -20 dBFS, the level speech recognition models are trained on, and returns the applied
+// gain. The level of the speech is that of the loudest frames, the gain is at most 24 dB
+// and never clips more than a thousandth of the samples; loud recordings are not
+// attenuated.
+func AutoGain(samples []float32) float64 {
+	frames := len(samples) / gainFrameSize
+	if frames == 0 {
+		return 1
+	}
+
+	levels := make([]float64, frames)
+	for f := range levels {
+		var sumSquares float64
+		for _, sample := range samples[f*gainFrameSize : (f+1)*gainFrameSize] {
+			sumSquares += float64(sample) * float64(sample)
+		}
+		levels[f] = math.Sqrt(sumSquares / gainFrameSize)
+	}
+	slices.Sort(levels)
+	speech := levels[int(float64(frames-1)*gainSpeechPercentile)]
+	if speech <= 0 {
+		return 1
+	}
+
+	gain := min(gainTargetRMS/speech, maxGain)
+	if peak := percentileMagnitude(samples, gainPeakPercentile); peak > 0 {
+		gain = min(gain, maxPeak/peak)
+	}
+	if gain <= 1 {
+		return 1
+	}
+
+	for i, sample := range samples {
+		samples[i] = float32(max(min(float64(sample)*gain, 1), -1))
+	}
+	return gain
+}
+
+// magnitudeBuckets is the resolution of percentileMagnitude.
+const magnitudeBuckets = 4096
+
+// percentileMagnitude returns the magnitude below which the fraction p of the samples is,
+// rounded up to 1/4096, counting the samples instead of sorting them as recordings are
+// long.
+func percentileMagnitude(samples []float32, p float64) float64 {
+	counts := make([]int, magnitudeBuckets)
+	for _, sample := range samples {
+		counts[min(int(math.Abs(float64(sample))*magnitudeBuckets), magnitudeBuckets-1)]++
+	}
+
+	target := int(math.Ceil(float64(len(samples)) * p))
+	seen := 0
+	for bucket, count := range counts {
+		seen += count
+		if seen >= target {
+			return float64(bucket+1) / magnitudeBuckets
+		}
+	}
+	return 1
+}
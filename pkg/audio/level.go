@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Levels returns the peak and RMS levels of samples normalized to [-1, 1], in dBFS. Full
+// scale is 0 dBFS and digital silence is negative infinity.
+func Levels(samples []float32) (peak, rms float64) {
+	var maxAbs, sumSquares float64
+	for _, sample := range samples {
+		value := math.Abs(float64(sample))
+		maxAbs = max(maxAbs, value)
+		sumSquares += value * value
+	}
+
+	if len(samples) > 0 {
+		rms = math.Sqrt(sumSquares / float64(len(samples)))
+	}
+	return decibels(maxAbs), decibels(rms)
+}
+
+// decibels converts an amplitude relative to full scale into dBFS.
+func decibels(amplitude float64) float64 {
+	return 20 * math.Log10(amplitude)
+}
+
+// PCM16Levels returns the peak and RMS levels of little-endian 16-bit PCM data in dBFS,
+// like Levels without converting the samples first.
+func PCM16Levels(data []byte) (peak, rms float64) {
+	var maxAbs, sumSquares float64
+	samples := len(data) / 2
+	for i := range samples {
+		value := math.Abs(float64(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768)
+		maxAbs = max(maxAbs, value)
+		sumSquares += value * value
+	}
+
+	if samples > 0 {
+		rms = math.Sqrt(sumSquares / float64(samples))
+	}
+	return decibels(maxAbs), decibels(rms)
+}
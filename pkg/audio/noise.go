@@ -0,0 +1,175 @@
+package audio
+
+import (
+	"cmp"
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+const (
+	// noiseFrameSize is the length of the analyzed frames, 32ms at 16kHz, and
+	// noiseHopSize the distance between them, so frames overlap by half.
+	noiseFrameSize = 512
+	noiseHopSize   = noiseFrameSize / 2
+	// noiseQuietFraction is the fraction of the frames, the quietest ones, whose spectrum
+	// estimates the background noise.
+	noiseQuietFraction = 0.1
+	// minNoiseFrames is the fewest frames the noise is estimated from, shorter recordings
+	// are left as they are.
+	minNoiseFrames = 8
+	// noiseSmoothing is the weight of the previous frame in the a priori SNR estimate,
+	// which avoids the "musical noise" of isolated bins switching on and off.
+	noiseSmoothing = 0.98
+	// minNoiseGain is the strongest attenuation, about -20 dB, so the background is
+	// reduced rather than removed and speech keeps sounding natural.
+	minNoiseGain = 0.1
+	// silentFrameEnergy is the mean squared sample below which a frame is digital silence,
+	// e.g. muted clicks, which says nothing about the background noise.
+	silentFrameEnergy = 1e-10
+)
+
+// SuppressNoise reduces the stationary background noise of 16kHz mono samples, such as
+// the hum of a fan or the murmur of an office, and returns the cleaned samples. The noise
+// spectrum is estimated from the quietest frames of the recording and every frequency of
+// every frame is attenuated according to its estimated signal-to-noise ratio (a Wiener
+// filter with the decision-directed estimate of Ephraim and Malah), so speech is kept and
+// the background is lowered by up to 20 dB. Recordings too short to estimate the noise
+// are returned as they are.
+func SuppressNoise(samples []float32) []float32 {
+	frames := (len(samples) - noiseFrameSize) / noiseHopSize
+	if frames < minNoiseFrames {
+		return samples
+	}
+
+	window := make([]float64, noiseFrameSize)
+	for i := range window {
+		// Square root of a periodic Hann window, applied before and after the filter: the
+		// squares of the overlapping halves add up to one.
+		window[i] = math.Sqrt(0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/noiseFrameSize))
+	}
+
+	bins := noiseFrameSize/2 + 1
+	spectra := make([][]complex128, frames)
+	energies := make([]float64, frames)
+	for f := range spectra {
+		frame := make([]complex128, noiseFrameSize)
+		for i := range frame {
+			sample := float64(samples[f*noiseHopSize+i])
+			frame[i] = complex(sample*window[i], 0)
+			energies[f] += sample * sample
+		}
+		energies[f] /= noiseFrameSize
+		fft(frame, false)
+		spectra[f] = frame
+	}
+
+	noise, ok := estimateNoise(spectra, energies, bins)
+	if !ok {
+		return samples
+	}
+
+	output := make([]float64, len(samples))
+	prevGain := make([]float64, bins)
+	prevSNR := make([]float64, bins)
+	for f, frame := range spectra {
+		for k := range bins {
+			power := real(frame[k])*real(frame[k]) + imag(frame[k])*imag(frame[k])
+			posterior := power / noise[k]
+			prior := noiseSmoothing*prevGain[k]*prevGain[k]*prevSNR[k] + (1-noiseSmoothing)*max(posterior-1, 0)
+			gain := max(prior/(1+prior), minNoiseGain)
+			prevGain[k], prevSNR[k] = gain, posterior
+
+			frame[k] *= complex(gain, 0)
+			// The spectrum of a real signal is symmetric.
+			if k > 0 && k < noiseFrameSize/2 {
+				frame[noiseFrameSize-k] = cmplx.Conj(frame[k])
+			}
+		}
+		fft(frame, true)
+
+		start := f * noiseHopSize
+		for i, value := range frame {
+			output[start+i] += real(value) * window[i]
+		}
+	}
+
+	result := make([]float32, len(samples))
+	for i, value := range output {
+		// The edges of the first and last frames are not overlapped, and the tail after
+		// the last frame is not processed, so they are kept as captured.
+		if i < noiseHopSize || i >= frames*noiseHopSize {
+			result[i] = samples[i]
+			continue
+		}
+		result[i] = float32(max(min(value, 1), -1))
+	}
+	return result
+}
+
+// estimateNoise returns the mean power spectrum of the quietest frames that are not
+// digital silence, false if there are too few of them.
+func estimateNoise(spectra [][]complex128, energies []float64, bins int) ([]float64, bool) {
+	order := make([]int, 0, len(energies))
+	for f, energy := range energies {
+		if energy > silentFrameEnergy {
+			order = append(order, f)
+		}
+	}
+	if len(order) < minNoiseFrames {
+		return nil, false
+	}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(energies[a], energies[b]) })
+	quiet := order[:max(int(float64(len(order))*noiseQuietFraction), minNoiseFrames)]
+
+	noise := make([]float64, bins)
+	for _, f := range quiet {
+		for k := range bins {
+			value := spectra[f][k]
+			noise[k] += real(value)*real(value) + imag(value)*imag(value)
+		}
+	}
+	for k := range noise {
+		noise[k] = max(noise[k]/float64(len(quiet)), 1e-12)
+	}
+	return noise, true
+}
+
+// fft computes in place the discrete Fourier transform of x, whose length must be a power
+// of two, or its inverse, scaled by 1/len(x).
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+
+	if inverse {
+		for i := range x {
+			x[i] /= complex(float64(n), 0)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// speechLike returns seconds of 16kHz audio alternating half a second of a 440Hz tone, the
+// "speech", and half a second of silence, so the recording has pauses to estimate the
+// noise from.
+func speechLike(seconds float64) []float32 {
+	samples := tone(440, 16000, seconds)
+	for i := range samples {
+		if i/8000%2 == 1 {
+			samples[i] = 0
+		}
+	}
+	return samples
+}
+
+// withNoise returns the samples with white noise of the given peak amplitude added.
+func withNoise(samples []float32, amplitude float32) []float32 {
+	random := rand.New(rand.NewPCG(1, 2))
+	noisy := make([]float32, len(samples))
+	for i, sample := range samples {
+		noisy[i] = sample + amplitude*(2*random.Float32()-1)
+	}
+	return noisy
+}
+
+func TestSuppressNoiseImprovesSNR(t *testing.T) {
+	clean := speechLike(4)
+	noisy := withNoise(clean, 0.05)
+
+	before := snr(trimEdges(noisy, 16000), trimEdges(clean, 16000))
+	after := snr(trimEdges(SuppressNoise(noisy), 16000), trimEdges(clean, 16000))
+
+	if after < before+6 {
+		t.Errorf("SNR is %.1f dB, not 6 dB better than the noisy input (%.1f dB)", after, before)
+	}
+}
+
+func TestSuppressNoiseKeepsShortRecordings(t *testing.T) {
+	samples := withNoise(make([]float32, noiseFrameSize*4), 0.05)
+
+	got := SuppressNoise(samples)
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Fatalf("sample %d changed from %v to %v", i, samples[i], got[i])
+		}
+	}
+}
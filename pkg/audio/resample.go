@@ -0,0 +1,108 @@
+package audio
+
+import "math"
+
+const (
+	// resampleZeroCrossings is the number of zero crossings of the sinc on each side of
+	// the kernel; more give a sharper anti-aliasing filter at a higher cost.
+	resampleZeroCrossings = 16
+	// resampleMaxPhases bounds the precomputed kernel table, conversions between rates
+	// with a larger reduced ratio compute the kernel for every output sample.
+	resampleMaxPhases = 1024
+)
+
+// Resample converts the sample rate with a windowed-sinc (Blackman) polyphase filter.
+// When downsampling, the cutoff is lowered to the output Nyquist frequency so content
+// above it is filtered out instead of aliasing into the speech band.
+func Resample(input []float32, fromRate, toRate int) []float32 {
+	if fromRate == toRate || len(input) == 0 || fromRate <= 0 || toRate <= 0 {
+		return input
+	}
+
+	divisor := gcd(fromRate, toRate)
+	up, down := toRate/divisor, fromRate/divisor
+
+	// The cutoff is relative to the input Nyquist frequency, the kernel widens in input
+	// samples as it decreases so the number of zero crossings stays the same.
+	cutoff := min(1, float64(toRate)/float64(fromRate))
+	halfWidth := int(math.Ceil(resampleZeroCrossings / cutoff))
+
+	var table [][]float32
+	if up <= resampleMaxPhases {
+		table = make([][]float32, up)
+		for phase := range table {
+			table[phase] = resampleKernel(float64(phase)/float64(up), cutoff, halfWidth)
+		}
+	}
+
+	outputLength := int(int64(len(input)) * int64(up) / int64(down))
+	output := make([]float32, outputLength)
+
+	for i := range output {
+		position := int64(i) * int64(down)
+		center := int(position / int64(up))
+		phase := int(position % int64(up))
+
+		var weights []float32
+		if table != nil {
+			weights = table[phase]
+		}
+		if table == nil {
+			weights = resampleKernel(float64(phase)/float64(up), cutoff, halfWidth)
+		}
+
+		// weights[k] applies to the input sample center-halfWidth+1+k.
+		first := center - halfWidth + 1
+		var sum float32
+		for k, weight := range weights {
+			if n := first + k; n >= 0 && n < len(input) {
+				sum += weight * input[n]
+			}
+		}
+		output[i] = sum
+	}
+
+	return output
+}
+
+// resampleKernel returns the filter weights for an output sample that falls frac input
+// samples after an input sample, normalized to unity gain so the level is preserved.
+func resampleKernel(frac, cutoff float64, halfWidth int) []float32 {
+	weights := make([]float64, 2*halfWidth)
+	var total float64
+	for k := range weights {
+		t := float64(k-halfWidth+1) - frac
+		weights[k] = cutoff * sinc(cutoff*t) * blackman(t/float64(halfWidth))
+		total += weights[k]
+	}
+
+	kernel := make([]float32, len(weights))
+	for k, weight := range weights {
+		kernel[k] = float32(weight / total)
+	}
+	return kernel
+}
+
+// sinc is the normalized sinc function, sin(πx)/(πx).
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is the Blackman window centered at zero, for x between -1 and 1.
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
+
+// gcd returns the greatest common divisor of two positive integers.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// tone returns seconds of a sine wave at frequency Hz with peak amplitude 0.5.
+func tone(frequency float64, rate int, seconds float64) []float32 {
+	samples := make([]float32, int(seconds*float64(rate)))
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*frequency*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// linearResample is the linear interpolation resampler Resample replaced, the baseline
+// its quality is compared against.
+func linearResample(input []float32, fromRate, toRate int) []float32 {
+	ratio := float64(fromRate) / float64(toRate)
+	output := make([]float32, int(float64(len(input))/ratio))
+	for i := range output {
+		pos := float64(i) * ratio
+		index := int(pos)
+		frac := float32(pos - float64(index))
+		high := min(index+1, len(input)-1)
+		output[i] = (1-frac)*input[index] + frac*input[high]
+	}
+	return output
+}
+
+// trimEdges drops the first and last 50ms, where the filter runs past the input.
+func trimEdges(samples []float32, rate int) []float32 {
+	edge := rate / 20
+	return samples[edge : len(samples)-edge]
+}
+
+// snr returns the ratio in dB between the power of want and of the difference to got.
+func snr(got, want []float32) float64 {
+	var signal, noise float64
+	for i := range want {
+		signal += float64(want[i]) * float64(want[i])
+		diff := float64(got[i]) - float64(want[i])
+		noise += diff * diff
+	}
+	return 10 * math.Log10(signal/noise)
+}
+
+// rmsDB returns the RMS level of the samples in dB relative to full scale.
+func rmsDB(samples []float32) float64 {
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample) * float64(sample)
+	}
+	return 10 * math.Log10(sum/float64(len(samples)))
+}
+
+func TestResampleTone(t *testing.T) {
+	input := tone(1000, 44100, 1)
+	want := trimEdges(tone(1000, 16000, 1), 16000)
+
+	got := trimEdges(Resample(input, 44100, 16000), 16000)
+	linear := trimEdges(linearResample(input, 44100, 16000), 16000)
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(got), len(want))
+	}
+	if diff := rmsDB(got) - rmsDB(want); math.Abs(diff) > 0.01 {
+		t.Errorf("level changed by %.3f dB", diff)
+	}
+
+	gotSNR, linearSNR := snr(got, want), snr(linear, want)
+	if gotSNR < 60 {
+		t.Errorf("SNR is %.1f dB, want at least 60 dB", gotSNR)
+	}
+	if gotSNR < linearSNR+20 {
+		t.Errorf("SNR is %.1f dB, not 20 dB better than linear interpolation (%.1f dB)", gotSNR, linearSNR)
+	}
+}
+
+func TestResampleRejectsAliases(t *testing.T) {
+	// 10 kHz is above the 8 kHz Nyquist frequency of the output, it would alias to 6 kHz.
+	input := tone(10000, 44100, 1)
+
+	got := rmsDB(trimEdges(Resample(input, 44100, 16000), 16000))
+	linear := rmsDB(trimEdges(linearResample(input, 44100, 16000), 16000))
+
+	if level := got - rmsDB(input); level > -60 {
+		t.Errorf("alias at %.1f dB, want below -60 dB", level)
+	}
+	if got > linear-40 {
+		t.Errorf("alias at %.1f dBFS, not 40 dB below linear interpolation (%.1f dBFS)", got, linear)
+	}
+}
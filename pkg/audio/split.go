@@ -0,0 +1,70 @@
+package audio
+
+import "time"
+
+// splitFrameDuration is the size of the frames compared when looking for a quiet point.
+const splitFrameDuration = 20 * time.Millisecond
+
+// SplitAtSilence splits 16kHz mono samples into chunks of at most maxDuration. Each cut
+// is placed at the quietest frame of the last quarter of the chunk, so words are rarely
+// cut in half. Audio shorter than maxDuration is returned as a single chunk.
+func SplitAtSilence(samples []float32, maxDuration time.Duration) [][]float32 {
+	maxSamples := int(maxDuration.Seconds() * SampleRate)
+	if maxSamples <= 0 || len(samples) <= maxSamples {
+		return [][]float32{samples}
+	}
+
+	frameSize := int(splitFrameDuration.Seconds() * SampleRate)
+	var chunks [][]float32
+
+	for len(samples) > maxSamples {
+		cut := quietestFrame(samples, maxSamples-maxSamples/4, maxSamples, frameSize)
+		chunks = append(chunks, samples[:cut])
+		samples = samples[cut:]
+	}
+
+	if len(samples) > 0 {
+		chunks = append(chunks, samples)
+	}
+	return chunks
+}
+
+// SplitWithOverlap splits 16kHz mono samples at the same quiet points as SplitAtSilence,
+// but every chunk extends half the overlap into its neighbours, so a word around a cut is
+// whole in at least one of them. The text of the overlapping audio is transcribed twice
+// and must be merged by the caller.
+func SplitWithOverlap(samples []float32, maxDuration, overlap time.Duration) [][]float32 {
+	chunks := SplitAtSilence(samples, maxDuration)
+	if len(chunks) == 1 {
+		return chunks
+	}
+
+	margin := int(overlap.Seconds() * SampleRate / 2)
+	overlapped := make([][]float32, 0, len(chunks))
+	start := 0
+	for _, chunk := range chunks {
+		end := start + len(chunk)
+		overlapped = append(overlapped, samples[max(start-margin, 0):min(end+margin, len(samples))])
+		start = end
+	}
+	return overlapped
+}
+
+// quietestFrame returns the start of the frame with the lowest energy in [from, to).
+func quietestFrame(samples []float32, from, to, frameSize int) int {
+	best := to
+	bestEnergy := float32(-1)
+
+	for start := from; start+frameSize <= to; start += frameSize {
+		var energy float32
+		for _, sample := range samples[start : start+frameSize] {
+			energy += sample * sample
+		}
+		if bestEnergy < 0 || energy < bestEnergy {
+			best = start
+			bestEnergy = energy
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,549 @@
+// Package record captures audio from an input device, the system's default one unless
+// another is selected, as 16kHz mono 16-bit PCM, the format expected by the speech
+// recognition models.
+package record
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/gen2brain/malgo"
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+var (
+	ErrAlreadyRecording = fmt.Errorf("recording is already in progress")
+	ErrNotRecording     = fmt.Errorf("no recording in progress")
+)
+
+// Recorder captures audio into an in-memory buffer.
+type Recorder struct {
+	device      *malgo.Device
+	ctx         *malgo.AllocatedContext
+	isRecording bool
+	data        []byte
+	mu          sync.Mutex
+	// paused drops the audio delivered by the device, see Pause.
+	paused bool
+	// startedAt and firstDataAt measure the input latency of the last recording.
+	startedAt   time.Time
+	firstDataAt time.Time
+	// startTrim and clickWindow clean the start of the recordings, see SetStartCleanup.
+	startTrim   time.Duration
+	clickWindow time.Duration
+	// suppressNoise reduces the background noise of the recordings, see
+	// SetNoiseSuppression, and autoGain amplifies quiet ones, see SetAutoGain.
+	suppressNoise bool
+	autoGain      bool
+	// deviceID is the input device selected with SetDevice, empty for the default one.
+	// devicePointers keeps the C copies of the IDs passed to malgo, allocated once per
+	// device.
+	deviceID       string
+	devicePointers map[string]unsafe.Pointer
+	// levelPeak and levelRMS are the levels of the last audio captured, see Level.
+	levelPeak float64
+	levelRMS  float64
+	// preRoll is the audio kept in ring while not recording, see SetPreRoll, and
+	// preRollLength the bytes of it at the start of the recording in progress.
+	preRoll       time.Duration
+	ring          []byte
+	preRollLength int
+}
+
+// Device is an input device.
+type Device struct {
+	// ID identifies the device for SetDevice, it is specific to the audio backend.
+	ID      string
+	Name    string
+	Default bool
+}
+
+// clickSearchWindow is how much of the start of a recording is searched for clicks.
+const clickSearchWindow = 500 * time.Millisecond
+
+// NewRecorder creates a new recorder initializing the audio backend.
+func NewRecorder() (*Recorder, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{ctx: ctx, devicePointers: make(map[string]unsafe.Pointer)}, nil
+}
+
+// Devices lists the input devices.
+func (r *Recorder) Devices() ([]Device, error) {
+	infos, err := r.ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list input devices: %w", err)
+	}
+
+	devices := make([]Device, 0, len(infos))
+	for _, info := range infos {
+		devices = append(devices, Device{
+			ID:      info.ID.String(),
+			Name:    info.Name(),
+			Default: info.IsDefault != 0,
+		})
+	}
+	return devices, nil
+}
+
+// SetDevice selects the input device of the next recordings by its ID (see Devices),
+// empty selects the system's default device. A recording in progress keeps its device,
+// the pre-roll switches to the new one at once.
+func (r *Recorder) SetDevice(id string) {
+	r.mu.Lock()
+	changed := r.deviceID != id
+	r.deviceID = id
+	standby := r.device != nil && !r.isRecording
+	r.mu.Unlock()
+
+	if changed && standby {
+		r.restartPreRoll()
+	}
+}
+
+// SetPreRoll keeps the input device capturing the last d of audio between recordings, so
+// a recording starts with the audio from just before it was started, e.g. the first
+// syllable said together with the hotkey. The device stays open while it is set, zero
+// releases it between recordings. A recording in progress keeps the device open until it
+// stops.
+func (r *Recorder) SetPreRoll(d time.Duration) error {
+	r.mu.Lock()
+	r.preRoll = max(d, 0)
+	r.ring = nil
+	if r.isRecording {
+		r.mu.Unlock()
+		return nil
+	}
+
+	if r.preRoll > 0 && r.device == nil {
+		defer r.mu.Unlock()
+		return r.openDevice()
+	}
+
+	var device *malgo.Device
+	if r.preRoll == 0 {
+		device, r.device = r.device, nil
+	}
+	r.mu.Unlock()
+
+	closeDevice(device)
+	return nil
+}
+
+// restartPreRoll opens the selected device again for the pre-roll, e.g. after another
+// device was selected. The pre-roll stops if it cannot be opened, and the next recording
+// reports the error.
+func (r *Recorder) restartPreRoll() {
+	r.mu.Lock()
+	device := r.device
+	r.device = nil
+	r.ring = nil
+	r.mu.Unlock()
+
+	closeDevice(device)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.device == nil && !r.isRecording && r.preRoll > 0 {
+		_ = r.openDevice()
+	}
+}
+
+// DeviceName returns the name of the input device recordings are captured from: the
+// selected one, or the default one if none is selected or it is not connected.
+func (r *Recorder) DeviceName() (string, error) {
+	r.mu.Lock()
+	id := r.deviceID
+	r.mu.Unlock()
+
+	if id != "" {
+		infos, err := r.ctx.Devices(malgo.Capture)
+		if err != nil {
+			return "", fmt.Errorf("cannot list input devices: %w", err)
+		}
+		for _, info := range infos {
+			if info.ID.String() == id {
+				return info.Name(), nil
+			}
+		}
+	}
+	return r.DefaultDeviceName()
+}
+
+// devicePointer returns the malgo ID of the selected input device, nil for the default
+// one or if the selected device is not connected, r.mu must be held.
+func (r *Recorder) devicePointer() unsafe.Pointer {
+	if r.deviceID == "" {
+		return nil
+	}
+
+	infos, err := r.ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil
+	}
+	for _, info := range infos {
+		if info.ID.String() != r.deviceID {
+			continue
+		}
+		pointer, ok := r.devicePointers[r.deviceID]
+		if !ok {
+			// Pointer copies the ID to C memory, which is never freed, so it is reused.
+			pointer = info.ID.Pointer()
+			r.devicePointers[r.deviceID] = pointer
+		}
+		return pointer
+	}
+	return nil
+}
+
+// Start begins the recording process. It cleans the buffer and starts capturing audio
+// data, after the pre-roll captured since the last recording, if any.
+func (r *Recorder) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isRecording {
+		return ErrAlreadyRecording
+	}
+
+	r.data = []byte{} // Clean the buffer before starting
+	r.paused = false
+	r.startedAt = time.Now()
+	r.firstDataAt = time.Time{}
+	r.levelPeak, r.levelRMS = math.Inf(-1), math.Inf(-1)
+	r.preRollLength = 0
+
+	if r.device != nil {
+		// The device is already capturing for the pre-roll.
+		r.data = append(r.data, r.ring[max(len(r.ring)-r.preRollSize(), 0):]...)
+		r.preRollLength = len(r.data)
+		r.ring = nil
+		r.isRecording = true
+		return nil
+	}
+
+	if err := r.openDevice(); err != nil {
+		return err
+	}
+	r.isRecording = true
+	return nil
+}
+
+// openDevice opens and starts the selected input device, r.mu must be held.
+func (r *Recorder) openDevice() error {
+	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
+	deviceConfig.Capture.Format = malgo.FormatS16
+	deviceConfig.Capture.Channels = 1
+	deviceConfig.SampleRate = audio.SampleRate
+	deviceConfig.Capture.DeviceID = r.devicePointer()
+
+	device, err := malgo.InitDevice(r.ctx.Context, deviceConfig, malgo.DeviceCallbacks{Data: r.onData})
+	if err != nil {
+		return fmt.Errorf("cannot open the input device (is microphone access allowed?): %w", err)
+	}
+
+	if err := device.Start(); err != nil {
+		device.Uninit()
+		return fmt.Errorf("cannot start the input device (is microphone access allowed?): %w", err)
+	}
+	r.device = device
+	return nil
+}
+
+// onData receives the audio captured by the device: it is recorded while recording and
+// kept as pre-roll otherwise.
+func (r *Recorder) onData(pOutput, pInput []byte, frameCount uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.isRecording && !r.paused:
+		if r.firstDataAt.IsZero() && len(pInput) > 0 {
+			r.firstDataAt = time.Now()
+		}
+		r.data = append(r.data, pInput...)
+		r.levelPeak, r.levelRMS = audio.PCM16Levels(pInput)
+	case !r.isRecording && r.preRoll > 0:
+		r.ring = append(r.ring, pInput...)
+		// The ring is shortened once it doubles, so the copy is amortized.
+		if size := r.preRollSize(); len(r.ring) > 2*size {
+			r.ring = r.ring[:copy(r.ring, r.ring[len(r.ring)-size:])]
+		}
+	}
+}
+
+// preRollSize returns the size in bytes of the pre-roll, r.mu must be held.
+func (r *Recorder) preRollSize() int {
+	return int(r.preRoll.Seconds()*audio.SampleRate) * 2
+}
+
+// closeDevice stops and releases a device, if any.
+func closeDevice(device *malgo.Device) {
+	if device == nil {
+		return
+	}
+	_ = device.Stop()
+	device.Uninit()
+}
+
+// SetStartCleanup sets how the start of the next recordings is cleaned of the click of
+// the key or hotkey that started them, which is often captured and sometimes transcribed
+// as a spurious word. The first trim of audio is dropped, and with suppressClicks the
+// short transients before the speech starts are muted (see audio.SuppressClicks).
+func (r *Recorder) SetStartCleanup(trim time.Duration, suppressClicks bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.startTrim = max(trim, 0)
+	r.clickWindow = 0
+	if suppressClicks {
+		r.clickWindow = clickSearchWindow
+	}
+}
+
+// SetNoiseSuppression sets whether the background noise of the next recordings is reduced
+// when they stop (see audio.SuppressNoise), for dictation in noisy rooms. The level, Tail
+// and the pre-roll are not affected.
+func (r *Recorder) SetNoiseSuppression(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.suppressNoise = enabled
+}
+
+// SetAutoGain sets whether quiet recordings are amplified when they stop (see
+// audio.AutoGain), after the noise suppression, so quiet microphones are transcribed as
+// well as the others. The level, Tail and the pre-roll are not affected.
+func (r *Recorder) SetAutoGain(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoGain = enabled
+}
+
+// Pause stops capturing audio without ending the recording, releasing the device until
+// Resume continues the recording after the audio captured so far. It does nothing while
+// paused.
+func (r *Recorder) Pause() error {
+	r.mu.Lock()
+	if !r.isRecording {
+		r.mu.Unlock()
+		return ErrNotRecording
+	}
+	if r.paused {
+		r.mu.Unlock()
+		return nil
+	}
+	r.paused = true
+	r.levelPeak, r.levelRMS = math.Inf(-1), math.Inf(-1)
+	device := r.device
+	r.mu.Unlock()
+
+	// The audio delivered while the device stops is already dropped, so a failure to stop
+	// it only keeps the device busy.
+	_ = device.Stop()
+	return nil
+}
+
+// Resume continues a paused recording. It does nothing while not paused.
+func (r *Recorder) Resume() error {
+	r.mu.Lock()
+	if !r.isRecording {
+		r.mu.Unlock()
+		return ErrNotRecording
+	}
+	if !r.paused {
+		r.mu.Unlock()
+		return nil
+	}
+	device := r.device
+	r.mu.Unlock()
+
+	if err := device.Start(); err != nil {
+		return fmt.Errorf("cannot resume the input device: %w", err)
+	}
+
+	r.mu.Lock()
+	r.paused = false
+	r.mu.Unlock()
+	return nil
+}
+
+// Stop stops the recording process, paused or not, and cleans the recorded audio. With a
+// pre-roll, the device keeps capturing for the next recording.
+func (r *Recorder) Stop() {
+	r.mu.Lock()
+	r.isRecording = false
+	paused := r.paused
+	r.paused = false
+	r.ring = nil
+	device := r.device
+	keep := device != nil && r.preRoll > 0
+	if !keep {
+		r.device = nil
+	}
+	r.mu.Unlock()
+
+	// A paused device was stopped, it is started again for the pre-roll.
+	if keep && paused && device.Start() != nil {
+		keep = false
+		r.mu.Lock()
+		r.device = nil
+		r.mu.Unlock()
+	}
+	if !keep {
+		closeDevice(device)
+	}
+
+	r.mu.Lock()
+	r.cleanStart()
+	data, suppressNoise, autoGain := r.data, r.suppressNoise, r.autoGain
+	r.mu.Unlock()
+
+	// The audio is processed without the lock, which the audio callback of the pre-roll
+	// needs, as long recordings take a moment.
+	if !suppressNoise && !autoGain {
+		return
+	}
+	samples := audio.PCM16ToFloat32(data)
+	if suppressNoise {
+		samples = audio.SuppressNoise(samples)
+	}
+	if autoGain {
+		audio.AutoGain(samples)
+	}
+	cleaned := audio.Float32ToPCM16(samples)
+
+	r.mu.Lock()
+	r.data = cleaned
+	r.mu.Unlock()
+}
+
+// cleanStart applies the start cleanup to the recorded data after the pre-roll, where the
+// recording was started, r.mu must be held.
+func (r *Recorder) cleanStart() {
+	start := r.preRollLength
+	trim := int(r.startTrim.Seconds()*audio.SampleRate) * 2
+	r.data = slices.Delete(r.data, start, start+min(trim, len(r.data)-start))
+
+	if r.clickWindow > 0 {
+		samples := audio.PCM16ToFloat32(r.data[start:])
+		if audio.SuppressClicks(samples, r.clickWindow) > 0 {
+			r.data = append(r.data[:start], audio.Float32ToPCM16(samples)...)
+		}
+	}
+}
+
+// Level returns the peak and RMS levels, in dBFS, of the last audio captured by the
+// device, a few milliseconds, so frontends can show that the microphone picks up sound.
+// Both are -Inf while paused and ok is false while not recording.
+func (r *Recorder) Level() (peak, rms float64, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRecording {
+		return 0, 0, false
+	}
+	return r.levelPeak, r.levelRMS, true
+}
+
+// Tail returns the last d of the recording in progress as float32 samples normalized to
+// [-1, 1], less at its start, to analyze it while recording. ok is false while not
+// recording.
+func (r *Recorder) Tail(d time.Duration) (samples []float32, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRecording {
+		return nil, false
+	}
+	size := int(d.Seconds()*audio.SampleRate) * 2
+	return audio.PCM16ToFloat32(r.data[max(len(r.data)-size, 0):]), true
+}
+
+// Duration returns the length of the audio recorded so far, without the start trim (see
+// SetStartCleanup), i.e. the position in the saved recording of what is being captured.
+func (r *Recorder) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recorded := time.Duration(len(r.data)/2) * time.Second / audio.SampleRate
+	return max(recorded-r.startTrim, 0)
+}
+
+// Release frees the buffer of the last recording, which is otherwise kept until the next
+// one starts. It does nothing while recording.
+func (r *Recorder) Release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isRecording {
+		r.data = nil
+	}
+}
+
+// InputLatency returns the time between the start of the last recording and the first
+// audio captured by the device, zero if nothing was captured.
+func (r *Recorder) InputLatency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.firstDataAt.IsZero() {
+		return 0
+	}
+	return r.firstDataAt.Sub(r.startedAt)
+}
+
+// DefaultDeviceName returns the name of the system's default input device.
+func (r *Recorder) DefaultDeviceName() (string, error) {
+	devices, err := r.ctx.Devices(malgo.Capture)
+	if err != nil {
+		return "", fmt.Errorf("cannot list input devices: %w", err)
+	}
+
+	for _, device := range devices {
+		if device.IsDefault != 0 {
+			return device.Name(), nil
+		}
+	}
+	if len(devices) > 0 {
+		return devices[0].Name(), nil
+	}
+	return "", fmt.Errorf("no input device found")
+}
+
+// SaveWAV saves the recorded audio data to a WAV file at the specified path.
+func (r *Recorder) SaveWAV(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return r.WriteWAV(f)
+}
+
+// WriteWAV writes the recorded audio data as a WAV file to w, e.g. to keep it in memory.
+func (r *Recorder) WriteWAV(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := audio.WriteWAVHeader(w, len(r.data), audio.SampleRate, 1); err != nil {
+		return err
+	}
+	_, err := w.Write(r.data)
+	return err
+}
+
+// Samples returns a copy of the recorded audio as float32 samples normalized to [-1, 1].
+func (r *Recorder) Samples() []float32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return audio.PCM16ToFloat32(r.data)
+}
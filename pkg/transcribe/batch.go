@@ -0,0 +1,73 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+// BatchResult is the outcome of transcribing one file of a batch. Err is set, and Result
+// empty, when the file could not be read, decoded or transcribed.
+type BatchResult struct {
+	Path   string
+	Result Result
+	Err    error
+}
+
+// TranscribeBatch transcribes the audio files in order and returns one BatchResult per
+// file, in the same order. All files are transcribed with the model active when the call
+// starts, reusing its sessions, and long files are chunked like in
+// TranscribeSamplesWithPartials, with the chunk workers set when the file starts, so
+// SetChunkWorkers can throttle a batch in progress. A failing file does not stop the
+// batch, but once ctx is canceled the remaining files fail with its error.
+func (i *Instance) TranscribeBatch(ctx context.Context, files []string) []BatchResult {
+	return i.TranscribeBatchWithProgress(ctx, files, nil)
+}
+
+// TranscribeBatchWithProgress transcribes the files like TranscribeBatch and calls
+// onProgress with the fraction of the batch completed, counting the chunks of long files.
+func (i *Instance) TranscribeBatchWithProgress(ctx context.Context, files []string, onProgress ProgressCallback) []BatchResult {
+	model := i.activeModel()
+
+	results := make([]BatchResult, 0, len(files))
+	for n, path := range files {
+		var onFileProgress ProgressCallback
+		if onProgress != nil {
+			onFileProgress = func(fraction float64) {
+				onProgress((float64(n) + fraction) / float64(len(files)))
+			}
+		}
+
+		result, err := transcribeFile(ctx, model, path, i.chunking(), onFileProgress)
+		results = append(results, BatchResult{Path: path, Result: result, Err: err})
+		if onProgress != nil {
+			onProgress(float64(n+1) / float64(len(files)))
+		}
+	}
+	return results
+}
+
+// transcribeFile reads, decodes and transcribes an audio file with the given model.
+func transcribeFile(ctx context.Context, model Model, path string, cfg chunking, onProgress ProgressCallback) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, fmt.Errorf("error transcribing %s: %w", path, err)
+	}
+
+	wavData, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	samples, err := audio.Decode(wavData)
+	if err != nil {
+		return Result{}, fmt.Errorf("error processing audio data of %s: %w", path, err)
+	}
+
+	result, err := transcribeChunked(ctx, model, samples, cfg, nil, onProgress)
+	if err != nil {
+		return Result{}, fmt.Errorf("error transcribing %s: %w", path, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package transcribe
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+// benchmarkClipDuration is the length of the clip BenchmarkSamples generates.
+const benchmarkClipDuration = 20 * time.Second
+
+// benchmarkData holds the speech clip BenchmarkSamples prefers, benchdata/speech.wav (see
+// benchdata/README.md for its requirements).
+//
+//go:embed benchdata
+var benchmarkData embed.FS
+
+// BenchmarkReport is the outcome of Benchmark.
+type BenchmarkReport struct {
+	ModelID           string            `json:"model_id"`
+	ExecutionProvider ExecutionProvider `json:"execution_provider"`
+	Precision         Precision         `json:"precision"`
+	// AudioDuration is the length of the benchmarked audio.
+	AudioDuration time.Duration `json:"audio_duration"`
+	// Runs is the number of measured runs, the warm-up run is not counted.
+	Runs int `json:"runs"`
+	// Mean, Min and Max are the wall time of the runs.
+	Mean time.Duration `json:"mean"`
+	Min  time.Duration `json:"min"`
+	Max  time.Duration `json:"max"`
+	// RTF is the real-time factor, Mean divided by AudioDuration: below 1 the audio is
+	// transcribed faster than it plays.
+	RTF float64 `json:"rtf"`
+	// Stages is the mean time of every stage of the model, empty if it does not report
+	// them.
+	Stages []StageTiming `json:"stages,omitempty"`
+	// PeakMemoryBytes is the peak resident memory of the process, 0 if unknown.
+	PeakMemoryBytes uint64 `json:"peak_memory_bytes"`
+	// Text is the transcription of the last run.
+	Text string `json:"text"`
+}
+
+// Benchmark transcribes samples runs times with the loaded model and reports how long it
+// took. A first warm-up run, which pays for the lazy allocations of ONNX Runtime, is not
+// measured. The audio is transcribed in one piece, without chunking, so the numbers only
+// depend on the model, the execution provider and the threads.
+func (i *Instance) Benchmark(ctx context.Context, samples []float32, runs int) (BenchmarkReport, error) {
+	if runs < 1 {
+		return BenchmarkReport{}, errors.New("the benchmark needs at least one run")
+	}
+	if len(samples) == 0 {
+		return BenchmarkReport{}, errors.New("the benchmark audio is empty")
+	}
+
+	model := i.activeModel()
+	if _, err := model.Transcribe(ctx, samples); err != nil {
+		return BenchmarkReport{}, fmt.Errorf("warm-up run failed: %w", err)
+	}
+
+	report := BenchmarkReport{
+		ModelID:           i.ModelID(),
+		ExecutionProvider: i.ExecutionProvider(),
+		Precision:         i.Precision(),
+		AudioDuration:     time.Duration(len(samples)) * time.Second / audio.SampleRate,
+		Runs:              runs,
+		Min:               time.Duration(math.MaxInt64),
+	}
+
+	var (
+		total  time.Duration
+		stages []StageTiming
+	)
+	for run := range runs {
+		started := time.Now()
+		result, err := model.Transcribe(ctx, samples)
+		if err != nil {
+			return BenchmarkReport{}, fmt.Errorf("run %d failed: %w", run+1, err)
+		}
+		elapsed := time.Since(started)
+
+		total += elapsed
+		report.Min = min(report.Min, elapsed)
+		report.Max = max(report.Max, elapsed)
+		report.Text = result.Text
+
+		if stages == nil {
+			stages = make([]StageTiming, len(result.Timings))
+		}
+		for s, timing := range result.Timings {
+			if s < len(stages) {
+				stages[s].Stage = timing.Stage
+				stages[s].Duration += timing.Duration
+			}
+		}
+	}
+
+	report.Mean = total / time.Duration(runs)
+	report.RTF = report.Mean.Seconds() / report.AudioDuration.Seconds()
+	for s := range stages {
+		stages[s].Duration /= time.Duration(runs)
+	}
+	report.Stages = stages
+	report.PeakMemoryBytes = peakMemory()
+
+	return report, nil
+}
+
+// BenchmarkSamples returns the clip Benchmark uses when no recording is given and its
+// description: the embedded speech clip, or if none is embedded 20 seconds of synthetic,
+// speech-like audio (harmonic tones with a syllable rhythm and pauses). The synthetic clip
+// exercises the preprocessor and the encoder like speech does, but the decoder emits
+// almost no tokens, so it gives optimistic decoder numbers.
+func BenchmarkSamples() ([]float32, string) {
+	if data, err := benchmarkData.ReadFile("benchdata/speech.wav"); err == nil {
+		if samples, err := audio.Decode(data); err == nil && len(samples) > 0 {
+			return samples, "embedded speech clip"
+		}
+	}
+	return syntheticSpeech(), "synthetic clip"
+}
+
+// syntheticSpeech generates benchmarkClipDuration of speech-like audio.
+func syntheticSpeech() []float32 {
+	n := int(benchmarkClipDuration.Seconds() * audio.SampleRate)
+	samples := make([]float32, n)
+	for s := range samples {
+		t := float64(s) / audio.SampleRate
+
+		// Four syllables per second, with a pause every three seconds.
+		envelope := math.Max(0, math.Sin(math.Pi*4*t))
+		if math.Mod(t, 3) > 2.5 {
+			envelope = 0
+		}
+
+		// A pitch around 140Hz with a slow intonation and its harmonics.
+		pitch := 140 + 20*math.Sin(2*math.Pi*0.5*t)
+		var value float64
+		for harmonic := 1.0; harmonic <= 5; harmonic++ {
+			value += math.Sin(2*math.Pi*pitch*harmonic*t) / harmonic
+		}
+		samples[s] = float32(0.2 * envelope * value)
+	}
+	return samples
+}
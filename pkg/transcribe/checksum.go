@@ -0,0 +1,94 @@
+package transcribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrCorruptedModel is returned when a model file does not match its checksum. The file
+// must be downloaded again.
+var ErrCorruptedModel = errors.New("corrupted model, re-download it")
+
+// checksumPath returns the path of the file recording the checksum of a model file.
+func checksumPath(path string) string {
+	return path + ".sha256"
+}
+
+// writeChecksum records the checksum of a downloaded model file.
+func writeChecksum(path, checksum string) error {
+	return os.WriteFile(checksumPath(path), []byte(checksum+"\n"), 0644)
+}
+
+// readChecksum returns the checksum recorded when a model file was downloaded.
+func readChecksum(path string) (string, error) {
+	recorded, err := os.ReadFile(checksumPath(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(recorded)), nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Verify checks the file against its declared checksum or, if it has none, the one
+// recorded when it was downloaded. Files downloaded by older versions, without a recorded
+// checksum, are accepted and their checksum is recorded. It returns an error wrapping
+// ErrCorruptedModel if the content does not match.
+func (f ModelFile) Verify() error {
+	expected := f.SHA256
+	if expected == "" {
+		recorded, err := os.ReadFile(checksumPath(f.Path))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading checksum of %s: %w", f.Name, err)
+		}
+		expected = strings.TrimSpace(string(recorded))
+	}
+
+	checksum, err := fileSHA256(f.Path)
+	if err != nil {
+		return fmt.Errorf("error computing checksum of %s: %w", f.Name, err)
+	}
+
+	if expected == "" {
+		return writeChecksum(f.Path, checksum)
+	}
+	if !strings.EqualFold(checksum, expected) {
+		return fmt.Errorf("%w: %s has checksum %s, expected %s", ErrCorruptedModel, f.Name, checksum, expected)
+	}
+	return nil
+}
+
+// VerifyModelFiles verifies every file and deletes the corrupted ones, so the next
+// download fetches them again.
+func VerifyModelFiles(files []ModelFile) error {
+	var errs []error
+	for _, file := range files {
+		err := file.Verify()
+		if errors.Is(err, ErrCorruptedModel) {
+			_ = os.Remove(file.Path)
+			_ = os.Remove(checksumPath(file.Path))
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
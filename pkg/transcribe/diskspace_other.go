@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package transcribe
+
+import "errors"
+
+// freeDiskSpace is not supported on this platform.
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errors.New("free disk space unknown on this platform")
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package transcribe
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to the process on the disk of dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
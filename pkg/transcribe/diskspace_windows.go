@@ -0,0 +1,19 @@
+//go:build windows
+
+package transcribe
+
+import "golang.org/x/sys/windows"
+
+// freeDiskSpace returns the bytes available to the process on the disk of dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,224 @@
+package transcribe
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/varavelio/tribar/pkg/audio"
+)
+
+// ExternalModelID is the registry ID conventionally used for an external transcriber.
+const ExternalModelID = "external"
+
+// ExternalRequest is sent to an external transcriber, as a single JSON line on its
+// standard input, for every transcription. AudioPath is a 16kHz mono 16-bit WAV file that
+// is deleted once the response is received.
+type ExternalRequest struct {
+	AudioPath  string `json:"audio_path"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+// ExternalResponse is the single JSON line an external transcriber writes to its standard
+// output for every request. Tokens are optional; Error reports a failed transcription
+// without exiting.
+type ExternalResponse struct {
+	Result
+	Error string `json:"error,omitempty"`
+}
+
+// ExternalModel runs speech-to-text in an external command, for engines this module does
+// not bundle (e.g. a whisper.cpp wrapper). The command is started by Load and kept
+// running: it reads one ExternalRequest per line from its standard input and answers each
+// with one ExternalResponse line on its standard output, in order. Its standard error is
+// forwarded to the standard error of this process.
+type ExternalModel struct {
+	command []string
+
+	// mu serializes the requests, the protocol handles one at a time.
+	mu      sync.Mutex
+	process *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+}
+
+// NewExternalModel creates a model that runs the given command and arguments.
+func NewExternalModel(command []string) (*ExternalModel, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, errors.New("the external transcriber command is required")
+	}
+	return &ExternalModel{command: command}, nil
+}
+
+// ExternalModelInfo returns the registry entry of an external transcriber with the given
+// ID, display name and supported languages.
+func ExternalModelInfo(id, name string, languages, command []string) ModelInfo {
+	return ModelInfo{
+		ID:        id,
+		Name:      name,
+		Languages: languages,
+		Factory: func(ModelConfig) (Model, error) {
+			return NewExternalModel(command)
+		},
+	}
+}
+
+// GetModelFiles returns no files, the external command manages its own.
+func (m *ExternalModel) GetModelFiles() []ModelFile {
+	return nil
+}
+
+// CheckModelsExist always succeeds, the external command manages its own files.
+func (m *ExternalModel) CheckModelsExist() (bool, []ModelFile) {
+	return true, nil
+}
+
+// DownloadModels does nothing, the external command manages its own files.
+func (m *ExternalModel) DownloadModels(DownloadProgressCallback) error {
+	return nil
+}
+
+// Load starts the external command, restarting it if it was already running.
+func (m *ExternalModel) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stopUnsafe()
+	return m.startUnsafe()
+}
+
+// Close stops the external command, waiting for the transcription in progress to finish.
+func (m *ExternalModel) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stopUnsafe()
+	return nil
+}
+
+// startUnsafe starts the command without acquiring the lock.
+func (m *ExternalModel) startUnsafe() error {
+	process := exec.Command(m.command[0], m.command[1:]...)
+	process.Stderr = os.Stderr
+
+	stdin, err := process.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error creating external transcriber input: %w", err)
+	}
+	stdout, err := process.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error creating external transcriber output: %w", err)
+	}
+
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("error starting external transcriber %q: %w", m.command[0], err)
+	}
+
+	m.process = process
+	m.stdin = stdin
+	m.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+// stopUnsafe closes the input of the command and kills it without acquiring the lock.
+func (m *ExternalModel) stopUnsafe() {
+	if m.process == nil {
+		return
+	}
+
+	_ = m.stdin.Close()
+	_ = m.process.Process.Kill()
+	_ = m.process.Wait()
+	m.process = nil
+}
+
+// Transcribe sends the samples to the external command and waits for its response. If
+// the exchange fails (e.g. the command crashed), the command is restarted on the next
+// transcription. Canceling ctx kills the command, which is restarted the same way.
+func (m *ExternalModel) Transcribe(ctx context.Context, samples []float32) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	wavFile, err := os.CreateTemp("", "tribar-external-*.wav")
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating audio file: %w", err)
+	}
+	defer func() { _ = os.Remove(wavFile.Name()) }()
+
+	pcm := audio.Float32ToPCM16(samples)
+	err = audio.WriteWAVHeader(wavFile, len(pcm), audio.SampleRate, 1)
+	if err == nil {
+		_, err = wavFile.Write(pcm)
+	}
+	if closeErr := wavFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("error writing audio file: %w", err)
+	}
+
+	request, err := json.Marshal(ExternalRequest{AudioPath: wavFile.Name(), SampleRate: audio.SampleRate})
+	if err != nil {
+		return Result{}, fmt.Errorf("error encoding request: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.process == nil {
+		if err := m.startUnsafe(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	process := m.process
+	stop := context.AfterFunc(ctx, func() { _ = process.Process.Kill() })
+	response, err := m.roundTripUnsafe(request)
+	stop()
+	if err != nil {
+		// The stream may be out of sync after a failed exchange, start over next time.
+		m.stopUnsafe()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Result{}, ctxErr
+		}
+		return Result{}, err
+	}
+	if response.Error != "" {
+		return Result{}, fmt.Errorf("external transcriber error: %s", response.Error)
+	}
+	return response.Result, nil
+}
+
+// roundTripUnsafe writes a request line and reads the response line without acquiring
+// the lock.
+func (m *ExternalModel) roundTripUnsafe(request []byte) (ExternalResponse, error) {
+	if _, err := m.stdin.Write(append(request, '\n')); err != nil {
+		return ExternalResponse{}, fmt.Errorf("error sending request to external transcriber: %w", err)
+	}
+
+	line, err := m.stdout.ReadBytes('\n')
+	if err != nil {
+		return ExternalResponse{}, fmt.Errorf("error reading external transcriber response: %w", err)
+	}
+
+	var response ExternalResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return ExternalResponse{}, fmt.Errorf("invalid external transcriber response: %w", err)
+	}
+	return response, nil
+}
+
+// SetIntraOpThreads does nothing, the external command controls its own resources.
+func (m *ExternalModel) SetIntraOpThreads(int) {}
+
+// ExecutionProvider returns the CPU, the hardware used by the external command is unknown.
+func (m *ExternalModel) ExecutionProvider() ExecutionProvider {
+	return ExecutionProviderCPU
+}
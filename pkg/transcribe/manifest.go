@@ -0,0 +1,162 @@
+package transcribe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest of the installed model files, stored at
+// the root of the model directory.
+const ManifestFileName = "models.json"
+
+// manifestMu serializes the updates of the manifests, models may be downloaded at the
+// same time.
+var manifestMu sync.Mutex
+
+// Manifest records the installed model files by their path relative to the model
+// directory (e.g. "parakeet-tdt-0.6b-v2/vocab.txt"), so updates can be detected.
+type Manifest struct {
+	Files map[string]InstalledFile `json:"files"`
+}
+
+// InstalledFile is a downloaded model file. URL pins the revision it was downloaded from
+// and Revision is the version the server reported for it (its ETag), empty if none.
+type InstalledFile struct {
+	URL         string    `json:"url"`
+	Revision    string    `json:"revision,omitempty"`
+	SHA256      string    `json:"sha256"`
+	Size        int64     `json:"size"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ModelUpdate is a model file whose installed revision is outdated. Reason is "new
+// revision" when the app pins a different URL, or "changed upstream" when the server
+// reports a different version of the same URL.
+type ModelUpdate struct {
+	File   ModelFile
+	Reason string
+}
+
+// LoadManifest reads the manifest of the model directory, empty if it does not exist yet.
+func LoadManifest(modelDir string) (Manifest, error) {
+	manifest := Manifest{Files: map[string]InstalledFile{}}
+
+	data, err := os.ReadFile(filepath.Join(modelDir, ManifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("error reading model manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("error parsing model manifest: %w", err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]InstalledFile{}
+	}
+	return manifest, nil
+}
+
+// save writes the manifest to the model directory.
+func (m Manifest) save(modelDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(modelDir, ManifestFileName), data, 0644)
+}
+
+// manifestKey returns the directory holding the manifest of a model file, the parent of
+// its model subdirectory, and its key in the manifest.
+func manifestKey(path string) (modelDir, key string) {
+	dir := filepath.Dir(path)
+	return filepath.Dir(dir), filepath.ToSlash(filepath.Join(filepath.Base(dir), filepath.Base(path)))
+}
+
+// recordInstalled adds a downloaded model file to the manifest of its model directory.
+func recordInstalled(file ModelFile, installed InstalledFile) error {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	modelDir, key := manifestKey(file.Path)
+	manifest, err := LoadManifest(modelDir)
+	if err != nil {
+		// A corrupted manifest is rebuilt as the files are downloaded again.
+		manifest = Manifest{Files: map[string]InstalledFile{}}
+	}
+	manifest.Files[key] = installed
+	return manifest.save(modelDir)
+}
+
+// responseRevision returns the version of a downloaded file reported by the server.
+func responseRevision(resp *http.Response) string {
+	revision := strings.TrimPrefix(resp.Header.Get("ETag"), "W/")
+	return strings.Trim(revision, `"`)
+}
+
+// checkUpdates returns the files whose installed revision is outdated: those downloaded
+// from another URL than the current one, or whose version on the server changed. Files
+// missing from the manifest, installed before it existed, are recorded with the current
+// server version as their baseline.
+func checkUpdates(client *http.Client, files []ModelFile) ([]ModelUpdate, error) {
+	if client == nil {
+		client = defaultDownloadClient
+	}
+
+	var updates []ModelUpdate
+	for _, file := range files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			continue // Missing files are downloaded by DownloadModels
+		}
+
+		modelDir, key := manifestKey(file.Path)
+		manifest, err := LoadManifest(modelDir)
+		if err != nil {
+			return nil, err
+		}
+
+		installed, ok := manifest.Files[key]
+		if ok && installed.URL != file.URL {
+			updates = append(updates, ModelUpdate{File: file, Reason: "new revision"})
+			continue
+		}
+
+		resp, err := client.Head(file.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error checking %s: %w", file.Name, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error checking %s: %w", file.Name, newStatusError(resp))
+		}
+		revision := responseRevision(resp)
+
+		if !ok {
+			checksum, _ := readChecksum(file.Path)
+			baseline := InstalledFile{
+				URL:         file.URL,
+				Revision:    revision,
+				SHA256:      checksum,
+				Size:        info.Size(),
+				InstalledAt: info.ModTime(),
+			}
+			if err := recordInstalled(file, baseline); err != nil {
+				return nil, fmt.Errorf("error recording %s in the manifest: %w", file.Name, err)
+			}
+			continue
+		}
+
+		if revision != "" && installed.Revision != "" && revision != installed.Revision {
+			updates = append(updates, ModelUpdate{File: file, Reason: "changed upstream"})
+		}
+	}
+	return updates, nil
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package transcribe
+
+import "syscall"
+
+// peakMemory returns the peak resident memory of the process in bytes, 0 if unknown.
+func peakMemory() uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	// macOS reports it in bytes.
+	return uint64(usage.Maxrss)
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package transcribe
+
+import "syscall"
+
+// peakMemory returns the peak resident memory of the process in bytes, 0 if unknown.
+func peakMemory() uint64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	// Linux reports it in kilobytes.
+	return uint64(usage.Maxrss) * 1024
+}
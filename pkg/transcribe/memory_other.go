@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package transcribe
+
+// peakMemory is not supported on this platform.
+func peakMemory() uint64 {
+	return 0
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package transcribe
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// peakMemory returns the peak working set of the process in bytes, 0 if unknown.
+func peakMemory() uint64 {
+	var counters windows.PROCESS_MEMORY_COUNTERS
+	err := windows.GetProcessMemoryInfo(windows.CurrentProcess(), &counters, uint32(unsafe.Sizeof(counters)))
+	if err != nil {
+		return 0
+	}
+	return uint64(counters.PeakWorkingSetSize)
+}
@@ -0,0 +1,20 @@
+package transcribe
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// mirroredURL returns the download URL of a model file. Without a mirror it is the
+// upstream URL; with one, the mirror is laid out like the model directory, so a copy of
+// it can be served as is: <mirror>/<model directory>/<file name>, e.g.
+// https://models.example.com/tribar/parakeet/vocab.txt.
+func mirroredURL(mirror, upstream, path string) string {
+	if mirror == "" {
+		return upstream
+	}
+
+	dir := filepath.Base(filepath.Dir(path))
+	return strings.TrimRight(mirror, "/") + "/" + url.PathEscape(dir) + "/" + url.PathEscape(filepath.Base(path))
+}
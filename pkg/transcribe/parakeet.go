@@ -0,0 +1,1086 @@
+package transcribe
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/audio"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Parakeet model URLs from HuggingFace, used unless a mirror is configured (see
+// ModelConfig.MirrorURL).
+const (
+	ParakeetVocabURL       = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/vocab.txt?download=true"
+	ParakeetNemoURL        = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/nemo128.onnx?download=true"
+	ParakeetEncoderURL     = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/encoder-model.int8.onnx?download=true"
+	ParakeetEncoderDataURL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/encoder-model.onnx.data?download=true"
+	ParakeetDecoderURL     = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/decoder_joint-model.int8.onnx?download=true"
+
+	ParakeetEncoderFP32URL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/encoder-model.onnx?download=true"
+	ParakeetDecoderFP32URL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v2-onnx/resolve/d808c3be882f47cf6a15a42c0eb9ee751b99a379/decoder_joint-model.onnx?download=true"
+)
+
+// Multilingual Parakeet TDT v3 model URLs from HuggingFace, it supports 25 European
+// languages and detects the spoken one automatically.
+const (
+	ParakeetMultilingualVocabURL       = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/vocab.txt?download=true"
+	ParakeetMultilingualNemoURL        = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/nemo128.onnx?download=true"
+	ParakeetMultilingualEncoderURL     = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/encoder-model.int8.onnx?download=true"
+	ParakeetMultilingualEncoderDataURL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/encoder-model.onnx.data?download=true"
+	ParakeetMultilingualDecoderURL     = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/decoder_joint-model.int8.onnx?download=true"
+
+	ParakeetMultilingualEncoderFP32URL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/encoder-model.onnx?download=true"
+	ParakeetMultilingualDecoderFP32URL = "https://huggingface.co/istupakov/parakeet-tdt-0.6b-v3-onnx/resolve/main/decoder_joint-model.onnx?download=true"
+)
+
+// parakeetURLs are the download URLs of the files of a Parakeet TDT release, the encoder
+// and decoder are published both quantized to int8 and in full precision.
+type parakeetURLs struct {
+	vocab       string
+	nemo        string
+	encoder     string
+	encoderData string
+	decoder     string
+	encoderFP32 string
+	decoderFP32 string
+}
+
+var (
+	parakeetEnglishURLs = parakeetURLs{
+		vocab:       ParakeetVocabURL,
+		nemo:        ParakeetNemoURL,
+		encoder:     ParakeetEncoderURL,
+		encoderData: ParakeetEncoderDataURL,
+		decoder:     ParakeetDecoderURL,
+		encoderFP32: ParakeetEncoderFP32URL,
+		decoderFP32: ParakeetDecoderFP32URL,
+	}
+	parakeetMultilingualURLs = parakeetURLs{
+		vocab:       ParakeetMultilingualVocabURL,
+		nemo:        ParakeetMultilingualNemoURL,
+		encoder:     ParakeetMultilingualEncoderURL,
+		encoderData: ParakeetMultilingualEncoderDataURL,
+		decoder:     ParakeetMultilingualDecoderURL,
+		encoderFP32: ParakeetMultilingualEncoderFP32URL,
+		decoderFP32: ParakeetMultilingualDecoderFP32URL,
+	}
+)
+
+// Parakeet model file names
+const (
+	ParakeetVocabFile       = "vocab.txt"
+	ParakeetNemoFile        = "nemo128.onnx"
+	ParakeetEncoderFile     = "encoder-model.int8.onnx"
+	ParakeetEncoderDataFile = "encoder-model.onnx.data"
+	ParakeetDecoderFile     = "decoder-model.int8.onnx"
+
+	// Full-precision variants. The fp32 encoder reads its weights from the encoder data
+	// file, which must be next to it.
+	ParakeetEncoderFP32File = "encoder-model.onnx"
+	ParakeetDecoderFP32File = "decoder-model.onnx"
+)
+
+// Parakeet model constants
+const (
+	parakeetSubsamplingFactor = 8
+	parakeetDecoderHiddenSize = 640
+	parakeetEncoderHiddenSize = 1024
+	parakeetNumMelBins        = 128
+	parakeetHopLength         = 160 // 10ms @ 16kHz
+	parakeetNumDurations      = 5   // TDT duration options
+	parakeetMaxTokensPerFrame = 10  // Tokens emitted on a frame before forcing a move
+
+	// parakeetFrameDuration is the audio covered by an encoder frame, 80ms.
+	parakeetFrameDuration = parakeetSubsamplingFactor * parakeetHopLength * time.Second / audio.SampleRate
+)
+
+// parakeetDurations are the frame counts predicted by each output of the duration head.
+var parakeetDurations = [parakeetNumDurations]int64{0, 1, 2, 3, 4}
+
+// Registry IDs of the Parakeet TDT models, they are also the names of the directories
+// their files are stored in.
+const (
+	ParakeetModelID             = "parakeet"
+	ParakeetMultilingualModelID = "parakeet-multilingual"
+)
+
+// parakeetMultilingualLanguages are the languages supported by Parakeet TDT v3.
+var parakeetMultilingualLanguages = []string{
+	"bg", "cs", "da", "de", "el", "en", "es", "et", "fi", "fr", "hr", "hu", "it",
+	"lt", "lv", "mt", "nl", "pl", "pt", "ro", "ru", "sk", "sl", "sv", "uk",
+}
+
+func init() {
+	models := []ModelInfo{
+		{
+			ID:        ParakeetModelID,
+			Name:      "Parakeet TDT 0.6B v2 (English)",
+			Languages: []string{"en"},
+			Factory:   parakeetFactory(parakeetEnglishURLs),
+		},
+		{
+			ID:        ParakeetMultilingualModelID,
+			Name:      "Parakeet TDT 0.6B v3 (Multilingual)",
+			Languages: parakeetMultilingualLanguages,
+			Factory:   parakeetFactory(parakeetMultilingualURLs),
+		},
+	}
+
+	for _, info := range models {
+		if err := Register(info); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func parakeetFactory(urls parakeetURLs) ModelFactory {
+	return func(cfg ModelConfig) (Model, error) {
+		model := newParakeetModel(cfg.Dir, urls)
+		model.SetExecutionProvider(cfg.ExecutionProvider, cfg.CUDADeviceID)
+		model.SetMirrorURL(cfg.MirrorURL)
+		model.SetDownloadBufferSize(cfg.DownloadBufferSize)
+		model.SetDownloadRetries(cfg.DownloadRetries)
+		model.SetDownloadClient(cfg.DownloadClient)
+		model.SetPrecision(cfg.Precision)
+		return model, nil
+	}
+}
+
+// ParakeetModel represents the Parakeet TDT model for speech recognition.
+type ParakeetModel struct {
+	vocab    []string
+	blankIdx int32
+
+	urls            parakeetURLs
+	dir             string
+	precision       Precision
+	mirrorURL       string
+	bufferSize      int
+	retries         int
+	client          *http.Client
+	vocabPath       string
+	nemoPath        string
+	encoderPath     string
+	encoderDataPath string
+	decoderPath     string
+
+	intraOpThreads atomic.Int32
+	// frameDecoding ignores the TDT duration head, see SetTDTDecoding.
+	frameDecoding atomic.Bool
+
+	executionProvider ExecutionProvider
+	cudaDeviceID      int
+	gpuUnavailable    atomic.Bool
+
+	// mu guards sessions: transcriptions share them for reading while loading, rebuilding
+	// and closing take exclusive access.
+	mu       sync.RWMutex
+	sessions *parakeetSessions
+}
+
+// parakeetSessions holds the ONNX sessions of the preprocessor, encoder and decoder. They
+// are created once when the model is loaded and reused by every transcription.
+type parakeetSessions struct {
+	preprocessor *ort.DynamicAdvancedSession
+	encoder      *ort.DynamicAdvancedSession
+	decoder      *ort.DynamicAdvancedSession
+
+	// intraOpThreads and provider are the thread limit and the encoder provider the
+	// sessions were created with.
+	intraOpThreads int32
+	provider       ExecutionProvider
+}
+
+func (s *parakeetSessions) destroy() {
+	for _, session := range []*ort.DynamicAdvancedSession{s.preprocessor, s.encoder, s.decoder} {
+		if session != nil {
+			_ = session.Destroy()
+		}
+	}
+}
+
+// NewParakeetModel creates a new English ParakeetModel instance using the model files
+// stored in parakeetDir.
+func NewParakeetModel(parakeetDir string) (*ParakeetModel, error) {
+	return newParakeetModel(parakeetDir, parakeetEnglishURLs), nil
+}
+
+// NewParakeetMultilingualModel creates a new multilingual ParakeetModel instance using
+// the model files stored in parakeetDir.
+func NewParakeetMultilingualModel(parakeetDir string) (*ParakeetModel, error) {
+	return newParakeetModel(parakeetDir, parakeetMultilingualURLs), nil
+}
+
+func newParakeetModel(parakeetDir string, urls parakeetURLs) *ParakeetModel {
+	vocabPath := path.Join(parakeetDir, ParakeetVocabFile)
+	nemoPath := path.Join(parakeetDir, ParakeetNemoFile)
+	encoderPath := path.Join(parakeetDir, ParakeetEncoderFile)
+	decoderPath := path.Join(parakeetDir, ParakeetDecoderFile)
+	encoderDataPath := path.Join(parakeetDir, ParakeetEncoderDataFile)
+
+	return &ParakeetModel{
+		urls:            urls,
+		dir:             parakeetDir,
+		precision:       PrecisionInt8,
+		vocabPath:       vocabPath,
+		nemoPath:        nemoPath,
+		encoderPath:     encoderPath,
+		encoderDataPath: encoderDataPath,
+		decoderPath:     decoderPath,
+	}
+}
+
+// ModelFile represents a model file with its URL and local path. SHA256 is the expected
+// hex checksum of the file; when empty, the checksum computed after downloading it is the
+// reference used to verify it later.
+type ModelFile struct {
+	Name   string
+	URL    string
+	Path   string
+	SHA256 string
+}
+
+// GetModelFiles returns all model files with their URLs and paths, the encoder and
+// decoder of the selected precision.
+func (p *ParakeetModel) GetModelFiles() []ModelFile {
+	encoderURL, decoderURL := p.urls.encoder, p.urls.decoder
+	if p.precision == PrecisionFP32 {
+		encoderURL, decoderURL = p.urls.encoderFP32, p.urls.decoderFP32
+	}
+
+	return []ModelFile{
+		{Name: "Vocabulary", URL: mirroredURL(p.mirrorURL, p.urls.vocab, p.vocabPath), Path: p.vocabPath},
+		{Name: "Preprocessor (nemo128)", URL: mirroredURL(p.mirrorURL, p.urls.nemo, p.nemoPath), Path: p.nemoPath},
+		{Name: "Encoder", URL: mirroredURL(p.mirrorURL, encoderURL, p.encoderPath), Path: p.encoderPath},
+		{Name: "Encoder Data", URL: mirroredURL(p.mirrorURL, p.urls.encoderData, p.encoderDataPath), Path: p.encoderDataPath},
+		{Name: "Decoder", URL: mirroredURL(p.mirrorURL, decoderURL, p.decoderPath), Path: p.decoderPath},
+	}
+}
+
+// SetPrecision selects the int8 or fp32 encoder and decoder, it must be called before
+// downloading and loading. Unknown precisions select int8.
+func (p *ParakeetModel) SetPrecision(precision Precision) {
+	p.precision = PrecisionInt8
+	p.encoderPath = path.Join(p.dir, ParakeetEncoderFile)
+	p.decoderPath = path.Join(p.dir, ParakeetDecoderFile)
+
+	if precision == PrecisionFP32 {
+		p.precision = PrecisionFP32
+		p.encoderPath = path.Join(p.dir, ParakeetEncoderFP32File)
+		p.decoderPath = path.Join(p.dir, ParakeetDecoderFP32File)
+	}
+}
+
+// SetMirrorURL downloads the model files from a mirror instead of HuggingFace, see
+// ModelConfig.MirrorURL. An empty URL restores the upstream URLs.
+func (p *ParakeetModel) SetMirrorURL(mirrorURL string) {
+	p.mirrorURL = mirrorURL
+}
+
+// CheckModelsExist checks if all required model files exist.
+func (p *ParakeetModel) CheckModelsExist() (bool, []ModelFile) {
+	var missing []ModelFile
+
+	for _, file := range p.GetModelFiles() {
+		if _, err := os.Stat(file.Path); os.IsNotExist(err) {
+			missing = append(missing, file)
+		}
+	}
+
+	return len(missing) == 0, missing
+}
+
+// SetDownloadBufferSize sets the size in bytes of the buffer the model files are
+// downloaded through, zero restores the default.
+func (p *ParakeetModel) SetDownloadBufferSize(size int) {
+	p.bufferSize = size
+}
+
+// SetDownloadRetries sets how many times a download failing with a transient error (a
+// server error or a dropped connection) is retried, zero restores the default and a
+// negative number disables the retries.
+func (p *ParakeetModel) SetDownloadRetries(retries int) {
+	p.retries = retries
+}
+
+// SetDownloadClient sets the HTTP client the model files are downloaded with, e.g. one
+// going through a proxy (see NewDownloadClient). Nil restores the default.
+func (p *ParakeetModel) SetDownloadClient(client *http.Client) {
+	p.client = client
+}
+
+// defaultDownloadBufferSize is the size of the buffer downloads are copied through.
+const defaultDownloadBufferSize = 32 * 1024
+
+// DownloadProgressCallback is called during download with progress information.
+type DownloadProgressCallback func(filename string, downloaded, total int64, percent float64)
+
+// DownloadModels downloads all missing model files.
+func (p *ParakeetModel) DownloadModels(progressCallback DownloadProgressCallback) error {
+	_, missing := p.CheckModelsExist()
+	if len(missing) == 0 {
+		return nil // All models already exist
+	}
+	if err := checkDiskSpace(p.client, missing); err != nil {
+		return err
+	}
+
+	for _, file := range missing {
+		if err := downloadWithRetry(p.client, file, p.bufferSize, p.retries, progressCallback); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// downloadFile downloads a model file with progress tracking. The data is written to a
+// temporary file that only replaces the destination once it is complete and its checksum
+// matches, so an interrupted or corrupted download never looks like a valid model. The
+// checksum is recorded next to the file to verify it when loading. A bufferSize of zero
+// uses defaultDownloadBufferSize.
+func downloadFile(client *http.Client, file ModelFile, bufferSize int, progressCallback DownloadProgressCallback) error {
+	if bufferSize <= 0 {
+		bufferSize = defaultDownloadBufferSize
+	}
+	if client == nil {
+		client = defaultDownloadClient
+	}
+
+	partPath := file.Path + ".part"
+	out, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(partPath) // No-op once renamed
+	}()
+
+	// Get the data
+	resp, err := client.Get(file.URL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp)
+	}
+
+	// Get content length for progress
+	contentLength := resp.ContentLength
+	if err := ensureFreeSpace(filepath.Dir(file.Path), contentLength); err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	writer := io.MultiWriter(out, hash)
+
+	var written int64
+	buf := make([]byte, bufferSize)
+
+	for {
+		nr, readErr := resp.Body.Read(buf)
+		if nr > 0 {
+			nw, writeErr := writer.Write(buf[0:nr])
+			if nw > 0 {
+				written += int64(nw)
+			}
+			if writeErr != nil {
+				return writeErr
+			}
+			if nr != nw {
+				return io.ErrShortWrite
+			}
+
+			// Report progress
+			if progressCallback != nil && contentLength > 0 {
+				percent := float64(written) / float64(contentLength) * 100
+				progressCallback(file.Name, written, contentLength, percent)
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+			return readErr
+		}
+	}
+
+	if contentLength > 0 && written != contentLength {
+		return fmt.Errorf("%w, got %d of %d bytes", errIncompleteDownload, written, contentLength)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if file.SHA256 != "" && !strings.EqualFold(checksum, file.SHA256) {
+		return fmt.Errorf("%w: %s has checksum %s, expected %s", ErrCorruptedModel, file.Name, checksum, file.SHA256)
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, file.Path); err != nil {
+		return err
+	}
+	if err := writeChecksum(file.Path, checksum); err != nil {
+		return err
+	}
+	return recordInstalled(file, InstalledFile{
+		URL:         file.URL,
+		Revision:    responseRevision(resp),
+		SHA256:      checksum,
+		Size:        written,
+		InstalledAt: time.Now(),
+	})
+}
+
+// SetIntraOpThreads limits the number of threads used by the ONNX sessions. Zero lets ONNX
+// Runtime decide based on the available cores. Loaded sessions are rebuilt with the new
+// limit before the next transcription.
+func (p *ParakeetModel) SetIntraOpThreads(threads int) {
+	p.intraOpThreads.Store(int32(max(threads, 0)))
+}
+
+// SetTDTDecoding selects how the decoder moves through the encoder frames: following
+// the durations predicted by the TDT head, or, if disabled, visiting every frame once and
+// emitting a token repeated on consecutive frames once, like CTC decoding. A new model
+// follows the durations until it is called; the app disables it unless the tdt_decoding
+// feature flag is on. It applies to transcriptions started after the call.
+func (p *ParakeetModel) SetTDTDecoding(enabled bool) {
+	p.frameDecoding.Store(!enabled)
+}
+
+// SetExecutionProvider selects the hardware used to run the encoder, which dominates the
+// inference time; the small preprocessor and decoder always run on the CPU. It must be
+// called before transcribing.
+func (p *ParakeetModel) SetExecutionProvider(provider ExecutionProvider, cudaDeviceID int) {
+	p.executionProvider = provider
+	p.cudaDeviceID = cudaDeviceID
+}
+
+// ExecutionProvider returns the provider effectively used for the encoder, which is the
+// CPU if the configured GPU provider could not be initialized.
+func (p *ParakeetModel) ExecutionProvider() ExecutionProvider {
+	if p.executionProvider == ExecutionProviderCUDA && !p.gpuUnavailable.Load() {
+		return ExecutionProviderCUDA
+	}
+	return ExecutionProviderCPU
+}
+
+// newSessionOptions builds the session options for a new ONNX session, the caller must
+// destroy them after creating the session. When useGPU is set and CUDA is configured, the
+// CUDA provider is appended; if it cannot be initialized the session falls back to the
+// CPU and CUDA is not tried again.
+func (p *ParakeetModel) newSessionOptions(useGPU bool) (*ort.SessionOptions, error) {
+	options, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, fmt.Errorf("error creating session options: %w", err)
+	}
+
+	if threads := p.intraOpThreads.Load(); threads > 0 {
+		if err := options.SetIntraOpNumThreads(int(threads)); err != nil {
+			_ = options.Destroy()
+			return nil, fmt.Errorf("error setting intra-op threads: %w", err)
+		}
+	}
+
+	if useGPU && p.ExecutionProvider() == ExecutionProviderCUDA {
+		if err := appendCUDAProvider(options, p.cudaDeviceID); err != nil {
+			p.gpuUnavailable.Store(true)
+		}
+	}
+
+	return options, nil
+}
+
+// appendCUDAProvider enables the CUDA execution provider on the given device.
+func appendCUDAProvider(options *ort.SessionOptions, deviceID int) error {
+	cudaOptions, err := ort.NewCUDAProviderOptions()
+	if err != nil {
+		return fmt.Errorf("error creating CUDA provider options: %w", err)
+	}
+	defer func() { _ = cudaOptions.Destroy() }()
+
+	if err := cudaOptions.Update(map[string]string{"device_id": strconv.Itoa(deviceID)}); err != nil {
+		return fmt.Errorf("error configuring CUDA provider: %w", err)
+	}
+
+	if err := options.AppendExecutionProviderCUDA(cudaOptions); err != nil {
+		return fmt.Errorf("error enabling CUDA provider: %w", err)
+	}
+	return nil
+}
+
+// Load prepares the model for transcription by loading its vocabulary and creating the
+// ONNX sessions. Calling it again recreates the sessions.
+func (p *ParakeetModel) Load() error {
+	if err := p.LoadVocabulary(); err != nil {
+		return err
+	}
+
+	sessions, err := p.newSessions()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sessions != nil {
+		p.sessions.destroy()
+	}
+	p.sessions = sessions
+	return nil
+}
+
+// Close destroys the ONNX sessions, waiting for transcriptions in progress to finish.
+// The model must be loaded again before transcribing.
+func (p *ParakeetModel) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sessions != nil {
+		p.sessions.destroy()
+		p.sessions = nil
+	}
+	return nil
+}
+
+// newSessions creates the sessions of the three networks with the current thread limit.
+// Only the encoder uses the configured execution provider.
+func (p *ParakeetModel) newSessions() (*parakeetSessions, error) {
+	sessions := &parakeetSessions{intraOpThreads: p.intraOpThreads.Load()}
+
+	specs := []struct {
+		name    string
+		path    string
+		inputs  []string
+		outputs []string
+		useGPU  bool
+		target  **ort.DynamicAdvancedSession
+	}{
+		{
+			name:    "preprocessor",
+			path:    p.nemoPath,
+			inputs:  []string{"waveforms", "waveforms_lens"},
+			outputs: []string{"features", "features_lens"},
+			target:  &sessions.preprocessor,
+		},
+		{
+			name:    "encoder",
+			path:    p.encoderPath,
+			inputs:  []string{"audio_signal", "length"},
+			outputs: []string{"outputs", "encoded_lengths"},
+			useGPU:  true,
+			target:  &sessions.encoder,
+		},
+		{
+			name:    "decoder",
+			path:    p.decoderPath,
+			inputs:  []string{"encoder_outputs", "targets", "target_length", "input_states_1", "input_states_2"},
+			outputs: []string{"outputs", "output_states_1", "output_states_2"},
+			target:  &sessions.decoder,
+		},
+	}
+
+	for _, spec := range specs {
+		options, err := p.newSessionOptions(spec.useGPU)
+		if err != nil {
+			sessions.destroy()
+			return nil, err
+		}
+
+		session, err := ort.NewDynamicAdvancedSession(spec.path, spec.inputs, spec.outputs, options)
+		_ = options.Destroy()
+		if err != nil {
+			sessions.destroy()
+			return nil, fmt.Errorf("error creating %s session: %w", spec.name, err)
+		}
+		*spec.target = session
+	}
+
+	sessions.provider = p.ExecutionProvider()
+	return sessions, nil
+}
+
+// refreshSessions recreates the loaded sessions if the thread limit or the execution
+// provider changed since they were created.
+func (p *ParakeetModel) refreshSessions() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sessions == nil {
+		return nil
+	}
+	if p.sessions.intraOpThreads == p.intraOpThreads.Load() && p.sessions.provider == p.ExecutionProvider() {
+		return nil
+	}
+
+	sessions, err := p.newSessions()
+	if err != nil {
+		return err
+	}
+	p.sessions.destroy()
+	p.sessions = sessions
+	return nil
+}
+
+// LoadVocabulary loads the vocabulary file.
+func (p *ParakeetModel) LoadVocabulary() error {
+	file, err := os.Open(p.vocabPath)
+	if err != nil {
+		return fmt.Errorf("error opening vocab file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var vocab []string
+	var blankIdx int32 = -1
+	scanner := bufio.NewScanner(file)
+	for idx := 0; scanner.Scan(); idx++ {
+		line := scanner.Text()
+		parts := strings.Fields(line)
+		if len(parts) > 0 {
+			token := parts[0]
+			vocab = append(vocab, token)
+			if token == "<blk>" {
+				blankIdx = int32(idx)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading vocab file: %w", err)
+	}
+
+	// If no <blk> token found, assume last token is blank
+	if blankIdx == -1 {
+		blankIdx = int32(len(vocab) - 1)
+	}
+
+	p.vocab = vocab
+	p.blankIdx = blankIdx
+
+	return nil
+}
+
+// Transcribe performs speech-to-text on audio samples.
+// samples should be 16kHz mono float32 audio normalized to [-1, 1].
+// If the GPU runs out of memory, the sessions are rebuilt on the CPU, which is used from
+// then on, and the transcription is retried. Canceling ctx aborts the transcription
+// between the networks and between the decoder steps.
+func (p *ParakeetModel) Transcribe(ctx context.Context, samples []float32) (Result, error) {
+	return p.TranscribeWithProgress(ctx, samples, nil)
+}
+
+// TranscribeWithProgress transcribes like Transcribe and calls onProgress with the
+// fraction of the encoder frames decoded so far, whenever it grows by a percent. The
+// callback may be nil.
+func (p *ParakeetModel) TranscribeWithProgress(ctx context.Context, samples []float32, onProgress ProgressCallback) (Result, error) {
+	return p.TranscribeStreaming(ctx, samples, onProgress, nil)
+}
+
+// TranscribeStreaming transcribes like TranscribeWithProgress and also calls onToken with
+// every token as the decoder emits it. Either callback may be nil. When the transcription
+// is retried on the CPU, the tokens emitted before the GPU ran out of memory are not
+// emitted again.
+func (p *ParakeetModel) TranscribeStreaming(ctx context.Context, samples []float32, onProgress ProgressCallback, onToken TokenCallback) (Result, error) {
+	emitted := 0
+	var countTokens TokenCallback
+	if onToken != nil {
+		countTokens = func(token Token) {
+			emitted++
+			onToken(token)
+		}
+	}
+
+	result, err := p.transcribe(ctx, samples, onProgress, countTokens)
+	if err == nil || p.ExecutionProvider() != ExecutionProviderCUDA || !isGPUMemoryError(err) {
+		return result, err
+	}
+
+	p.gpuUnavailable.Store(true)
+
+	var skipEmitted TokenCallback
+	if onToken != nil {
+		skipEmitted = func(token Token) {
+			if emitted > 0 {
+				emitted--
+				return
+			}
+			onToken(token)
+		}
+	}
+	return p.transcribe(ctx, samples, onProgress, skipEmitted)
+}
+
+// isGPUMemoryError reports whether an ONNX Runtime error is caused by the GPU running out
+// of memory (CUDA, cuDNN or the ONNX Runtime arena failing to allocate).
+func isGPUMemoryError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "out of memory") ||
+		strings.Contains(message, "failed to allocate") ||
+		strings.Contains(message, "alloc_failed")
+}
+
+// transcribe runs the three networks on the samples with the current sessions.
+func (p *ParakeetModel) transcribe(ctx context.Context, samples []float32, onProgress ProgressCallback, onToken TokenCallback) (Result, error) {
+	if err := p.refreshSessions(); err != nil {
+		return Result{}, fmt.Errorf("error recreating sessions: %w", err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.vocab) == 0 || p.sessions == nil {
+		return Result{}, fmt.Errorf("model not loaded, call Load first")
+	}
+
+	// Run preprocessor
+	started := time.Now()
+	features, featuresLen, err := p.runPreprocessor(samples)
+	if err != nil {
+		return Result{}, fmt.Errorf("preprocessor error: %w", err)
+	}
+	timings := []StageTiming{{Stage: "preprocessor", Duration: time.Since(started)}}
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	// Run encoder
+	started = time.Now()
+	encoderOut, encoderLen, err := p.runEncoder(features, featuresLen)
+	if err != nil {
+		return Result{}, fmt.Errorf("encoder error: %w", err)
+	}
+	timings = append(timings, StageTiming{Stage: "encoder", Duration: time.Since(started)})
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	// Run decoder
+	started = time.Now()
+	result, err := p.runDecoder(ctx, encoderOut, encoderLen, onProgress, onToken)
+	if err != nil {
+		return Result{}, fmt.Errorf("decoder error: %w", err)
+	}
+	result.Timings = append(timings, StageTiming{Stage: "decoder", Duration: time.Since(started)})
+
+	return result, nil
+}
+
+func (p *ParakeetModel) runPreprocessor(samples []float32) ([]float32, int64, error) {
+	samplesLen := int64(len(samples))
+
+	// Input tensors
+	waveformsTensor, err := ort.NewTensor(ort.NewShape(1, samplesLen), samples)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating waveforms tensor: %w", err)
+	}
+	defer func() { _ = waveformsTensor.Destroy() }()
+
+	waveformsLensTensor, err := ort.NewTensor(ort.NewShape(1), []int64{samplesLen})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating waveforms_lens tensor: %w", err)
+	}
+	defer func() { _ = waveformsLensTensor.Destroy() }()
+
+	// Output tensors - calculate expected size
+	expectedTimeSteps := (samplesLen / parakeetHopLength) + 1
+
+	featShape := ort.NewShape(1, parakeetNumMelBins, expectedTimeSteps)
+	featTensor, err := ort.NewEmptyTensor[float32](featShape)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating features tensor: %w", err)
+	}
+	defer func() { _ = featTensor.Destroy() }()
+
+	featLensTensor, err := ort.NewEmptyTensor[int64](ort.NewShape(1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating features_lens tensor: %w", err)
+	}
+	defer func() { _ = featLensTensor.Destroy() }()
+
+	err = p.sessions.preprocessor.Run(
+		[]ort.Value{waveformsTensor, waveformsLensTensor},
+		[]ort.Value{featTensor, featLensTensor},
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error running preprocessor: %w", err)
+	}
+
+	features := make([]float32, len(featTensor.GetData()))
+	copy(features, featTensor.GetData())
+	featLen := featLensTensor.GetData()[0]
+
+	return features, featLen, nil
+}
+
+func (p *ParakeetModel) runEncoder(features []float32, featuresLen int64) ([]float32, int64, error) {
+	timeSteps := int64(len(features)) / parakeetNumMelBins
+
+	// Input tensors
+	audioSignalTensor, err := ort.NewTensor(ort.NewShape(1, parakeetNumMelBins, timeSteps), features)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating audio_signal tensor: %w", err)
+	}
+	defer func() { _ = audioSignalTensor.Destroy() }()
+
+	lengthTensor, err := ort.NewTensor(ort.NewShape(1), []int64{featuresLen})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating length tensor: %w", err)
+	}
+	defer func() { _ = lengthTensor.Destroy() }()
+
+	// Output tensors
+	encoderTimeSteps := (featuresLen + parakeetSubsamplingFactor - 1) / parakeetSubsamplingFactor
+
+	encOutShape := ort.NewShape(1, parakeetEncoderHiddenSize, encoderTimeSteps)
+	encOutTensor, err := ort.NewEmptyTensor[float32](encOutShape)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating encoder output tensor: %w", err)
+	}
+	defer func() { _ = encOutTensor.Destroy() }()
+
+	encLensTensor, err := ort.NewEmptyTensor[int64](ort.NewShape(1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating encoder lengths tensor: %w", err)
+	}
+	defer func() { _ = encLensTensor.Destroy() }()
+
+	err = p.sessions.encoder.Run(
+		[]ort.Value{audioSignalTensor, lengthTensor},
+		[]ort.Value{encOutTensor, encLensTensor},
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error running encoder: %w", err)
+	}
+
+	encoderOut := make([]float32, len(encOutTensor.GetData()))
+	copy(encoderOut, encOutTensor.GetData())
+	encoderLen := encLensTensor.GetData()[0]
+
+	return encoderOut, encoderLen, nil
+}
+
+// runDecoder performs greedy TDT (token-and-duration transducer) decoding. Besides the
+// token, the joint network predicts how many encoder frames the token spans, so the
+// decoder jumps ahead by that duration instead of visiting every frame, and can emit
+// several tokens on the same frame when the predicted duration is zero. With TDT decoding
+// disabled (see SetTDTDecoding) the durations are ignored and every frame is visited once.
+func (p *ParakeetModel) runDecoder(ctx context.Context, encoderOut []float32, encoderLen int64, onProgress ProgressCallback, onToken TokenCallback) (Result, error) {
+	var transcribedTokens []Token
+	frameDecoding := p.frameDecoding.Load()
+	lastEmittedToken := int32(-1)
+
+	step, err := p.newDecoderStep()
+	if err != nil {
+		return Result{}, err
+	}
+	defer step.destroy()
+
+	vocabSize := len(p.vocab)
+	lastToken := p.blankIdx
+	emittedOnFrame := 0
+
+	reportedPercent := int64(0)
+	for t := int64(0); t < encoderLen; {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		if percent := t * 100 / encoderLen; onProgress != nil && percent > reportedPercent {
+			reportedPercent = percent
+			onProgress(float64(t) / float64(encoderLen))
+		}
+
+		// Extract encoder output for current step
+		stepData := step.encoderStep.GetData()
+		for k := range parakeetEncoderHiddenSize {
+			stepData[k] = 0
+			idx := int64(k)*encoderLen + t
+			if idx < int64(len(encoderOut)) {
+				stepData[k] = encoderOut[idx]
+			}
+		}
+		step.targets.GetData()[0] = lastToken
+
+		if err := p.sessions.decoder.Run(step.inputs(), step.outputs()); err != nil {
+			return Result{}, fmt.Errorf("decoder step error at t=%d: %w", t, err)
+		}
+
+		// The joint output holds the vocabulary logits followed by the duration logits
+		logits := step.logits.GetData()
+		vocabLogits := logits[:vocabSize]
+		bestToken := argmax(vocabLogits)
+		duration := parakeetDurations[argmax(logits[vocabSize:vocabSize+parakeetNumDurations])]
+
+		emit := bestToken != p.blankIdx
+		if frameDecoding {
+			// Frame by frame, a token spanning several frames is predicted on each of
+			// them: it is emitted once, until a blank separates it from a repetition.
+			duration = 1
+			emit = emit && bestToken != lastEmittedToken
+			if bestToken == p.blankIdx {
+				lastEmittedToken = -1
+			}
+		}
+
+		if emit {
+			token := Token{
+				Text:       strings.ReplaceAll(p.vocab[bestToken], "\u2581", " "),
+				Confidence: softmaxAt(vocabLogits, bestToken),
+				Start:      time.Duration(t) * parakeetFrameDuration,
+				End:        time.Duration(t+max(duration, 1)) * parakeetFrameDuration,
+			}
+			transcribedTokens = append(transcribedTokens, token)
+			if onToken != nil {
+				onToken(token)
+			}
+			lastToken = bestToken
+			lastEmittedToken = bestToken
+			emittedOnFrame++
+			copy(step.state1.GetData(), step.outState1.GetData())
+			copy(step.state2.GetData(), step.outState2.GetData())
+		}
+
+		// A zero duration keeps the decoder on the same frame, which is bounded so a
+		// blank or a model stuck on one frame cannot loop forever.
+		switch {
+		case duration > 0:
+			t += duration
+			emittedOnFrame = 0
+		case bestToken == p.blankIdx || emittedOnFrame >= parakeetMaxTokensPerFrame:
+			t++
+			emittedOnFrame = 0
+		}
+	}
+	if onProgress != nil {
+		onProgress(1)
+	}
+
+	return tokensResult(transcribedTokens), nil
+}
+
+// decoderStep holds the tensors of a single decoder step. They are allocated once per
+// transcription and their data is overwritten on every time step.
+type decoderStep struct {
+	encoderStep  *ort.Tensor[float32]
+	targets      *ort.Tensor[int32]
+	targetLength *ort.Tensor[int32]
+	state1       *ort.Tensor[float32]
+	state2       *ort.Tensor[float32]
+
+	logits    *ort.Tensor[float32]
+	outState1 *ort.Tensor[float32]
+	outState2 *ort.Tensor[float32]
+}
+
+func (p *ParakeetModel) newDecoderStep() (*decoderStep, error) {
+	step := &decoderStep{}
+	stateShape := ort.NewShape(2, 1, parakeetDecoderHiddenSize)
+	outputSize := int64(len(p.vocab) + parakeetNumDurations)
+
+	var err error
+	if step.encoderStep, err = ort.NewEmptyTensor[float32](ort.NewShape(1, parakeetEncoderHiddenSize, 1)); err != nil {
+		return nil, fmt.Errorf("error creating encoder_outputs tensor: %w", err)
+	}
+	if step.targets, err = ort.NewEmptyTensor[int32](ort.NewShape(1, 1)); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating targets tensor: %w", err)
+	}
+	if step.targetLength, err = ort.NewTensor(ort.NewShape(1), []int32{1}); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating target_length tensor: %w", err)
+	}
+	if step.state1, err = ort.NewEmptyTensor[float32](stateShape); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating input_states_1 tensor: %w", err)
+	}
+	if step.state2, err = ort.NewEmptyTensor[float32](stateShape); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating input_states_2 tensor: %w", err)
+	}
+	if step.logits, err = ort.NewEmptyTensor[float32](ort.NewShape(1, 1, 1, outputSize)); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating outputs tensor: %w", err)
+	}
+	if step.outState1, err = ort.NewEmptyTensor[float32](stateShape); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating output_states_1 tensor: %w", err)
+	}
+	if step.outState2, err = ort.NewEmptyTensor[float32](stateShape); err != nil {
+		step.destroy()
+		return nil, fmt.Errorf("error creating output_states_2 tensor: %w", err)
+	}
+
+	return step, nil
+}
+
+func (s *decoderStep) inputs() []ort.Value {
+	return []ort.Value{s.encoderStep, s.targets, s.targetLength, s.state1, s.state2}
+}
+
+func (s *decoderStep) outputs() []ort.Value {
+	return []ort.Value{s.logits, s.outState1, s.outState2}
+}
+
+func (s *decoderStep) destroy() {
+	destroyTensor(s.encoderStep)
+	destroyTensor(s.targets)
+	destroyTensor(s.targetLength)
+	destroyTensor(s.state1)
+	destroyTensor(s.state2)
+	destroyTensor(s.logits)
+	destroyTensor(s.outState1)
+	destroyTensor(s.outState2)
+}
+
+// destroyTensor destroys a tensor that may not have been created.
+func destroyTensor[T ort.TensorData](tensor *ort.Tensor[T]) {
+	if tensor != nil {
+		_ = tensor.Destroy()
+	}
+}
+
+func argmax(slice []float32) int32 {
+	if len(slice) == 0 {
+		return 0
+	}
+	var maxIdx int32
+	maxVal := slice[0]
+	for i, val := range slice {
+		if val > maxVal {
+			maxVal = val
+			maxIdx = int32(i)
+		}
+	}
+	return maxIdx
+}
+
+// softmaxAt returns the softmax probability of the logit at index idx.
+func softmaxAt(logits []float32, idx int32) float32 {
+	maxVal := logits[argmax(logits)]
+
+	var sum float64
+	for _, logit := range logits {
+		sum += math.Exp(float64(logit - maxVal))
+	}
+	return float32(math.Exp(float64(logits[idx]-maxVal)) / sum)
+}
@@ -0,0 +1,423 @@
+package transcribe
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Files of the punctuation restoration model. The model is a token classifier exported to
+// ONNX (e.g. a BERT fine-tuned to restore punctuation) with an uncased WordPiece
+// vocabulary. Every line of the labels file names a class as the punctuation to append
+// after the word, or "O" for none, followed by "U" if the word is capitalized or "O" if
+// not: "OO", ".U", ",O", "?O"...
+const (
+	PunctuatorModelFile  = "model.onnx"
+	PunctuatorVocabFile  = "vocab.txt"
+	PunctuatorLabelsFile = "labels.txt"
+
+	// PunctuatorID is the name of the subdirectory of the model directory the
+	// punctuation model is stored in.
+	PunctuatorID = "punctuation"
+)
+
+// punctuatorMaxTokens is the longest sequence given to the model at once, longer texts
+// are punctuated in windows of whole words.
+const punctuatorMaxTokens = 256
+
+// sentenceEnds are the punctuation marks after which a word is capitalized.
+const sentenceEnds = ".?!"
+
+// punctuationLabel is a class of the punctuation model.
+type punctuationLabel struct {
+	punctuation string
+	capitalize  bool
+}
+
+// Punctuator restores the punctuation and capitalization of a raw transcription with a
+// small local model, for models and setups that output a lowercase run-on text. It must
+// be downloaded and loaded before use, after the ONNX Runtime environment is initialized
+// by New.
+type Punctuator struct {
+	dir        string
+	baseURL    string
+	mirrorURL  string
+	bufferSize int
+	retries    int
+	client     *http.Client
+
+	mu      sync.RWMutex
+	session *ort.DynamicAdvancedSession
+	// inputs are the names of the model inputs, token_type_ids is only given to models
+	// that declare it.
+	inputs []string
+	vocab  map[string]int64
+	labels []punctuationLabel
+}
+
+// NewPunctuator creates a punctuator that stores its model in the "punctuation"
+// subdirectory of modelDir.
+func NewPunctuator(modelDir string) *Punctuator {
+	return &Punctuator{dir: filepath.Join(modelDir, PunctuatorID)}
+}
+
+// SetBaseURL sets the URL the model files are downloaded from, as <baseURL>/<file name>.
+// Without it the files must be copied to the model directory by hand. It must be called
+// before downloading.
+func (p *Punctuator) SetBaseURL(baseURL string) {
+	p.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetMirrorURL downloads the model from a mirror, see ModelConfig.MirrorURL. It must be
+// called before downloading.
+func (p *Punctuator) SetMirrorURL(mirrorURL string) {
+	p.mirrorURL = mirrorURL
+}
+
+// SetDownloadBufferSize sets the size in bytes of the buffer the model is downloaded
+// through, zero restores the default.
+func (p *Punctuator) SetDownloadBufferSize(size int) {
+	p.bufferSize = size
+}
+
+// SetDownloadRetries sets how many times a download failing with a transient error is
+// retried, see ParakeetModel.SetDownloadRetries.
+func (p *Punctuator) SetDownloadRetries(retries int) {
+	p.retries = retries
+}
+
+// SetDownloadClient sets the HTTP client the model is downloaded with, see
+// ParakeetModel.SetDownloadClient.
+func (p *Punctuator) SetDownloadClient(client *http.Client) {
+	p.client = client
+}
+
+// GetModelFiles returns the model files with their URLs and paths.
+func (p *Punctuator) GetModelFiles() []ModelFile {
+	files := make([]ModelFile, 0, 3)
+	for _, name := range []string{PunctuatorModelFile, PunctuatorVocabFile, PunctuatorLabelsFile} {
+		path := filepath.Join(p.dir, name)
+		url := ""
+		if p.baseURL != "" {
+			url = p.baseURL + "/" + name
+		}
+		files = append(files, ModelFile{Name: "Punctuation " + name, URL: mirroredURL(p.mirrorURL, url, path), Path: path})
+	}
+	return files
+}
+
+// CheckModelsExist checks if the model files exist.
+func (p *Punctuator) CheckModelsExist() (bool, []ModelFile) {
+	var missing []ModelFile
+	for _, file := range p.GetModelFiles() {
+		if _, err := os.Stat(file.Path); os.IsNotExist(err) {
+			missing = append(missing, file)
+		}
+	}
+	return len(missing) == 0, missing
+}
+
+// DownloadModels downloads the missing model files. It fails if some are missing and no
+// URL to download them from is configured.
+func (p *Punctuator) DownloadModels(progressCallback DownloadProgressCallback) error {
+	_, missing := p.CheckModelsExist()
+	if err := checkDiskSpace(p.client, missing); err != nil {
+		return err
+	}
+	for _, file := range missing {
+		if file.URL == "" {
+			return fmt.Errorf("%s is missing and no punctuation model URL is configured", file.Path)
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return fmt.Errorf("error creating model directory: %w", err)
+		}
+		if err := downloadWithRetry(p.client, file, p.bufferSize, p.retries, progressCallback); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load reads the vocabulary and the labels and creates the ONNX session. The model is
+// small, so it runs on a single CPU thread. Calling it again recreates the session.
+func (p *Punctuator) Load() error {
+	vocab, err := loadWordPieceVocab(filepath.Join(p.dir, PunctuatorVocabFile))
+	if err != nil {
+		return err
+	}
+	labels, err := loadPunctuationLabels(filepath.Join(p.dir, PunctuatorLabelsFile))
+	if err != nil {
+		return err
+	}
+
+	modelPath := filepath.Join(p.dir, PunctuatorModelFile)
+	inputInfo, _, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return fmt.Errorf("error reading punctuation model inputs: %w", err)
+	}
+	inputs := []string{"input_ids", "attention_mask"}
+	for _, info := range inputInfo {
+		if info.Name == "token_type_ids" {
+			inputs = append(inputs, info.Name)
+		}
+	}
+
+	options, err := ort.NewSessionOptions()
+	if err != nil {
+		return fmt.Errorf("error creating session options: %w", err)
+	}
+	defer func() { _ = options.Destroy() }()
+
+	if err := options.SetIntraOpNumThreads(1); err != nil {
+		return fmt.Errorf("error setting intra-op threads: %w", err)
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(modelPath, inputs, []string{"logits"}, options)
+	if err != nil {
+		return fmt.Errorf("error creating punctuation session: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.session != nil {
+		_ = p.session.Destroy()
+	}
+	p.session = session
+	p.inputs = inputs
+	p.vocab = vocab
+	p.labels = labels
+	return nil
+}
+
+// Loaded reports whether the session is ready to use.
+func (p *Punctuator) Loaded() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.session != nil
+}
+
+// Close destroys the ONNX session, waiting for the punctuation in progress to finish.
+func (p *Punctuator) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.session != nil {
+		_ = p.session.Destroy()
+		p.session = nil
+	}
+	return nil
+}
+
+// Punctuate returns the text with the punctuation and capitalization predicted by the
+// model. The punctuation already present is replaced; words the model keeps lowercase
+// keep their original casing, so names and acronyms the transcriber got right survive.
+func (p *Punctuator) Punctuate(text string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.session == nil {
+		return "", errors.New("punctuation model not loaded, call Load first")
+	}
+
+	words := strings.Fields(text)
+	for n, word := range words {
+		words[n] = strings.TrimRightFunc(word, unicode.IsPunct)
+	}
+	words = slices.DeleteFunc(words, func(word string) bool { return word == "" })
+	if len(words) == 0 {
+		return "", nil
+	}
+
+	labels := make([]punctuationLabel, 0, len(words))
+	for start := 0; start < len(words); {
+		ids, firsts, end := p.encodeWindow(words, start)
+		windowLabels, err := p.classify(ids, firsts)
+		if err != nil {
+			return "", err
+		}
+		labels = append(labels, windowLabels...)
+		start = end
+	}
+
+	var sb strings.Builder
+	capitalizeNext := true
+	for n, word := range words {
+		if n > 0 {
+			sb.WriteByte(' ')
+		}
+		if capitalizeNext || labels[n].capitalize {
+			word = capitalize(word)
+		}
+		punctuation := labels[n].punctuation
+		if n == len(words)-1 && !strings.ContainsAny(punctuation, sentenceEnds) {
+			punctuation = "."
+		}
+		sb.WriteString(word)
+		sb.WriteString(punctuation)
+		capitalizeNext = strings.ContainsAny(punctuation, sentenceEnds)
+	}
+	return sb.String(), nil
+}
+
+// encodeWindow tokenizes the words from start until the window is full, returning the
+// token IDs wrapped in [CLS] and [SEP], the index of the first token of every word and
+// the index of the first word left out.
+func (p *Punctuator) encodeWindow(words []string, start int) ([]int64, []int, int) {
+	ids := []int64{p.vocab["[CLS]"]}
+	var firsts []int
+
+	end := start
+	for ; end < len(words); end++ {
+		pieces := wordPieces(p.vocab, strings.ToLower(words[end]))
+		if len(ids)+len(pieces)+1 > punctuatorMaxTokens && end > start {
+			break
+		}
+		firsts = append(firsts, len(ids))
+		ids = append(ids, pieces...)
+	}
+	ids = append(ids[:min(len(ids), punctuatorMaxTokens-1)], p.vocab["[SEP]"])
+	for n, first := range firsts {
+		firsts[n] = min(first, len(ids)-2)
+	}
+	return ids, firsts, end
+}
+
+// classify runs the model on the tokens and returns the label of the first token of
+// every word.
+func (p *Punctuator) classify(ids []int64, firsts []int) ([]punctuationLabel, error) {
+	shape := ort.NewShape(1, int64(len(ids)))
+	mask := make([]int64, len(ids))
+	for n := range mask {
+		mask[n] = 1
+	}
+
+	inputData := map[string][]int64{
+		"input_ids":      ids,
+		"attention_mask": mask,
+		"token_type_ids": make([]int64, len(ids)),
+	}
+	inputs := make([]ort.Value, 0, len(p.inputs))
+	for _, name := range p.inputs {
+		tensor, err := ort.NewTensor(shape, inputData[name])
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s tensor: %w", name, err)
+		}
+		defer destroyTensor(tensor)
+		inputs = append(inputs, tensor)
+	}
+
+	logits, err := ort.NewEmptyTensor[float32](ort.NewShape(1, int64(len(ids)), int64(len(p.labels))))
+	if err != nil {
+		return nil, fmt.Errorf("error creating logits tensor: %w", err)
+	}
+	defer destroyTensor(logits)
+
+	if err := p.session.Run(inputs, []ort.Value{logits}); err != nil {
+		return nil, fmt.Errorf("error running punctuation model: %w", err)
+	}
+
+	data := logits.GetData()
+	labels := make([]punctuationLabel, len(firsts))
+	for n, first := range firsts {
+		offset := first * len(p.labels)
+		labels[n] = p.labels[argmax(data[offset:offset+len(p.labels)])]
+	}
+	return labels, nil
+}
+
+// wordPieces splits a word into WordPiece token IDs, greedily matching the longest
+// piece of the vocabulary; continuation pieces are prefixed with "##". A word that cannot
+// be split is a single [UNK] token.
+func wordPieces(vocab map[string]int64, word string) []int64 {
+	var ids []int64
+	for start := 0; start < len(word); {
+		end := len(word)
+		found := false
+		for end > start {
+			piece := word[start:end]
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+			_, size := utf8.DecodeLastRuneInString(word[start:end])
+			end -= size
+		}
+		if !found {
+			return []int64{vocab["[UNK]"]}
+		}
+		start = end
+	}
+	return ids
+}
+
+// loadWordPieceVocab reads a vocabulary file with one token per line, the line number
+// being its ID.
+func loadWordPieceVocab(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening punctuation vocabulary: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimSpace(scanner.Text())] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading punctuation vocabulary: %w", err)
+	}
+
+	for _, special := range []string{"[CLS]", "[SEP]", "[UNK]"} {
+		if _, ok := vocab[special]; !ok {
+			return nil, fmt.Errorf("punctuation vocabulary has no %s token", special)
+		}
+	}
+	return vocab, nil
+}
+
+// loadPunctuationLabels reads the labels file, one class per line in the order of the
+// model outputs.
+func loadPunctuationLabels(path string) ([]punctuationLabel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening punctuation labels: %w", err)
+	}
+
+	var labels []punctuationLabel
+	for _, line := range strings.Fields(string(data)) {
+		if len(line) < 2 || (line[len(line)-1] != 'U' && line[len(line)-1] != 'O') {
+			return nil, fmt.Errorf("invalid punctuation label %q", line)
+		}
+		punctuation := line[:len(line)-1]
+		if punctuation == "O" {
+			punctuation = ""
+		}
+		labels = append(labels, punctuationLabel{punctuation: punctuation, capitalize: line[len(line)-1] == 'U'})
+	}
+	if len(labels) == 0 {
+		return nil, errors.New("the punctuation labels file is empty")
+	}
+	return labels, nil
+}
+
+// capitalize returns the word with its first letter in upper case.
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,122 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/audio"
+	"golang.org/x/sync/errgroup"
+)
+
+// readerBufferSize is the size of the reads from the PCM stream, 1/16 s of audio.
+const readerBufferSize = audio.SampleRate / 8
+
+// TranscribeReader transcribes 16kHz mono 16-bit little-endian PCM read from r as it
+// arrives, e.g. from a microphone or a network stream, until r returns io.EOF. The audio is
+// never held in memory whole: as soon as a chunk (see Options.ChunkDuration) is buffered,
+// it is cut at a quiet point and transcribed while the next one is read, and the chunks are
+// merged like TranscribeSamplesWithPartials does. Canceling ctx stops the transcription
+// and the reading, once the read in progress returns.
+func (i *Instance) TranscribeReader(ctx context.Context, r io.Reader) (Result, error) {
+	return i.TranscribeReaderWithPartials(ctx, r, nil)
+}
+
+// TranscribeReaderWithPartials transcribes like TranscribeReader and calls onPartial, if
+// not nil, with the text transcribed so far every time a chunk is done.
+func (i *Instance) TranscribeReaderWithPartials(ctx context.Context, r io.Reader, onPartial PartialResultCallback) (Result, error) {
+	return transcribeStream(ctx, i.activeModel(), r, i.chunking().duration, onPartial)
+}
+
+// transcribeStream implements TranscribeReaderWithPartials with the given model. The
+// chunks are transcribed one at a time, in order, while the reader buffers the next one.
+func transcribeStream(ctx context.Context, model Model, r io.Reader, chunkDuration time.Duration, onPartial PartialResultCallback) (Result, error) {
+	maxSamples := int(chunkDuration.Seconds() * audio.SampleRate)
+	margin := int(chunkOverlap.Seconds() * audio.SampleRate / 2)
+
+	// streamChunk is a chunk of the stream and the sample of the stream it starts at.
+	type streamChunk struct {
+		samples []float32
+		start   int
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	chunks := make(chan streamChunk, 1)
+
+	var results []Result
+	group.Go(func() error {
+		for chunk := range chunks {
+			result, err := transcribeWithProgress(groupCtx, model, chunk.samples, nil)
+			if err != nil {
+				return fmt.Errorf("error transcribing chunk %d: %w", len(results)+1, err)
+			}
+
+			offset := time.Duration(chunk.start) * time.Second / audio.SampleRate
+			results = append(results, shiftTokens(result, offset))
+			if onPartial != nil {
+				onPartial(mergeResults(spokenResults(results)).Text)
+			}
+		}
+		return nil
+	})
+
+	group.Go(func() error {
+		defer close(chunks)
+
+		send := func(chunk streamChunk) error {
+			select {
+			case chunks <- chunk:
+				return nil
+			case <-groupCtx.Done():
+				return groupCtx.Err()
+			}
+		}
+
+		var (
+			buffer   []float32
+			pcm      = make([]byte, readerBufferSize)
+			leftover int
+			// start is the sample of the stream the buffer starts at.
+			start int
+		)
+		for {
+			if err := groupCtx.Err(); err != nil {
+				return err
+			}
+
+			n, readErr := r.Read(pcm[leftover:])
+			n += leftover
+			even := n - n%2
+			buffer = append(buffer, audio.PCM16ToFloat32(pcm[:even])...)
+			leftover = copy(pcm, pcm[even:n])
+
+			// A chunk is cut once the audio after it, which the next chunk shares, is
+			// buffered too.
+			for len(buffer) >= maxSamples+margin {
+				cut := len(audio.SplitAtSilence(buffer, chunkDuration)[0])
+				if err := send(streamChunk{samples: buffer[:cut+margin], start: start}); err != nil {
+					return err
+				}
+				start += max(cut-margin, 0)
+				buffer = append([]float32(nil), buffer[max(cut-margin, 0):]...)
+			}
+
+			if errors.Is(readErr, io.EOF) {
+				if len(buffer) > 0 {
+					return send(streamChunk{samples: buffer, start: start})
+				}
+				return nil
+			}
+			if readErr != nil {
+				return fmt.Errorf("error reading audio: %w", readErr)
+			}
+		}
+	})
+
+	if err := group.Wait(); err != nil {
+		return Result{}, err
+	}
+	return mergeResults(spokenResults(results)), nil
+}
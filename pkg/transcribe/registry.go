@@ -0,0 +1,239 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultModelID is the ID of the model used when none is selected.
+const DefaultModelID = ParakeetModelID
+
+// Model is a speech recognition model that can be downloaded, loaded and run.
+type Model interface {
+	// GetModelFiles returns all the files the model needs with their URLs and paths.
+	GetModelFiles() []ModelFile
+	// CheckModelsExist checks if all required model files exist.
+	CheckModelsExist() (bool, []ModelFile)
+	// DownloadModels downloads all missing model files.
+	DownloadModels(progressCallback DownloadProgressCallback) error
+	// Load prepares the model for transcription once its files exist.
+	Load() error
+	// Close releases the resources acquired by Load, waiting for transcriptions in
+	// progress to finish.
+	Close() error
+	// Transcribe performs speech-to-text on 16kHz mono float32 samples, returning the
+	// context error as soon as possible once ctx is canceled.
+	Transcribe(ctx context.Context, samples []float32) (Result, error)
+	// SetIntraOpThreads limits the CPU threads used for inference, zero means no limit.
+	SetIntraOpThreads(threads int)
+	// ExecutionProvider returns the provider effectively used for inference.
+	ExecutionProvider() ExecutionProvider
+}
+
+// ProgressModel is implemented by models that report their progress while transcribing,
+// so a single long chunk does not look stuck.
+type ProgressModel interface {
+	// TranscribeWithProgress transcribes like Model.Transcribe and calls onProgress with
+	// the fraction of the samples decoded so far.
+	TranscribeWithProgress(ctx context.Context, samples []float32, onProgress ProgressCallback) (Result, error)
+}
+
+// StreamingModel is implemented by models that emit their tokens as they are decoded, so
+// the text can be shown while a transcription is in progress.
+type StreamingModel interface {
+	// TranscribeStreaming transcribes like ProgressModel.TranscribeWithProgress and calls
+	// onToken with every token as it is emitted, in order. Either callback may be nil.
+	TranscribeStreaming(ctx context.Context, samples []float32, onProgress ProgressCallback, onToken TokenCallback) (Result, error)
+}
+
+// TDTModel is implemented by token-and-duration transducer models (Parakeet), whose
+// duration predictions can be ignored to decode them frame by frame instead.
+type TDTModel interface {
+	// SetTDTDecoding follows the predicted durations if enabled and visits every encoder
+	// frame otherwise, which Instance.SetTDTDecoding selects for every model it loads.
+	SetTDTDecoding(enabled bool)
+}
+
+// transcribeStreaming transcribes with the model, emitting its tokens as they are decoded
+// if it supports it and otherwise none.
+func transcribeStreaming(ctx context.Context, model Model, samples []float32, onProgress ProgressCallback, onToken TokenCallback) (Result, error) {
+	if streamingModel, ok := model.(StreamingModel); ok && onToken != nil {
+		return streamingModel.TranscribeStreaming(ctx, samples, onProgress, onToken)
+	}
+	return transcribeWithProgress(ctx, model, samples, onProgress)
+}
+
+// transcribeWithProgress transcribes with the model, reporting its progress if it
+// supports it and otherwise only once the transcription is complete.
+func transcribeWithProgress(ctx context.Context, model Model, samples []float32, onProgress ProgressCallback) (Result, error) {
+	if progressModel, ok := model.(ProgressModel); ok && onProgress != nil {
+		return progressModel.TranscribeWithProgress(ctx, samples, onProgress)
+	}
+
+	result, err := model.Transcribe(ctx, samples)
+	if err == nil && onProgress != nil {
+		onProgress(1)
+	}
+	return result, err
+}
+
+// ExecutionProvider identifies the hardware ONNX Runtime uses to run a model.
+type ExecutionProvider string
+
+const (
+	ExecutionProviderCPU  ExecutionProvider = "cpu"
+	ExecutionProviderCUDA ExecutionProvider = "cuda"
+)
+
+// Precision is the numeric format of the model weights. Quantized int8 weights are smaller
+// and faster, full-precision fp32 weights are slightly more accurate and need about four
+// times the memory.
+type Precision string
+
+const (
+	PrecisionInt8 Precision = "int8"
+	PrecisionFP32 Precision = "fp32"
+)
+
+// ModelConfig configures a model created from the registry.
+type ModelConfig struct {
+	// Dir is the directory the model files are stored in.
+	Dir string
+	// ExecutionProvider selects the hardware used for inference. CUDA requires the GPU
+	// build of ONNX Runtime; models fall back to the CPU if it is not available.
+	ExecutionProvider ExecutionProvider
+	// CUDADeviceID is the index of the GPU used with the CUDA provider.
+	CUDADeviceID int
+	// MirrorURL is the base URL of a mirror to download the model files from, laid out
+	// like the model directory (<mirror>/<model ID>/<file name>); empty uses the upstream
+	// URLs.
+	MirrorURL string
+	// DownloadBufferSize is the size in bytes of the buffer downloads are copied through,
+	// defaultDownloadBufferSize if zero.
+	DownloadBufferSize int
+	// DownloadRetries is the number of times a download failing with a transient error is
+	// retried with exponential backoff, defaultDownloadRetries if zero and none if
+	// negative.
+	DownloadRetries int
+	// DownloadClient is the HTTP client model files are downloaded with, a client using
+	// the proxy of the environment if nil. See NewDownloadClient.
+	DownloadClient *http.Client
+	// Precision selects the weights of models published in several precisions, int8 if
+	// empty. Models with a single variant ignore it.
+	Precision Precision
+}
+
+// ModelFactory creates a model from its configuration.
+type ModelFactory func(cfg ModelConfig) (Model, error)
+
+// ModelInfo describes a model available in the registry. Languages lists the ISO 639-1
+// codes the model can transcribe, empty if it is not restricted.
+type ModelInfo struct {
+	ID        string
+	Name      string
+	Languages []string
+	Factory   ModelFactory
+}
+
+// SupportsLanguage reports whether the model can transcribe the language, every model
+// supports the empty language which means "any".
+func (m ModelInfo) SupportsLanguage(language string) bool {
+	if language == "" || len(m.Languages) == 0 {
+		return true
+	}
+
+	language = strings.ToLower(language)
+	if base, _, found := strings.Cut(language, "-"); found {
+		language = base
+	}
+	return slices.Contains(m.Languages, language)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ModelInfo{}
+)
+
+// Register makes a model available by its ID. It fails if the ID is empty or already
+// registered.
+func Register(info ModelInfo) error {
+	if info.ID == "" || info.Factory == nil {
+		return fmt.Errorf("the model ID and factory are required")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[info.ID]; exists {
+		return fmt.Errorf("model %q is already registered", info.ID)
+	}
+	registry[info.ID] = info
+	return nil
+}
+
+// Get returns the registered model with the given ID.
+func Get(id string) (ModelInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := registry[id]
+	return info, ok
+}
+
+// Models returns all registered models sorted by ID.
+func Models() []ModelInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	models := make([]ModelInfo, 0, len(registry))
+	for _, info := range registry {
+		models = append(models, info)
+	}
+	sort.Slice(models, func(a, b int) bool { return models[a].ID < models[b].ID })
+	return models
+}
+
+// ModelForLanguage returns the model to use for a language: the preferred model if it
+// supports the language, otherwise the first registered model, sorted by ID, that does.
+func ModelForLanguage(preferredID, language string) (ModelInfo, bool) {
+	if info, ok := Get(preferredID); ok && info.SupportsLanguage(language) {
+		return info, true
+	}
+
+	for _, info := range Models() {
+		if info.SupportsLanguage(language) {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// newModel creates the registered model with the given ID, storing its files in a
+// subdirectory of the options model directory named after the ID.
+func newModel(id string, opts Options) (Model, error) {
+	info, ok := Get(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown model %q", id)
+	}
+
+	model, err := info.Factory(ModelConfig{
+		Dir:                filepath.Join(opts.ModelDir, id),
+		ExecutionProvider:  opts.ExecutionProvider,
+		CUDADeviceID:       opts.CUDADeviceID,
+		MirrorURL:          opts.MirrorURL,
+		DownloadBufferSize: opts.DownloadBufferSize,
+		DownloadRetries:    opts.DownloadRetries,
+		DownloadClient:     opts.DownloadClient,
+		Precision:          opts.Precision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating model %q: %w", id, err)
+	}
+	return model, nil
+}
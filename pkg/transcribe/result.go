@@ -0,0 +1,205 @@
+package transcribe
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Token is a piece of text emitted by the decoder with the probability the model assigned
+// to it, between 0 and 1. Start and End locate it in the transcribed audio, both are zero
+// for models that do not report timestamps.
+type Token struct {
+	Text       string        `json:"text"`
+	Confidence float32       `json:"confidence"`
+	Start      time.Duration `json:"start"`
+	End        time.Duration `json:"end"`
+}
+
+// Result is a transcription with the tokens it was built from.
+type Result struct {
+	Text   string  `json:"text"`
+	Tokens []Token `json:"tokens"`
+	// Timings is the time spent in every stage of the model, for models that report it.
+	// Merged chunks do not keep them.
+	Timings []StageTiming `json:"timings,omitempty"`
+}
+
+// StageTiming is the time a transcription spent in a stage of the model, e.g. "encoder".
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+}
+
+// tokensResult builds the result made of the tokens emitted by a decoder.
+func tokensResult(tokens []Token) Result {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.Text)
+	}
+	return Result{Text: strings.TrimSpace(text.String()), Tokens: tokens}
+}
+
+// shiftTokens returns the result with the timestamps of its tokens moved by offset, e.g.
+// from the start of a chunk to the start of the whole audio.
+func shiftTokens(result Result, offset time.Duration) Result {
+	if offset == 0 || len(result.Tokens) == 0 {
+		return result
+	}
+
+	tokens := make([]Token, len(result.Tokens))
+	for n, token := range result.Tokens {
+		if token.End > 0 {
+			token.Start += offset
+			token.End += offset
+		}
+		tokens[n] = token
+	}
+	result.Tokens = tokens
+	return result
+}
+
+// Confidence returns the mean confidence of the emitted tokens, zero if there are none.
+func (r Result) Confidence() float32 {
+	if len(r.Tokens) == 0 {
+		return 0
+	}
+
+	var sum float32
+	for _, token := range r.Tokens {
+		sum += token.Confidence
+	}
+	return sum / float32(len(r.Tokens))
+}
+
+// maxOverlapWords is the number of words at the end and the start of consecutive chunks
+// searched for the text they have in common: about what fits in the overlapping audio, as
+// a larger window makes coincidental matches more likely.
+const maxOverlapWords = 8
+
+// word is a word of a result with the tokens it was built from; results without tokens
+// have words without tokens.
+type word struct {
+	text   string
+	tokens []Token
+}
+
+// key returns the word normalized for comparison, without case nor punctuation.
+func (w word) key() string {
+	return strings.ToLower(strings.TrimFunc(w.text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
+
+// resultWords splits a result into words, a token starting with a space starts a word.
+func resultWords(result Result) []word {
+	if len(result.Tokens) == 0 {
+		fields := strings.Fields(result.Text)
+		words := make([]word, len(fields))
+		for n, field := range fields {
+			words[n] = word{text: " " + field}
+		}
+		return words
+	}
+
+	var words []word
+	for _, token := range result.Tokens {
+		if len(words) == 0 || strings.HasPrefix(token.Text, " ") {
+			words = append(words, word{})
+		}
+		last := &words[len(words)-1]
+		last.text += token.Text
+		last.tokens = append(last.tokens, token)
+	}
+	return words
+}
+
+// mergeResults joins the results of consecutive overlapping chunks of the same audio (see
+// audio.SplitWithOverlap) into one continuous text. The overlapping audio appears at the
+// end of a chunk and the start of the next; the longest run of words both have in common
+// there, spoken at the same time (see overlapSplice), is kept once, and the words after it
+// in the first chunk and before it in the second, which may be cut by the chunk edges,
+// are dropped. Chunks without words in common are simply concatenated.
+func mergeResults(results []Result) Result {
+	var merged []word
+	for _, result := range results {
+		words := resultWords(result)
+		if len(words) == 0 {
+			continue
+		}
+
+		tailStart := max(len(merged)-maxOverlapWords, 0)
+		matchEnd, nextStart := overlapSplice(merged[tailStart:], words[:min(maxOverlapWords, len(words))])
+		if nextStart > 0 {
+			merged = merged[:tailStart+matchEnd]
+			words = words[nextStart:]
+		}
+		merged = append(merged, words...)
+	}
+
+	var text strings.Builder
+	var tokens []Token
+	for _, w := range merged {
+		text.WriteString(w.text)
+		tokens = append(tokens, w.tokens...)
+	}
+	return Result{Text: strings.TrimSpace(text.String()), Tokens: tokens}
+}
+
+// overlapSplice finds the longest run of words at the same positions in tail and head
+// and returns where it ends in each, or zeros if they have no word in common. Longer runs
+// win; among runs of the same length, the one closest to the end of tail does. Words with
+// timestamps only match if they were spoken at the same time, so the same word said twice
+// in the overlap is not mistaken for one; without timestamps a run needs at least
+// minUntimedOverlapWords words and at most maxCutWords words after it in tail and before
+// it in head, which is all the overlapping audio can hold.
+func overlapSplice(tail, head []word) (tailEnd, headEnd int) {
+	best := 0
+	for i := range tail {
+		for j := range head {
+			length := 0
+			for i+length < len(tail) && j+length < len(head) &&
+				tail[i+length].key() != "" && tail[i+length].key() == head[j+length].key() &&
+				sameTime(tail[i+length], head[j+length]) {
+				length++
+			}
+			if length == 0 || length < best {
+				continue
+			}
+			timed := tail[i].timed() && head[j].timed()
+			if !timed && (length < minUntimedOverlapWords || len(tail)-(i+length) > maxCutWords || j > maxCutWords) {
+				continue
+			}
+			best = length
+			tailEnd, headEnd = i+length, j+length
+		}
+	}
+	return tailEnd, headEnd
+}
+
+const (
+	// overlapTolerance is how far apart the starts of the same word transcribed in two
+	// overlapping chunks may be.
+	overlapTolerance = 500 * time.Millisecond
+	// minUntimedOverlapWords is the shortest run of words without timestamps taken as the
+	// overlap, a single common word such as "the" is likely a coincidence.
+	minUntimedOverlapWords = 2
+	// maxCutWords is the most words without timestamps dropped around the overlap, the
+	// ones cut by the chunk edges.
+	maxCutWords = 3
+)
+
+// timed reports whether the word has a timestamp, external models may not report any.
+func (w word) timed() bool {
+	return len(w.tokens) > 0 && (w.tokens[0].Start > 0 || w.tokens[0].End > 0)
+}
+
+// sameTime reports whether two words may be the same utterance: they start within
+// overlapTolerance of each other, or either has no timestamp.
+func sameTime(a, b word) bool {
+	if !a.timed() || !b.timed() {
+		return true
+	}
+	diff := a.tokens[0].Start - b.tokens[0].Start
+	return diff.Abs() <= overlapTolerance
+}
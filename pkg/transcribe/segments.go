@@ -0,0 +1,65 @@
+package transcribe
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// maxSegmentDuration and maxSegmentLength bound a segment to what a subtitle cue can
+	// show: a few seconds and two lines of 42 characters.
+	maxSegmentDuration = 6 * time.Second
+	maxSegmentLength   = 84
+	// segmentPause is the silence between words that ends a segment.
+	segmentPause = 800 * time.Millisecond
+)
+
+// Segment is a span of a transcription located in the audio, e.g. a subtitle cue.
+type Segment struct {
+	Text  string        `json:"text"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// Segments groups the words of the result into segments suitable for subtitles: a segment
+// ends with a sentence, at a pause, or before growing longer than 6 seconds or 84
+// characters. It returns nil if the model did not report timestamps.
+func (r Result) Segments() []Segment {
+	words := resultWords(r)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var (
+		segments []Segment
+		current  *Segment
+	)
+	for _, w := range words {
+		if len(w.tokens) == 0 || w.tokens[len(w.tokens)-1].End == 0 {
+			return nil
+		}
+		text := strings.TrimSpace(w.text)
+		if text == "" {
+			continue
+		}
+		start, end := w.tokens[0].Start, w.tokens[len(w.tokens)-1].End
+
+		if current != nil && (start-current.End >= segmentPause ||
+			end-current.Start > maxSegmentDuration ||
+			len(current.Text)+1+len(text) > maxSegmentLength) {
+			current = nil
+		}
+		if current == nil {
+			segments = append(segments, Segment{Text: text, Start: start, End: end})
+			current = &segments[len(segments)-1]
+		} else {
+			current.Text += " " + text
+			current.End = end
+		}
+
+		if strings.ContainsAny(text[len(text)-1:], ".?!") {
+			current = nil
+		}
+	}
+	return segments
+}
@@ -0,0 +1,599 @@
+// Package transcribe runs local speech-to-text on top of the ONNX Runtime. It is
+// independent of the Tribar application and can be embedded by other Go programs: create
+// an Instance, download and load the models, and transcribe audio. Models are pluggable
+// through a registry, Parakeet TDT is registered by default.
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/audio"
+	ort "github.com/yalue/onnxruntime_go"
+	"golang.org/x/sync/errgroup"
+)
+
+// Options configures a transcription instance.
+type Options struct {
+	// SharedLibraryPath is the path to the ONNX Runtime shared library
+	// (e.g. libonnxruntime.so, onnxruntime.dll).
+	SharedLibraryPath string
+	// ModelDir is the directory where the model files are stored and downloaded, every
+	// model uses a subdirectory named after its ID.
+	ModelDir string
+	// ModelID selects the registered model to use, DefaultModelID if empty.
+	ModelID string
+	// ExecutionProvider selects the hardware used for inference, the CPU if empty. CUDA
+	// requires SharedLibraryPath to point to the GPU build of ONNX Runtime.
+	ExecutionProvider ExecutionProvider
+	// CUDADeviceID is the index of the GPU used with the CUDA provider.
+	CUDADeviceID int
+	// MirrorURL is the base URL of a mirror to download the model files from instead of
+	// their upstream hosts, see ModelConfig.MirrorURL.
+	MirrorURL string
+	// DownloadBufferSize is the size in bytes of the buffer model files are downloaded
+	// through, 32KB if zero.
+	DownloadBufferSize int
+	// DownloadRetries is the number of times a model download failing with a transient
+	// error (a server error or a dropped connection) is retried with exponential backoff,
+	// 3 if zero and none if negative. Permanent errors, such as a missing file, fail at
+	// once.
+	DownloadRetries int
+	// DownloadClient is the HTTP client model files are downloaded with, e.g. one created
+	// by NewDownloadClient for a proxy or a custom certificate authority. Nil uses the
+	// proxy of the HTTPS_PROXY and HTTP_PROXY environment variables.
+	DownloadClient *http.Client
+	// ChunkDuration is the length of the chunks long audio is split into, 30 seconds if
+	// zero. Longer chunks give the model more context but use more memory.
+	ChunkDuration time.Duration
+	// ChunkWorkers is the number of chunks of a long audio transcribed at the same time,
+	// one if zero. Every worker runs its own inference, so with several workers the intra-op
+	// threads (see SetIntraOpThreads) should be limited to share the cores among them.
+	ChunkWorkers int
+	// Precision selects the weights of the model, PrecisionInt8 if empty. Each precision
+	// is a separate download.
+	Precision Precision
+	// VerifyChecksums makes LoadModels check the SHA-256 of every model file first, which
+	// takes a moment for large models but turns a corrupted file into ErrCorruptedModel
+	// instead of an ONNX Runtime failure. Downloads are always verified.
+	VerifyChecksums bool
+}
+
+// Instance represents a transcription engine instance.
+type Instance struct {
+	opts Options
+
+	mu             sync.RWMutex
+	model          Model
+	modelID        string
+	precision      Precision
+	intraOpThreads int
+	frameDecoding  bool
+	chunkPartials  bool
+}
+
+// New creates a new transcription instance, initializing the ONNX Runtime environment.
+func New(opts Options) (*Instance, error) {
+	if opts.SharedLibraryPath == "" || opts.ModelDir == "" {
+		return nil, errors.New("the shared library path and the model directory are required")
+	}
+
+	if opts.ModelID == "" {
+		opts.ModelID = DefaultModelID
+	}
+	if opts.ExecutionProvider == "" {
+		opts.ExecutionProvider = ExecutionProviderCPU
+	}
+	if opts.ChunkDuration <= 0 {
+		opts.ChunkDuration = defaultChunkDuration
+	}
+	opts.ChunkWorkers = max(opts.ChunkWorkers, 1)
+	if opts.Precision == "" {
+		opts.Precision = PrecisionInt8
+	}
+
+	model, err := newModel(opts.ModelID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ort.SetSharedLibraryPath(opts.SharedLibraryPath)
+
+	if err := ort.InitializeEnvironment(); err != nil {
+		return nil, fmt.Errorf("error initializing onnx runtime: %w", err)
+	}
+
+	return &Instance{
+		opts:      opts,
+		model:     model,
+		modelID:   opts.ModelID,
+		precision: opts.Precision,
+	}, nil
+}
+
+// Shutdown cleans up resources used by the transcription instance.
+func (i *Instance) Shutdown() error {
+	_ = i.activeModel().Close()
+
+	if err := ort.DestroyEnvironment(); err != nil {
+		return fmt.Errorf("error destroying onnx runtime environment: %w", err)
+	}
+	return nil
+}
+
+// ModelID returns the ID of the active model.
+func (i *Instance) ModelID() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.modelID
+}
+
+// Precision returns the precision the active model was created with.
+func (i *Instance) Precision() Precision {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.precision
+}
+
+// SetPrecision selects the precision of the models created by the following SwitchModel
+// calls, the active model keeps its own until it is switched.
+func (i *Instance) SetPrecision(precision Precision) {
+	if precision == "" {
+		precision = PrecisionInt8
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.opts.Precision = precision
+}
+
+// ExecutionProvider returns the provider effectively used by the active model, which is
+// the CPU when the configured GPU provider could not be initialized.
+func (i *Instance) ExecutionProvider() ExecutionProvider {
+	return i.activeModel().ExecutionProvider()
+}
+
+// SwitchModel replaces the active model with the registered model with the given ID. The
+// new model must be downloaded and loaded with DownloadModels and LoadModels before
+// transcribing; transcriptions in progress finish with the previous model, which is
+// closed afterwards. If the previous model fell back to the CPU, so does the new one.
+func (i *Instance) SwitchModel(id string) error {
+	i.mu.Lock()
+	if i.model.ExecutionProvider() != i.opts.ExecutionProvider {
+		// The active model fell back to the CPU, do not try the GPU again.
+		i.opts.ExecutionProvider = ExecutionProviderCPU
+	}
+	opts := i.opts
+	i.mu.Unlock()
+
+	model, err := newModel(id, opts)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	previous := i.model
+	model.SetIntraOpThreads(i.intraOpThreads)
+	if tdtModel, ok := model.(TDTModel); ok {
+		tdtModel.SetTDTDecoding(!i.frameDecoding)
+	}
+	i.model = model
+	i.modelID = id
+	i.precision = opts.Precision
+	i.mu.Unlock()
+
+	return previous.Close()
+}
+
+// activeModel returns the model in use.
+func (i *Instance) activeModel() Model {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.model
+}
+
+// CheckModels checks if all required models exist.
+// Returns true if all models exist, false otherwise with the list of missing models.
+func (i *Instance) CheckModels() (bool, []ModelFile) {
+	return i.activeModel().CheckModelsExist()
+}
+
+// DownloadModels downloads all missing model files.
+func (i *Instance) DownloadModels(progressCallback DownloadProgressCallback) error {
+	return downloadModel(i.activeModel(), progressCallback)
+}
+
+// CheckModel checks if the files of the registered model with the given ID exist, in the
+// precision selected with SetPrecision, without making it the active model.
+func (i *Instance) CheckModel(id string) (bool, []ModelFile, error) {
+	i.mu.RLock()
+	precision := i.opts.Precision
+	i.mu.RUnlock()
+
+	return i.CheckModelPrecision(id, precision)
+}
+
+// CheckModelPrecision is like CheckModel, but checks the files of the given precision
+// instead of the one selected with SetPrecision.
+func (i *Instance) CheckModelPrecision(id string, precision Precision) (bool, []ModelFile, error) {
+	i.mu.RLock()
+	opts := i.opts
+	i.mu.RUnlock()
+	opts.Precision = precision
+
+	model, err := newModel(id, opts)
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = model.Close() }()
+
+	allExist, missing := model.CheckModelsExist()
+	return allExist, missing, nil
+}
+
+// DownloadModel downloads the missing files of the registered model with the given ID, in
+// the precision selected with SetPrecision, while the active model keeps transcribing, so
+// SwitchModel can replace it without waiting for a download.
+func (i *Instance) DownloadModel(id string, progressCallback DownloadProgressCallback) error {
+	i.mu.RLock()
+	opts := i.opts
+	i.mu.RUnlock()
+
+	model, err := newModel(id, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = model.Close() }()
+
+	if allExist, _ := model.CheckModelsExist(); allExist {
+		return nil
+	}
+	return downloadModel(model, progressCallback)
+}
+
+// downloadModel downloads the missing files of a model, creating their directories.
+func downloadModel(model Model, progressCallback DownloadProgressCallback) error {
+	for _, file := range model.GetModelFiles() {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return fmt.Errorf("error creating model directory: %w", err)
+		}
+	}
+	return model.DownloadModels(progressCallback)
+}
+
+// CheckModelUpdates returns the installed files of the active model whose revision is
+// outdated, comparing the manifest of the model directory with the pinned URLs and the
+// versions reported by the server.
+func (i *Instance) CheckModelUpdates() ([]ModelUpdate, error) {
+	i.mu.RLock()
+	client := i.opts.DownloadClient
+	i.mu.RUnlock()
+	return checkUpdates(client, i.activeModel().GetModelFiles())
+}
+
+// UpdateModels deletes the outdated files of the active model and downloads their new
+// revision. The model must be unloaded first and loaded again with LoadModels. It returns
+// the updated files, none if the model is up to date.
+func (i *Instance) UpdateModels(progressCallback DownloadProgressCallback) ([]ModelUpdate, error) {
+	updates, err := i.CheckModelUpdates()
+	if err != nil || len(updates) == 0 {
+		return nil, err
+	}
+
+	for _, update := range updates {
+		if err := os.Remove(update.File.Path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error removing %s: %w", update.File.Name, err)
+		}
+		_ = os.Remove(checksumPath(update.File.Path))
+	}
+	if err := i.DownloadModels(progressCallback); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// LoadModels prepares the active model for transcription. Corrupted files found by the
+// checksum verification are deleted so DownloadModels fetches them again.
+func (i *Instance) LoadModels() error {
+	// Check if models exist
+	allExist, missing := i.CheckModels()
+	if !allExist {
+		var missingNames []string
+		for _, m := range missing {
+			missingNames = append(missingNames, m.Name)
+		}
+		return fmt.Errorf("missing model files: %v. Call DownloadModels first", missingNames)
+	}
+
+	model := i.activeModel()
+	if i.opts.VerifyChecksums {
+		if err := VerifyModelFiles(model.GetModelFiles()); err != nil {
+			return fmt.Errorf("error verifying model files: %w", err)
+		}
+	}
+
+	if err := model.Load(); err != nil {
+		return fmt.Errorf("error loading model: %w", err)
+	}
+
+	return nil
+}
+
+// UnloadModels releases the ONNX sessions of the active model to free memory, waiting for
+// the transcription in progress to finish. The model stays selected and LoadModels loads
+// it again.
+func (i *Instance) UnloadModels() error {
+	if err := i.activeModel().Close(); err != nil {
+		return fmt.Errorf("error unloading model: %w", err)
+	}
+	return nil
+}
+
+// SetIntraOpThreads limits the number of CPU threads used for inference, zero means
+// letting ONNX Runtime decide. It applies to transcriptions started after the call.
+func (i *Instance) SetIntraOpThreads(threads int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.intraOpThreads = threads
+	i.model.SetIntraOpThreads(threads)
+}
+
+// TranscribeWAV transcribes audio from WAV bytes.
+// The WAV can be in any format (sample rate, channels, bit depth) - it will be
+// automatically converted to the required format (16kHz, mono, float32). Other formats
+// (MP3, FLAC, OGG...) are accepted too when ffmpeg is installed, see audio.Decode.
+// Canceling ctx aborts the transcription with the context error.
+func (i *Instance) TranscribeWAV(ctx context.Context, wavData []byte) (string, error) {
+	samples, err := audio.Decode(wavData)
+	if err != nil {
+		return "", fmt.Errorf("error processing audio data: %w", err)
+	}
+
+	return i.TranscribeSamples(ctx, samples)
+}
+
+// TranscribeSamples transcribes audio from float32 samples.
+// Samples must already be 16kHz mono audio normalized to [-1, 1].
+func (i *Instance) TranscribeSamples(ctx context.Context, samples []float32) (string, error) {
+	result, err := i.activeModel().Transcribe(ctx, samples)
+	return result.Text, err
+}
+
+// defaultChunkDuration is the length of the chunks long audio is split into unless
+// Options.ChunkDuration says otherwise.
+const defaultChunkDuration = 30 * time.Second
+
+// chunkOverlap is the audio shared by consecutive chunks, so words around a cut are
+// transcribed whole and merged.
+const chunkOverlap = 2 * time.Second
+
+// ProgressCallback receives the fraction of a long task completed so far, from 0 to 1.
+type ProgressCallback func(fraction float64)
+
+// PartialResultCallback receives the text decoded so far while a long audio is transcribed.
+type PartialResultCallback func(text string)
+
+// TokenCallback receives a token as soon as the decoder emits it.
+type TokenCallback func(token Token)
+
+// TranscribeSamplesWithPartials transcribes audio like TranscribeSamples, but returns the
+// tokens with their confidence, and audio longer than the chunk duration (30 seconds by
+// default, see Options.ChunkDuration) is processed in chunks split at quiet points,
+// calling onPartial with the accumulated text as the decoder emits tokens (for models
+// implementing StreamingModel unless disabled with SetStreamingPartials, otherwise as
+// chunks complete) so callers can show the text while it is transcribed. Chunks are
+// transcribed in parallel when Options.ChunkWorkers allows it. They overlap by two
+// seconds and the words transcribed twice are merged in order, so the text reads
+// continuously across the cuts. Canceling ctx stops every chunk.
+func (i *Instance) TranscribeSamplesWithPartials(ctx context.Context, samples []float32, onPartial PartialResultCallback) (Result, error) {
+	return transcribeChunked(ctx, i.activeModel(), samples, i.chunking(), onPartial, nil)
+}
+
+// TranscribeSamplesWithProgress transcribes audio like TranscribeSamplesWithPartials and
+// also calls onProgress with the fraction of the audio processed, as the decoder advances
+// for models implementing ProgressModel and after every chunk for the others.
+func (i *Instance) TranscribeSamplesWithProgress(ctx context.Context, samples []float32, onPartial PartialResultCallback, onProgress ProgressCallback) (Result, error) {
+	return transcribeChunked(ctx, i.activeModel(), samples, i.chunking(), onPartial, onProgress)
+}
+
+// SetChunkDuration changes the length of the chunks long audio is split into, see
+// Options.ChunkDuration. It applies to transcriptions started after the call.
+func (i *Instance) SetChunkDuration(duration time.Duration) {
+	if duration <= 0 {
+		duration = defaultChunkDuration
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.opts.ChunkDuration = duration
+}
+
+// SetChunkWorkers changes the number of chunks transcribed at the same time, see
+// Options.ChunkWorkers. It applies to transcriptions started after the call.
+func (i *Instance) SetChunkWorkers(workers int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.opts.ChunkWorkers = max(workers, 1)
+}
+
+// SetTDTDecoding makes models implementing TDTModel follow their predicted durations, or
+// decode every encoder frame if disabled. Durations are followed until it is called. It
+// applies to transcriptions started after the call and to the models switched to later.
+func (i *Instance) SetTDTDecoding(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.frameDecoding = !enabled
+	if tdtModel, ok := i.model.(TDTModel); ok {
+		tdtModel.SetTDTDecoding(enabled)
+	}
+}
+
+// SetStreamingPartials makes the partial results follow the tokens as models implementing
+// StreamingModel emit them, the default, or only report the text of completed chunks if
+// disabled. It applies to transcriptions started after the call.
+func (i *Instance) SetStreamingPartials(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.chunkPartials = !enabled
+}
+
+// chunking returns how long audio is split and transcribed.
+func (i *Instance) chunking() chunking {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return chunking{duration: i.opts.ChunkDuration, workers: i.opts.ChunkWorkers, chunkPartials: i.chunkPartials}
+}
+
+// chunking configures how long audio is split and transcribed. With chunkPartials the
+// partial results are only updated as chunks complete, not as tokens are decoded.
+type chunking struct {
+	duration      time.Duration
+	workers       int
+	chunkPartials bool
+}
+
+// transcribeChunked implements TranscribeSamplesWithProgress with the given model. Up to
+// cfg.workers chunks are transcribed at the same time, sharing the model sessions; either
+// callback may be nil and they are never called concurrently.
+func transcribeChunked(ctx context.Context, model Model, samples []float32, cfg chunking, onPartial PartialResultCallback, onProgress ProgressCallback) (Result, error) {
+	chunks := audio.SplitWithOverlap(samples, cfg.duration, chunkOverlap)
+	if len(chunks) == 1 {
+		var onToken TokenCallback
+		if onPartial != nil && !cfg.chunkPartials {
+			var live []Token
+			onToken = func(token Token) {
+				live = append(live, token)
+				onPartial(tokensResult(live).Text)
+			}
+		}
+		return transcribeStreaming(ctx, model, samples, onProgress, onToken)
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make([]Result, len(chunks))
+		done    = make([]bool, len(chunks))
+		// fractions holds the progress of every chunk, their mean is the overall one.
+		fractions = make([]float64, len(chunks))
+		// ordered is the number of leading chunks already transcribed, partial results
+		// only include them and the tokens decoded so far of the next one, live, so the
+		// text grows from the start.
+		ordered     int
+		live        = make([][]Token, len(chunks))
+		lastPartial string
+	)
+
+	// emitPartial reports the text decoded so far if it changed, mu must be held.
+	emitPartial := func() {
+		if onPartial == nil {
+			return
+		}
+		partial := results[:ordered:ordered]
+		if ordered < len(chunks) && len(live[ordered]) > 0 {
+			partial = append(partial, tokensResult(live[ordered]))
+		}
+		if text := mergeResults(spokenResults(partial)).Text; text != lastPartial {
+			lastPartial = text
+			onPartial(text)
+		}
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(cfg.workers, 1))
+
+	for n, chunk := range chunks {
+		group.Go(func() error {
+			// Another chunk failed or the caller canceled, the result is discarded anyway.
+			if err := groupCtx.Err(); err != nil {
+				return err
+			}
+
+			var onChunkProgress ProgressCallback
+			if onProgress != nil {
+				onChunkProgress = func(fraction float64) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					fractions[n] = fraction
+					var total float64
+					for _, f := range fractions {
+						total += f
+					}
+					onProgress(total / float64(len(chunks)))
+				}
+			}
+
+			offset := chunkOffset(samples, chunk)
+
+			var onChunkToken TokenCallback
+			if onPartial != nil && !cfg.chunkPartials {
+				onChunkToken = func(token Token) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					// Shifted like the finished chunks, so the overlap with the previous
+					// one is matched in time when they are merged.
+					live[n] = append(live[n], shiftTokens(Result{Tokens: []Token{token}}, offset).Tokens...)
+					if n == ordered {
+						emitPartial()
+					}
+				}
+			}
+
+			result, err := transcribeStreaming(groupCtx, model, chunk, onChunkProgress, onChunkToken)
+			if err != nil {
+				return fmt.Errorf("error transcribing chunk %d of %d: %w", n+1, len(chunks), err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[n] = shiftTokens(result, offset)
+			done[n] = true
+
+			live[n] = nil
+
+			for ordered < len(chunks) && done[ordered] {
+				ordered++
+			}
+			emitPartial()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return Result{}, err
+	}
+	return mergeResults(spokenResults(results)), nil
+}
+
+// chunkOffset returns where a chunk made by audio.SplitWithOverlap, which is a subslice of
+// samples, starts in them.
+func chunkOffset(samples, chunk []float32) time.Duration {
+	return time.Duration(cap(samples)-cap(chunk)) * time.Second / audio.SampleRate
+}
+
+// spokenResults returns the results that contain text, chunks of silence transcribe to
+// nothing and are left out of the merge.
+func spokenResults(results []Result) []Result {
+	spoken := make([]Result, 0, len(results))
+	for _, result := range results {
+		if result.Text != "" {
+			spoken = append(spoken, result)
+		}
+	}
+	return spoken
+}
+
+// ReadWAVFile is a helper function to read a WAV file into bytes.
+func ReadWAVFile(filepath string) ([]byte, error) {
+	return os.ReadFile(filepath)
+}
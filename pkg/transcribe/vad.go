@@ -0,0 +1,290 @@
+package transcribe
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/varavelio/tribar/pkg/audio"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Silero VAD v5, a voice activity detector small enough (about 2MB) to run before every
+// transcription.
+const (
+	SileroVADURL  = "https://github.com/snakers4/silero-vad/raw/v5.1.2/src/silero_vad/data/silero_vad.onnx"
+	SileroVADFile = "silero_vad.onnx"
+
+	// SileroVADID is the name of the subdirectory of the model directory the VAD is
+	// stored in.
+	SileroVADID = "silero-vad"
+)
+
+// Silero VAD constants for 16kHz audio. Every window is prefixed with the last samples of
+// the previous one, as the model was trained with that context.
+const (
+	vadWindowSize  = 512
+	vadContextSize = 64
+	vadStateSize   = 2 * 1 * 128
+)
+
+// Trimming parameters, the defaults of the reference implementation. Speech shorter than
+// vadMinSpeech is considered noise, and vadPadding is kept around speech so the first and
+// last phonemes are not clipped; longer pauses are shortened to twice the padding.
+const (
+	vadThreshold = 0.5
+	vadMinSpeech = 250 * time.Millisecond
+	vadPadding   = 200 * time.Millisecond
+)
+
+// VAD detects speech in 16kHz mono audio with the Silero VAD model. It must be downloaded
+// and loaded before use, after the ONNX Runtime environment is initialized by New.
+type VAD struct {
+	path       string
+	mirrorURL  string
+	bufferSize int
+	retries    int
+	client     *http.Client
+
+	mu      sync.RWMutex
+	session *ort.DynamicAdvancedSession
+}
+
+// NewVAD creates a voice activity detector that stores its model in the "silero-vad"
+// subdirectory of modelDir.
+func NewVAD(modelDir string) *VAD {
+	return &VAD{path: filepath.Join(modelDir, SileroVADID, SileroVADFile)}
+}
+
+// GetModelFiles returns the model file with its URL and path.
+func (v *VAD) GetModelFiles() []ModelFile {
+	return []ModelFile{{Name: "Silero VAD", URL: mirroredURL(v.mirrorURL, SileroVADURL, v.path), Path: v.path}}
+}
+
+// SetMirrorURL downloads the model from a mirror, see ModelConfig.MirrorURL. It must be
+// called before downloading.
+func (v *VAD) SetMirrorURL(mirrorURL string) {
+	v.mirrorURL = mirrorURL
+}
+
+// SetDownloadBufferSize sets the size in bytes of the buffer the model is downloaded
+// through, zero restores the default.
+func (v *VAD) SetDownloadBufferSize(size int) {
+	v.bufferSize = size
+}
+
+// SetDownloadRetries sets how many times a download failing with a transient error is
+// retried, see ParakeetModel.SetDownloadRetries.
+func (v *VAD) SetDownloadRetries(retries int) {
+	v.retries = retries
+}
+
+// SetDownloadClient sets the HTTP client the model is downloaded with, see
+// ParakeetModel.SetDownloadClient.
+func (v *VAD) SetDownloadClient(client *http.Client) {
+	v.client = client
+}
+
+// CheckModelsExist checks if the model file exists.
+func (v *VAD) CheckModelsExist() (bool, []ModelFile) {
+	if _, err := os.Stat(v.path); os.IsNotExist(err) {
+		return false, v.GetModelFiles()
+	}
+	return true, nil
+}
+
+// DownloadModels downloads the model file if it is missing.
+func (v *VAD) DownloadModels(progressCallback DownloadProgressCallback) error {
+	_, missing := v.CheckModelsExist()
+	for _, file := range missing {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return fmt.Errorf("error creating model directory: %w", err)
+		}
+		if err := downloadWithRetry(v.client, file, v.bufferSize, v.retries, progressCallback); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load verifies the model file and creates the ONNX session. The model is tiny and
+// sequential, so it runs on a single CPU thread. Calling it again recreates the session.
+func (v *VAD) Load() error {
+	if err := VerifyModelFiles(v.GetModelFiles()); err != nil {
+		return err
+	}
+
+	options, err := ort.NewSessionOptions()
+	if err != nil {
+		return fmt.Errorf("error creating session options: %w", err)
+	}
+	defer func() { _ = options.Destroy() }()
+
+	if err := options.SetIntraOpNumThreads(1); err != nil {
+		return fmt.Errorf("error setting intra-op threads: %w", err)
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(v.path,
+		[]string{"input", "state", "sr"},
+		[]string{"output", "stateN"},
+		options,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating VAD session: %w", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.session != nil {
+		_ = v.session.Destroy()
+	}
+	v.session = session
+	return nil
+}
+
+// Loaded reports whether the session is ready to use.
+func (v *VAD) Loaded() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.session != nil
+}
+
+// Close destroys the ONNX session, waiting for detections in progress to finish.
+func (v *VAD) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.session != nil {
+		_ = v.session.Destroy()
+		v.session = nil
+	}
+	return nil
+}
+
+// SpeechProbabilities returns the probability of speech of every 32ms window (512
+// samples) of 16kHz mono audio, the last window is padded with silence.
+func (v *VAD) SpeechProbabilities(samples []float32) ([]float32, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.session == nil {
+		return nil, fmt.Errorf("VAD not loaded, call Load first")
+	}
+
+	input, err := ort.NewEmptyTensor[float32](ort.NewShape(1, vadContextSize+vadWindowSize))
+	if err != nil {
+		return nil, fmt.Errorf("error creating input tensor: %w", err)
+	}
+	defer destroyTensor(input)
+
+	state, err := ort.NewEmptyTensor[float32](ort.NewShape(2, 1, 128))
+	if err != nil {
+		return nil, fmt.Errorf("error creating state tensor: %w", err)
+	}
+	defer destroyTensor(state)
+
+	sampleRate, err := ort.NewScalar(int64(audio.SampleRate))
+	if err != nil {
+		return nil, fmt.Errorf("error creating sample rate tensor: %w", err)
+	}
+	defer func() { _ = sampleRate.Destroy() }()
+
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 1))
+	if err != nil {
+		return nil, fmt.Errorf("error creating output tensor: %w", err)
+	}
+	defer destroyTensor(output)
+
+	nextState, err := ort.NewEmptyTensor[float32](ort.NewShape(2, 1, 128))
+	if err != nil {
+		return nil, fmt.Errorf("error creating state tensor: %w", err)
+	}
+	defer destroyTensor(nextState)
+
+	windows := (len(samples) + vadWindowSize - 1) / vadWindowSize
+	probabilities := make([]float32, 0, windows)
+	window := input.GetData()
+
+	for start := 0; start < len(samples); start += vadWindowSize {
+		// Shift the tail of the previous window into the context and copy the next one.
+		copy(window[:vadContextSize], window[vadWindowSize:])
+		n := copy(window[vadContextSize:], samples[start:min(start+vadWindowSize, len(samples))])
+		clear(window[vadContextSize+n:])
+
+		inputs := []ort.Value{input, state, sampleRate}
+		outputs := []ort.Value{output, nextState}
+		if err := v.session.Run(inputs, outputs); err != nil {
+			return nil, fmt.Errorf("error running VAD: %w", err)
+		}
+
+		probabilities = append(probabilities, output.GetData()[0])
+		copy(state.GetData()[:vadStateSize], nextState.GetData())
+	}
+
+	return probabilities, nil
+}
+
+// TrimSilence removes the leading and trailing silence of 16kHz mono audio and shortens
+// long pauses, keeping some padding around speech. It returns no samples if the audio
+// contains no speech.
+func (v *VAD) TrimSilence(samples []float32) ([]float32, error) {
+	probabilities, err := v.SpeechProbabilities(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := speechWindows(probabilities)
+
+	var trimmed []float32
+	for n, kept := range keep {
+		if kept {
+			start := n * vadWindowSize
+			trimmed = append(trimmed, samples[start:min(start+vadWindowSize, len(samples))]...)
+		}
+	}
+	return trimmed, nil
+}
+
+// ContainsSpeech reports whether 16kHz mono audio contains speech, a run of windows long
+// enough not to be noise.
+func (v *VAD) ContainsSpeech(samples []float32) (bool, error) {
+	probabilities, err := v.SpeechProbabilities(samples)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(speechWindows(probabilities), true), nil
+}
+
+// speechWindows marks the windows to keep: runs of speech long enough not to be noise,
+// extended by the padding on both sides.
+func speechWindows(probabilities []float32) []bool {
+	windowDuration := time.Duration(vadWindowSize) * time.Second / audio.SampleRate
+	minSpeech := int(vadMinSpeech / windowDuration)
+	padding := int((vadPadding + windowDuration - 1) / windowDuration)
+
+	keep := make([]bool, len(probabilities))
+	for start := 0; start < len(probabilities); {
+		if probabilities[start] < vadThreshold {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(probabilities) && probabilities[end] >= vadThreshold {
+			end++
+		}
+
+		if end-start >= minSpeech {
+			for n := max(start-padding, 0); n < min(end+padding, len(keep)); n++ {
+				keep[n] = true
+			}
+		}
+		start = end
+	}
+	return keep
+}